@@ -86,6 +86,7 @@ func (c *Capture) reset(ctx context.Context) error {
 		ID:            uuid.New().String(),
 		AdvertiseAddr: conf.AdvertiseAddr,
 		Version:       version.ReleaseVersion,
+		Labels:        conf.Labels,
 	}
 	c.processorManager = c.newProcessorManager()
 	if c.session != nil {
@@ -371,6 +372,18 @@ func (c *Capture) WriteDebugInfo(w io.Writer) {
 	}
 }
 
+// QueryTables returns the replication status of the tables of the changefeed
+// replicated by this capture.
+func (c *Capture) QueryTables(ctx context.Context, changefeedID model.ChangeFeedID) ([]*model.TableReplicationStatus, error) {
+	c.captureMu.Lock()
+	manager := c.processorManager
+	c.captureMu.Unlock()
+	if manager == nil {
+		return nil, nil
+	}
+	return manager.QueryTables(ctx, changefeedID)
+}
+
 // IsOwner returns whether the capture is an owner
 func (c *Capture) IsOwner() bool {
 	c.ownerMu.Lock()
@@ -15,10 +15,18 @@ package capture
 
 import (
 	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/kv"
 	"github.com/pingcap/ticdc/cdc/model"
@@ -27,6 +35,7 @@ import (
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/retry"
+	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/pingcap/ticdc/pkg/version"
 	"github.com/pingcap/tidb/br/pkg/httputil"
 	"github.com/tikv/client-go/v2/oracle"
@@ -40,21 +49,33 @@ const (
 	apiOpVarChangefeedID = "changefeed_id"
 	// apiOpVarCaptureID is the key of capture ID in HTTP API
 	apiOpVarCaptureID = "capture_id"
+	// apiOpVarStopTs is the key of stop ts in HTTP API
+	apiOpVarStopTs = "stop_ts"
+	// apiOpVarOverwriteCheckpointTs is the key of overwritten checkpoint ts in HTTP API
+	apiOpVarOverwriteCheckpointTs = "overwrite_checkpoint_ts"
+	// apiOpVarDryRun is the key of the dry-run flag of the create and update changefeed API
+	apiOpVarDryRun = "dry_run"
 	// forWardFromCapture is a header to be set when a request is forwarded from another capture
 	forWardFromCapture = "TiCDC-ForwardFromCapture"
 	// getOwnerRetryMaxTime is the retry max time to get an owner
 	getOwnerRetryMaxTime = 3
+	// tableStatusUnknown is the status of the tables whose captures can not be reached
+	tableStatusUnknown = "Unknown"
+	// queryCaptureTimeout is the timeout to query the tables of another capture
+	queryCaptureTimeout = 5 * time.Second
 )
 
 // HTTPHandler is a  HTTPHandler of capture
 type HTTPHandler struct {
-	capture *Capture
+	capture  *Capture
+	watchHub *watchHub
 }
 
 // NewHTTPHandler return a HTTPHandler for OpenAPI
 func NewHTTPHandler(capture *Capture) HTTPHandler {
 	return HTTPHandler{
-		capture: capture,
+		capture:  capture,
+		watchHub: newWatchHub(),
 	}
 }
 
@@ -172,7 +193,7 @@ func (h *HTTPHandler) GetChangefeed(c *gin.Context) {
 
 	changefeedDetail := &model.ChangefeedDetail{
 		ID:             changefeedID,
-		SinkURI:        info.SinkURI,
+		SinkURI:        security.RedactURI(info.SinkURI),
 		CreateTime:     model.JSONTime(info.CreateTime),
 		StartTs:        info.StartTs,
 		TargetTs:       info.TargetTs,
@@ -181,6 +202,8 @@ func (h *HTTPHandler) GetChangefeed(c *gin.Context) {
 		Engine:         info.Engine,
 		FeedState:      info.State,
 		TaskStatus:     taskStatus,
+		Priority:       info.GetPriority(),
+		Quota:          info.Quota,
 	}
 
 	c.JSON(http.StatusOK, changefeedDetail)
@@ -193,7 +216,9 @@ func (h *HTTPHandler) GetChangefeed(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param changefeed body model.ChangefeedConfig true "changefeed config"
+// @Param dry_run query boolean false "validate the config and return the effects of creating the changefeed without creating it"
 // @Success 202
+// @Success 200 {object} model.ChangefeedDryRunResult
 // @Failure 500,400 {object} model.HTTPError
 // @Router	/api/v1/changefeeds [post]
 func (h *HTTPHandler) CreateChangefeed(c *gin.Context) {
@@ -202,13 +227,19 @@ func (h *HTTPHandler) CreateChangefeed(c *gin.Context) {
 		return
 	}
 
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+		return
+	}
+
 	var changefeedConfig model.ChangefeedConfig
 	if err := c.BindJSON(&changefeedConfig); err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
 		return
 	}
 
-	info, err := verifyCreateChangefeedConfig(c, changefeedConfig, h.capture)
+	info, err := verifyCreateChangefeedConfig(c, changefeedConfig, h.capture, dryRun)
 	if err != nil {
 		if cerror.ErrPDEtcdAPIError.Equal(err) {
 			c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
@@ -218,6 +249,11 @@ func (h *HTTPHandler) CreateChangefeed(c *gin.Context) {
 		return
 	}
 
+	if dryRun {
+		h.writeDryRunResult(c, nil, info)
+		return
+	}
+
 	infoStr, err := info.Marshal()
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
@@ -241,6 +277,7 @@ func (h *HTTPHandler) CreateChangefeed(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param changefeed_id  path  string  true  "changefeed_id"
+// @Param stop_ts query integer false "pause the changefeed after all the events with commit ts <= stop_ts are replicated"
 // @Success 202
 // @Failure 500,400 {object} model.HTTPError
 // @Router /api/v1/changefeeds/{changefeed_id}/pause [post]
@@ -271,6 +308,20 @@ func (h *HTTPHandler) PauseChangefeed(c *gin.Context) {
 		CfID: changefeedID,
 		Type: model.AdminStop,
 	}
+	if stopTsStr := c.Query(apiOpVarStopTs); stopTsStr != "" {
+		stopTs, err := strconv.ParseUint(stopTsStr, 10, 64)
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest,
+				model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("invalid stop_ts: %s", stopTsStr)))
+			return
+		}
+		if err := verifyStopTs(c, h.capture, changefeedID, stopTs); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+			return
+		}
+		job.Type = model.AdminStopAtTs
+		job.Opts = &model.AdminJobOption{StopTs: stopTs}
+	}
 
 	_ = h.capture.OperateOwnerUnderLock(func(owner *owner.Owner) error {
 		owner.EnqueueJob(job)
@@ -287,6 +338,7 @@ func (h *HTTPHandler) PauseChangefeed(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param changefeed-id path string true "changefeed_id"
+// @Param overwrite_checkpoint_ts query integer false "resume the changefeed from the specified checkpoint ts"
 // @Success 202
 // @Failure 500,400 {object} model.HTTPError
 // @Router	/api/v1/changefeeds/{changefeed_id}/resume [post]
@@ -317,6 +369,19 @@ func (h *HTTPHandler) ResumeChangefeed(c *gin.Context) {
 		CfID: changefeedID,
 		Type: model.AdminResume,
 	}
+	if checkpointTsStr := c.Query(apiOpVarOverwriteCheckpointTs); checkpointTsStr != "" {
+		checkpointTs, err := strconv.ParseUint(checkpointTsStr, 10, 64)
+		if err != nil {
+			c.IndentedJSON(http.StatusBadRequest,
+				model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("invalid overwrite_checkpoint_ts: %s", checkpointTsStr)))
+			return
+		}
+		if err := verifyOverwriteCheckpointTs(c, h.capture, changefeedID, checkpointTs); err != nil {
+			c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+			return
+		}
+		job.Opts = &model.AdminJobOption{OverwriteCheckpointTs: checkpointTs}
+	}
 
 	_ = h.capture.OperateOwnerUnderLock(func(owner *owner.Owner) error {
 		owner.EnqueueJob(job)
@@ -339,7 +404,9 @@ func (h *HTTPHandler) ResumeChangefeed(c *gin.Context) {
 // @Param ignore_txn_start_ts body integer false "ignore transaction start ts"
 // @Param mounter_worker_num body integer false "mounter worker nums"
 // @Param sink_config body config.SinkConfig false "sink config"
+// @Param dry_run query boolean false "validate the config and return the effects of updating the changefeed without updating it, the changefeed is not required to be stopped"
 // @Success 202
+// @Success 200 {object} model.ChangefeedDryRunResult
 // @Failure 500,400 {object} model.HTTPError
 // @Router /api/v1/changefeeds/{changefeed_id} [put]
 func (h *HTTPHandler) UpdateChangefeed(c *gin.Context) {
@@ -355,6 +422,11 @@ func (h *HTTPHandler) UpdateChangefeed(c *gin.Context) {
 			model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("invalid changefeed_id: %s", changefeedID)))
 		return
 	}
+	dryRun, err := parseDryRun(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+		return
+	}
 	info, err := h.capture.etcdClient.GetChangeFeedInfo(c, changefeedID)
 	if err != nil {
 		if cerror.ErrChangeFeedNotExists.Equal(err) {
@@ -364,7 +436,8 @@ func (h *HTTPHandler) UpdateChangefeed(c *gin.Context) {
 		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
 		return
 	}
-	if info.State != model.StateStopped {
+	// a dry run is allowed to preview the update before stopping the changefeed
+	if !dryRun && info.State != model.StateStopped {
 		c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(cerror.ErrChangefeedUpdateRefused.GenWithStackByArgs("can only update changefeed config when it is stopped")))
 		return
 	}
@@ -383,6 +456,11 @@ func (h *HTTPHandler) UpdateChangefeed(c *gin.Context) {
 		return
 	}
 
+	if dryRun {
+		h.writeDryRunResult(c, info, newInfo)
+		return
+	}
+
 	err = h.capture.etcdClient.SaveChangeFeedInfo(c, newInfo, changefeedID)
 	if err != nil {
 		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
@@ -392,6 +470,94 @@ func (h *HTTPHandler) UpdateChangefeed(c *gin.Context) {
 	c.Status(http.StatusAccepted)
 }
 
+// parseDryRun parses the dry-run flag of a request
+func parseDryRun(c *gin.Context) (bool, error) {
+	dryRunStr := c.Query(apiOpVarDryRun)
+	if dryRunStr == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(dryRunStr)
+	if err != nil {
+		return false, cerror.ErrAPIInvalidParam.GenWithStack("invalid dry_run: %s", dryRunStr)
+	}
+	return dryRun, nil
+}
+
+// writeDryRunResult writes the effects of creating or updating a changefeed to
+// newInfo, oldInfo is nil if the changefeed is created.
+func (h *HTTPHandler) writeDryRunResult(c *gin.Context, oldInfo, newInfo *model.ChangeFeedInfo) {
+	result, err := dryRunChangefeed(c, h.capture, oldInfo, newInfo)
+	if err != nil {
+		if cerror.ErrPDEtcdAPIError.Equal(err) {
+			c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
+			return
+		}
+		c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+		return
+	}
+	c.IndentedJSON(http.StatusOK, result)
+}
+
+// UpdateChangefeedTargetTs updates the target ts of a changefeed
+// @Summary Update the target ts of a changefeed
+// @Description Extend or shrink the target ts of a changefeed without stopping it
+// @Tags changefeed
+// @Accept json
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Param target_ts body integer true "new target ts, 0 means the changefeed never finishes"
+// @Success 202
+// @Failure 500,400 {object} model.HTTPError
+// @Router /api/v1/changefeeds/{changefeed_id}/target_ts [post]
+func (h *HTTPHandler) UpdateChangefeedTargetTs(c *gin.Context) {
+	if !h.capture.IsOwner() {
+		h.forwardToOwner(c)
+		return
+	}
+
+	changefeedID := c.Param(apiOpVarChangefeedID)
+	if err := model.ValidateChangefeedID(changefeedID); err != nil {
+		c.IndentedJSON(http.StatusBadRequest,
+			model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("invalid changefeed_id: %s", changefeedID)))
+		return
+	}
+	// check if the changefeed exists && check if the etcdClient work well
+	_, _, err := h.capture.etcdClient.GetChangeFeedStatus(c, changefeedID)
+	if err != nil {
+		if cerror.ErrChangeFeedNotExists.Equal(err) {
+			c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+			return
+		}
+		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
+		return
+	}
+
+	data := struct {
+		TargetTs uint64 `json:"target_ts"`
+	}{}
+	if err := c.BindJSON(&data); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+		return
+	}
+	if err := verifyTargetTs(c, h.capture, changefeedID, data.TargetTs); err != nil {
+		c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+		return
+	}
+
+	job := model.AdminJob{
+		CfID: changefeedID,
+		Type: model.AdminUpdateTargetTs,
+		Opts: &model.AdminJobOption{TargetTs: data.TargetTs},
+	}
+
+	_ = h.capture.OperateOwnerUnderLock(func(owner *owner.Owner) error {
+		owner.EnqueueJob(job)
+		return nil
+	})
+
+	c.Status(http.StatusAccepted)
+}
+
 // RemoveChangefeed removes a changefeed
 // @Summary Remove a changefeed
 // @Description Remove a changefeed
@@ -535,6 +701,144 @@ func (h *HTTPHandler) MoveTable(c *gin.Context) {
 	c.Status(http.StatusAccepted)
 }
 
+// ListTables lists the replication status of all the tables of a changefeed
+// @Summary List the replication status of tables
+// @Description list the replication status of all the tables of a changefeed, which are gathered from the captures replicating them
+// @Tags changefeed
+// @Accept json
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Success 200 {array} model.TableReplicationStatus
+// @Failure 500,400 {object} model.HTTPError
+// @Router /api/v1/changefeeds/{changefeed_id}/tables [get]
+func (h *HTTPHandler) ListTables(c *gin.Context) {
+	changefeedID := c.Param(apiOpVarChangefeedID)
+	if err := model.ValidateChangefeedID(changefeedID); err != nil {
+		c.IndentedJSON(http.StatusBadRequest,
+			model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("invalid changefeed_id: %s", changefeedID)))
+		return
+	}
+
+	_, err := h.capture.etcdClient.GetChangeFeedInfo(c, changefeedID)
+	if err != nil {
+		if cerror.ErrChangeFeedNotExists.Equal(err) {
+			c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+			return
+		}
+		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
+		return
+	}
+
+	processorInfos, err := h.capture.etcdClient.GetAllTaskStatus(c, changefeedID)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
+		return
+	}
+	_, captureInfos, err := h.capture.etcdClient.GetCaptures(c)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
+		return
+	}
+	captures := make(map[model.CaptureID]*model.CaptureInfo, len(captureInfos))
+	for _, info := range captureInfos {
+		captures[info.ID] = info
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		tables = make([]*model.TableReplicationStatus, 0)
+	)
+	for captureID, taskStatus := range processorInfos {
+		captureID, taskStatus := captureID, taskStatus
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses, err := h.queryCaptureTables(c, captures[captureID], changefeedID)
+			if err != nil {
+				log.Warn("failed to query the tables replicated by capture",
+					zap.String("changefeed-id", changefeedID), zap.String("capture-id", captureID), zap.Error(err))
+				statuses = make([]*model.TableReplicationStatus, 0, len(taskStatus.Tables))
+				for tableID := range taskStatus.Tables {
+					statuses = append(statuses, &model.TableReplicationStatus{
+						TableID:   tableID,
+						CaptureID: captureID,
+						Status:    tableStatusUnknown,
+						Error:     err.Error(),
+					})
+				}
+			}
+			mu.Lock()
+			tables = append(tables, statuses...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(tables, func(i, j int) bool {
+		if tables[i].TableID != tables[j].TableID {
+			return tables[i].TableID < tables[j].TableID
+		}
+		return tables[i].CaptureID < tables[j].CaptureID
+	})
+	c.IndentedJSON(http.StatusOK, tables)
+}
+
+// queryCaptureTables queries the replication status of the tables of the
+// changefeed replicated by the capture.
+func (h *HTTPHandler) queryCaptureTables(
+	c *gin.Context, capture *model.CaptureInfo, changefeedID model.ChangeFeedID,
+) ([]*model.TableReplicationStatus, error) {
+	if capture == nil {
+		return nil, cerror.ErrCaptureNotExist.GenWithStackByArgs("")
+	}
+	if capture.ID == h.capture.Info().ID {
+		return h.capture.QueryTables(c, changefeedID)
+	}
+
+	tlsConfig, err := config.GetGlobalServerConfig().Security.ToTLSConfigWithVerify()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	uri := fmt.Sprintf("%s://%s/api/v1/processors/%s/%s/tables", scheme, capture.AdvertiseAddr, changefeedID, capture.ID)
+	req, err := http.NewRequestWithContext(c, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// the caller has been authorized, pass its credential and identity to
+	// the capture
+	for _, key := range []string{"Authorization", model.ForwardedIdentityHeader, model.ForwardedRoleHeader} {
+		if value := c.GetHeader(key); value != "" {
+			req.Header.Set(key, value)
+		}
+	}
+	req.Header.Set(forWardFromCapture, h.capture.Info().ID)
+
+	cli := httputil.NewClient(tlsConfig)
+	cli.Timeout = queryCaptureTimeout
+	resp, err := cli.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("capture %s responded %d: %s", capture.ID, resp.StatusCode, string(body))
+	}
+	var statuses []*model.TableReplicationStatus
+	if err := json.Unmarshal(body, &statuses); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return statuses, nil
+}
+
 // ResignOwner makes the current owner resign
 // @Summary notify the owner to resign
 // @Description notify the current owner to resign
@@ -608,6 +912,58 @@ func (h *HTTPHandler) GetProcessor(c *gin.Context) {
 	c.JSON(http.StatusOK, processorDetail)
 }
 
+// GetProcessorTables gets the replication status of the tables replicated by a processor
+// @Summary Get the replication status of the tables of a processor
+// @Description get the replication status of the tables replicated by a processor
+// @Tags processor
+// @Accept json
+// @Produce json
+// @Param changefeed_id path string true "changefeed_id"
+// @Param capture_id path string true "capture_id"
+// @Success 200 {array} model.TableReplicationStatus
+// @Failure 500,400 {object} model.HTTPError
+// @Router	/api/v1/processors/{changefeed_id}/{capture_id}/tables [get]
+func (h *HTTPHandler) GetProcessorTables(c *gin.Context) {
+	changefeedID := c.Param(apiOpVarChangefeedID)
+	if err := model.ValidateChangefeedID(changefeedID); err != nil {
+		c.IndentedJSON(http.StatusBadRequest,
+			model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("invalid changefeed_id: %s", changefeedID)))
+		return
+	}
+	captureID := c.Param(apiOpVarCaptureID)
+
+	if captureID != h.capture.Info().ID {
+		// every request can only be forwarded one time
+		if len(c.GetHeader(forWardFromCapture)) != 0 {
+			c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(cerror.ErrCaptureNotExist.GenWithStackByArgs(captureID)))
+			return
+		}
+		_, captureInfos, err := h.capture.etcdClient.GetCaptures(c)
+		if err != nil {
+			c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
+			return
+		}
+		for _, info := range captureInfos {
+			if info.ID == captureID {
+				h.forwardToCapture(c, info)
+				return
+			}
+		}
+		c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(cerror.ErrCaptureNotExist.GenWithStackByArgs(captureID)))
+		return
+	}
+
+	tables, err := h.capture.QueryTables(c, changefeedID)
+	if err != nil {
+		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
+		return
+	}
+	if tables == nil {
+		tables = make([]*model.TableReplicationStatus, 0)
+	}
+	c.IndentedJSON(http.StatusOK, tables)
+}
+
 // ListProcessor lists all processors in the TiCDC cluster
 // @Summary List processors
 // @Description list all processors in the TiCDC cluster
@@ -657,12 +1013,53 @@ func (h *HTTPHandler) ListCapture(c *gin.Context) {
 	for _, c := range captureInfos {
 		isOwner := c.ID == ownerID
 		captures = append(captures,
-			&model.Capture{ID: c.ID, IsOwner: isOwner, AdvertiseAddr: c.AdvertiseAddr})
+			&model.Capture{ID: c.ID, IsOwner: isOwner, AdvertiseAddr: c.AdvertiseAddr, Labels: c.Labels})
 	}
 
 	c.IndentedJSON(http.StatusOK, captures)
 }
 
+// DrainCapture drains all tables from a capture
+// @Summary Drain a capture
+// @Description move all tables away from the capture, so that the capture can be stopped safely
+// @Tags capture
+// @Accept json
+// @Produce json
+// @Param capture_id path string true "capture_id"
+// @Success 202 {object} model.DrainCaptureStatus
+// @Failure 500,400 {object} model.HTTPError
+// @Router	/api/v1/captures/{capture_id}/drain [post]
+func (h *HTTPHandler) DrainCapture(c *gin.Context) {
+	if !h.capture.IsOwner() {
+		h.forwardToOwner(c)
+		return
+	}
+
+	captureID := c.Param(apiOpVarCaptureID)
+	if err := model.ValidateChangefeedID(captureID); err != nil {
+		c.IndentedJSON(http.StatusBadRequest,
+			model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("invalid capture_id: %s", captureID)))
+		return
+	}
+
+	var status *model.DrainCaptureStatus
+	err := h.capture.OperateOwnerUnderLock(func(owner *owner.Owner) error {
+		var err error
+		status, err = owner.DrainCapture(c.Request.Context(), captureID)
+		return err
+	})
+	if err != nil {
+		if cerror.ErrCaptureNotExist.Equal(err) || cerror.ErrDrainCaptureNoTarget.Equal(err) {
+			c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
+			return
+		}
+		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
+		return
+	}
+
+	c.IndentedJSON(http.StatusAccepted, status)
+}
+
 // ServerStatus gets the status of server(capture)
 // @Summary Get server status
 // @Description get the status of a server(capture)
@@ -702,35 +1099,49 @@ func (h *HTTPHandler) Health(c *gin.Context) {
 
 // SetLogLevel changes TiCDC log level dynamically.
 // @Summary Change TiCDC log level
-// @Description change TiCDC log level dynamically
+// @Description change the log level of the capture or one of its modules dynamically
 // @Tags common
 // @Accept json
 // @Produce json
-// @Param log_level body string true "log level"
+// @Param log_level body model.LogLevelConfig true "log level"
 // @Success 200
 // @Failure 400 {object} model.HTTPError
 // @Router	/api/v1/log [post]
 func SetLogLevel(c *gin.Context) {
 	// get json data from request body
-	data := struct {
-		Level string `json:"log_level"`
-	}{}
+	data := model.LogLevelConfig{}
 	err := c.BindJSON(&data)
 	if err != nil {
 		c.IndentedJSON(http.StatusBadRequest, model.NewHTTPError(err))
 		return
 	}
 
-	err = logutil.SetLogLevel(data.Level)
+	if data.Module != "" {
+		err = logutil.SetModuleLogLevel(data.Module, data.Level)
+	} else {
+		err = logutil.SetLogLevel(data.Level)
+	}
 	if err != nil {
 		c.IndentedJSON(http.StatusBadRequest,
 			model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("fail to change log level: %s", err)))
 		return
 	}
-	log.Warn("log level changed", zap.String("level", data.Level))
+	log.Warn("log level changed", zap.String("level", data.Level), zap.String("module", data.Module))
 	c.Status(http.StatusOK)
 }
 
+// GetLogLevel returns the log levels of the capture.
+// @Summary Get TiCDC log level
+// @Description get the log level of the capture and the levels of the modules set separately
+// @Tags common
+// @Produce json
+// @Success 200 {object} model.LogLevels
+// @Router	/api/v1/log [get]
+func GetLogLevel(c *gin.Context) {
+	level, moduleLevels := logutil.GetLogLevels()
+	c.IndentedJSON(http.StatusOK, &model.LogLevels{Level: level, ModuleLevels: moduleLevels})
+}
+
 // forwardToOwner forward an request to owner
 func (h *HTTPHandler) forwardToOwner(c *gin.Context) {
 	// every request can only forward to owner one time
@@ -738,8 +1149,6 @@ func (h *HTTPHandler) forwardToOwner(c *gin.Context) {
 		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(cerror.ErrRequestForwardErr.FastGenByArgs()))
 		return
 	}
-	c.Header(forWardFromCapture, h.capture.Info().ID)
-
 	var owner *model.CaptureInfo
 	// get owner
 	err := retry.Do(c, func() error {
@@ -755,6 +1164,17 @@ func (h *HTTPHandler) forwardToOwner(c *gin.Context) {
 		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
 		return
 	}
+	h.forwardToCapture(c, owner)
+}
+
+// forwardToCapture forwards a request to the capture. All headers of the
+// request are forwarded, including the identity of the caller set by the
+// authentication, which the capture trusts since the request is sent with the
+// certificate of this capture. A caller authenticated by its client
+// certificate is rejected by the capture if the certificate of this capture is
+// not in the peer-common-names of the auth config of the capture.
+func (h *HTTPHandler) forwardToCapture(c *gin.Context, capture *model.CaptureInfo) {
+	c.Header(forWardFromCapture, h.capture.Info().ID)
 
 	tslConfig, err := config.GetGlobalServerConfig().Security.ToTLSConfigWithVerify()
 	if err != nil {
@@ -764,7 +1184,7 @@ func (h *HTTPHandler) forwardToOwner(c *gin.Context) {
 
 	// init a request
 	req, _ := http.NewRequest(c.Request.Method, c.Request.RequestURI, c.Request.Body)
-	req.URL.Host = owner.AdvertiseAddr
+	req.URL.Host = capture.AdvertiseAddr
 	if tslConfig != nil {
 		req.URL.Scheme = "https"
 	} else {
@@ -776,7 +1196,6 @@ func (h *HTTPHandler) forwardToOwner(c *gin.Context) {
 		}
 	}
 
-	// forward to owner
 	cli := httputil.NewClient(tslConfig)
 	resp, err := cli.Do(req)
 	if err != nil {
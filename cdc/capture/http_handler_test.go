@@ -0,0 +1,68 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/logutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogLevelAPI(t *testing.T) {
+	level, _ := logutil.GetLogLevels()
+	defer func() {
+		require.Nil(t, logutil.SetModuleLogLevel(logutil.ModuleKVClient, ""))
+		require.Nil(t, logutil.SetLogLevel(level))
+	}()
+	router := gin.New()
+	router.GET("/api/v1/log", GetLogLevel)
+	router.POST("/api/v1/log", SetLogLevel)
+	request := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(method, "/api/v1/log", strings.NewReader(body))
+		require.Nil(t, err)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	getLevels := func() *model.LogLevels {
+		w := request(http.MethodGet, "")
+		require.Equal(t, http.StatusOK, w.Code)
+		levels := new(model.LogLevels)
+		require.Nil(t, json.Unmarshal(w.Body.Bytes(), levels))
+		return levels
+	}
+
+	require.Equal(t, http.StatusOK, request(http.MethodPost, `{"log_level":"warn"}`).Code)
+	require.Equal(t, http.StatusOK, request(http.MethodPost, `{"log_level":"debug","module":"kv-client"}`).Code)
+	require.Equal(t, &model.LogLevels{
+		Level:        "warn",
+		ModuleLevels: map[string]string{"kv-client": "debug"},
+	}, getLevels())
+
+	// reset the level of the module
+	require.Equal(t, http.StatusOK, request(http.MethodPost, `{"module":"kv-client"}`).Code)
+	require.Equal(t, &model.LogLevels{Level: "warn", ModuleLevels: map[string]string{}}, getLevels())
+
+	w := request(http.MethodPost, `{"log_level":"debug","module":"unknown"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "unknown log module unknown")
+	require.Equal(t, http.StatusBadRequest, request(http.MethodPost, `{"log_level":"verbose"}`).Code)
+}
@@ -33,8 +33,11 @@ import (
 	"github.com/tikv/client-go/v2/oracle"
 )
 
-// verifyCreateChangefeedConfig verify ChangefeedConfig for create a changefeed
-func verifyCreateChangefeedConfig(ctx context.Context, changefeedConfig model.ChangefeedConfig, capture *Capture) (*model.ChangeFeedInfo, error) {
+// verifyCreateChangefeedConfig verify ChangefeedConfig for create a changefeed,
+// no service GC safe point is registered if it is a dry run.
+func verifyCreateChangefeedConfig(
+	ctx context.Context, changefeedConfig model.ChangefeedConfig, capture *Capture, dryRun bool,
+) (*model.ChangeFeedInfo, error) {
 	// verify sinkURI
 	if changefeedConfig.SinkURI == "" {
 		return nil, cerror.ErrSinkURIInvalid.GenWithStackByArgs("sink-uri is empty, can't not create a changefeed without sink-uri")
@@ -62,7 +65,11 @@ func verifyCreateChangefeedConfig(ctx context.Context, changefeedConfig model.Ch
 		changefeedConfig.StartTS = oracle.ComposeTS(ts, logical)
 	}
 
-	if err := util.CheckSafetyOfStartTs(ctx, capture.pdClient, changefeedConfig.ID, changefeedConfig.StartTS); err != nil {
+	checkStartTs := util.CheckSafetyOfStartTs
+	if dryRun {
+		checkStartTs = util.CheckStartTsForDryRun
+	}
+	if err := checkStartTs(ctx, capture.pdClient, changefeedConfig.ID, changefeedConfig.StartTS); err != nil {
 		if err != cerror.ErrStartTsBeforeGC {
 			return nil, cerror.ErrPDEtcdAPIError.Wrap(err)
 		}
@@ -74,6 +81,25 @@ func verifyCreateChangefeedConfig(ctx context.Context, changefeedConfig model.Ch
 		return nil, cerror.ErrTargetTsBeforeStartTs.GenWithStackByArgs(changefeedConfig.TargetTS, changefeedConfig.StartTS)
 	}
 
+	// verify priority and quota
+	if err := model.ValidateChangefeedPriority(changefeedConfig.Priority); err != nil {
+		return nil, err
+	}
+	if changefeedConfig.SinkConcurrency < 0 {
+		return nil, cerror.ErrAPIInvalidParam.GenWithStack("invalid sink_concurrency: %d", changefeedConfig.SinkConcurrency)
+	}
+	if err := sink.ValidateSinkConcurrency(changefeedConfig.SinkURI, changefeedConfig.SinkConcurrency); err != nil {
+		return nil, err
+	}
+	var quota *model.ChangefeedQuota
+	if changefeedConfig.MemoryQuota != 0 || changefeedConfig.SorterDiskQuota != 0 || changefeedConfig.SinkConcurrency != 0 {
+		quota = &model.ChangefeedQuota{
+			MemoryQuota:     changefeedConfig.MemoryQuota,
+			SorterDiskQuota: changefeedConfig.SorterDiskQuota,
+			SinkConcurrency: changefeedConfig.SinkConcurrency,
+		}
+	}
+
 	// init replicaConfig
 	replicaConfig := config.GetDefaultReplicaConfig()
 	replicaConfig.ForceReplicate = changefeedConfig.ForceReplicate
@@ -121,6 +147,8 @@ func verifyCreateChangefeedConfig(ctx context.Context, changefeedConfig model.Ch
 		SyncPointEnabled:  false,
 		SyncPointInterval: 10 * time.Minute,
 		CreatorVersion:    version.ReleaseVersion,
+		Priority:          changefeedConfig.Priority,
+		Quota:             quota,
 	}
 
 	if !replicaConfig.ForceReplicate && !changefeedConfig.IgnoreIneligibleTable {
@@ -181,6 +209,30 @@ func verifyUpdateChangefeedConfig(ctx context.Context, changefeedConfig model.Ch
 		newInfo.Config.Sink = changefeedConfig.SinkConfig
 	}
 
+	// verify priority and quota
+	if changefeedConfig.Priority != "" {
+		if err := model.ValidateChangefeedPriority(changefeedConfig.Priority); err != nil {
+			return nil, cerror.ErrChangefeedUpdateRefused.GenWithStackByCause(err)
+		}
+		newInfo.Priority = changefeedConfig.Priority
+	}
+	if changefeedConfig.SinkConcurrency < 0 {
+		return nil, cerror.ErrChangefeedUpdateRefused.GenWithStack("invalid sink_concurrency: %d", changefeedConfig.SinkConcurrency)
+	}
+	if changefeedConfig.MemoryQuota != 0 || changefeedConfig.SorterDiskQuota != 0 || changefeedConfig.SinkConcurrency != 0 {
+		quota := newInfo.GetQuota()
+		if changefeedConfig.MemoryQuota != 0 {
+			quota.MemoryQuota = changefeedConfig.MemoryQuota
+		}
+		if changefeedConfig.SorterDiskQuota != 0 {
+			quota.SorterDiskQuota = changefeedConfig.SorterDiskQuota
+		}
+		if changefeedConfig.SinkConcurrency != 0 {
+			quota.SinkConcurrency = changefeedConfig.SinkConcurrency
+		}
+		newInfo.Quota = &quota
+	}
+
 	// verify sink_uri
 	if changefeedConfig.SinkURI != "" {
 		newInfo.SinkURI = changefeedConfig.SinkURI
@@ -189,6 +241,9 @@ func verifyUpdateChangefeedConfig(ctx context.Context, changefeedConfig model.Ch
 			return nil, cerror.ErrChangefeedUpdateRefused.GenWithStackByCause(err)
 		}
 	}
+	if err := sink.ValidateSinkConcurrency(newInfo.SinkURI, newInfo.GetQuota().SinkConcurrency); err != nil {
+		return nil, cerror.ErrChangefeedUpdateRefused.GenWithStackByCause(err)
+	}
 
 	if !diff.Changed(oldInfo, newInfo) {
 		return nil, cerror.ErrChangefeedUpdateRefused.GenWithStackByArgs("changefeed config is the same with the old one, do nothing")
@@ -248,3 +303,76 @@ func verifyTables(replicaConfig *config.ReplicaConfig, storage tidbkv.Storage, s
 	}
 	return
 }
+
+// dryRunChangefeed computes the effects of creating or updating a changefeed to
+// newInfo at the current ts, oldInfo is nil if the changefeed is created.
+func dryRunChangefeed(
+	ctx context.Context, capture *Capture, oldInfo, newInfo *model.ChangeFeedInfo,
+) (*model.ChangefeedDryRunResult, error) {
+	ts, logical, err := capture.pdClient.GetTS(ctx)
+	if err != nil {
+		return nil, cerror.ErrPDEtcdAPIError.GenWithStackByArgs("fail to get ts from pd client")
+	}
+	currentTs := oracle.ComposeTS(ts, logical)
+
+	ineligibleTables, eligibleTables, err := verifyTables(newInfo.Config, capture.kvStorage, currentTs)
+	if err != nil {
+		return nil, err
+	}
+	result := model.NewChangefeedDryRunResult(newInfo.Config, ineligibleTables, eligibleTables)
+	if oldInfo == nil {
+		return result, nil
+	}
+
+	oldIneligibleTables, oldEligibleTables, err := verifyTables(oldInfo.Config, capture.kvStorage, currentTs)
+	if err != nil {
+		return nil, err
+	}
+	if err := result.DiffWith(oldInfo, newInfo, oldIneligibleTables, oldEligibleTables); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// verifyStopTs verifies whether the changefeed can be stopped at stopTs
+func verifyStopTs(ctx context.Context, capture *Capture, changefeedID model.ChangeFeedID, stopTs uint64) error {
+	info, err := capture.etcdClient.GetChangeFeedInfo(ctx, changefeedID)
+	if err != nil {
+		return err
+	}
+	status, _, err := capture.etcdClient.GetChangeFeedStatus(ctx, changefeedID)
+	if err != nil {
+		return err
+	}
+	return info.VerifyStopTs(status, stopTs)
+}
+
+// verifyTargetTs verifies whether the target ts of the changefeed can be updated to targetTs
+func verifyTargetTs(ctx context.Context, capture *Capture, changefeedID model.ChangeFeedID, targetTs uint64) error {
+	info, err := capture.etcdClient.GetChangeFeedInfo(ctx, changefeedID)
+	if err != nil {
+		return err
+	}
+	status, _, err := capture.etcdClient.GetChangeFeedStatus(ctx, changefeedID)
+	if err != nil {
+		return err
+	}
+	return info.VerifyTargetTs(status, targetTs)
+}
+
+// verifyOverwriteCheckpointTs verifies whether the changefeed can be resumed from checkpointTs,
+// including the safety of checkpointTs against GC.
+func verifyOverwriteCheckpointTs(ctx context.Context, capture *Capture, changefeedID model.ChangeFeedID, checkpointTs uint64) error {
+	info, err := capture.etcdClient.GetChangeFeedInfo(ctx, changefeedID)
+	if err != nil {
+		return err
+	}
+	ts, logical, err := capture.pdClient.GetTS(ctx)
+	if err != nil {
+		return cerror.ErrPDEtcdAPIError.GenWithStackByArgs("fail to get ts from pd client")
+	}
+	if err := info.VerifyOverwriteCheckpointTs(checkpointTs, oracle.ComposeTS(ts, logical)); err != nil {
+		return err
+	}
+	return util.CheckSafetyOfStartTs(ctx, capture.pdClient, changefeedID, checkpointTs)
+}
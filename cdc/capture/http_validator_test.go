@@ -48,6 +48,28 @@ func TestVerifyUpdateChangefeedConfig(t *testing.T) {
 	newInfo, err = verifyUpdateChangefeedConfig(ctx, changefeedConfig, oldInfo)
 	require.Nil(t, err)
 	require.NotNil(t, newInfo)
+
+	// test update priority and quota
+	oldInfo.Quota = &model.ChangefeedQuota{MemoryQuota: 1024}
+	changefeedConfig = model.ChangefeedConfig{Priority: model.PriorityHigh, SorterDiskQuota: 2048}
+	newInfo, err = verifyUpdateChangefeedConfig(ctx, changefeedConfig, oldInfo)
+	require.Nil(t, err)
+	require.Equal(t, model.PriorityHigh, newInfo.Priority)
+	require.Equal(t, &model.ChangefeedQuota{MemoryQuota: 1024, SorterDiskQuota: 2048}, newInfo.Quota)
+
+	// test sink concurrency not supported by the sink
+	changefeedConfig = model.ChangefeedConfig{SinkConcurrency: 8}
+	newInfo, err = verifyUpdateChangefeedConfig(ctx, changefeedConfig, oldInfo)
+	require.NotNil(t, err)
+	require.Regexp(t, ".*sink-concurrency is not supported by the blackhole sink.*", err)
+	require.Nil(t, newInfo)
+
+	// test invalid priority
+	changefeedConfig = model.ChangefeedConfig{Priority: "urgent"}
+	newInfo, err = verifyUpdateChangefeedConfig(ctx, changefeedConfig, oldInfo)
+	require.NotNil(t, err)
+	require.Regexp(t, ".*ErrInvalidChangefeedPriority.*", err)
+	require.Nil(t, newInfo)
 }
 
 func TestVerifySink(t *testing.T) {
@@ -0,0 +1,279 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/etcd"
+	"go.uber.org/zap"
+)
+
+const (
+	// apiOpVarResumeToken is the key of the resume token of the watch API
+	apiOpVarResumeToken = "resume_token"
+	// lastEventIDHeader is the header set by the server-sent event clients when they reconnect
+	lastEventIDHeader = "Last-Event-ID"
+	// watchKeepaliveInterval is the interval to send keepalive comments to an idle watch stream
+	watchKeepaliveInterval = 15 * time.Second
+)
+
+// Watch streams the state changes of changefeeds, captures and tables
+// @Summary Watch the state changes of the cluster
+// @Description stream the state changes of the changefeeds, captures and tables as server-sent events.
+// @Description The stream starts with a snapshot of the current state which ends with a synced event,
+// @Description the id of the events is the resume token to continue the stream after a reconnection.
+// @Tags common
+// @Produce text/event-stream
+// @Param changefeed_id query string false "only stream the changes of this changefeed and the captures"
+// @Param resume_token query integer false "continue the stream after this token instead of starting with a snapshot, the Last-Event-ID header is used if it is empty"
+// @Success 200 {object} model.WatchEvent
+// @Failure 500,400,429 {object} model.HTTPError
+// @Router /api/v1/watch [get]
+func (h *HTTPHandler) Watch(c *gin.Context) {
+	changefeedID := c.Query(apiOpVarChangefeedID)
+	if changefeedID != "" {
+		if err := model.ValidateChangefeedID(changefeedID); err != nil {
+			c.IndentedJSON(http.StatusBadRequest,
+				model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("invalid changefeed_id: %s", changefeedID)))
+			return
+		}
+	}
+	resumeToken := c.Query(apiOpVarResumeToken)
+	if resumeToken == "" {
+		resumeToken = c.GetHeader(lastEventIDHeader)
+	}
+	var revision int64
+	if resumeToken != "" {
+		var err error
+		revision, err = strconv.ParseInt(resumeToken, 10, 64)
+		if err != nil || revision <= 0 {
+			c.IndentedJSON(http.StatusBadRequest,
+				model.NewHTTPError(cerror.ErrAPIInvalidParam.GenWithStack("invalid resume_token: %s", resumeToken)))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	sub, err := h.watchHub.subscribe(ctx, h.capture.etcdClient.Client, revision)
+	stream := newWatchStream(c.Writer, changefeedID)
+	switch {
+	case cerror.ErrAPIWatchTooManyClients.Equal(err):
+		c.IndentedJSON(http.StatusTooManyRequests, model.NewHTTPError(err))
+		return
+	case cerror.ErrAPIWatchCompacted.Equal(err):
+		stream.start()
+		stream.send(&model.WatchEvent{Type: model.WatchEventError, Revision: revision, Value: model.NewHTTPError(err)}, false)
+		stream.flush()
+		return
+	case err != nil:
+		c.IndentedJSON(http.StatusInternalServerError, model.NewHTTPError(err))
+		return
+	}
+	defer h.watchHub.unsubscribe(sub)
+
+	stream.start()
+	revision = sub.revision
+	if sub.snapshot != nil {
+		for _, event := range sub.snapshot {
+			// the snapshot events carry no resume token, a stream broken in the
+			// middle of the snapshot starts over with a new snapshot
+			stream.send(event, false)
+		}
+		stream.send(&model.WatchEvent{Type: model.WatchEventSynced, Revision: revision}, true)
+	}
+	for _, batch := range sub.backlog {
+		revision = stream.sendBatch(batch, revision)
+	}
+	stream.flush()
+
+	ticker := time.NewTicker(watchKeepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stream.keepalive()
+		case batch, ok := <-sub.ch:
+			if !ok {
+				stream.send(&model.WatchEvent{Type: model.WatchEventError, Revision: revision, Value: model.NewHTTPError(sub.err)}, false)
+				stream.flush()
+				return
+			}
+			revision = stream.sendBatch(batch, revision)
+			stream.flush()
+		}
+	}
+}
+
+// newWatchEvent decodes an etcd key-value pair to a watch event, it returns
+// nil if the key is not streamed by the watch API.
+func newWatchEvent(key, value []byte, revision int64, deleted bool) *model.WatchEvent {
+	k := new(etcd.CDCKey)
+	if err := k.Parse(string(key)); err != nil {
+		return nil
+	}
+	event := &model.WatchEvent{
+		Revision:     revision,
+		Deleted:      deleted,
+		ChangefeedID: k.ChangefeedID,
+		CaptureID:    k.CaptureID,
+	}
+	var err error
+	switch k.Tp {
+	case etcd.CDCKeyTypeOwner:
+		event.Type = model.WatchEventOwner
+		event.CaptureID = string(value)
+		return event
+	case etcd.CDCKeyTypeCapture:
+		event.Type = model.WatchEventCapture
+		if !deleted {
+			info := new(model.CaptureInfo)
+			err = info.Unmarshal(value)
+			event.Value = info
+		}
+	case etcd.CDCKeyTypeChangefeedInfo:
+		event.Type = model.WatchEventChangefeedInfo
+		if !deleted {
+			info := new(model.ChangeFeedInfo)
+			err = info.Unmarshal(value)
+			// never leak the secrets in the sink uri to the clients
+			event.Value = info.Redacted()
+		}
+	case etcd.CDCKeyTypeChangeFeedStatus:
+		event.Type = model.WatchEventChangefeedStatus
+		if !deleted {
+			status := new(model.ChangeFeedStatus)
+			err = status.Unmarshal(value)
+			event.Value = status
+		}
+	case etcd.CDCKeyTypeTaskStatus:
+		event.Type = model.WatchEventTaskStatus
+		if !deleted {
+			status := new(model.TaskStatus)
+			err = status.Unmarshal(value)
+			event.Value = status
+		}
+	case etcd.CDCKeyTypeTaskPosition:
+		event.Type = model.WatchEventTaskPosition
+		if !deleted {
+			position := new(model.TaskPosition)
+			err = position.Unmarshal(value)
+			event.Value = position
+		}
+	default:
+		// the workloads are updated frequently and are not useful to the clients
+		return nil
+	}
+	if err != nil {
+		log.Warn("watch api failed to decode the etcd value",
+			zap.ByteString("key", key), zap.Int64("revision", revision), zap.Error(err))
+		return nil
+	}
+	return event
+}
+
+// watchStream writes watch events to a client as server-sent events
+type watchStream struct {
+	w            gin.ResponseWriter
+	changefeedID model.ChangeFeedID
+}
+
+func newWatchStream(w gin.ResponseWriter, changefeedID model.ChangeFeedID) *watchStream {
+	return &watchStream{w: w, changefeedID: changefeedID}
+}
+
+func (s *watchStream) start() {
+	header := s.w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// disable the response buffering of the reverse proxies
+	header.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// accept returns false for the nil events and the events of other changefeeds
+func (s *watchStream) accept(event *model.WatchEvent) bool {
+	if event == nil {
+		return false
+	}
+	return s.changefeedID == "" || event.ChangefeedID == "" || event.ChangefeedID == s.changefeedID
+}
+
+// sendBatch sends the changes after the revision in the batch, and returns
+// the revision of the batch. The batch is shared by all streams, so it must
+// not be modified.
+func (s *watchStream) sendBatch(batch *watchBatch, revision int64) int64 {
+	if batch.progress {
+		// all the changes before the revision have been sent, advance the
+		// resume token of the client without dispatching an event
+		s.advance(batch.revision)
+		return batch.revision
+	}
+	events := make([]*model.WatchEvent, 0, len(batch.events))
+	for _, event := range batch.events {
+		if event.Revision > revision && s.accept(event) {
+			events = append(events, event)
+		}
+	}
+	for i, event := range events {
+		// only the last event of a revision carries the resume token, so
+		// that a resumed stream never misses the other changes in the
+		// same etcd transaction
+		last := i == len(events)-1 || events[i+1].Revision != event.Revision
+		s.send(event, last)
+	}
+	return batch.revision
+}
+
+// send writes an event to the stream, the revision of the event is sent as
+// the event id if withID is true.
+func (s *watchStream) send(event *model.WatchEvent, withID bool) {
+	if !s.accept(event) {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Warn("watch api failed to encode the event", zap.Any("event", event), zap.Error(err))
+		return
+	}
+	if withID {
+		fmt.Fprintf(s.w, "id: %d\n", event.Revision)
+	}
+	fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event.Type, data)
+}
+
+// advance updates the resume token of the client to the revision
+func (s *watchStream) advance(revision int64) {
+	fmt.Fprintf(s.w, "id: %d\n\n", revision)
+}
+
+func (s *watchStream) keepalive() {
+	fmt.Fprint(s.w, ": keepalive\n\n")
+	s.flush()
+}
+
+func (s *watchStream) flush() {
+	s.w.Flush()
+}
@@ -0,0 +1,301 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capture
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/etcd"
+	"go.etcd.io/etcd/clientv3"
+	"go.etcd.io/etcd/etcdserver/api/v3rpc/rpctypes"
+	"go.etcd.io/etcd/mvcc/mvccpb"
+	"go.uber.org/zap"
+)
+
+var (
+	// maxWatchClients is the max number of the watch api clients of a capture
+	maxWatchClients = 64
+	// watchHistorySize is the number of the recent changes kept to resume the
+	// streams, the streams resumed from an older token start over
+	watchHistorySize = 1024
+	// watchSubscriberBufferSize is the number of the changes buffered for a
+	// client, the client is disconnected if it falls further behind
+	watchSubscriberBufferSize = 256
+)
+
+// watchBatch is the changes of an etcd watch response, or a progress
+// notification which advances the revision without any change.
+type watchBatch struct {
+	revision int64
+	events   []*model.WatchEvent
+	progress bool
+}
+
+// watchSubscriber receives the changes from the watch hub
+type watchSubscriber struct {
+	// revision is the revision of the snapshot, or the resume token
+	revision int64
+	// snapshot is the states at the revision, it is nil for a resumed stream
+	snapshot []*model.WatchEvent
+	// backlog is the changes after the resume token kept in the history
+	backlog []*watchBatch
+
+	ch chan *watchBatch
+	// err is the reason why ch is closed, it is set before ch is closed
+	err error
+}
+
+// watchHub fans out a single etcd watch on the keys of TiCDC to all the watch
+// api clients of the capture. It caches the current states to serve the
+// snapshots and the recent changes to serve the resumed streams, so that the
+// clients cost no etcd requests. The etcd watch runs only while there are
+// clients.
+type watchHub struct {
+	mu          sync.Mutex
+	subscribers map[*watchSubscriber]struct{}
+	// cancel stops the etcd watch, it is nil if the watch is not running
+	cancel context.CancelFunc
+
+	// revision is the revision of the cached states
+	revision int64
+	states   map[string]*model.WatchEvent
+	history  []*watchBatch
+	// historyRevision is the revision before the oldest change in the history
+	historyRevision int64
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{subscribers: make(map[*watchSubscriber]struct{})}
+}
+
+// subscribe registers a client which receives the changes after the resume
+// token, or after a snapshot if the resume token is 0. The etcd watch is
+// started by the first client, from its resume token if any, so that a
+// client reconnecting to an idle capture is resumed from the etcd history.
+func (h *watchHub) subscribe(ctx context.Context, client *etcd.Client, resumeToken int64) (*watchSubscriber, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subscribers) >= maxWatchClients {
+		return nil, cerror.ErrAPIWatchTooManyClients.GenWithStackByArgs(maxWatchClients)
+	}
+	if h.cancel == nil {
+		if err := h.start(ctx, client, resumeToken); err != nil {
+			return nil, err
+		}
+	}
+
+	sub := &watchSubscriber{revision: resumeToken}
+	switch {
+	case resumeToken == 0:
+		sub.revision = h.revision
+		sub.snapshot = h.snapshot()
+	case resumeToken < h.historyRevision:
+		if len(h.subscribers) == 0 {
+			h.stop()
+		}
+		return nil, cerror.ErrAPIWatchCompacted.GenWithStackByArgs(resumeToken, h.historyRevision)
+	default:
+		for _, batch := range h.history {
+			if batch.revision > resumeToken {
+				sub.backlog = append(sub.backlog, batch)
+			}
+		}
+	}
+	sub.ch = make(chan *watchBatch, watchSubscriberBufferSize)
+	h.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// unsubscribe removes the client, the etcd watch is stopped with the last client.
+func (h *watchHub) unsubscribe(sub *watchSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers, sub)
+	if len(h.subscribers) == 0 && h.cancel != nil {
+		h.stop()
+	}
+}
+
+// start loads the states at the revision and starts the etcd watch after it,
+// the latest states are loaded if the revision is 0 or not available in etcd.
+// h.mu must be held.
+func (h *watchHub) start(ctx context.Context, client *etcd.Client, revision int64) error {
+	var (
+		resp *clientv3.GetResponse
+		err  error
+	)
+	if revision > 0 {
+		resp, err = client.Get(ctx, kv.EtcdKeyBase, clientv3.WithPrefix(), clientv3.WithRev(revision))
+		if err == rpctypes.ErrCompacted || err == rpctypes.ErrFutureRev {
+			revision = 0
+		}
+	}
+	if revision == 0 {
+		resp, err = client.Get(ctx, kv.EtcdKeyBase, clientv3.WithPrefix())
+	}
+	if err != nil {
+		return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+	}
+	if revision == 0 {
+		revision = resp.Header.Revision
+	}
+	h.revision = revision
+	h.historyRevision = h.revision
+	h.history = nil
+	h.states = make(map[string]*model.WatchEvent, len(resp.Kvs))
+	for _, item := range resp.Kvs {
+		if event := newWatchEvent(item.Key, item.Value, item.ModRevision, false); event != nil {
+			h.states[string(item.Key)] = event
+		}
+	}
+
+	// the watch outlives the request of the first client
+	watchCtx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	watchCh := client.Watch(watchCtx, kv.EtcdKeyBase,
+		clientv3.WithPrefix(), clientv3.WithRev(h.revision+1), clientv3.WithProgressNotify())
+	go h.run(watchCtx, watchCh)
+	return nil
+}
+
+// stop stops the etcd watch and drops the caches, h.mu must be held.
+func (h *watchHub) stop() {
+	h.cancel()
+	h.cancel = nil
+	h.states = nil
+	h.history = nil
+}
+
+// snapshot returns the cached states ordered by the keys, h.mu must be held.
+func (h *watchHub) snapshot() []*model.WatchEvent {
+	keys := make([]string, 0, len(h.states))
+	for key := range h.states {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	snapshot := make([]*model.WatchEvent, 0, len(keys))
+	for _, key := range keys {
+		// the events in a snapshot carry the revision of the snapshot
+		event := *h.states[key]
+		event.Revision = h.revision
+		snapshot = append(snapshot, &event)
+	}
+	return snapshot
+}
+
+func (h *watchHub) run(ctx context.Context, watchCh clientv3.WatchChan) {
+	for resp := range watchCh {
+		if err := resp.Err(); err != nil {
+			if err == rpctypes.ErrCompacted {
+				err = cerror.ErrAPIWatchCompacted.GenWithStackByArgs(h.currentRevision(), resp.CompactRevision)
+			} else {
+				err = cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+			}
+			h.fail(ctx, err)
+			return
+		}
+		h.dispatch(ctx, resp)
+	}
+	if ctx.Err() == nil {
+		h.fail(ctx, cerror.ErrPDEtcdAPIError.GenWithStack("the watch channel is closed"))
+	}
+}
+
+func (h *watchHub) currentRevision() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.revision
+}
+
+// dispatch applies the watch response to the caches and sends the changes to
+// all clients, the clients which can not keep up are disconnected.
+func (h *watchHub) dispatch(ctx context.Context, resp clientv3.WatchResponse) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	// the watch has been stopped, the caches may belong to a new watch
+	if ctx.Err() != nil {
+		return
+	}
+
+	var batch *watchBatch
+	if resp.IsProgressNotify() {
+		h.revision = resp.Header.Revision
+		batch = &watchBatch{revision: h.revision, progress: true}
+	} else {
+		events := make([]*model.WatchEvent, 0, len(resp.Events))
+		for _, event := range resp.Events {
+			key := string(event.Kv.Key)
+			deleted := event.Type == mvccpb.DELETE
+			watchEvent := newWatchEvent(event.Kv.Key, event.Kv.Value, event.Kv.ModRevision, deleted)
+			if watchEvent == nil {
+				continue
+			}
+			if deleted {
+				delete(h.states, key)
+			} else {
+				h.states[key] = watchEvent
+			}
+			events = append(events, watchEvent)
+		}
+		if len(resp.Events) > 0 {
+			h.revision = resp.Events[len(resp.Events)-1].Kv.ModRevision
+		}
+		if len(events) == 0 {
+			return
+		}
+		batch = &watchBatch{revision: h.revision, events: events}
+		h.history = append(h.history, batch)
+		if len(h.history) > watchHistorySize {
+			h.historyRevision = h.history[0].revision
+			h.history = h.history[1:]
+		}
+	}
+
+	for sub := range h.subscribers {
+		select {
+		case sub.ch <- batch:
+		default:
+			h.remove(sub, cerror.ErrAPIWatchTooSlow.GenWithStackByArgs())
+		}
+	}
+}
+
+// fail disconnects all clients after the etcd watch is broken
+func (h *watchHub) fail(ctx context.Context, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	log.Warn("watch api stream is broken", zap.Error(err))
+	for sub := range h.subscribers {
+		h.remove(sub, err)
+	}
+}
+
+// remove disconnects the client with the error, h.mu must be held.
+func (h *watchHub) remove(sub *watchSubscriber, err error) {
+	sub.err = err
+	close(sub.ch)
+	delete(h.subscribers, sub)
+	if len(h.subscribers) == 0 {
+		h.stop()
+	}
+}
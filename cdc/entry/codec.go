@@ -17,12 +17,9 @@ import (
 	"bytes"
 	"time"
 
-	"github.com/pingcap/errors"
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/ticdc/cdc/model"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
-	"github.com/pingcap/tidb/kv"
-	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/types"
 	"github.com/pingcap/tidb/util/codec"
 	"github.com/pingcap/tidb/util/rowcodec"
@@ -164,28 +161,10 @@ func decodeMetaKey(ek []byte) (meta, error) {
 	return nil, cerror.ErrUnknownMetaType.GenWithStackByArgs(rawTp)
 }
 
-// decodeRow decodes a byte slice into datums with a existing row map.
-func decodeRow(b []byte, recordID kv.Handle, tableInfo *model.TableInfo, tz *time.Location) (map[int64]types.Datum, error) {
-	if len(b) == 0 {
-		return map[int64]types.Datum{}, nil
-	}
-	handleColIDs, handleColFt, reqCols := tableInfo.GetRowColInfos()
-	var datums map[int64]types.Datum
-	var err error
-	if rowcodec.IsNewFormat(b) {
-		datums, err = decodeRowV2(b, reqCols, tz)
-	} else {
-		datums, err = decodeRowV1(b, tableInfo, tz)
-	}
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	return tablecodec.DecodeHandleToDatumMap(recordID, handleColIDs, handleColFt, tz, datums)
-}
-
-// decodeRowV1 decodes value data using old encoding format.
+// decodeRowV1 decodes value data using old encoding format, the columns not
+// in needed are skipped, and a nil needed means all columns are decoded.
 // Row layout: colID1, value1, colID2, value2, .....
-func decodeRowV1(b []byte, tableInfo *model.TableInfo, tz *time.Location) (map[int64]types.Datum, error) {
+func decodeRowV1(b []byte, tableInfo *model.TableInfo, tz *time.Location, needed map[int64]struct{}) (map[int64]types.Datum, error) {
 	row := make(map[int64]types.Datum)
 	if len(b) == 1 && b[0] == codec.NilFlag {
 		b = b[1:]
@@ -209,6 +188,11 @@ func decodeRowV1(b []byte, tableInfo *model.TableInfo, tz *time.Location) (map[i
 		if err != nil {
 			return nil, cerror.WrapError(cerror.ErrCodecDecode, err)
 		}
+		if needed != nil {
+			if _, ok := needed[id]; !ok {
+				continue
+			}
+		}
 		_, v, err := codec.DecodeOne(data)
 		if err != nil {
 			return nil, cerror.WrapError(cerror.ErrCodecDecode, err)
@@ -233,8 +217,7 @@ func decodeRowV1(b []byte, tableInfo *model.TableInfo, tz *time.Location) (map[i
 // decodeRowV2 decodes value data using new encoding format.
 // Ref: https://github.com/pingcap/tidb/pull/12634
 //      https://github.com/pingcap/tidb/blob/master/docs/design/2018-07-19-row-format.md
-func decodeRowV2(data []byte, columns []rowcodec.ColInfo, tz *time.Location) (map[int64]types.Datum, error) {
-	decoder := rowcodec.NewDatumMapDecoder(columns, tz)
+func decodeRowV2(data []byte, decoder *rowcodec.DatumMapDecoder) (map[int64]types.Datum, error) {
 	datums, err := decoder.DecodeToDatumMap(data, nil)
 	if err != nil {
 		return datums, cerror.WrapError(cerror.ErrDecodeRowToDatum, err)
@@ -28,17 +28,22 @@ import (
 	"github.com/pingcap/parser/mysql"
 	"github.com/pingcap/ticdc/cdc/model"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/tracing"
 	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/tablecodec"
 	"github.com/pingcap/tidb/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
 
 const (
 	defaultOutputChanSize = 128000
+	// defaultMounterBatchSize is the max number of events a worker mounts
+	// in a batch.
+	defaultMounterBatchSize = 256
 )
 
 type baseKVEntry struct {
@@ -74,11 +79,15 @@ type mounterImpl struct {
 	rawRowChangedChs []chan *model.PolymorphicEvent
 	tz               *time.Location
 	workerNum        int
-	enableOldValue   bool
+	oldValueMatcher  OldValueMatcher
+	columnSelector   ColumnSelector
 }
 
-// NewMounter creates a mounter
-func NewMounter(schemaStorage SchemaStorage, workerNum int, enableOldValue bool) Mounter {
+// NewMounter creates a mounter, columnSelector can be nil if all columns are
+// needed by the sink.
+func NewMounter(
+	schemaStorage SchemaStorage, workerNum int, oldValueMatcher OldValueMatcher, columnSelector ColumnSelector,
+) Mounter {
 	if workerNum <= 0 {
 		workerNum = defaultMounterWorkerNum
 	}
@@ -90,7 +99,8 @@ func NewMounter(schemaStorage SchemaStorage, workerNum int, enableOldValue bool)
 		schemaStorage:    schemaStorage,
 		rawRowChangedChs: chs,
 		workerNum:        workerNum,
-		enableOldValue:   enableOldValue,
+		oldValueMatcher:  oldValueMatcher,
+		columnSelector:   columnSelector,
 	}
 }
 
@@ -122,29 +132,121 @@ func (m *mounterImpl) codecWorker(ctx context.Context, index int) error {
 		totalRowsCountGauge.DeleteLabelValues(captureAddr, changefeedID)
 	}()
 
+	decoders := newTableDecoders(m.columnSelector, m.oldValueMatcher, m.tz)
+	batch := make([]*model.PolymorphicEvent, 0, defaultMounterBatchSize)
 	for {
-		var pEvent *model.PolymorphicEvent
+		batch = batch[:0]
 		select {
 		case <-ctx.Done():
 			return errors.Trace(ctx.Err())
-		case pEvent = <-m.rawRowChangedChs[index]:
+		case pEvent := <-m.rawRowChangedChs[index]:
+			batch = append(batch, pEvent)
 		}
+		// mount the events that have been received in a batch, so that the
+		// decoders and snapshots are reused by the events of the same table.
+	collect:
+		for len(batch) < defaultMounterBatchSize {
+			select {
+			case pEvent := <-m.rawRowChangedChs[index]:
+				batch = append(batch, pEvent)
+			default:
+				break collect
+			}
+		}
+
+		rows, err := m.mountBatch(ctx, batch, decoders, metricMountDuration)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		metricTotalRows.Add(float64(rows))
+	}
+}
+
+// mountBatch mounts the events in order, and returns the number of row
+// changed events mounted. The mount duration of each row is observed.
+func (m *mounterImpl) mountBatch(
+	ctx context.Context, batch []*model.PolymorphicEvent, decoders *tableDecoders, mountDuration prometheus.Observer,
+) (int, error) {
+	snapshots, err := newBatchSnapshots(ctx, m.schemaStorage, batch)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	rows := 0
+	changefeedID := util.ChangefeedIDFromCtx(ctx)
+	for _, pEvent := range batch {
 		if pEvent.RawKV.OpType == model.OpTypeResolved {
 			pEvent.PrepareFinished()
 			continue
 		}
 		startTime := time.Now()
-		rowEvent, err := m.unmarshalAndMountRowChanged(ctx, pEvent.RawKV)
+		span := tracing.StartKVEventSpan(ctx, tracing.StageMounter, changefeedID, pEvent.RawKV.Key, pEvent.CRTs)
+		rowEvent, err := m.mountRowChanged(pEvent.RawKV, snapshots.get, decoders)
+		span.End()
+		mountDuration.Observe(time.Since(startTime).Seconds())
 		if err != nil {
-			return errors.Trace(err)
+			return rows, errors.Trace(err)
 		}
 		pEvent.Row = rowEvent
 		pEvent.RawKV.Value = nil
 		pEvent.RawKV.OldValue = nil
 		pEvent.PrepareFinished()
-		metricMountDuration.Observe(time.Since(startTime).Seconds())
-		metricTotalRows.Inc()
+		rows++
+	}
+	return rows, nil
+}
+
+// batchSnapshots looks up the schema snapshots of the events in a batch. The
+// snapshot at the largest ts of the batch is looked up once, it is also the
+// snapshot at any ts not older than its own ts, so the other snapshots are
+// looked up only for the events before a DDL in the batch.
+type batchSnapshots struct {
+	ctx     context.Context
+	storage SchemaStorage
+
+	batchSnap *schemaSnapshot
+	batchTs   uint64
+
+	snap   *schemaSnapshot
+	snapTs uint64
+}
+
+func newBatchSnapshots(ctx context.Context, storage SchemaStorage, batch []*model.PolymorphicEvent) (*batchSnapshots, error) {
+	s := &batchSnapshots{ctx: ctx, storage: storage}
+	for _, pEvent := range batch {
+		// the same as the ts used by mountRowChanged
+		if pEvent.RawKV.OpType != model.OpTypeResolved && pEvent.CRTs-1 > s.batchTs {
+			s.batchTs = pEvent.CRTs - 1
+		}
+	}
+	// never wait for the schema storage to be resolved here, the events
+	// after the resolved ts are mounted after the events before it.
+	if resolvedTs := storage.ResolvedTs(); s.batchTs > resolvedTs {
+		s.batchTs = resolvedTs
+	}
+	if s.batchTs == 0 {
+		return s, nil
 	}
+	var err error
+	s.batchSnap, err = storage.GetSnapshot(ctx, s.batchTs)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return s, nil
+}
+
+func (s *batchSnapshots) get(ts uint64) (*schemaSnapshot, error) {
+	if s.batchSnap != nil && s.batchSnap.currentTs <= ts && ts <= s.batchTs {
+		return s.batchSnap, nil
+	}
+	if s.snap != nil && s.snapTs == ts {
+		return s.snap, nil
+	}
+	snap, err := s.storage.GetSnapshot(s.ctx, ts)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	s.snap, s.snapTs = snap, ts
+	return snap, nil
 }
 
 func (m *mounterImpl) Input() chan<- *model.PolymorphicEvent {
@@ -171,6 +273,17 @@ func (m *mounterImpl) collectMetrics(ctx context.Context) {
 }
 
 func (m *mounterImpl) unmarshalAndMountRowChanged(ctx context.Context, raw *model.RawKVEntry) (*model.RowChangedEvent, error) {
+	snapshot := func(ts uint64) (*schemaSnapshot, error) {
+		return m.schemaStorage.GetSnapshot(ctx, ts)
+	}
+	return m.mountRowChanged(raw, snapshot, newTableDecoders(m.columnSelector, m.oldValueMatcher, m.tz))
+}
+
+func (m *mounterImpl) mountRowChanged(
+	raw *model.RawKVEntry,
+	snapshot func(ts uint64) (*schemaSnapshot, error),
+	decoders *tableDecoders,
+) (*model.RowChangedEvent, error) {
 	if !bytes.HasPrefix(raw.Key, tablePrefix) {
 		return nil, nil
 	}
@@ -186,7 +299,7 @@ func (m *mounterImpl) unmarshalAndMountRowChanged(ctx context.Context, raw *mode
 	}
 	// when async commit is enabled, the commitTs of DMLs may be equals with DDL finishedTs
 	// a DML whose commitTs is equal to a DDL finishedTs using the schema info before the DDL
-	snap, err := m.schemaStorage.GetSnapshot(ctx, raw.CRTs-1)
+	snap, err := snapshot(raw.CRTs - 1)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -204,14 +317,15 @@ func (m *mounterImpl) unmarshalAndMountRowChanged(ctx context.Context, raw *mode
 			return nil, cerror.ErrSnapshotTableNotFound.GenWithStackByArgs(physicalTableID)
 		}
 		if bytes.HasPrefix(key, recordPrefix) {
-			rowKV, err := m.unmarshalRowKVEntry(tableInfo, raw.Key, raw.Value, raw.OldValue, baseInfo)
+			decoder := decoders.get(physicalTableID, tableInfo)
+			rowKV, err := m.unmarshalRowKVEntry(decoder, raw.Key, raw.Value, raw.OldValue, baseInfo)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
 			if rowKV == nil {
 				return nil, nil
 			}
-			return m.mountRowKVEntry(tableInfo, rowKV, raw.ApproximateSize())
+			return m.mountRowKVEntry(decoder, rowKV, raw.ApproximateSize())
 		}
 		return nil, nil
 	}()
@@ -222,7 +336,8 @@ func (m *mounterImpl) unmarshalAndMountRowChanged(ctx context.Context, raw *mode
 	return row, err
 }
 
-func (m *mounterImpl) unmarshalRowKVEntry(tableInfo *model.TableInfo, rawKey []byte, rawValue []byte, rawOldValue []byte, base baseKVEntry) (*rowKVEntry, error) {
+func (m *mounterImpl) unmarshalRowKVEntry(decoder *tableDecoder, rawKey []byte, rawValue []byte, rawOldValue []byte, base baseKVEntry) (*rowKVEntry, error) {
+	tableInfo := decoder.tableInfo
 	recordID, err := tablecodec.DecodeRowKey(rawKey)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -231,7 +346,7 @@ func (m *mounterImpl) unmarshalRowKVEntry(tableInfo *model.TableInfo, rawKey []b
 		if len(rawColValue) == 0 {
 			return nil, false, nil
 		}
-		row, err := decodeRow(rawColValue, recordID, tableInfo, m.tz)
+		row, err := decoder.decodeRow(rawColValue, recordID)
 		if err != nil {
 			return nil, false, errors.Trace(err)
 		}
@@ -247,7 +362,7 @@ func (m *mounterImpl) unmarshalRowKVEntry(tableInfo *model.TableInfo, rawKey []b
 		return nil, errors.Trace(err)
 	}
 
-	if base.Delete && !m.enableOldValue && (tableInfo.PKIsHandle || tableInfo.IsCommonHandle) {
+	if base.Delete && !decoder.enableOldValue && (tableInfo.PKIsHandle || tableInfo.IsCommonHandle) {
 		handleColIDs, fieldTps, _ := tableInfo.GetRowColInfos()
 		preRow, err = tablecodec.DecodeHandleToDatumMap(recordID, handleColIDs, fieldTps, m.tz, nil)
 		if err != nil {
@@ -302,10 +417,13 @@ func UnmarshalDDL(raw *model.RawKVEntry) (*timodel.Job, error) {
 	return job, nil
 }
 
-func datum2Column(tableInfo *model.TableInfo, datums map[int64]types.Datum, fillWithDefaultValue bool) ([]*model.Column, error) {
+// datum2Column converts the datums of the needed columns to columns, the
+// columns not needed are left nil.
+func datum2Column(decoder *tableDecoder, datums map[int64]types.Datum, fillWithDefaultValue bool) ([]*model.Column, error) {
+	tableInfo := decoder.tableInfo
 	cols := make([]*model.Column, len(tableInfo.RowColumnsOffset))
 	for _, colInfo := range tableInfo.Columns {
-		if !model.IsColCDCVisible(colInfo) {
+		if !model.IsColCDCVisible(colInfo) || !decoder.isNeeded(colInfo.ID) {
 			continue
 		}
 		colName := colInfo.Name.O
@@ -336,7 +454,8 @@ func datum2Column(tableInfo *model.TableInfo, datums map[int64]types.Datum, fill
 	return cols, nil
 }
 
-func (m *mounterImpl) mountRowKVEntry(tableInfo *model.TableInfo, row *rowKVEntry, dataSize int64) (*model.RowChangedEvent, error) {
+func (m *mounterImpl) mountRowKVEntry(decoder *tableDecoder, row *rowKVEntry, dataSize int64) (*model.RowChangedEvent, error) {
+	tableInfo := decoder.tableInfo
 	var err error
 	// Decode previous columns.
 	var preCols []*model.Column
@@ -348,14 +467,14 @@ func (m *mounterImpl) mountRowKVEntry(tableInfo *model.TableInfo, row *rowKVEntr
 	if row.PreRowExist {
 		// FIXME(leoppro): using pre table info to mounter pre column datum
 		// the pre column and current column in one event may using different table info
-		preCols, err = datum2Column(tableInfo, row.PreRow, m.enableOldValue)
+		preCols, err = datum2Column(decoder, row.PreRow, decoder.enableOldValue)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 
 		// NOTICE: When the old Value feature is off,
 		// the Delete event only needs to keep the handle key column.
-		if row.Delete && !m.enableOldValue {
+		if row.Delete && !decoder.enableOldValue {
 			for i := range preCols {
 				col := preCols[i]
 				if col != nil && !col.Flag.IsHandleKey() {
@@ -367,7 +486,7 @@ func (m *mounterImpl) mountRowKVEntry(tableInfo *model.TableInfo, row *rowKVEntr
 
 	var cols []*model.Column
 	if row.RowExist {
-		cols, err = datum2Column(tableInfo, row.Row, true)
+		cols, err = datum2Column(decoder, row.Row, true)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -382,7 +501,7 @@ func (m *mounterImpl) mountRowKVEntry(tableInfo *model.TableInfo, row *rowKVEntr
 
 	var tableInfoVersion uint64
 	// Align with the old format if old value disabled.
-	if row.Delete && !m.enableOldValue {
+	if row.Delete && !decoder.enableOldValue {
 		tableInfoVersion = 0
 	} else {
 		tableInfoVersion = tableInfo.TableInfoVersion
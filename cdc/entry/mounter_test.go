@@ -258,7 +258,7 @@ func testMounterDisableOldValue(c *check.C, tc struct {
 	ver, err := store.CurrentVersion(oracle.GlobalTxnScope)
 	c.Assert(err, check.IsNil)
 	scheamStorage.AdvanceResolvedTs(ver.Ver)
-	mounter := NewMounter(scheamStorage, 1, false).(*mounterImpl)
+	mounter := NewMounter(scheamStorage, 1, mockOldValueMatcher{}, nil).(*mounterImpl)
 	mounter.tz = time.Local
 	ctx := context.Background()
 
@@ -381,6 +381,57 @@ func prepareCheckSQL(c *check.C, tableName string, cols []*model.Column) (string
 	return sb.String(), params
 }
 
+// countingSchemaStorage counts the snapshots looked up
+type countingSchemaStorage struct {
+	SchemaStorage
+	lookups []uint64
+}
+
+func (s *countingSchemaStorage) GetSnapshot(ctx context.Context, ts uint64) (*schemaSnapshot, error) {
+	s.lookups = append(s.lookups, ts)
+	return s.SchemaStorage.GetSnapshot(ctx, ts)
+}
+
+func (s *mountTxnsSuite) TestBatchSnapshots(c *check.C) {
+	defer testleak.AfterTest(c)()
+	newSnapshot := func(ts uint64) *schemaSnapshot {
+		snap := newEmptySchemaSnapshot(false)
+		snap.currentTs = ts
+		return snap
+	}
+	storage := &countingSchemaStorage{SchemaStorage: &schemaStorageImpl{
+		snaps:      []*schemaSnapshot{newSnapshot(10), newSnapshot(20)},
+		resolvedTs: 30,
+	}}
+	kv := func(ts uint64) *model.PolymorphicEvent {
+		return model.NewPolymorphicEvent(&model.RawKVEntry{OpType: model.OpTypePut, CRTs: ts})
+	}
+	ctx := context.Background()
+
+	// the snapshot of the batch serves the events after the last DDL
+	batch := []*model.PolymorphicEvent{kv(22), kv(25), model.NewResolvedPolymorphicEvent(0, 40), kv(28)}
+	snapshots, err := newBatchSnapshots(ctx, storage, batch)
+	c.Assert(err, check.IsNil)
+	for _, ts := range []uint64{21, 24, 27} {
+		snap, err := snapshots.get(ts)
+		c.Assert(err, check.IsNil)
+		c.Assert(snap.currentTs, check.Equals, uint64(20))
+	}
+	c.Assert(storage.lookups, check.DeepEquals, []uint64{27})
+
+	// the events before a DDL look up their own snapshots, and the batch
+	// never waits for the schema storage to be resolved
+	storage.lookups = nil
+	batch = []*model.PolymorphicEvent{kv(15), kv(16), kv(25), kv(35)}
+	snapshots, err = newBatchSnapshots(ctx, storage, batch)
+	c.Assert(err, check.IsNil)
+	for _, ts := range []uint64{14, 14, 24} {
+		_, err := snapshots.get(ts)
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(storage.lookups, check.DeepEquals, []uint64{30, 14})
+}
+
 func walkTableSpanInStore(c *check.C, store tidbkv.Storage, tableID int64, f func(key []byte, value []byte)) {
 	txn, err := store.Begin()
 	c.Assert(err, check.IsNil)
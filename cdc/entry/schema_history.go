@@ -0,0 +1,644 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	timodel "github.com/pingcap/parser/model"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	timeta "github.com/pingcap/tidb/meta"
+	"github.com/syndtr/goleveldb/leveldb"
+	lutil "github.com/syndtr/goleveldb/leveldb/util"
+	"go.uber.org/zap"
+)
+
+const (
+	schemaHistoryDirName   = "schema-history"
+	schemaHistoryBatchSize = 1024
+)
+
+// the keys of the schema history db
+var (
+	schemaHistoryMetaKey      = []byte("m")
+	schemaHistorySchemaPrefix = []byte("s")
+	schemaHistoryTablePrefix  = []byte("t")
+	schemaHistoryJobPrefix    = []byte("j")
+)
+
+// schemaHistoryMeta is the range covered by the schema history, the base
+// snapshot is at BaseTs and all DDL jobs committed in (BaseTs, ResolvedTs]
+// are recorded. The history is empty if ResolvedTs is 0.
+type schemaHistoryMeta struct {
+	BaseTs     uint64 `json:"base-ts"`
+	ResolvedTs uint64 `json:"resolved-ts"`
+}
+
+// schemaHistoryTable is a table of the base snapshot.
+type schemaHistoryTable struct {
+	SchemaID int64              `json:"schema-id"`
+	Info     *timodel.TableInfo `json:"info"`
+}
+
+// schemaHistoryJob is a DDL job recorded in the schema history. The job is kept
+// encoded, because handling a DDL job modifies it.
+type schemaHistoryJob struct {
+	commitTs uint64
+	jobID    int64
+	value    []byte
+}
+
+// SchemaHistory is the schema history shared by all changefeeds of a capture.
+// It persists a base schema snapshot and the DDL jobs committed after it, which
+// are recorded by the DDL pullers of the owner and the processors, so that the
+// schema snapshot at the checkpoint of a changefeed can be built locally
+// instead of reading the whole schema from TiKV.
+type SchemaHistory struct {
+	mu               sync.Mutex
+	db               *leveldb.DB
+	compactThreshold int
+
+	meta schemaHistoryMeta
+	// base is loaded lazily, it is nil if it is not loaded yet
+	base   *schemaSnapshot
+	jobs   []schemaHistoryJob
+	jobIDs map[int64]struct{}
+	// compacting is true if the compacted snapshot is being built outside the
+	// lock, it prevents concurrent compactions.
+	compacting bool
+	// generation is increased whenever the history is cleared or reset, the
+	// DDL pullers must connect to the history again in a new generation.
+	generation uint64
+}
+
+var (
+	schemaHistoryMu sync.Mutex
+	schemaHistory   *SchemaHistory
+)
+
+// getSchemaHistory returns the schema history of the capture, it returns nil
+// if the schema history is disabled or can not be opened.
+func getSchemaHistory() *SchemaHistory {
+	schemaHistoryMu.Lock()
+	defer schemaHistoryMu.Unlock()
+	if schemaHistory != nil {
+		return schemaHistory
+	}
+	conf := config.GetGlobalServerConfig()
+	if conf.SchemaHistory == nil || !conf.SchemaHistory.Enable || conf.DataDir == "" {
+		return nil
+	}
+	h, err := openSchemaHistory(filepath.Join(conf.DataDir, schemaHistoryDirName), conf.SchemaHistory.CompactThreshold)
+	if err != nil {
+		log.Warn("failed to open the schema history, schema snapshots are loaded from TiKV", zap.Error(err))
+		return nil
+	}
+	schemaHistory = h
+	return schemaHistory
+}
+
+// SchemaHistoryCleanUp closes the schema history of the capture.
+func SchemaHistoryCleanUp() {
+	schemaHistoryMu.Lock()
+	defer schemaHistoryMu.Unlock()
+	if schemaHistory == nil {
+		return
+	}
+	if err := schemaHistory.close(); err != nil {
+		log.Warn("failed to close the schema history", zap.Error(err))
+	}
+	schemaHistory = nil
+}
+
+func openSchemaHistory(dir string, compactThreshold int) (*SchemaHistory, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if err != nil {
+		return nil, cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	h := &SchemaHistory{
+		db:               db,
+		compactThreshold: compactThreshold,
+		jobIDs:           make(map[int64]struct{}),
+		generation:       1,
+	}
+	if err := h.loadMetaAndJobs(); err != nil {
+		log.Warn("the schema history is corrupted, clear it", zap.Error(err))
+		h.invalidate()
+	}
+	log.Info("schema history opened", zap.String("dir", dir),
+		zap.Uint64("baseTs", h.meta.BaseTs), zap.Uint64("resolvedTs", h.meta.ResolvedTs),
+		zap.Int("jobs", len(h.jobs)))
+	return h, nil
+}
+
+func (h *SchemaHistory) close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.db.Close(); err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	return nil
+}
+
+func (h *SchemaHistory) loadMetaAndJobs() error {
+	value, err := h.db.Get(schemaHistoryMetaKey, nil)
+	if err == leveldb.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	if err := json.Unmarshal(value, &h.meta); err != nil {
+		return cerror.WrapError(cerror.ErrUnmarshalFailed, err)
+	}
+	iter := h.db.NewIterator(lutil.BytesPrefix(schemaHistoryJobPrefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()[len(schemaHistoryJobPrefix):]
+		job := schemaHistoryJob{
+			commitTs: binary.BigEndian.Uint64(key),
+			jobID:    int64(binary.BigEndian.Uint64(key[8:])),
+			value:    append([]byte(nil), iter.Value()...),
+		}
+		h.jobs = append(h.jobs, job)
+		h.jobIDs[job.jobID] = struct{}{}
+	}
+	if err := iter.Error(); err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	return nil
+}
+
+// loadBase reads the base snapshot from the db if it is not loaded.
+func (h *SchemaHistory) loadBase() error {
+	if h.base != nil {
+		return nil
+	}
+	startTime := time.Now()
+	base := newEmptySchemaSnapshot(false)
+	iter := h.db.NewIterator(lutil.BytesPrefix(schemaHistorySchemaPrefix), nil)
+	for iter.Next() {
+		dbInfo := new(timodel.DBInfo)
+		if err := json.Unmarshal(iter.Value(), dbInfo); err != nil {
+			iter.Release()
+			return cerror.WrapError(cerror.ErrUnmarshalFailed, err)
+		}
+		base.schemas[dbInfo.ID] = dbInfo
+		base.schemaNameToID[dbInfo.Name.O] = dbInfo.ID
+		base.tableInSchema[dbInfo.ID] = []int64{}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+
+	iter = h.db.NewIterator(lutil.BytesPrefix(schemaHistoryTablePrefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		var table schemaHistoryTable
+		if err := json.Unmarshal(iter.Value(), &table); err != nil {
+			return cerror.WrapError(cerror.ErrUnmarshalFailed, err)
+		}
+		dbInfo, ok := base.schemas[table.SchemaID]
+		if !ok {
+			return cerror.ErrSnapshotSchemaNotFound.GenWithStackByArgs(table.SchemaID)
+		}
+		base.initTable(model.WrapTableInfo(dbInfo.ID, dbInfo.Name.O, h.meta.BaseTs, table.Info))
+	}
+	if err := iter.Error(); err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	base.currentTs = h.meta.BaseTs
+	h.base = base
+	log.Info("schema history base snapshot loaded", zap.Uint64("baseTs", h.meta.BaseTs),
+		zap.Int("tables", len(base.tables)), zap.Duration("duration", time.Since(startTime)))
+	return nil
+}
+
+func (h *SchemaHistory) covers(ts uint64) bool {
+	return h.meta.ResolvedTs != 0 && h.meta.BaseTs <= ts && ts <= h.meta.ResolvedTs
+}
+
+// Snapshot builds the schema snapshot at ts, it returns false if ts is not
+// covered by the history.
+func (h *SchemaHistory) Snapshot(ts uint64, explicitTables bool) (*SingleSchemaSnapshot, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.covers(ts) {
+		return nil, false
+	}
+	if err := h.loadBase(); err != nil {
+		log.Warn("failed to load the base snapshot of the schema history, clear it",
+			zap.Uint64("ts", ts), zap.Error(err))
+		h.invalidate()
+		return nil, false
+	}
+	snap, err := applySchemaHistoryJobs(h.base, h.jobs, ts)
+	if err != nil {
+		log.Warn("failed to build the schema snapshot from the schema history, clear it",
+			zap.Uint64("ts", ts), zap.Error(err))
+		h.invalidate()
+		return nil, false
+	}
+	snap.setExplicitTables(explicitTables)
+	return snap, true
+}
+
+// applySchemaHistoryJobs applies the DDL jobs committed before or at ts to a
+// clone of the base snapshot. Neither base nor jobs is modified, so it can be
+// called without holding the lock of the history.
+func applySchemaHistoryJobs(base *schemaSnapshot, jobs []schemaHistoryJob, ts uint64) (*schemaSnapshot, error) {
+	snap := base.Clone()
+	for i := 0; i < len(jobs) && jobs[i].commitTs <= ts; i++ {
+		job := new(timodel.Job)
+		if err := json.Unmarshal(jobs[i].value, job); err != nil {
+			return nil, cerror.WrapError(cerror.ErrUnmarshalFailed, err)
+		}
+		if err := snap.handleDDL(job); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	snap.currentTs = ts
+	return snap, nil
+}
+
+// Reset replaces the base snapshot with the snapshot if it is newer than the
+// history. It is called after a snapshot is read from TiKV, because the
+// history does not cover it.
+func (h *SchemaHistory) Reset(snap *SingleSchemaSnapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if snap.currentTs <= h.meta.ResolvedTs {
+		return
+	}
+	startTime := time.Now()
+	base := snap.Clone()
+	base.setExplicitTables(false)
+	if err := h.rebase(base); err != nil {
+		log.Warn("failed to reset the schema history, clear it", zap.Error(err))
+		h.invalidate()
+		return
+	}
+	// the DDL pullers may have skipped the jobs between the previous resolved
+	// ts and the new base.
+	h.generation++
+	log.Info("schema history reset", zap.Uint64("baseTs", base.currentTs),
+		zap.Int("tables", len(base.tables)), zap.Duration("duration", time.Since(startTime)))
+}
+
+// rebase replaces the base snapshot, and removes the DDL jobs before it. The
+// previous base is not loaded if the new one is not built from it, all tables
+// are written to the db in this case. All changes are written in one batch, so
+// the history is never left half replaced.
+func (h *SchemaHistory) rebase(base *schemaSnapshot) error {
+	batch := new(leveldb.Batch)
+	prev := h.base
+	if prev == nil {
+		if err := h.deletePrefixInBatch(batch, schemaHistoryTablePrefix); err != nil {
+			return errors.Trace(err)
+		}
+		prev = newEmptySchemaSnapshot(false)
+	}
+	if err := h.deletePrefixInBatch(batch, schemaHistorySchemaPrefix); err != nil {
+		return errors.Trace(err)
+	}
+	for _, dbInfo := range base.schemas {
+		value, err := json.Marshal(dbInfo)
+		if err != nil {
+			return cerror.WrapError(cerror.ErrMarshalFailed, err)
+		}
+		batch.Put(encodeSchemaHistoryIDKey(schemaHistorySchemaPrefix, dbInfo.ID), value)
+	}
+	// the table infos are not modified by DDL jobs, so only the replaced
+	// tables are written.
+	for id, tableInfo := range base.tables {
+		if prev.tables[id] == tableInfo {
+			continue
+		}
+		value, err := json.Marshal(schemaHistoryTable{SchemaID: tableInfo.SchemaID, Info: tableInfo.TableInfo})
+		if err != nil {
+			return cerror.WrapError(cerror.ErrMarshalFailed, err)
+		}
+		batch.Put(encodeSchemaHistoryIDKey(schemaHistoryTablePrefix, id), value)
+	}
+	for id := range prev.tables {
+		if _, ok := base.tables[id]; !ok {
+			batch.Delete(encodeSchemaHistoryIDKey(schemaHistoryTablePrefix, id))
+		}
+	}
+
+	i := sort.Search(len(h.jobs), func(i int) bool {
+		return h.jobs[i].commitTs > base.currentTs
+	})
+	for _, job := range h.jobs[:i] {
+		batch.Delete(encodeSchemaHistoryJobKey(job.commitTs, job.jobID))
+	}
+	meta := schemaHistoryMeta{BaseTs: base.currentTs, ResolvedTs: h.meta.ResolvedTs}
+	if meta.ResolvedTs < meta.BaseTs {
+		meta.ResolvedTs = meta.BaseTs
+	}
+	value, err := json.Marshal(meta)
+	if err != nil {
+		return cerror.WrapError(cerror.ErrMarshalFailed, err)
+	}
+	batch.Put(schemaHistoryMetaKey, value)
+	if err := h.db.Write(batch, nil); err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	h.meta = meta
+	h.base = base
+	for _, job := range h.jobs[:i] {
+		delete(h.jobIDs, job.jobID)
+	}
+	h.jobs = h.jobs[i:]
+	return nil
+}
+
+// compact merges the oldest DDL jobs into the base snapshot if there are too
+// many resolved jobs, half of the threshold is kept for the changefeeds
+// starting from an earlier ts. The compacted snapshot is built without holding
+// the lock, so the DDL pullers are not blocked by the compaction.
+func (h *SchemaHistory) compact() {
+	h.mu.Lock()
+	if h.compacting || h.meta.ResolvedTs == 0 {
+		h.mu.Unlock()
+		return
+	}
+	resolved := sort.Search(len(h.jobs), func(i int) bool {
+		return h.jobs[i].commitTs > h.meta.ResolvedTs
+	})
+	if resolved <= h.compactThreshold {
+		h.mu.Unlock()
+		return
+	}
+	n := resolved - h.compactThreshold/2
+	ts := h.jobs[n-1].commitTs
+	if err := h.loadBase(); err != nil {
+		log.Warn("failed to load the base snapshot of the schema history, clear it", zap.Error(err))
+		h.invalidate()
+		h.mu.Unlock()
+		return
+	}
+	// the jobs are copied, because recording a job shifts the slice in place
+	base, jobs := h.base, append([]schemaHistoryJob(nil), h.jobs[:n]...)
+	h.compacting = true
+	h.mu.Unlock()
+
+	startTime := time.Now()
+	snap, err := applySchemaHistoryJobs(base, jobs, ts)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.compacting = false
+	if err != nil {
+		log.Warn("failed to compact the schema history, clear it", zap.Error(err))
+		h.invalidate()
+		return
+	}
+	// the history is reset or cleared during the compaction, jobs committed
+	// before or at ts can not be recorded in the meantime, because ts is
+	// resolved.
+	if h.base != base {
+		return
+	}
+	if err := h.rebase(snap); err != nil {
+		log.Warn("failed to compact the schema history, clear it", zap.Error(err))
+		h.invalidate()
+		return
+	}
+	log.Info("schema history compacted", zap.Uint64("baseTs", ts),
+		zap.Int("jobs", len(h.jobs)), zap.Duration("duration", time.Since(startTime)))
+}
+
+// connect returns whether the events of the DDL puller of the recorder connect
+// to the history in the current generation. The puller connects if it has not
+// output anything newer than the resolved ts of the history, so that it is
+// going to output all the jobs committed after the resolved ts. Once
+// connected, the puller stays connected until the history is cleared or reset.
+func (h *SchemaHistory) connect(r *SchemaHistoryRecorder) bool {
+	if r.generation == h.generation {
+		return true
+	}
+	if h.meta.ResolvedTs == 0 || r.position() > h.meta.ResolvedTs {
+		return false
+	}
+	r.generation = h.generation
+	return true
+}
+
+// record records a sorted raw kv entry of the DDL puller of the recorder.
+func (h *SchemaHistory) record(r *SchemaHistoryRecorder, raw *model.RawKVEntry) error {
+	if raw.OpType == model.OpTypeResolved {
+		defer func() {
+			r.resolvedTs = raw.CRTs
+		}()
+	}
+	if !h.connect(r) {
+		return nil
+	}
+	if raw.CRTs <= h.meta.ResolvedTs {
+		return nil
+	}
+	if raw.OpType == model.OpTypeResolved {
+		value, err := json.Marshal(schemaHistoryMeta{BaseTs: h.meta.BaseTs, ResolvedTs: raw.CRTs})
+		if err != nil {
+			return cerror.WrapError(cerror.ErrMarshalFailed, err)
+		}
+		if err := h.db.Put(schemaHistoryMetaKey, value, nil); err != nil {
+			return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+		}
+		h.meta.ResolvedTs = raw.CRTs
+		return nil
+	}
+
+	job, err := UnmarshalDDL(raw)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if job == nil {
+		return nil
+	}
+	if _, ok := h.jobIDs[job.ID]; ok {
+		return nil
+	}
+	value, err := json.Marshal(job)
+	if err != nil {
+		return cerror.WrapError(cerror.ErrMarshalFailed, err)
+	}
+	if err := h.db.Put(encodeSchemaHistoryJobKey(raw.CRTs, job.ID), value, nil); err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	// the jobs of different pullers can be interleaved
+	i := sort.Search(len(h.jobs), func(i int) bool {
+		return h.jobs[i].commitTs > raw.CRTs
+	})
+	h.jobs = append(h.jobs, schemaHistoryJob{})
+	copy(h.jobs[i+1:], h.jobs[i:])
+	h.jobs[i] = schemaHistoryJob{commitTs: raw.CRTs, jobID: job.ID, value: value}
+	h.jobIDs[job.ID] = struct{}{}
+	return nil
+}
+
+// invalidate clears the history, the changefeeds read the schema from TiKV
+// until it is reset.
+func (h *SchemaHistory) invalidate() {
+	h.meta = schemaHistoryMeta{}
+	h.base = nil
+	h.jobs = nil
+	h.jobIDs = make(map[int64]struct{})
+	h.generation++
+	for _, prefix := range [][]byte{schemaHistoryMetaKey, schemaHistorySchemaPrefix, schemaHistoryTablePrefix, schemaHistoryJobPrefix} {
+		if err := h.deletePrefix(prefix); err != nil {
+			log.Warn("failed to clear the schema history", zap.Error(err))
+		}
+	}
+}
+
+// deletePrefixInBatch adds the deletions of all keys with the prefix to the
+// batch.
+func (h *SchemaHistory) deletePrefixInBatch(batch *leveldb.Batch, prefix []byte) error {
+	iter := h.db.NewIterator(lutil.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	if err := iter.Error(); err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	return nil
+}
+
+func (h *SchemaHistory) deletePrefix(prefix []byte) error {
+	iter := h.db.NewIterator(lutil.BytesPrefix(prefix), nil)
+	defer iter.Release()
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+		if batch.Len() >= schemaHistoryBatchSize {
+			if err := h.db.Write(batch, nil); err != nil {
+				return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	if err := h.db.Write(batch, nil); err != nil {
+		return cerror.ErrSchemaHistoryIOError.GenWithStackByArgs(err.Error())
+	}
+	return nil
+}
+
+func encodeSchemaHistoryIDKey(prefix []byte, id int64) []byte {
+	key := make([]byte, len(prefix)+8)
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], uint64(id))
+	return key
+}
+
+func encodeSchemaHistoryJobKey(commitTs uint64, jobID int64) []byte {
+	key := make([]byte, len(schemaHistoryJobPrefix)+16)
+	copy(key, schemaHistoryJobPrefix)
+	binary.BigEndian.PutUint64(key[len(schemaHistoryJobPrefix):], commitTs)
+	binary.BigEndian.PutUint64(key[len(schemaHistoryJobPrefix)+8:], uint64(jobID))
+	return key
+}
+
+// SchemaHistoryRecorder records the DDL jobs pulled by a DDL puller into the
+// schema history of the capture. A nil recorder records nothing.
+type SchemaHistoryRecorder struct {
+	history *SchemaHistory
+	startTs uint64
+	// resolvedTs is the last resolved ts output by the DDL puller
+	resolvedTs uint64
+	// generation is the generation of the history the puller is connected
+	// to, it is only accessed with the lock of the history held.
+	generation uint64
+}
+
+// position returns the ts after which the DDL puller outputs all the events
+func (r *SchemaHistoryRecorder) position() uint64 {
+	if r.resolvedTs > r.startTs {
+		return r.resolvedTs
+	}
+	return r.startTs
+}
+
+// NewSchemaHistoryRecorder creates a recorder for the DDL puller started at
+// startTs, it returns nil if the schema history is disabled.
+func NewSchemaHistoryRecorder(startTs uint64) *SchemaHistoryRecorder {
+	history := getSchemaHistory()
+	if history == nil {
+		return nil
+	}
+	return &SchemaHistoryRecorder{history: history, startTs: startTs}
+}
+
+// Record records a raw kv entry of the sorted output of the DDL puller. The
+// history is cleared if it fails to be updated.
+func (r *SchemaHistoryRecorder) Record(raw *model.RawKVEntry) {
+	if r == nil || raw == nil {
+		return
+	}
+	h := r.history
+	h.mu.Lock()
+	err := h.record(r, raw)
+	if err != nil {
+		log.Warn("failed to record the DDL puller output into the schema history, clear it", zap.Error(err))
+		h.invalidate()
+	}
+	h.mu.Unlock()
+	if err == nil && raw.OpType == model.OpTypeResolved {
+		h.compact()
+	}
+}
+
+// LoadSchemaSnapshot returns the schema snapshot at ts. The snapshot is built
+// from the schema history of the capture if the history covers ts, otherwise
+// it is built from the TiDB meta returned by getMeta, and the history is
+// reset to it.
+func LoadSchemaSnapshot(ts uint64, explicitTables bool, getMeta func() (*timeta.Meta, error)) (*SingleSchemaSnapshot, error) {
+	history := getSchemaHistory()
+	if history != nil {
+		startTime := time.Now()
+		if snap, ok := history.Snapshot(ts, explicitTables); ok {
+			log.Info("schema snapshot loaded from the schema history",
+				zap.Uint64("ts", ts), zap.Duration("duration", time.Since(startTime)))
+			return snap, nil
+		}
+	}
+	meta, err := getMeta()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	snap, err := NewSingleSchemaSnapshotFromMeta(meta, ts, explicitTables)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// meta is nil only in unit tests
+	if history != nil && meta != nil {
+		history.Reset(snap)
+	}
+	return snap, nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entry
+
+import (
+	"encoding/json"
+
+	"github.com/pingcap/check"
+	timodel "github.com/pingcap/parser/model"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	timeta "github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/tikv/client-go/v2/oracle"
+)
+
+type schemaHistorySuite struct{}
+
+var _ = check.Suite(&schemaHistorySuite{})
+
+func newDDLJobRawKVEntry(c *check.C, job *timodel.Job) *model.RawKVEntry {
+	value, err := json.Marshal(job)
+	c.Assert(err, check.IsNil)
+	key := codec.EncodeBytes(metaPrefix, []byte(ddlJobListKey))
+	key = codec.EncodeUint(key, uint64(ListData))
+	key = codec.EncodeInt(key, 1)
+	return &model.RawKVEntry{
+		OpType:  model.OpTypePut,
+		Key:     key,
+		Value:   value,
+		StartTs: job.StartTS,
+		CRTs:    job.BinlogInfo.FinishedTS,
+	}
+}
+
+func newResolvedRawKVEntry(ts uint64) *model.RawKVEntry {
+	return &model.RawKVEntry{OpType: model.OpTypeResolved, CRTs: ts, StartTs: ts}
+}
+
+func currentSchemaSnapshot(c *check.C, helper *SchemaTestHelper) *schemaSnapshot {
+	ver, err := helper.Storage().CurrentVersion(oracle.GlobalTxnScope)
+	c.Assert(err, check.IsNil)
+	snap, err := newSchemaSnapshotFromMeta(helper.GetCurrentMeta(), ver.Ver, false)
+	c.Assert(err, check.IsNil)
+	return snap
+}
+
+func (s *schemaHistorySuite) TestSchemaHistory(c *check.C) {
+	defer testleak.AfterTest(c)()
+	helper := NewSchemaTestHelper(c)
+	defer helper.Close()
+	helper.tk.MustExec("create table test.t1 (id bigint primary key)")
+	baseSnap := currentSchemaSnapshot(c, helper)
+	baseTs := baseSnap.currentTs
+
+	dir := c.MkDir()
+	history, err := openSchemaHistory(dir, 1024)
+	c.Assert(err, check.IsNil)
+	_, ok := history.Snapshot(baseTs, false)
+	c.Assert(ok, check.IsFalse)
+	history.Reset(baseSnap)
+	snap, ok := history.Snapshot(baseTs, false)
+	c.Assert(ok, check.IsTrue)
+	_, ok = snap.GetTableByName("test", "t1")
+	c.Assert(ok, check.IsTrue)
+
+	job1 := helper.DDL2Job("create table test.t2 (a bigint)")
+	job2 := helper.DDL2Job("alter table test.t1 add column b int")
+	ts1, ts2 := job1.BinlogInfo.FinishedTS, job2.BinlogInfo.FinishedTS
+
+	// the puller started after the resolved ts of the history is ignored
+	(&SchemaHistoryRecorder{history: history, startTs: baseTs + 1}).Record(newDDLJobRawKVEntry(c, job1))
+	c.Assert(history.jobs, check.HasLen, 0)
+
+	recorder := &SchemaHistoryRecorder{history: history, startTs: baseTs}
+	recorder.Record(newDDLJobRawKVEntry(c, job1))
+	recorder.Record(newDDLJobRawKVEntry(c, job1))
+	recorder.Record(newDDLJobRawKVEntry(c, job2))
+	c.Assert(history.jobs, check.HasLen, 2)
+	_, ok = history.Snapshot(ts2, false)
+	c.Assert(ok, check.IsFalse)
+	recorder.Record(newResolvedRawKVEntry(ts2))
+
+	verify := func(history *SchemaHistory) {
+		snap, ok := history.Snapshot(ts1, false)
+		c.Assert(ok, check.IsTrue)
+		c.Assert(snap.currentTs, check.Equals, ts1)
+		t1, ok := snap.GetTableByName("test", "t1")
+		c.Assert(ok, check.IsTrue)
+		c.Assert(t1.Columns, check.HasLen, 1)
+		t2ID, ok := snap.GetTableIDByName("test", "t2")
+		c.Assert(ok, check.IsTrue)
+		c.Assert(snap.IsIneligibleTableID(t2ID), check.IsTrue)
+
+		snap, ok = history.Snapshot(ts2, true)
+		c.Assert(ok, check.IsTrue)
+		t1, ok = snap.GetTableByName("test", "t1")
+		c.Assert(ok, check.IsTrue)
+		c.Assert(t1.Columns, check.HasLen, 2)
+		c.Assert(snap.IsIneligibleTableID(t2ID), check.IsFalse)
+	}
+	verify(history)
+
+	// the history is loaded from the db after a restart
+	c.Assert(history.close(), check.IsNil)
+	history, err = openSchemaHistory(dir, 1)
+	c.Assert(err, check.IsNil)
+	verify(history)
+
+	// the oldest job is merged into the base snapshot
+	job3 := helper.DDL2Job("drop table test.t2")
+	ts3 := job3.BinlogInfo.FinishedTS
+	recorder = &SchemaHistoryRecorder{history: history, startTs: ts2}
+	recorder.Record(newDDLJobRawKVEntry(c, job3))
+	recorder.Record(newResolvedRawKVEntry(ts3))
+	c.Assert(history.meta, check.DeepEquals, schemaHistoryMeta{BaseTs: ts3, ResolvedTs: ts3})
+	c.Assert(history.jobs, check.HasLen, 0)
+	_, ok = history.Snapshot(ts2, false)
+	c.Assert(ok, check.IsFalse)
+
+	c.Assert(history.close(), check.IsNil)
+	history, err = openSchemaHistory(dir, 1)
+	c.Assert(err, check.IsNil)
+	defer history.close() //nolint:errcheck
+	snap, ok = history.Snapshot(ts3, false)
+	c.Assert(ok, check.IsTrue)
+	_, ok = snap.GetTableByName("test", "t2")
+	c.Assert(ok, check.IsFalse)
+	t1, ok := snap.GetTableByName("test", "t1")
+	c.Assert(ok, check.IsTrue)
+	c.Assert(t1.Columns, check.HasLen, 2)
+}
+
+func (s *schemaHistorySuite) TestSchemaHistoryCompact(c *check.C) {
+	defer testleak.AfterTest(c)()
+	helper := NewSchemaTestHelper(c)
+	defer helper.Close()
+	helper.tk.MustExec("create table test.t1 (id bigint primary key)")
+	baseSnap := currentSchemaSnapshot(c, helper)
+	baseTs := baseSnap.currentTs
+
+	history, err := openSchemaHistory(c.MkDir(), 1)
+	c.Assert(err, check.IsNil)
+	defer history.close() //nolint:errcheck
+	history.Reset(baseSnap)
+
+	job1 := helper.DDL2Job("create table test.t2 (a bigint)")
+	job2 := helper.DDL2Job("create table test.t3 (a bigint)")
+	ts1, ts2 := job1.BinlogInfo.FinishedTS, job2.BinlogInfo.FinishedTS
+	recorder := &SchemaHistoryRecorder{history: history, startTs: baseTs}
+	recorder.Record(newDDLJobRawKVEntry(c, job1))
+	recorder.Record(newDDLJobRawKVEntry(c, job2))
+
+	// a running compaction skips the others
+	history.compacting = true
+	recorder.Record(newResolvedRawKVEntry(ts2))
+	c.Assert(history.meta, check.DeepEquals, schemaHistoryMeta{BaseTs: baseTs, ResolvedTs: ts2})
+	c.Assert(history.jobs, check.HasLen, 2)
+
+	history.compacting = false
+	history.compact()
+	c.Assert(history.compacting, check.IsFalse)
+	c.Assert(history.meta, check.DeepEquals, schemaHistoryMeta{BaseTs: ts2, ResolvedTs: ts2})
+	c.Assert(history.jobs, check.HasLen, 0)
+	_, ok := history.Snapshot(ts1, false)
+	c.Assert(ok, check.IsFalse)
+	snap, ok := history.Snapshot(ts2, false)
+	c.Assert(ok, check.IsTrue)
+	for _, name := range []string{"t1", "t2", "t3"} {
+		_, ok = snap.GetTableByName("test", name)
+		c.Assert(ok, check.IsTrue)
+	}
+
+	// the base snapshot written by the compaction replaces the whole previous
+	// one, the history is the same after a restart.
+	history.base = nil
+	snap, ok = history.Snapshot(ts2, false)
+	c.Assert(ok, check.IsTrue)
+	for _, name := range []string{"t1", "t2", "t3"} {
+		_, ok = snap.GetTableByName("test", name)
+		c.Assert(ok, check.IsTrue)
+	}
+}
+
+func (s *schemaHistorySuite) TestSchemaHistoryInvalidateAndReset(c *check.C) {
+	defer testleak.AfterTest(c)()
+	helper := NewSchemaTestHelper(c)
+	defer helper.Close()
+	helper.tk.MustExec("create table test.t1 (id bigint primary key)")
+	baseSnap := currentSchemaSnapshot(c, helper)
+	baseTs := baseSnap.currentTs
+
+	history, err := openSchemaHistory(c.MkDir(), 1024)
+	c.Assert(err, check.IsNil)
+	defer history.close() //nolint:errcheck
+	history.Reset(baseSnap)
+
+	job1 := helper.DDL2Job("create table test.t2 (a bigint)")
+	job2 := helper.DDL2Job("create table test.t3 (a bigint)")
+	ts1, ts2 := job1.BinlogInfo.FinishedTS, job2.BinlogInfo.FinishedTS
+	recorder := &SchemaHistoryRecorder{history: history, startTs: baseTs}
+	recorder.Record(newDDLJobRawKVEntry(c, job1))
+	recorder.Record(newResolvedRawKVEntry(ts1))
+	c.Assert(history.meta.ResolvedTs, check.Equals, ts1)
+
+	// the history is cleared, the puller keeps going without recording
+	history.mu.Lock()
+	history.invalidate()
+	history.mu.Unlock()
+	c.Assert(history.jobIDs, check.HasLen, 0)
+	recorder.Record(newDDLJobRawKVEntry(c, job2))
+	recorder.Record(newResolvedRawKVEntry(ts2))
+
+	// the history is reset to the older base, the puller skipped the jobs
+	// after it and can't advance the resolved ts any more.
+	history.Reset(baseSnap)
+	recorder.Record(newResolvedRawKVEntry(ts2 + 1))
+	c.Assert(history.meta, check.DeepEquals, schemaHistoryMeta{BaseTs: baseTs, ResolvedTs: baseTs})
+	_, ok := history.Snapshot(ts2, false)
+	c.Assert(ok, check.IsFalse)
+
+	// a new puller records the jobs again, including the ones recorded before
+	// the history was cleared.
+	recorder = &SchemaHistoryRecorder{history: history, startTs: baseTs}
+	recorder.Record(newDDLJobRawKVEntry(c, job1))
+	recorder.Record(newDDLJobRawKVEntry(c, job2))
+	recorder.Record(newResolvedRawKVEntry(ts2))
+	c.Assert(history.jobs, check.HasLen, 2)
+	snap, ok := history.Snapshot(ts2, false)
+	c.Assert(ok, check.IsTrue)
+	for _, name := range []string{"t1", "t2", "t3"} {
+		_, ok = snap.GetTableByName("test", name)
+		c.Assert(ok, check.IsTrue)
+	}
+}
+
+func (s *schemaHistorySuite) TestLoadSchemaSnapshot(c *check.C) {
+	defer testleak.AfterTest(c)()
+	helper := NewSchemaTestHelper(c)
+	defer helper.Close()
+	helper.tk.MustExec("create table test.t1 (id bigint primary key)")
+
+	originalConfig := config.GetGlobalServerConfig()
+	conf := originalConfig.Clone()
+	conf.DataDir = c.MkDir()
+	conf.SchemaHistory.Enable = true
+	config.StoreGlobalServerConfig(conf)
+	defer config.StoreGlobalServerConfig(originalConfig)
+	defer SchemaHistoryCleanUp()
+
+	ts := currentSchemaSnapshot(c, helper).currentTs
+	metaLoaded := 0
+	getMeta := func() (*timeta.Meta, error) {
+		metaLoaded++
+		return helper.GetCurrentMeta(), nil
+	}
+	for i := 0; i < 2; i++ {
+		snap, err := LoadSchemaSnapshot(ts, false, getMeta)
+		c.Assert(err, check.IsNil)
+		_, ok := snap.GetTableByName("test", "t1")
+		c.Assert(ok, check.IsTrue)
+	}
+	c.Assert(metaLoaded, check.Equals, 1)
+	c.Assert(NewSchemaHistoryRecorder(ts), check.NotNil)
+}
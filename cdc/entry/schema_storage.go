@@ -26,10 +26,10 @@ import (
 	"github.com/pingcap/ticdc/cdc/model"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/retry"
 	timeta "github.com/pingcap/tidb/meta"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
 // schemaSnapshot stores the source TiDB all schema information
@@ -142,28 +142,51 @@ func newSchemaSnapshotFromMeta(meta *timeta.Meta, currentTs uint64, explicitTabl
 		}
 		snap.tableInSchema[schemaID] = make([]int64, 0, len(tableInfos))
 		for _, tableInfo := range tableInfos {
-			snap.tableInSchema[schemaID] = append(snap.tableInSchema[schemaID], tableInfo.ID)
-			tableInfo := model.WrapTableInfo(dbinfo.ID, dbinfo.Name.O, currentTs, tableInfo)
-			snap.tables[tableInfo.ID] = tableInfo
-			snap.tableNameToID[model.TableName{Schema: dbinfo.Name.O, Table: tableInfo.Name.O}] = tableInfo.ID
-			isEligible := tableInfo.IsEligible(explicitTables)
-			if !isEligible {
-				snap.ineligibleTableID[tableInfo.ID] = struct{}{}
-			}
-			if pi := tableInfo.GetPartitionInfo(); pi != nil {
-				for _, partition := range pi.Definitions {
-					snap.partitionTable[partition.ID] = tableInfo
-					if !isEligible {
-						snap.ineligibleTableID[partition.ID] = struct{}{}
-					}
-				}
-			}
+			snap.initTable(model.WrapTableInfo(dbinfo.ID, dbinfo.Name.O, currentTs, tableInfo))
 		}
 	}
 	snap.currentTs = currentTs
 	return snap, nil
 }
 
+// initTable adds a table when the snapshot is built from a full schema
+func (s *schemaSnapshot) initTable(tableInfo *model.TableInfo) {
+	s.tableInSchema[tableInfo.SchemaID] = append(s.tableInSchema[tableInfo.SchemaID], tableInfo.ID)
+	s.tables[tableInfo.ID] = tableInfo
+	s.tableNameToID[tableInfo.TableName] = tableInfo.ID
+	s.markIneligibleTable(tableInfo)
+	if pi := tableInfo.GetPartitionInfo(); pi != nil {
+		for _, partition := range pi.Definitions {
+			s.partitionTable[partition.ID] = tableInfo
+		}
+	}
+}
+
+func (s *schemaSnapshot) markIneligibleTable(tableInfo *model.TableInfo) {
+	if tableInfo.IsEligible(s.explicitTables) {
+		return
+	}
+	s.ineligibleTableID[tableInfo.ID] = struct{}{}
+	if pi := tableInfo.GetPartitionInfo(); pi != nil {
+		for _, partition := range pi.Definitions {
+			s.ineligibleTableID[partition.ID] = struct{}{}
+		}
+	}
+}
+
+// setExplicitTables rebuilds the ineligible tables if explicitTables changes,
+// the snapshots in the schema history are built with explicitTables disabled.
+func (s *schemaSnapshot) setExplicitTables(explicitTables bool) {
+	if s.explicitTables == explicitTables {
+		return
+	}
+	s.explicitTables = explicitTables
+	s.ineligibleTableID = make(map[int64]struct{})
+	for _, tableInfo := range s.tables {
+		s.markIneligibleTable(tableInfo)
+	}
+}
+
 func (s *schemaSnapshot) PrintStatus(logger func(msg string, fields ...zap.Field)) {
 	logger("[SchemaSnap] Start to print status", zap.Uint64("currentTs", s.currentTs))
 	for id, dbInfo := range s.schemas {
@@ -692,6 +715,16 @@ func NewSchemaStorage(meta *timeta.Meta, startTs uint64, filter *filter.Filter,
 	return schema, nil
 }
 
+// NewSchemaStorageFromSnapshot creates a new schema storage starting from the snapshot
+func NewSchemaStorageFromSnapshot(snap *SingleSchemaSnapshot, filter *filter.Filter) SchemaStorage {
+	return &schemaStorageImpl{
+		snaps:          []*schemaSnapshot{snap},
+		resolvedTs:     snap.currentTs,
+		filter:         filter,
+		explicitTables: snap.explicitTables,
+	}
+}
+
 func (s *schemaStorageImpl) getSnapshot(ts uint64) (*schemaSnapshot, error) {
 	gcTs := atomic.LoadUint64(&s.gcTs)
 	if ts < gcTs {
@@ -800,7 +833,7 @@ func (s *schemaStorageImpl) DoGC(ts uint64) {
 	if startIdx == 0 {
 		return
 	}
-	if log.GetLevel() == zapcore.DebugLevel {
+	if logutil.DebugEnabled() {
 		log.Debug("Do GC in schema storage")
 		for i := 0; i < startIdx; i++ {
 			s.snaps[i].PrintStatus(log.Debug)
@@ -0,0 +1,157 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entry
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/rowcodec"
+)
+
+// ColumnSelector selects the columns of the row changed events needed by a
+// sink. The mounter does not decode the other columns, and leaves them nil in
+// the row changed events.
+type ColumnSelector interface {
+	// NeededColumns returns the names of the columns of the table needed by
+	// the sink, nil means all columns are needed. The handle key columns are
+	// always decoded.
+	NeededColumns(schema, table string) []string
+}
+
+// OldValueMatcher decides whether the row changed events of a table carry old
+// values.
+type OldValueMatcher interface {
+	IsOldValueEnabled(schema, table string) bool
+}
+
+// tableDecoder decodes the rows of a table with a specific table info version.
+// It is not thread-safe.
+type tableDecoder struct {
+	tableInfo      *model.TableInfo
+	tz             *time.Location
+	enableOldValue bool
+	// needed is nil if all columns are decoded
+	needed       map[int64]struct{}
+	handleColIDs []int64
+	handleColFt  map[int64]*types.FieldType
+	rowDecoder   *rowcodec.DatumMapDecoder
+}
+
+func newTableDecoder(
+	tableInfo *model.TableInfo, selector ColumnSelector, enableOldValue bool, tz *time.Location,
+) *tableDecoder {
+	handleColIDs, handleColFt, reqCols := tableInfo.GetRowColInfos()
+	d := &tableDecoder{
+		tableInfo:      tableInfo,
+		tz:             tz,
+		enableOldValue: enableOldValue,
+		handleColIDs:   handleColIDs,
+		handleColFt:    handleColFt,
+	}
+	var names []string
+	if selector != nil {
+		names = selector.NeededColumns(tableInfo.TableName.Schema, tableInfo.TableName.Table)
+	}
+	if names != nil {
+		colIDs := make(map[string]int64, len(tableInfo.Columns))
+		for _, col := range tableInfo.Columns {
+			colIDs[col.Name.L] = col.ID
+		}
+		d.needed = make(map[int64]struct{}, len(names))
+		for _, name := range names {
+			if colID, ok := colIDs[strings.ToLower(name)]; ok {
+				d.needed[colID] = struct{}{}
+			}
+		}
+		for colID, flag := range tableInfo.ColumnsFlag {
+			if flag.IsHandleKey() {
+				d.needed[colID] = struct{}{}
+			}
+		}
+		for _, colID := range handleColIDs {
+			d.needed[colID] = struct{}{}
+		}
+		prunedCols := make([]rowcodec.ColInfo, 0, len(d.needed))
+		for _, col := range reqCols {
+			if _, ok := d.needed[col.ID]; ok {
+				prunedCols = append(prunedCols, col)
+			}
+		}
+		reqCols = prunedCols
+	}
+	d.rowDecoder = rowcodec.NewDatumMapDecoder(reqCols, tz)
+	return d
+}
+
+// isNeeded returns whether the column should be decoded.
+func (d *tableDecoder) isNeeded(colID int64) bool {
+	if d.needed == nil {
+		return true
+	}
+	_, ok := d.needed[colID]
+	return ok
+}
+
+// decodeRow decodes a byte slice into datums of the needed columns.
+func (d *tableDecoder) decodeRow(b []byte, recordID kv.Handle) (map[int64]types.Datum, error) {
+	if len(b) == 0 {
+		return map[int64]types.Datum{}, nil
+	}
+	var datums map[int64]types.Datum
+	var err error
+	if rowcodec.IsNewFormat(b) {
+		datums, err = decodeRowV2(b, d.rowDecoder)
+	} else {
+		datums, err = decodeRowV1(b, d.tableInfo, d.tz, d.needed)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return tablecodec.DecodeHandleToDatumMap(recordID, d.handleColIDs, d.handleColFt, d.tz, datums)
+}
+
+// tableDecoders caches the tableDecoders of a mounter worker, the decoder of a
+// table is rebuilt when the table info version changes. It is not thread-safe.
+type tableDecoders struct {
+	selector ColumnSelector
+	oldValue OldValueMatcher
+	tz       *time.Location
+	decoders map[model.TableID]*tableDecoder
+}
+
+func newTableDecoders(selector ColumnSelector, oldValue OldValueMatcher, tz *time.Location) *tableDecoders {
+	return &tableDecoders{
+		selector: selector,
+		oldValue: oldValue,
+		tz:       tz,
+		decoders: make(map[model.TableID]*tableDecoder),
+	}
+}
+
+func (ds *tableDecoders) get(physicalTableID model.TableID, tableInfo *model.TableInfo) *tableDecoder {
+	d, ok := ds.decoders[physicalTableID]
+	if ok && d.tableInfo.TableInfoVersion == tableInfo.TableInfoVersion {
+		return d
+	}
+	enableOldValue := ds.oldValue.IsOldValueEnabled(tableInfo.TableName.Schema, tableInfo.TableName.Table)
+	d = newTableDecoder(tableInfo, ds.selector, enableOldValue, ds.tz)
+	ds.decoders[physicalTableID] = d
+	return d
+}
@@ -0,0 +1,130 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package entry
+
+import (
+	"time"
+
+	"github.com/pingcap/check"
+	timodel "github.com/pingcap/parser/model"
+	"github.com/pingcap/parser/mysql"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/sessionctx/stmtctx"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/pingcap/tidb/types"
+	"github.com/pingcap/tidb/util/rowcodec"
+)
+
+type tableDecoderSuite struct{}
+
+var _ = check.Suite(&tableDecoderSuite{})
+
+type mockColumnSelector map[string][]string
+
+func (s mockColumnSelector) NeededColumns(schema, table string) []string {
+	return s[schema+"."+table]
+}
+
+type mockOldValueMatcher map[string]bool
+
+func (m mockOldValueMatcher) IsOldValueEnabled(schema, table string) bool {
+	return m[schema+"."+table]
+}
+
+func getInt64(datums map[int64]types.Datum, colID int64) int64 {
+	d := datums[colID]
+	return d.GetInt64()
+}
+
+func newTableDecoderTestInfo(version uint64) *model.TableInfo {
+	newCol := func(id int64, name string, flag uint) *timodel.ColumnInfo {
+		ft := types.NewFieldType(mysql.TypeLonglong)
+		ft.Flag = flag
+		return &timodel.ColumnInfo{
+			ID:        id,
+			Name:      timodel.NewCIStr(name),
+			Offset:    int(id - 1),
+			FieldType: *ft,
+			State:     timodel.StatePublic,
+		}
+	}
+	return model.WrapTableInfo(1, "test", version, &timodel.TableInfo{
+		ID:         100,
+		Name:       timodel.NewCIStr("t"),
+		PKIsHandle: true,
+		Columns: []*timodel.ColumnInfo{
+			newCol(1, "id", mysql.PriKeyFlag|mysql.NotNullFlag),
+			newCol(2, "a", 0),
+			newCol(3, "b", 0),
+		},
+	})
+}
+
+func (s *tableDecoderSuite) TestColumnPruning(c *check.C) {
+	defer testleak.AfterTest(c)()
+	tableInfo := newTableDecoderTestInfo(1)
+	sc := &stmtctx.StatementContext{TimeZone: time.UTC}
+	colIDs := []int64{2, 3}
+	values := []types.Datum{types.NewIntDatum(20), types.NewIntDatum(30)}
+
+	var encoder rowcodec.Encoder
+	rowV2, err := encoder.Encode(sc, colIDs, values, nil)
+	c.Assert(err, check.IsNil)
+	rowV1, err := tablecodec.EncodeOldRow(sc, values, colIDs, nil, nil)
+	c.Assert(err, check.IsNil)
+
+	selector := mockColumnSelector{"test.t": {"B"}}
+	for _, row := range [][]byte{rowV2, rowV1} {
+		// all columns are decoded without a selector
+		datums, err := newTableDecoder(tableInfo, nil, false, time.UTC).decodeRow(row, kv.IntHandle(10))
+		c.Assert(err, check.IsNil)
+		c.Assert(datums, check.HasLen, 3)
+		c.Assert(getInt64(datums, 2), check.Equals, int64(20))
+
+		// the handle column is always decoded
+		decoder := newTableDecoder(tableInfo, selector, false, time.UTC)
+		c.Assert(decoder.isNeeded(1), check.IsTrue)
+		c.Assert(decoder.isNeeded(2), check.IsFalse)
+		datums, err = decoder.decodeRow(row, kv.IntHandle(10))
+		c.Assert(err, check.IsNil)
+		c.Assert(datums, check.HasLen, 2)
+		c.Assert(getInt64(datums, 1), check.Equals, int64(10))
+		c.Assert(getInt64(datums, 3), check.Equals, int64(30))
+
+		cols, err := datum2Column(decoder, datums, true)
+		c.Assert(err, check.IsNil)
+		c.Assert(cols, check.HasLen, 3)
+		c.Assert(cols[0].Name, check.Equals, "id")
+		c.Assert(cols[1], check.IsNil)
+		c.Assert(cols[2].Value, check.Equals, int64(30))
+	}
+}
+
+func (s *tableDecoderSuite) TestTableDecodersCache(c *check.C) {
+	defer testleak.AfterTest(c)()
+	decoders := newTableDecoders(mockColumnSelector{"test.t": {}}, mockOldValueMatcher{"test.t": true}, time.UTC)
+	tableInfo := newTableDecoderTestInfo(1)
+	decoder := decoders.get(100, tableInfo)
+	c.Assert(decoders.get(100, tableInfo), check.Equals, decoder)
+	c.Assert(decoder.enableOldValue, check.IsTrue)
+	c.Assert(decoder.isNeeded(1), check.IsTrue)
+	c.Assert(decoder.isNeeded(3), check.IsFalse)
+
+	// the decoder is rebuilt after the table info changes
+	newDecoder := decoders.get(100, newTableDecoderTestInfo(2))
+	c.Assert(newDecoder, check.Not(check.Equals), decoder)
+	c.Assert(decoders.decoders, check.HasLen, 1)
+}
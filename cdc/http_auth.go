@@ -0,0 +1,246 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// the path parameter of the changefeed ID in the OpenAPI
+	apiOpVarChangefeedID = "changefeed_id"
+	// the key of the authenticated identity in the gin context
+	authIdentityKey = "ticdc-auth-identity"
+	// the identity recorded in the audit log when the authentication is disabled
+	anonymousIdentity = "anonymous"
+)
+
+// authIdentity is the authenticated caller of the HTTP API
+type authIdentity struct {
+	name string
+	role string
+	// forwardedBy is the common name of the certificate of the capture which
+	// forwards the call, it is empty if the call is not forwarded.
+	forwardedBy string
+}
+
+// authenticator authenticates the callers of the HTTP API by the static bearer
+// tokens or the common names of the client certificates, authorizes them by
+// their roles, and records the mutating calls to the audit log.
+type authenticator struct {
+	enable bool
+	// SHA-256 digest of the token -> token
+	tokens map[string]*config.AuthToken
+	// common name -> identity
+	certIdentities map[string]*config.AuthCertIdentity
+	// the common names of the certificates of the captures
+	peerCommonNames map[string]struct{}
+	audit           *zap.Logger
+}
+
+// newAuthenticator creates an authenticator from the auth config, the audit
+// log is written with the rotation settings of logFile.
+func newAuthenticator(
+	cfg *config.AuthConfig, security *config.SecurityConfig, logFile *config.LogFileConfig,
+) (*authenticator, error) {
+	if cfg == nil {
+		cfg = &config.AuthConfig{}
+	}
+	a := &authenticator{
+		enable:          cfg.Enable,
+		tokens:          make(map[string]*config.AuthToken, len(cfg.Tokens)),
+		certIdentities:  make(map[string]*config.AuthCertIdentity, len(cfg.CertIdentities)),
+		peerCommonNames: make(map[string]struct{}, len(cfg.PeerCommonNames)),
+		audit:           log.L().With(zap.String("component", "audit")),
+	}
+	for _, token := range cfg.Tokens {
+		a.tokens[strings.ToLower(token.SHA256)] = token
+	}
+	for _, identity := range cfg.CertIdentities {
+		a.certIdentities[identity.CommonName] = identity
+	}
+	for _, cn := range cfg.PeerCommonNames {
+		a.peerCommonNames[cn] = struct{}{}
+	}
+	if a.enable && len(a.peerCommonNames) == 0 && security != nil && security.IsTLSEnabled() && security.CertPath != "" {
+		cn, err := security.CertCommonName()
+		if err != nil {
+			return nil, errors.Annotate(err, "read the common name of the capture certificate")
+		}
+		a.peerCommonNames[cn] = struct{}{}
+	}
+	if cfg.AuditLogFile != "" {
+		logCfg := &log.Config{
+			Level: "info",
+			File: log.FileLogConfig{
+				Filename: cfg.AuditLogFile,
+			},
+		}
+		if logFile != nil {
+			logCfg.File.MaxSize = logFile.MaxSize
+			logCfg.File.MaxDays = logFile.MaxDays
+			logCfg.File.MaxBackups = logFile.MaxBackups
+		}
+		lg, _, err := log.InitLogger(logCfg)
+		if err != nil {
+			return nil, errors.Annotate(err, "init audit logger")
+		}
+		a.audit = lg
+	}
+	return a, nil
+}
+
+// authenticate returns the identity of the caller of the request. The
+// identity of a call forwarded by another capture is taken from the forwarded
+// headers, which are only trusted if the call is sent with the certificate of
+// a capture, because the client certificate of the original caller can not be
+// forwarded.
+func (a *authenticator) authenticate(req *http.Request) (*authIdentity, error) {
+	var cn string
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		cn = req.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	name := req.Header.Get(model.ForwardedIdentityHeader)
+	if _, ok := a.peerCommonNames[cn]; ok && cn != "" && name != "" {
+		role := req.Header.Get(model.ForwardedRoleHeader)
+		if !config.IsValidAuthRole(role) {
+			return nil, cerror.ErrAPIUnauthenticated.GenWithStackByArgs("unknown forwarded role " + role)
+		}
+		return &authIdentity{name: name, role: role, forwardedBy: cn}, nil
+	}
+
+	if header := req.Header.Get("Authorization"); header != "" {
+		const prefix = "Bearer "
+		if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+			return nil, cerror.ErrAPIUnauthenticated.GenWithStackByArgs("unsupported authorization scheme")
+		}
+		digest := sha256.Sum256([]byte(strings.TrimSpace(header[len(prefix):])))
+		token, ok := a.tokens[hex.EncodeToString(digest[:])]
+		if !ok {
+			return nil, cerror.ErrAPIUnauthenticated.GenWithStackByArgs("invalid token")
+		}
+		return &authIdentity{name: "token:" + token.Name, role: token.Role}, nil
+	}
+	if cn != "" {
+		identity, ok := a.certIdentities[cn]
+		if !ok {
+			if name != "" {
+				return nil, cerror.ErrAPIUnauthenticated.GenWithStackByArgs(
+					"the call of " + name + " is forwarded with the certificate " + cn +
+						", which is neither a capture certificate nor a cert identity")
+			}
+			return nil, cerror.ErrAPIUnauthenticated.GenWithStackByArgs("unknown certificate common name " + cn)
+		}
+		return &authIdentity{name: "cert:" + cn, role: identity.Role}, nil
+	}
+	return nil, cerror.ErrAPIUnauthenticated.GenWithStackByArgs("no token or client certificate is provided")
+}
+
+// require returns a middleware that only lets the callers with the role or a
+// more privileged role through. The calls that mutate the cluster, i.e. the
+// non-GET calls which require a role above viewer, are recorded to the audit
+// log whether or not the authentication is enabled.
+func (a *authenticator) require(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identity := &authIdentity{name: anonymousIdentity}
+		if a.enable {
+			var err error
+			identity, err = a.authenticate(c.Request)
+			if err != nil {
+				a.auditCall(c, identity, time.Now(), err)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, model.NewHTTPError(err))
+				return
+			}
+			if config.AuthRoleLevel(identity.role) < config.AuthRoleLevel(role) {
+				err = cerror.ErrAPIPermissionDenied.GenWithStackByArgs(role)
+				a.auditCall(c, identity, time.Now(), err)
+				c.AbortWithStatusJSON(http.StatusForbidden, model.NewHTTPError(err))
+				return
+			}
+			// the identity is sent with the call if the handler forwards it
+			// to another capture
+			c.Request.Header.Set(model.ForwardedIdentityHeader, identity.name)
+			c.Request.Header.Set(model.ForwardedRoleHeader, identity.role)
+		}
+		c.Set(authIdentityKey, identity)
+		if !isMutatingCall(c.Request.Method, role) {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		c.Next()
+		a.auditCall(c, identity, start, nil)
+	}
+}
+
+func isMutatingCall(method, role string) bool {
+	if method == http.MethodGet || method == http.MethodHead {
+		return false
+	}
+	return config.AuthRoleLevel(role) > config.AuthRoleLevel(config.AuthRoleViewer)
+}
+
+// auditCall writes a call to the audit log, identity is nil if the caller is
+// not authenticated, err is the reason why the call is rejected.
+func (a *authenticator) auditCall(c *gin.Context, identity *authIdentity, start time.Time, err error) {
+	name, role := "", ""
+	if identity != nil {
+		name, role = identity.name, identity.role
+	}
+	status := c.Writer.Status()
+	if err != nil {
+		if identity == nil {
+			status = http.StatusUnauthorized
+		} else {
+			status = http.StatusForbidden
+		}
+	}
+	fields := []zap.Field{
+		zap.String("identity", name),
+		zap.String("role", role),
+		zap.String("remote-addr", c.Request.RemoteAddr),
+		zap.String("method", c.Request.Method),
+		zap.String("path", c.Request.URL.Path),
+		zap.Int("status", status),
+		zap.Duration("duration", time.Since(start)),
+	}
+	changefeedID := c.Param(apiOpVarChangefeedID)
+	if changefeedID == "" && c.Request.Form != nil {
+		// the legacy API takes the changefeed ID from the form, which has
+		// been parsed by the handler
+		changefeedID = c.Request.Form.Get(APIOpVarChangefeedID)
+	}
+	if changefeedID != "" {
+		fields = append(fields, zap.String("changefeed-id", changefeedID))
+	}
+	if identity != nil && identity.forwardedBy != "" {
+		fields = append(fields, zap.String("forwarded-by", identity.forwardedBy))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	a.audit.Info("audit http api call", fields...)
+}
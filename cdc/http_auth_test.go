@@ -0,0 +1,214 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdc
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type httpAuthSuite struct{}
+
+var _ = check.Suite(&httpAuthSuite{})
+
+func sha256Hex(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+func newAuthTestRouter(c *check.C, cfg *config.AuthConfig) (*gin.Engine, *observer.ObservedLogs) {
+	auth, err := newAuthenticator(cfg, nil, nil)
+	c.Assert(err, check.IsNil)
+	core, logs := observer.New(zapcore.InfoLevel)
+	auth.audit = zap.New(core)
+
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	router.GET("/api/v1/changefeeds", auth.require(config.AuthRoleViewer), ok)
+	router.DELETE("/api/v1/changefeeds/:changefeed_id", auth.require(config.AuthRoleOperator), ok)
+	router.POST("/api/v1/owner/resign", auth.require(config.AuthRoleAdmin), ok)
+	router.POST("/capture/owner/admin", auth.require(config.AuthRoleOperator), func(c *gin.Context) {
+		_ = c.Request.ParseForm()
+		c.Status(http.StatusOK)
+	})
+	return router, logs
+}
+
+func doAuthRequest(router *gin.Engine, method, path, token string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func (s *httpAuthSuite) TestTokenAuth(c *check.C) {
+	defer testleak.AfterTest(c)()
+	router, logs := newAuthTestRouter(c, &config.AuthConfig{
+		Enable: true,
+		Tokens: []*config.AuthToken{
+			{Name: "portal", SHA256: sha256Hex("portal-token"), Role: config.AuthRoleOperator},
+			{Name: "dashboard", SHA256: strings.ToUpper(sha256Hex("dashboard-token")), Role: config.AuthRoleViewer},
+		},
+	})
+
+	c.Assert(doAuthRequest(router, http.MethodGet, "/api/v1/changefeeds", ""), check.Equals, http.StatusUnauthorized)
+	c.Assert(doAuthRequest(router, http.MethodGet, "/api/v1/changefeeds", "wrong"), check.Equals, http.StatusUnauthorized)
+	c.Assert(doAuthRequest(router, http.MethodGet, "/api/v1/changefeeds", "dashboard-token"), check.Equals, http.StatusOK)
+	c.Assert(doAuthRequest(router, http.MethodGet, "/api/v1/changefeeds", "portal-token"), check.Equals, http.StatusOK)
+	c.Assert(doAuthRequest(router, http.MethodDelete, "/api/v1/changefeeds/cf1", "dashboard-token"), check.Equals, http.StatusForbidden)
+	c.Assert(doAuthRequest(router, http.MethodDelete, "/api/v1/changefeeds/cf1", "portal-token"), check.Equals, http.StatusOK)
+	c.Assert(doAuthRequest(router, http.MethodPost, "/api/v1/owner/resign", "portal-token"), check.Equals, http.StatusForbidden)
+
+	// the rejected calls and the mutating calls are audited, the successful
+	// read only calls are not
+	entries := logs.AllUntimed()
+	c.Assert(entries, check.HasLen, 5)
+	c.Assert(entries[0].ContextMap()["status"], check.Equals, int64(http.StatusUnauthorized))
+	c.Assert(entries[1].ContextMap()["status"], check.Equals, int64(http.StatusUnauthorized))
+	deleted := entries[3].ContextMap()
+	c.Assert(deleted["identity"], check.Equals, "token:portal")
+	c.Assert(deleted["role"], check.Equals, config.AuthRoleOperator)
+	c.Assert(deleted["method"], check.Equals, http.MethodDelete)
+	c.Assert(deleted["changefeed-id"], check.Equals, "cf1")
+	c.Assert(deleted["status"], check.Equals, int64(http.StatusOK))
+	c.Assert(entries[4].ContextMap()["status"], check.Equals, int64(http.StatusForbidden))
+}
+
+func (s *httpAuthSuite) TestCertAuth(c *check.C) {
+	defer testleak.AfterTest(c)()
+	router, _ := newAuthTestRouter(c, &config.AuthConfig{
+		Enable: true,
+		CertIdentities: []*config.AuthCertIdentity{
+			{CommonName: "portal", Role: config.AuthRoleAdmin},
+		},
+	})
+	doCertRequest := func(cn string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/owner/resign", nil)
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: cn}}},
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	c.Assert(doCertRequest("portal"), check.Equals, http.StatusOK)
+	c.Assert(doCertRequest("unknown"), check.Equals, http.StatusUnauthorized)
+}
+
+func (s *httpAuthSuite) TestAuthDisabled(c *check.C) {
+	defer testleak.AfterTest(c)()
+	router, logs := newAuthTestRouter(c, nil)
+	c.Assert(doAuthRequest(router, http.MethodGet, "/api/v1/changefeeds", ""), check.Equals, http.StatusOK)
+	c.Assert(doAuthRequest(router, http.MethodPost, "/api/v1/owner/resign", ""), check.Equals, http.StatusOK)
+
+	req := httptest.NewRequest(http.MethodPost, "/capture/owner/admin",
+		strings.NewReader(url.Values{APIOpVarChangefeedID: {"cf2"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	// mutating calls are audited even if the authentication is disabled
+	entries := logs.AllUntimed()
+	c.Assert(entries, check.HasLen, 2)
+	c.Assert(entries[0].ContextMap()["identity"], check.Equals, anonymousIdentity)
+	c.Assert(entries[1].ContextMap()["changefeed-id"], check.Equals, "cf2")
+}
+
+func (s *httpAuthSuite) TestForwardedCertAuth(c *check.C) {
+	defer testleak.AfterTest(c)()
+	cfg := &config.AuthConfig{
+		Enable: true,
+		Tokens: []*config.AuthToken{
+			{Name: "portal", SHA256: sha256Hex("portal-token"), Role: config.AuthRoleOperator},
+		},
+		CertIdentities: []*config.AuthCertIdentity{
+			{CommonName: "portal", Role: config.AuthRoleOperator},
+			{CommonName: "dashboard", Role: config.AuthRoleViewer},
+		},
+		PeerCommonNames: []string{"capture"},
+	}
+	owner, logs := newAuthTestRouter(c, cfg)
+	newCertRequest := func(cn string) *http.Request {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/changefeeds/cf1", nil)
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: cn}}},
+		}
+		return req
+	}
+
+	// the capture forwards the calls to the owner with its own certificate
+	auth, err := newAuthenticator(cfg, nil, nil)
+	c.Assert(err, check.IsNil)
+	auth.audit = zap.NewNop()
+	capture := gin.New()
+	capture.DELETE("/api/v1/changefeeds/:changefeed_id", auth.require(config.AuthRoleOperator), func(ctx *gin.Context) {
+		req := newCertRequest("capture")
+		req.Header = ctx.Request.Header.Clone()
+		w := httptest.NewRecorder()
+		owner.ServeHTTP(w, req)
+		ctx.Status(w.Code)
+	})
+	doForward := func(req *http.Request) int {
+		w := httptest.NewRecorder()
+		capture.ServeHTTP(w, req)
+		return w.Code
+	}
+	c.Assert(doForward(newCertRequest("portal")), check.Equals, http.StatusOK)
+	c.Assert(doForward(newCertRequest("dashboard")), check.Equals, http.StatusForbidden)
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/changefeeds/cf1", nil)
+	req.Header.Set("Authorization", "Bearer portal-token")
+	c.Assert(doForward(req), check.Equals, http.StatusOK)
+
+	entries := logs.AllUntimed()
+	c.Assert(entries, check.HasLen, 2)
+	forwarded := entries[0].ContextMap()
+	c.Assert(forwarded["identity"], check.Equals, "cert:portal")
+	c.Assert(forwarded["role"], check.Equals, config.AuthRoleOperator)
+	c.Assert(forwarded["forwarded-by"], check.Equals, "capture")
+	c.Assert(entries[1].ContextMap()["identity"], check.Equals, "token:portal")
+
+	// the forwarded identity is not trusted if the call is not sent with the
+	// certificate of a capture
+	req = newCertRequest("dashboard")
+	req.Header.Set(model.ForwardedIdentityHeader, "cert:portal")
+	req.Header.Set(model.ForwardedRoleHeader, config.AuthRoleAdmin)
+	w := httptest.NewRecorder()
+	owner.ServeHTTP(w, req)
+	c.Assert(w.Code, check.Equals, http.StatusForbidden)
+	req = newCertRequest("unknown")
+	req.Header.Set(model.ForwardedIdentityHeader, "cert:portal")
+	req.Header.Set(model.ForwardedRoleHeader, config.AuthRoleAdmin)
+	w = httptest.NewRecorder()
+	owner.ServeHTTP(w, req)
+	c.Assert(w.Code, check.Equals, http.StatusUnauthorized)
+	c.Assert(w.Body.String(), check.Matches, "(?s).*neither a capture certificate nor a cert identity.*")
+}
@@ -43,6 +43,14 @@ const (
 	APIOpVarTableID = "table-id"
 	// APIOpForceRemoveChangefeed is used when remove a changefeed
 	APIOpForceRemoveChangefeed = "force-remove"
+	// APIOpVarStopTs is the key of stop ts in HTTP API
+	APIOpVarStopTs = "stop-ts"
+	// APIOpVarTargetTs is the key of target ts in HTTP API
+	APIOpVarTargetTs = "target-ts"
+	// APIOpVarOverwriteCheckpointTs is the key of overwritten checkpoint ts in HTTP API
+	APIOpVarOverwriteCheckpointTs = "overwrite-checkpoint-ts"
+	// APIOpVarLimit is the key of the max number of returned items in HTTP API
+	APIOpVarLimit = "limit"
 )
 
 type commonResp struct {
@@ -111,6 +119,22 @@ func (s *Server) handleChangefeedAdmin(w http.ResponseWriter, req *http.Request)
 		}
 		opts.ForceRemove = forceRemoveOpt
 	}
+	for key, ts := range map[string]*uint64{
+		APIOpVarStopTs:                &opts.StopTs,
+		APIOpVarTargetTs:              &opts.TargetTs,
+		APIOpVarOverwriteCheckpointTs: &opts.OverwriteCheckpointTs,
+	} {
+		tsStr := req.Form.Get(key)
+		if tsStr == "" {
+			continue
+		}
+		*ts, err = strconv.ParseUint(tsStr, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest,
+				cerror.ErrAPIInvalidParam.GenWithStack("invalid %s option: %s", key, tsStr))
+			return
+		}
+	}
 	job := model.AdminJob{
 		CfID: req.Form.Get(APIOpVarChangefeedID),
 		Type: model.AdminJobType(typ),
@@ -22,23 +22,32 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/pingcap/ticdc/cdc/capture"
+	"github.com/pingcap/ticdc/pkg/config"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	// use for OpenAPI online docs
 	_ "github.com/pingcap/ticdc/docs/api"
 )
 
-// newRouter create a router for OpenAPI
-func newRouter(capture2 *capture.Capture) *gin.Engine {
+// watchAPIPath is the path of the watch API, which streams the state changes
+// until the client disconnects
+const watchAPIPath = "/api/v1/watch"
+
+// newRouter create a router for OpenAPI, the routes except the health, status
+// and docs ones are guarded by the roles of auth.
+func newRouter(capture2 *capture.Capture, auth *authenticator) *gin.Engine {
 	// discard gin log output
 	gin.DefaultWriter = ioutil.Discard
 
 	router := gin.New()
 
 	// request will timeout after 10 second
-	router.Use(timeoutMiddleware(time.Second * 10))
+	router.Use(timeoutMiddleware(time.Second*10, watchAPIPath))
 
 	captureHandler := capture.NewHTTPHandler(capture2)
+	viewer := auth.require(config.AuthRoleViewer)
+	operator := auth.require(config.AuthRoleOperator)
+	admin := auth.require(config.AuthRoleAdmin)
 
 	// OpenAPI online docs
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -46,43 +55,49 @@ func newRouter(capture2 *capture.Capture) *gin.Engine {
 	// common API
 	router.GET("/api/v1/status", captureHandler.ServerStatus)
 	router.GET("/api/v1/health", captureHandler.Health)
-	router.POST("/api/v1/log", capture.SetLogLevel)
+	router.GET("/api/v1/log", viewer, capture.GetLogLevel)
+	router.POST("/api/v1/log", admin, capture.SetLogLevel)
+	router.GET(watchAPIPath, viewer, captureHandler.Watch)
 
 	// changefeed API
 	changefeedGroup := router.Group("/api/v1/changefeeds")
 	{
-		changefeedGroup.GET("", captureHandler.ListChangefeed)
-		changefeedGroup.GET("/:changefeed_id", captureHandler.GetChangefeed)
-		changefeedGroup.POST("", captureHandler.CreateChangefeed)
-		changefeedGroup.PUT("/:changefeed_id", captureHandler.UpdateChangefeed)
-		changefeedGroup.POST("/:changefeed_id/pause", captureHandler.PauseChangefeed)
-		changefeedGroup.POST("/:changefeed_id/resume", captureHandler.ResumeChangefeed)
-		changefeedGroup.DELETE("/:changefeed_id", captureHandler.RemoveChangefeed)
-		changefeedGroup.POST("/:changefeed_id/tables/rebalance_table", captureHandler.RebalanceTable)
-		changefeedGroup.POST("/:changefeed_id/tables/move_table", captureHandler.MoveTable)
+		changefeedGroup.GET("", viewer, captureHandler.ListChangefeed)
+		changefeedGroup.GET("/:changefeed_id", viewer, captureHandler.GetChangefeed)
+		changefeedGroup.POST("", operator, captureHandler.CreateChangefeed)
+		changefeedGroup.PUT("/:changefeed_id", operator, captureHandler.UpdateChangefeed)
+		changefeedGroup.POST("/:changefeed_id/pause", operator, captureHandler.PauseChangefeed)
+		changefeedGroup.POST("/:changefeed_id/resume", operator, captureHandler.ResumeChangefeed)
+		changefeedGroup.POST("/:changefeed_id/target_ts", operator, captureHandler.UpdateChangefeedTargetTs)
+		changefeedGroup.DELETE("/:changefeed_id", operator, captureHandler.RemoveChangefeed)
+		changefeedGroup.POST("/:changefeed_id/tables/rebalance_table", operator, captureHandler.RebalanceTable)
+		changefeedGroup.POST("/:changefeed_id/tables/move_table", operator, captureHandler.MoveTable)
+		changefeedGroup.GET("/:changefeed_id/tables", viewer, captureHandler.ListTables)
 	}
 
 	// owner API
-	ownerGroup := router.Group("/api/v1/owner")
+	ownerGroup := router.Group("/api/v1/owner", admin)
 	{
 		ownerGroup.POST("/resign", captureHandler.ResignOwner)
 	}
 
 	// processor API
-	processorGroup := router.Group("/api/v1/processors")
+	processorGroup := router.Group("/api/v1/processors", viewer)
 	{
 		processorGroup.GET("", captureHandler.ListProcessor)
 		processorGroup.GET("/:changefeed_id/:capture_id", captureHandler.GetProcessor)
+		processorGroup.GET("/:changefeed_id/:capture_id/tables", captureHandler.GetProcessorTables)
 	}
 
 	// capture API
 	captureGroup := router.Group("/api/v1/captures")
 	{
-		captureGroup.GET("", captureHandler.ListCapture)
+		captureGroup.GET("", viewer, captureHandler.ListCapture)
+		captureGroup.POST("/:capture_id/drain", admin, captureHandler.DrainCapture)
 	}
 
 	// pprof debug API
-	pprofGroup := router.Group("/debug/pprof")
+	pprofGroup := router.Group("/debug/pprof", admin)
 	{
 		pprofGroup.GET("", gin.WrapF(pprof.Index))
 		pprofGroup.GET("/:any", gin.WrapF(pprof.Index))
@@ -95,9 +110,16 @@ func newRouter(capture2 *capture.Capture) *gin.Engine {
 	return router
 }
 
-// timeoutMiddleware wraps the request context with a timeout
-func timeoutMiddleware(timeout time.Duration) func(c *gin.Context) {
+// timeoutMiddleware wraps the request context with a timeout, the requests to
+// skipPaths are long-lived streams and are not limited.
+func timeoutMiddleware(timeout time.Duration, skipPaths ...string) func(c *gin.Context) {
 	return func(c *gin.Context) {
+		for _, path := range skipPaths {
+			if c.FullPath() == path {
+				c.Next()
+				return
+			}
+		}
 		// wrap the request context with a timeout
 		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 
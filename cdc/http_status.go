@@ -21,14 +21,17 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/pkg/config"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/pingcap/ticdc/pkg/version"
 	"github.com/prometheus/client_golang/prometheus"
@@ -38,26 +41,37 @@ import (
 )
 
 func (s *Server) startStatusHTTP() error {
-	router := newRouter(s.capture)
+	conf := config.GetGlobalServerConfig()
+	auth, err := newAuthenticator(conf.Auth, conf.Security, conf.Log.File)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	viewer := auth.require(config.AuthRoleViewer)
+	operator := auth.require(config.AuthRoleOperator)
+	admin := auth.require(config.AuthRoleAdmin)
+
+	router := newRouter(s.capture, auth)
 
 	router.GET("/status", gin.WrapF(s.handleStatus))
-	router.GET("/debug/info", gin.WrapF(s.handleDebugInfo))
-	router.POST("/capture/owner/resign", gin.WrapF(s.handleResignOwner))
-	router.POST("/capture/owner/admin", gin.WrapF(s.handleChangefeedAdmin))
-	router.POST("/capture/owner/rebalance_trigger", gin.WrapF(s.handleRebalanceTrigger))
-	router.POST("/capture/owner/move_table", gin.WrapF(s.handleMoveTable))
-	router.POST("/capture/owner/changefeed/query", gin.WrapF(s.handleChangefeedQuery))
-	router.POST("/admin/log", gin.WrapF(handleAdminLogLevel))
+	router.GET("/debug/info", admin, gin.WrapF(s.handleDebugInfo))
+	router.GET("/debug/region-lag", viewer, gin.WrapF(handleRegionLag))
+	router.GET("/debug/config", admin, gin.WrapF(handleDebugConfig))
+	router.GET("/debug/log", admin, gin.WrapF(handleDebugLog))
+	router.POST("/capture/owner/resign", admin, gin.WrapF(s.handleResignOwner))
+	router.POST("/capture/owner/admin", operator, gin.WrapF(s.handleChangefeedAdmin))
+	router.POST("/capture/owner/rebalance_trigger", operator, gin.WrapF(s.handleRebalanceTrigger))
+	router.POST("/capture/owner/move_table", operator, gin.WrapF(s.handleMoveTable))
+	router.POST("/capture/owner/changefeed/query", viewer, gin.WrapF(s.handleChangefeedQuery))
+	router.POST("/admin/log", admin, gin.WrapF(handleAdminLogLevel))
 
 	if util.FailpointBuild {
 		// `http.StripPrefix` is needed because `failpoint.HttpHandler` assumes that it handles the prefix `/`.
-		router.Any("/debug/fail/*any", gin.WrapH(http.StripPrefix("/debug/fail", &failpoint.HttpHandler{})))
+		router.Any("/debug/fail/*any", admin, gin.WrapH(http.StripPrefix("/debug/fail", &failpoint.HttpHandler{})))
 	}
 
 	prometheus.DefaultGatherer = registry
 	router.Any("/metrics", gin.WrapH(promhttp.Handler()))
 
-	conf := config.GetGlobalServerConfig()
 	tlsConfig, err := conf.Security.ToTLSConfigWithVerify()
 	if err != nil {
 		log.Error("status server get tls config failed", zap.Error(err))
@@ -100,8 +114,9 @@ func (s *Server) writeEtcdInfo(ctx context.Context, cli *kv.CDCEtcdClient, w io.
 		return
 	}
 
+	// the changefeed infos contain the credentials of the sinks
 	for _, kv := range resp.Kvs {
-		fmt.Fprintf(w, "%s\n\t%s\n\n", string(kv.Key), string(kv.Value))
+		fmt.Fprintf(w, "%s\n\t%s\n\n", string(kv.Key), security.RedactText(string(kv.Value)))
 	}
 }
 
@@ -111,6 +126,95 @@ func (s *Server) handleDebugInfo(w http.ResponseWriter, req *http.Request) {
 	s.writeEtcdInfo(req.Context(), s.etcdClient, w)
 }
 
+// defaultSlowestRegionLimit is the number of regions returned for each table
+// by /debug/region-lag if the limit is not specified.
+const defaultSlowestRegionLimit = 10
+
+// handleRegionLag returns the regions with the smallest resolved ts of each
+// table of a changefeed replicated by this capture.
+func handleRegionLag(w http.ResponseWriter, req *http.Request) {
+	err := req.ParseForm()
+	if err != nil {
+		writeInternalServerError(w, err)
+		return
+	}
+	changefeedID := req.Form.Get(APIOpVarChangefeedID)
+	if err := model.ValidateChangefeedID(changefeedID); err != nil {
+		writeError(w, http.StatusBadRequest,
+			cerror.ErrAPIInvalidParam.GenWithStack("invalid changefeed id: %s", changefeedID))
+		return
+	}
+	limit := defaultSlowestRegionLimit
+	if limitStr := req.Form.Get(APIOpVarLimit); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest,
+				cerror.ErrAPIInvalidParam.GenWithStack("invalid limit: %s", limitStr))
+			return
+		}
+	}
+	writeData(w, kv.SlowestRegions(changefeedID, limit))
+}
+
+// handleDebugConfig returns the server configuration of this capture with
+// the secrets redacted.
+func handleDebugConfig(w http.ResponseWriter, req *http.Request) {
+	writeData(w, config.GetGlobalServerConfig().Redacted())
+}
+
+// defaultDebugLogLimit is the number of bytes returned by /debug/log if the
+// limit is not specified.
+const defaultDebugLogLimit = 64 * 1024 * 1024
+
+// handleDebugLog returns the tail of the log file of this capture.
+func handleDebugLog(w http.ResponseWriter, req *http.Request) {
+	err := req.ParseForm()
+	if err != nil {
+		writeInternalServerError(w, err)
+		return
+	}
+	limit := int64(defaultDebugLogLimit)
+	if limitStr := req.Form.Get(APIOpVarLimit); limitStr != "" {
+		limit, err = strconv.ParseInt(limitStr, 10, 64)
+		if err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest,
+				cerror.ErrAPIInvalidParam.GenWithStack("invalid limit: %s", limitStr))
+			return
+		}
+	}
+	logFile := config.GetGlobalServerConfig().LogFile
+	if logFile == "" {
+		writeError(w, http.StatusNotFound,
+			cerror.ErrAPIInvalidParam.GenWithStack("the log of this capture is not written to a file"))
+		return
+	}
+	f, err := os.Open(logFile)
+	if err != nil {
+		writeInternalServerError(w, err)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		writeInternalServerError(w, err)
+		return
+	}
+	// the log file keeps growing, only the part before the request is returned
+	size := info.Size()
+	if size > limit {
+		if _, err := f.Seek(size-limit, io.SeekStart); err != nil {
+			writeInternalServerError(w, err)
+			return
+		}
+		size = limit
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.CopyN(w, f, size); err != nil {
+		log.Warn("fail to write log", zap.Error(err))
+	}
+}
+
 func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
 	st := status{
 		Version: version.ReleaseVersion,
@@ -18,7 +18,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"time"
 
 	"github.com/pingcap/check"
@@ -73,6 +75,7 @@ func (s *httpStatusSuite) TestHTTPStatus(c *check.C) {
 	testHandleRebalance(c)
 	testHandleMoveTable(c)
 	testHandleChangefeedQuery(c)
+	testHandleRegionLag(c)
 	testHandleFailpoint(c)
 }
 
@@ -116,6 +119,24 @@ func testHandleChangefeedQuery(c *check.C) {
 	testRequestNonOwnerFailed(c, uri)
 }
 
+func testHandleRegionLag(c *check.C) {
+	get := func(query string) (int, string) {
+		resp, err := http.Get(fmt.Sprintf("http://%s/debug/region-lag?%s", advertiseAddr4Test, query))
+		c.Assert(err, check.IsNil)
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		c.Assert(err, check.IsNil)
+		return resp.StatusCode, string(data)
+	}
+	code, _ := get("cf-id=")
+	c.Assert(code, check.Equals, http.StatusBadRequest)
+	code, _ = get("cf-id=test&limit=-1")
+	c.Assert(code, check.Equals, http.StatusBadRequest)
+	code, data := get("cf-id=test&limit=5")
+	c.Assert(code, check.Equals, http.StatusOK)
+	c.Assert(data, check.Equals, "[]")
+}
+
 func testRequestNonOwnerFailed(c *check.C, uri string) {
 	resp, err := http.PostForm(uri, url.Values{})
 	c.Assert(err, check.IsNil)
@@ -159,3 +180,35 @@ func testHandleFailpoint(c *check.C) {
 	})
 	c.Assert(failpointHit, check.IsFalse)
 }
+
+func (s *httpStatusSuite) TestHandleDebugLogAndConfig(c *check.C) {
+	defer testleak.AfterTest(c)()
+	oldConf := config.GetGlobalServerConfig()
+	defer config.StoreGlobalServerConfig(oldConf)
+
+	get := func(handler http.HandlerFunc, query string) (int, string) {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+		return w.Code, w.Body.String()
+	}
+
+	conf := config.GetDefaultServerConfig()
+	conf.Auth.Tokens = []*config.AuthToken{{Name: "admin", SHA256: "digest", Role: config.AuthRoleAdmin}}
+	config.StoreGlobalServerConfig(conf)
+	code, data := get(handleDebugConfig, "")
+	c.Assert(code, check.Equals, http.StatusOK)
+	c.Assert(data, check.Not(check.Matches), "(?s).*digest.*")
+	code, _ = get(handleDebugLog, "")
+	c.Assert(code, check.Equals, http.StatusNotFound)
+
+	conf.LogFile = filepath.Join(c.MkDir(), "cdc.log")
+	c.Assert(ioutil.WriteFile(conf.LogFile, []byte("line1\nline2\n"), 0o644), check.IsNil)
+	code, data = get(handleDebugLog, "")
+	c.Assert(code, check.Equals, http.StatusOK)
+	c.Assert(data, check.Equals, "line1\nline2\n")
+	code, data = get(handleDebugLog, "limit=6")
+	c.Assert(code, check.Equals, http.StatusOK)
+	c.Assert(data, check.Equals, "line2\n")
+	code, _ = get(handleDebugLog, "limit=0")
+	c.Assert(code, check.Equals, http.StatusBadRequest)
+}
@@ -161,6 +161,8 @@ type regionFeedState struct {
 	matcher        *matcher
 	startFeedTime  time.Time
 	lastResolvedTs uint64
+
+	lagStats regionLagStats
 }
 
 func newRegionFeedState(sri singleRegionInfo, requestID uint64) *regionFeedState {
@@ -169,6 +171,7 @@ func newRegionFeedState(sri singleRegionInfo, requestID uint64) *regionFeedState
 		requestID:     requestID,
 		regionEventCh: make(chan regionEvent, 16),
 		stopped:       0,
+		lagStats:      regionLagStats{resolvedTs: sri.ts},
 	}
 }
 
@@ -305,6 +308,9 @@ type CDCClient struct {
 	kvStorage   TiKVStorage
 
 	regionLimiters *regionEventFeedLimiters
+
+	// streamMux is nil if stream multiplexing is disabled.
+	streamMux *streamMultiplexer
 }
 
 // NewCDCClient creates a CDCClient instance
@@ -329,6 +335,9 @@ func NewCDCClient(ctx context.Context, pd pd.Client, kvStorage tikv.Storage, grp
 		regionCache:    tikv.NewRegionCache(pd),
 		regionLimiters: defaultRegionEventFeedLimiters,
 	}
+	if config.GetGlobalServerConfig().KVClient.EnableStreamMultiplexing {
+		c.(*CDCClient).streamMux = getStreamMultiplexer(grpcPool)
+	}
 	return
 }
 
@@ -387,6 +396,26 @@ func (c *CDCClient) newStream(ctx context.Context, addr string, storeID uint64)
 	return
 }
 
+// newEventFeedStream creates a stream to the store for an event feed session.
+// If stream multiplexing is enabled, the returned stream shares the ChangeData
+// streams to the store with other sessions of the capture, and its conn is nil.
+func (c *CDCClient) newEventFeedStream(ctx context.Context, addr string, storeID uint64) (*eventFeedStream, error) {
+	if c.streamMux == nil {
+		return c.newStream(ctx, addr, storeID)
+	}
+	create := func(ctx context.Context) (*eventFeedStream, error) {
+		return c.newStream(ctx, addr, storeID)
+	}
+	release := func(stream *eventFeedStream) {
+		c.grpcPool.ReleaseConn(stream.conn, addr)
+	}
+	client, err := c.streamMux.newMuxStream(ctx, addr, create, release)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &eventFeedStream{client: client}, nil
+}
+
 // PullerInitialization is a workaround to solved cyclic import.
 type PullerInitialization interface {
 	IsInitialized() bool
@@ -406,6 +435,9 @@ func (c *CDCClient) EventFeed(
 	s := newEventFeedSession(ctx, c, c.regionCache, c.kvStorage, span,
 		lockResolver, isPullerInit,
 		enableOldValue, ts, eventCh)
+	tableID, _ := util.TableIDFromCtx(ctx)
+	unregister := registerRegionLagTracker(util.ChangefeedIDFromCtx(ctx), tableID, s.lagTracker)
+	defer unregister()
 	return s.eventFeed(ctx, ts)
 }
 
@@ -436,6 +468,8 @@ type eventFeedSession struct {
 	// The token based region router, it controls the uninitialized regions with
 	// a given size limit.
 	regionRouter LimitRegionRouter
+	// storeScanLimiter limits the incremental scans of all tables in a store
+	storeScanLimiter *storeScanLimiter
 	// The channel to put the region that will be sent requests.
 	regionCh chan singleRegionInfo
 	// The channel to notify that an error is happening, so that the error will be handled and the affected region
@@ -448,6 +482,13 @@ type eventFeedSession struct {
 	enableOldValue   bool
 	enableKVClientV2 bool
 
+	// lagTracker tracks the regions for diagnosing resolved ts lag
+	lagTracker *regionLagTracker
+
+	// changefeedID is the changefeed the events are pulled for, it is used to
+	// trace the events
+	changefeedID string
+
 	// To identify metrics of different eventFeedSession
 	id                string
 	regionChSizeGauge prometheus.Gauge
@@ -485,6 +526,7 @@ func newEventFeedSession(
 		totalSpan:         totalSpan,
 		eventCh:           eventCh,
 		regionRouter:      NewSizedRegionRouter(ctx, kvClientCfg.RegionScanLimit),
+		storeScanLimiter:  getStoreScanLimiter(),
 		regionCh:          make(chan singleRegionInfo, defaultRegionChanSize),
 		errCh:             make(chan regionErrorInfo, defaultRegionChanSize),
 		requestRangeCh:    make(chan rangeRequestTask, defaultRegionChanSize),
@@ -499,6 +541,8 @@ func newEventFeedSession(
 		rangeChSizeGauge:  clientChannelSize.WithLabelValues("range"),
 		streams:           make(map[string]*eventFeedStream),
 		streamsCanceller:  make(map[string]context.CancelFunc),
+		lagTracker:        newRegionLagTracker(),
+		changefeedID:      util.ChangefeedIDFromCtx(ctx),
 	}
 }
 
@@ -638,6 +682,7 @@ func (s *eventFeedSession) scheduleRegionRequest(ctx context.Context, sri single
 // CAUTION: Note that this should only be called in a context that the region has locked it's range.
 func (s *eventFeedSession) onRegionFail(ctx context.Context, errorInfo regionErrorInfo, revokeToken bool) error {
 	log.Debug("region failed", zap.Uint64("regionID", errorInfo.verID.GetID()), zap.Error(errorInfo.err))
+	s.lagTracker.untrack(errorInfo.verID.GetID())
 	s.rangeLock.UnlockRange(errorInfo.span.Start, errorInfo.span.End, errorInfo.verID.GetID(), errorInfo.verID.GetVer(), errorInfo.ts)
 	if revokeToken {
 		s.regionRouter.Release(errorInfo.rpcCtx.Addr)
@@ -730,7 +775,7 @@ func (s *eventFeedSession) requestRegionToStore(
 				zap.String("addr", rpcCtx.Addr))
 			streamCtx, streamCancel := context.WithCancel(ctx)
 			_ = streamCancel // to avoid possible context leak warning from govet
-			stream, err = s.client.newStream(streamCtx, rpcCtx.Addr, storeID)
+			stream, err = s.client.newEventFeedStream(streamCtx, rpcCtx.Addr, storeID)
 			if err != nil {
 				// if get stream failed, maybe the store is down permanently, we should try to relocate the active store
 				log.Warn("get grpc stream client failed",
@@ -748,6 +793,7 @@ func (s *eventFeedSession) requestRegionToStore(
 				}
 				bo := tikv.NewBackoffer(ctx, tikvRequestMaxBackoff)
 				s.client.regionCache.OnSendFail(bo, rpcCtx, regionScheduleReload, err)
+				s.regionRouter.Revoke(rpcCtx.Addr)
 				err = s.onRegionFail(ctx, regionErrorInfo{
 					singleRegionInfo: sri,
 					err:              &connectToStoreErr{},
@@ -771,6 +817,7 @@ func (s *eventFeedSession) requestRegionToStore(
 
 		state := newRegionFeedState(sri, requestID)
 		pendingRegions.insert(requestID, state)
+		s.lagTracker.track(state)
 
 		logReq := log.Debug
 		if s.isPullerInit.IsInitialized() {
@@ -812,6 +859,7 @@ func (s *eventFeedSession) requestRegionToStore(
 				continue
 			}
 
+			s.regionRouter.Revoke(rpcCtx.Addr)
 			// Wait for a while and retry sending the request
 			time.Sleep(time.Millisecond * time.Duration(rand.Intn(100)))
 			err = s.onRegionFail(ctx, regionErrorInfo{
@@ -1142,6 +1190,7 @@ func (s *eventFeedSession) receiveFromStream(
 		remainingRegions := pendingRegions.takeAll()
 
 		for _, state := range remainingRegions {
+			state.markStopped()
 			err := s.onRegionFail(ctx, regionErrorInfo{
 				singleRegionInfo: state.sri,
 				err:              cerror.ErrPendingRegionCancel.GenWithStackByArgs(),
@@ -1464,6 +1513,12 @@ func (s *eventFeedSession) singleEventFeed(
 			metricReceivedEventSize.Observe(float64(event.changeEvent.Event.Size()))
 			switch x := event.changeEvent.Event.(type) {
 			case *cdcpb.Event_Entries_:
+				if !initialized {
+					// the entries before the region is initialized are incremental scan data
+					if err = s.storeScanLimiter.waitBytes(ctx, storeAddr, x.Entries.Size()); err != nil {
+						return
+					}
+				}
 				for _, entry := range x.Entries.GetEntries() {
 					// if a region with kv range [a, z)
 					// and we only want the get [b, c) from this region,
@@ -1487,7 +1542,7 @@ func (s *eventFeedSession) singleEventFeed(
 						s.regionRouter.Release(storeAddr)
 						cachedEvents := matcher.matchCachedRow()
 						for _, cachedEvent := range cachedEvents {
-							revent, err = assembleRowEvent(regionID, cachedEvent, s.enableOldValue)
+							revent, err = assembleRowEvent(regionID, cachedEvent, s.enableOldValue, initialized)
 							if err != nil {
 								return
 							}
@@ -1501,7 +1556,7 @@ func (s *eventFeedSession) singleEventFeed(
 						}
 					case cdcpb.Event_COMMITTED:
 						metricPullEventCommittedCounter.Inc()
-						revent, err = assembleRowEvent(regionID, entry, s.enableOldValue)
+						revent, err = assembleRowEvent(regionID, entry, s.enableOldValue, initialized)
 						if err != nil {
 							return
 						}
@@ -1546,7 +1601,7 @@ func (s *eventFeedSession) singleEventFeed(
 							return
 						}
 
-						revent, err = assembleRowEvent(regionID, entry, s.enableOldValue)
+						revent, err = assembleRowEvent(regionID, entry, s.enableOldValue, initialized)
 						if err != nil {
 							return
 						}
@@ -1594,7 +1649,10 @@ func (s *eventFeedSession) deleteStream(storeAddr string) {
 	s.streamsLock.Lock()
 	defer s.streamsLock.Unlock()
 	if stream, ok := s.streams[storeAddr]; ok {
-		s.client.grpcPool.ReleaseConn(stream.conn, storeAddr)
+		// the conn of a multiplexed stream is released by the multiplexer
+		if stream.conn != nil {
+			s.client.grpcPool.ReleaseConn(stream.conn, storeAddr)
+		}
 		delete(s.streams, storeAddr)
 	}
 	if cancel, ok := s.streamsCanceller[storeAddr]; ok {
@@ -1617,7 +1675,11 @@ func (s *eventFeedSession) getStreamCancel(storeAddr string) (cancel context.Can
 	return
 }
 
-func assembleRowEvent(regionID uint64, entry *cdcpb.Event_Row, enableOldValue bool) (model.RegionFeedEvent, error) {
+// assembleRowEvent converts a row of TiKV into a region feed event, the rows
+// received before the region is initialized are incremental scan data.
+func assembleRowEvent(
+	regionID uint64, entry *cdcpb.Event_Row, enableOldValue bool, initialized bool,
+) (model.RegionFeedEvent, error) {
 	var opType model.OpType
 	switch entry.GetOpType() {
 	case cdcpb.Event_Row_DELETE:
@@ -1644,6 +1706,14 @@ func assembleRowEvent(regionID uint64, entry *cdcpb.Event_Row, enableOldValue bo
 	// we need avoid a old-value sent to downstream when old-value is disabled
 	if enableOldValue {
 		revent.Val.OldValue = entry.GetOldValue()
+		// A deleted row must have an old value. A put without an old value is
+		// an insert, because the compatible TiKV versions supply the old values
+		// of all the other puts, including the ones committed by 1PC. So the
+		// puts are never flagged, otherwise every insert would be read again.
+		// The rows of the incremental scan are never read again either, TiKV
+		// supplies their old values.
+		revent.OldValueMissing = initialized && len(revent.Val.OldValue) == 0 &&
+			opType == model.OpTypeDelete
 	}
 	return revent, nil
 }
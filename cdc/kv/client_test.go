@@ -85,6 +85,7 @@ func (s *clientSuite) TestAssembleRowEvent(c *check.C) {
 		regionID       uint64
 		entry          *cdcpb.Event_Row
 		enableOldValue bool
+		initialized    bool
 		expected       model.RegionFeedEvent
 		err            string
 	}{{
@@ -174,6 +175,97 @@ func (s *clientSuite) TestAssembleRowEvent(c *check.C) {
 				RegionID: 4,
 			},
 		},
+	}, {
+		regionID: 5,
+		entry: &cdcpb.Event_Row{
+			StartTs:  1,
+			CommitTs: 2,
+			Key:      []byte("k4"),
+			Value:    []byte("v4"),
+			Type:     cdcpb.Event_COMMITTED,
+			OpType:   cdcpb.Event_Row_PUT,
+		},
+		enableOldValue: true,
+		initialized:    true,
+		// a 1PC insert is not flagged, so its old value is not read
+		expected: model.RegionFeedEvent{
+			RegionID: 5,
+			Val: &model.RawKVEntry{
+				OpType:   model.OpTypePut,
+				StartTs:  1,
+				CRTs:     2,
+				Key:      []byte("k4"),
+				Value:    []byte("v4"),
+				RegionID: 5,
+			},
+		},
+	}, {
+		regionID: 6,
+		entry: &cdcpb.Event_Row{
+			StartTs:  1,
+			CommitTs: 2,
+			Key:      []byte("k5"),
+			Type:     cdcpb.Event_COMMIT,
+			OpType:   cdcpb.Event_Row_DELETE,
+		},
+		enableOldValue: true,
+		initialized:    true,
+		expected: model.RegionFeedEvent{
+			RegionID: 6,
+			Val: &model.RawKVEntry{
+				OpType:   model.OpTypeDelete,
+				StartTs:  1,
+				CRTs:     2,
+				Key:      []byte("k5"),
+				RegionID: 6,
+			},
+			OldValueMissing: true,
+		},
+	}, {
+		// the rows of the incremental scan are not flagged
+		regionID: 7,
+		entry: &cdcpb.Event_Row{
+			StartTs:  1,
+			CommitTs: 2,
+			Key:      []byte("k6"),
+			Type:     cdcpb.Event_COMMITTED,
+			OpType:   cdcpb.Event_Row_DELETE,
+		},
+		enableOldValue: true,
+		expected: model.RegionFeedEvent{
+			RegionID: 7,
+			Val: &model.RawKVEntry{
+				OpType:   model.OpTypeDelete,
+				StartTs:  1,
+				CRTs:     2,
+				Key:      []byte("k6"),
+				RegionID: 7,
+			},
+		},
+	}, {
+		// an inserted row has no old value
+		regionID: 8,
+		entry: &cdcpb.Event_Row{
+			StartTs:  1,
+			CommitTs: 2,
+			Key:      []byte("k7"),
+			Value:    []byte("v7"),
+			Type:     cdcpb.Event_COMMIT,
+			OpType:   cdcpb.Event_Row_PUT,
+		},
+		enableOldValue: true,
+		initialized:    true,
+		expected: model.RegionFeedEvent{
+			RegionID: 8,
+			Val: &model.RawKVEntry{
+				OpType:   model.OpTypePut,
+				StartTs:  1,
+				CRTs:     2,
+				Key:      []byte("k7"),
+				Value:    []byte("v7"),
+				RegionID: 8,
+			},
+		},
 	}, {
 		regionID: 2,
 		entry: &cdcpb.Event_Row{
@@ -188,7 +280,7 @@ func (s *clientSuite) TestAssembleRowEvent(c *check.C) {
 	}}
 
 	for _, tc := range testCases {
-		event, err := assembleRowEvent(tc.regionID, tc.entry, tc.enableOldValue)
+		event, err := assembleRowEvent(tc.regionID, tc.entry, tc.enableOldValue, tc.initialized)
 		c.Assert(event, check.DeepEquals, tc.expected)
 		if err != nil {
 			c.Assert(err.Error(), check.Equals, tc.err)
@@ -166,6 +166,7 @@ func (s *eventFeedSession) receiveFromStreamV2(
 
 		remainingRegions := pendingRegions.takeAll()
 		for _, state := range remainingRegions {
+			state.markStopped()
 			err := s.onRegionFail(ctx, regionErrorInfo{
 				singleRegionInfo: state.sri,
 				err:              cerror.ErrPendingRegionCancel.GenWithStackByArgs(),
@@ -356,6 +356,55 @@ func (c CDCEtcdClient) SaveChangeFeedInfo(ctx context.Context, info *model.Chang
 	return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
 }
 
+// UpdateChangeFeedInfo updates the change feed info in etcd atomically. The
+// info is read again and updateFunc is applied to it again if the info is
+// modified concurrently, e.g. by the owner.
+func (c CDCEtcdClient) UpdateChangeFeedInfo(
+	ctx context.Context, changeFeedID string, updateFunc func(info *model.ChangeFeedInfo) error,
+) (*model.ChangeFeedInfo, error) {
+	key := GetEtcdKeyChangeFeedInfo(changeFeedID)
+	var info *model.ChangeFeedInfo
+	err := retry.Do(ctx, func() error {
+		resp, err := c.Client.Get(ctx, key)
+		if err != nil {
+			return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+		}
+		if resp.Count == 0 {
+			return cerror.ErrChangeFeedNotExists.GenWithStackByArgs(key)
+		}
+		info = &model.ChangeFeedInfo{}
+		if err := info.Unmarshal(resp.Kvs[0].Value); err != nil {
+			return errors.Trace(err)
+		}
+		if err := updateFunc(info); err != nil {
+			return err
+		}
+		value, err := info.Marshal()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		txnResp, err := c.Client.Txn(ctx).If(
+			clientv3.Compare(clientv3.ModRevision(key), "=", resp.Kvs[0].ModRevision),
+		).Then(
+			clientv3.OpPut(key, value),
+		).Commit()
+		if err != nil {
+			return cerror.WrapError(cerror.ErrPDEtcdAPIError, err)
+		}
+		if !txnResp.Succeeded {
+			log.Info("changefeed info is modified concurrently, update it again",
+				zap.String("changefeed", changeFeedID))
+			return cerror.ErrWriteTsConflict.GenWithStackByArgs(key)
+		}
+		return nil
+	}, retry.WithBackoffBaseDelay(putTaskStatusBackoffBaseDelayInMs), retry.WithMaxTries(putTaskStatusMaxTries),
+		retry.WithIsRetryableErr(cerror.ErrWriteTsConflict.Equal))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return info, nil
+}
+
 // GetAllTaskPositions queries all task positions of a changefeed, and returns a map
 // mapping from captureID to TaskPositions
 func (c CDCEtcdClient) GetAllTaskPositions(ctx context.Context, changefeedID string) (map[string]*model.TaskPosition, error) {
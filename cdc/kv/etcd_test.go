@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
 	"github.com/pingcap/ticdc/cdc/model"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/etcd"
@@ -520,6 +521,47 @@ func (s *etcdSuite) TestCreateChangefeed(c *check.C) {
 	c.Assert(cerror.ErrChangeFeedAlreadyExists.Equal(err), check.IsTrue)
 }
 
+func (s *etcdSuite) TestUpdateChangefeedInfo(c *check.C) {
+	defer testleak.AfterTest(c)()
+	defer s.TearDownTest(c)
+	ctx := context.Background()
+
+	_, err := s.client.UpdateChangeFeedInfo(ctx, "test-id", func(info *model.ChangeFeedInfo) error {
+		return nil
+	})
+	c.Assert(cerror.ErrChangeFeedNotExists.Equal(err), check.IsTrue)
+
+	err = s.client.CreateChangefeedInfo(ctx, &model.ChangeFeedInfo{SinkURI: "blackhole://"}, "test-id")
+	c.Assert(err, check.IsNil)
+
+	// the update is applied again if the info is modified concurrently.
+	calls := 0
+	info, err := s.client.UpdateChangeFeedInfo(ctx, "test-id", func(info *model.ChangeFeedInfo) error {
+		calls++
+		if calls == 1 {
+			err := s.client.SaveChangeFeedInfo(ctx, &model.ChangeFeedInfo{
+				SinkURI: "blackhole://", State: model.StateStopped,
+			}, "test-id")
+			c.Assert(err, check.IsNil)
+		}
+		info.SinkURI = "blackhole://new"
+		return nil
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(calls, check.Equals, 2)
+	c.Assert(info.SinkURI, check.Equals, "blackhole://new")
+	info, err = s.client.GetChangeFeedInfo(ctx, "test-id")
+	c.Assert(err, check.IsNil)
+	c.Assert(info.SinkURI, check.Equals, "blackhole://new")
+	c.Assert(info.State, check.Equals, model.StateStopped)
+
+	// the error of the update function is returned as is.
+	_, err = s.client.UpdateChangeFeedInfo(ctx, "test-id", func(info *model.ChangeFeedInfo) error {
+		return errors.New("refused")
+	})
+	c.Assert(err, check.ErrorMatches, "refused")
+}
+
 type Captures []*model.CaptureInfo
 
 func (c Captures) Len() int           { return len(c) }
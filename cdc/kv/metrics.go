@@ -79,6 +79,21 @@ var (
 			Name:      "region_token",
 			Help:      "size of region token in kv client",
 		}, []string{"store", "changefeed", "capture"})
+	clientStoreScanTokenGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "store_scan_token",
+			Help:      "number of region incremental scans of all tables in a store",
+		}, []string{"store", "capture"})
+	clientStoreScanRateLimitDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "store_scan_rate_limit_duration",
+			Help:      "Bucketed histogram of the time incremental scans are throttled for by the byte rate limit",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+		}, []string{"store", "capture"})
 	batchResolvedEventSize = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: "ticdc",
@@ -101,6 +116,20 @@ var (
 			Name:      "grpc_stream_count",
 			Help:      "active stream count of each gRPC connection",
 		}, []string{"store"})
+	clientSharedStreamGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "shared_stream_count",
+			Help:      "number of gRPC streams to a store shared by all event feed sessions",
+		}, []string{"store"})
+	clientMuxStreamGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "kvclient",
+			Name:      "mux_stream_count",
+			Help:      "number of event feed sessions multiplexed over the shared streams to a store",
+		}, []string{"store"})
 )
 
 // InitMetrics registers all metrics in the kv package
@@ -113,9 +142,13 @@ func InitMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(sendEventCounter)
 	registry.MustRegister(clientChannelSize)
 	registry.MustRegister(clientRegionTokenSize)
+	registry.MustRegister(clientStoreScanTokenGauge)
+	registry.MustRegister(clientStoreScanRateLimitDuration)
 	registry.MustRegister(batchResolvedEventSize)
 	registry.MustRegister(etcdRequestCounter)
 	registry.MustRegister(grpcPoolStreamGauge)
+	registry.MustRegister(clientSharedStreamGauge)
+	registry.MustRegister(clientMuxStreamGauge)
 
 	// Register client metrics to registry.
 	registry.MustRegister(grpcMetrics)
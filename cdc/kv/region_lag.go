@@ -0,0 +1,174 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/ticdc/cdc/model"
+)
+
+// regionLagStats is the resolved ts diagnostics of a regionFeedState. The
+// fields are accessed atomically, because the lock of the regionFeedState can
+// be held for a long time when the downstream is slow.
+type regionLagStats struct {
+	resolvedTs       uint64
+	initialized      int32
+	resolveLockCount int32
+}
+
+func (s *regionLagStats) setResolvedTs(ts uint64) {
+	atomic.StoreUint64(&s.resolvedTs, ts)
+	atomic.StoreInt32(&s.resolveLockCount, 0)
+}
+
+func (s *regionLagStats) setInitialized() {
+	atomic.StoreInt32(&s.initialized, 1)
+}
+
+func (s *regionLagStats) incResolveLock() {
+	atomic.AddInt32(&s.resolveLockCount, 1)
+}
+
+func (s *regionLagStats) info(regionID uint64, storeAddr string) model.RegionResolvedTsInfo {
+	info := model.RegionResolvedTsInfo{
+		RegionID:         regionID,
+		StoreAddr:        storeAddr,
+		ResolvedTs:       atomic.LoadUint64(&s.resolvedTs),
+		ResolveLockCount: int(atomic.LoadInt32(&s.resolveLockCount)),
+	}
+	switch {
+	case atomic.LoadInt32(&s.initialized) == 0:
+		info.Status = model.RegionFeedStatusInitializing
+	case info.ResolveLockCount > 0:
+		info.Status = model.RegionFeedStatusResolvingLock
+	default:
+		info.Status = model.RegionFeedStatusNormal
+	}
+	return info
+}
+
+// regionLagTracker tracks the regions subscribed by an event feed session.
+type regionLagTracker struct {
+	mu     sync.Mutex
+	states map[uint64]*regionFeedState
+}
+
+func newRegionLagTracker() *regionLagTracker {
+	return &regionLagTracker{states: make(map[uint64]*regionFeedState)}
+}
+
+// track replaces the previous state of the region, a region is subscribed
+// at most once by a session at any time.
+func (t *regionLagTracker) track(state *regionFeedState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.states[state.sri.verID.GetID()] = state
+}
+
+// untrack removes the state of the region once it is stopped, the state is
+// kept if the region has been subscribed again.
+func (t *regionLagTracker) untrack(regionID uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state, ok := t.states[regionID]; ok && state.isStopped() {
+		delete(t.states, regionID)
+	}
+}
+
+// collect appends the diagnostics of all regions being subscribed.
+func (t *regionLagTracker) collect(regions []model.RegionResolvedTsInfo) []model.RegionResolvedTsInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for regionID, state := range t.states {
+		if state.isStopped() {
+			continue
+		}
+		regions = append(regions, state.lagStats.info(regionID, state.sri.rpcCtx.Addr))
+	}
+	return regions
+}
+
+type regionLagKey struct {
+	changefeedID string
+	tableID      model.TableID
+}
+
+// regionLagTrackers holds the trackers of all event feed sessions of the
+// capture, a table can have several sessions, one for each of its spans.
+var regionLagTrackers = struct {
+	sync.Mutex
+	m map[regionLagKey]map[*regionLagTracker]struct{}
+}{m: make(map[regionLagKey]map[*regionLagTracker]struct{})}
+
+// registerRegionLagTracker makes the regions of the tracker visible to
+// SlowestRegions, and returns the function to unregister it.
+func registerRegionLagTracker(changefeedID string, tableID model.TableID, tracker *regionLagTracker) func() {
+	key := regionLagKey{changefeedID: changefeedID, tableID: tableID}
+	regionLagTrackers.Lock()
+	defer regionLagTrackers.Unlock()
+	trackers, ok := regionLagTrackers.m[key]
+	if !ok {
+		trackers = make(map[*regionLagTracker]struct{})
+		regionLagTrackers.m[key] = trackers
+	}
+	trackers[tracker] = struct{}{}
+	return func() {
+		regionLagTrackers.Lock()
+		defer regionLagTrackers.Unlock()
+		delete(trackers, tracker)
+		if len(trackers) == 0 {
+			delete(regionLagTrackers.m, key)
+		}
+	}
+}
+
+// SlowestRegions returns at most limit regions with the smallest resolved ts
+// of each table of the changefeed on this capture, the tables are sorted by
+// table ID.
+func SlowestRegions(changefeedID string, limit int) []model.TableRegionsLag {
+	regionLagTrackers.Lock()
+	tables := make(map[model.TableID][]*regionLagTracker)
+	for key, trackers := range regionLagTrackers.m {
+		if key.changefeedID != changefeedID {
+			continue
+		}
+		for tracker := range trackers {
+			tables[key.tableID] = append(tables[key.tableID], tracker)
+		}
+	}
+	regionLagTrackers.Unlock()
+
+	result := make([]model.TableRegionsLag, 0, len(tables))
+	for tableID, trackers := range tables {
+		var regions []model.RegionResolvedTsInfo
+		for _, tracker := range trackers {
+			regions = tracker.collect(regions)
+		}
+		sort.Slice(regions, func(i, j int) bool {
+			if regions[i].ResolvedTs != regions[j].ResolvedTs {
+				return regions[i].ResolvedTs < regions[j].ResolvedTs
+			}
+			return regions[i].RegionID < regions[j].RegionID
+		})
+		if limit > 0 && len(regions) > limit {
+			regions = regions[:limit]
+		}
+		result = append(result, model.TableRegionsLag{TableID: tableID, Regions: regions})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].TableID < result[j].TableID })
+	return result
+}
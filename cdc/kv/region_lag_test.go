@@ -0,0 +1,92 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/regionspan"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	"github.com/tikv/client-go/v2/tikv"
+)
+
+type regionLagSuite struct{}
+
+var _ = check.Suite(&regionLagSuite{})
+
+func (s *regionLagSuite) TestSlowestRegions(c *check.C) {
+	defer testleak.AfterTest(c)()
+
+	newStateWithSpan := func(regionID uint64, addr string, ts uint64, start, end string) *regionFeedState {
+		span := regionspan.ComparableSpan{Start: []byte(start), End: []byte(end)}
+		sri := newSingleRegionInfo(tikv.NewRegionVerID(regionID, 1, 1), span, ts, &tikv.RPCContext{Addr: addr})
+		return newRegionFeedState(sri, allocID())
+	}
+	newState := func(regionID uint64, addr string, ts uint64) *regionFeedState {
+		key := string(rune('a' + regionID))
+		return newStateWithSpan(regionID, addr, ts, key, key+"z")
+	}
+
+	// table 1 is subscribed by two sessions
+	trackers := []*regionLagTracker{newRegionLagTracker(), newRegionLagTracker(), newRegionLagTracker()}
+	unregister1 := registerRegionLagTracker("cf-1", 1, trackers[0])
+	unregister2 := registerRegionLagTracker("cf-1", 1, trackers[1])
+	unregister3 := registerRegionLagTracker("cf-2", 2, trackers[2])
+	defer unregister3()
+
+	initializing := newState(1, "store-1", 100)
+	trackers[0].track(initializing)
+	normal := newState(2, "store-2", 100)
+	normal.lagStats.setInitialized()
+	normal.lagStats.setResolvedTs(300)
+	trackers[0].track(normal)
+	resolvingLock := newState(3, "store-1", 100)
+	resolvingLock.lagStats.setInitialized()
+	resolvingLock.lagStats.setResolvedTs(200)
+	resolvingLock.lagStats.incResolveLock()
+	trackers[1].track(resolvingLock)
+	trackers[2].track(newState(4, "store-1", 100))
+
+	c.Assert(SlowestRegions("cf-1", 2), check.DeepEquals, []model.TableRegionsLag{{
+		TableID: 1,
+		Regions: []model.RegionResolvedTsInfo{
+			{RegionID: 1, StoreAddr: "store-1", ResolvedTs: 100, Status: model.RegionFeedStatusInitializing},
+			{RegionID: 3, StoreAddr: "store-1", ResolvedTs: 200, Status: model.RegionFeedStatusResolvingLock, ResolveLockCount: 1},
+		},
+	}})
+
+	// the lock resolving status is cleared after the resolved ts advances,
+	// and stopped regions are not reported.
+	resolvingLock.lagStats.setResolvedTs(400)
+	initializing.markStopped()
+	c.Assert(SlowestRegions("cf-1", 0), check.DeepEquals, []model.TableRegionsLag{{
+		TableID: 1,
+		Regions: []model.RegionResolvedTsInfo{
+			{RegionID: 2, StoreAddr: "store-2", ResolvedTs: 300, Status: model.RegionFeedStatusNormal},
+			{RegionID: 3, StoreAddr: "store-1", ResolvedTs: 400, Status: model.RegionFeedStatusNormal},
+		},
+	}})
+
+	// a stopped region is untracked, unless it has been subscribed again.
+	trackers[0].untrack(1)
+	c.Assert(trackers[0].states, check.HasLen, 1)
+	trackers[0].track(newState(2, "store-2", 500))
+	trackers[0].untrack(2)
+	c.Assert(trackers[0].states, check.HasLen, 1)
+
+	unregister1()
+	unregister2()
+	c.Assert(SlowestRegions("cf-1", 0), check.HasLen, 0)
+	c.Assert(SlowestRegions("cf-2", 0), check.HasLen, 1)
+}
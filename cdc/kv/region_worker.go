@@ -29,6 +29,7 @@ import (
 	"github.com/pingcap/ticdc/pkg/config"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/regionspan"
+	"github.com/pingcap/ticdc/pkg/tracing"
 	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/pingcap/ticdc/pkg/workerpool"
 	"github.com/prometheus/client_golang/prometheus"
@@ -381,6 +382,7 @@ func (w *regionWorker) resolveLock(ctx context.Context) error {
 						zap.Duration("lastEvent", sinceLastEvent),
 						zap.Uint64("resolvedTs", lastResolvedTs),
 					)
+					state.lagStats.incResolveLock()
 					err = w.session.lockResolver.Resolve(ctx, rts.regionID, maxVersion)
 					if err != nil {
 						log.Warn("failed to resolve lock", zap.Uint64("regionID", rts.regionID), zap.Error(err))
@@ -633,6 +635,13 @@ func (w *regionWorker) handleEventEntry(
 	state *regionFeedState,
 ) error {
 	regionID := state.sri.verID.GetID()
+	if !state.initialized {
+		// the entries before the region is initialized are incremental scan data
+		err := w.session.storeScanLimiter.waitBytes(ctx, state.sri.rpcCtx.Addr, x.Entries.Size())
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
 	for _, entry := range x.Entries.GetEntries() {
 		// if a region with kv range [a, z)
 		// and we only want the get [b, c) from this region,
@@ -654,23 +663,22 @@ func (w *regionWorker) handleEventEntry(
 			w.metrics.metricPullEventInitializedCounter.Inc()
 
 			state.initialized = true
+			state.lagStats.setInitialized()
 			w.session.regionRouter.Release(state.sri.rpcCtx.Addr)
 			cachedEvents := state.matcher.matchCachedRow()
 			for _, cachedEvent := range cachedEvents {
-				revent, err := assembleRowEvent(regionID, cachedEvent, w.enableOldValue)
+				revent, err := assembleRowEvent(regionID, cachedEvent, w.enableOldValue, true)
 				if err != nil {
 					return errors.Trace(err)
 				}
-				select {
-				case w.outputCh <- revent:
-					w.metrics.metricSendEventCommitCounter.Inc()
-				case <-ctx.Done():
-					return errors.Trace(ctx.Err())
+				if err := w.sendRowEvent(ctx, revent); err != nil {
+					return errors.Trace(err)
 				}
+				w.metrics.metricSendEventCommitCounter.Inc()
 			}
 		case cdcpb.Event_COMMITTED:
 			w.metrics.metricPullEventCommittedCounter.Inc()
-			revent, err := assembleRowEvent(regionID, entry, w.enableOldValue)
+			revent, err := assembleRowEvent(regionID, entry, w.enableOldValue, state.initialized)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -683,12 +691,10 @@ func (w *regionWorker) handleEventEntry(
 					zap.Uint64("regionID", regionID))
 				return errUnreachable
 			}
-			select {
-			case w.outputCh <- revent:
-				w.metrics.metricSendEventCommittedCounter.Inc()
-			case <-ctx.Done():
-				return errors.Trace(ctx.Err())
+			if err := w.sendRowEvent(ctx, revent); err != nil {
+				return errors.Trace(err)
 			}
+			w.metrics.metricSendEventCommittedCounter.Inc()
 		case cdcpb.Event_PREWRITE:
 			w.metrics.metricPullEventPrewriteCounter.Inc()
 			state.matcher.putPrewriteRow(entry)
@@ -711,17 +717,14 @@ func (w *regionWorker) handleEventEntry(
 				return cerror.ErrPrewriteNotMatch.GenWithStackByArgs(entry.GetKey(), entry.GetStartTs())
 			}
 
-			revent, err := assembleRowEvent(regionID, entry, w.enableOldValue)
+			revent, err := assembleRowEvent(regionID, entry, w.enableOldValue, state.initialized)
 			if err != nil {
 				return errors.Trace(err)
 			}
-
-			select {
-			case w.outputCh <- revent:
-				w.metrics.metricSendEventCommitCounter.Inc()
-			case <-ctx.Done():
-				return errors.Trace(ctx.Err())
+			if err := w.sendRowEvent(ctx, revent); err != nil {
+				return errors.Trace(err)
 			}
+			w.metrics.metricSendEventCommitCounter.Inc()
 		case cdcpb.Event_ROLLBACK:
 			w.metrics.metricPullEventRollbackCounter.Inc()
 			state.matcher.rollbackRow(entry)
@@ -730,6 +733,20 @@ func (w *regionWorker) handleEventEntry(
 	return nil
 }
 
+// sendRowEvent sends a committed row event to the output channel, the time
+// spent is traced as the receive stage of the event.
+func (w *regionWorker) sendRowEvent(ctx context.Context, revent model.RegionFeedEvent) error {
+	span := tracing.StartKVEventSpan(ctx, tracing.StageKVClientReceive,
+		w.session.changefeedID, revent.Val.Key, revent.Val.CRTs)
+	defer span.End()
+	select {
+	case w.outputCh <- revent:
+		return nil
+	case <-ctx.Done():
+		return errors.Trace(ctx.Err())
+	}
+}
+
 func (w *regionWorker) handleResolvedTs(
 	ctx context.Context,
 	resolvedTs uint64,
@@ -756,6 +773,7 @@ func (w *regionWorker) handleResolvedTs(
 		},
 	}
 	state.lastResolvedTs = resolvedTs
+	state.lagStats.setResolvedTs(resolvedTs)
 	// Send resolved ts update in non blocking way, since we can re-query real
 	// resolved ts from region state even if resolved ts update is discarded.
 	select {
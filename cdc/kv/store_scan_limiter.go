@@ -0,0 +1,156 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+var (
+	storeScanLimiterOnce   sync.Once
+	globalStoreScanLimiter *storeScanLimiter
+)
+
+// getStoreScanLimiter returns the storeScanLimiter shared by all event feed
+// sessions of the capture. It is created from the server config the first
+// time it is used.
+func getStoreScanLimiter() *storeScanLimiter {
+	storeScanLimiterOnce.Do(func() {
+		cfg := config.GetGlobalServerConfig()
+		globalStoreScanLimiter = newStoreScanLimiter(
+			cfg.KVClient.StoreScanLimit, cfg.KVClient.StoreScanRateLimit, cfg.AdvertiseAddr)
+	})
+	return globalStoreScanLimiter
+}
+
+// storeScanLimiter limits the region incremental scans against each TiKV
+// store across all tables of the capture. It limits both the number of
+// concurrent incremental scans and the rate of the data received from them.
+// A nil storeScanLimiter limits nothing.
+type storeScanLimiter struct {
+	concurrencyLimit int
+	rateLimit        rate.Limit
+	burst            int
+	captureAddr      string
+
+	mu       sync.Mutex
+	tokens   map[string]int
+	limiters map[string]*rate.Limiter
+	metrics  map[string]prometheus.Gauge
+}
+
+func newStoreScanLimiter(concurrencyLimit int, rateLimit uint64, captureAddr string) *storeScanLimiter {
+	l := &storeScanLimiter{
+		concurrencyLimit: concurrencyLimit,
+		captureAddr:      captureAddr,
+		tokens:           make(map[string]int),
+		limiters:         make(map[string]*rate.Limiter),
+		metrics:          make(map[string]prometheus.Gauge),
+	}
+	if rateLimit > 0 {
+		l.rateLimit = rate.Limit(rateLimit)
+		// allow the data of one second to be received at once
+		l.burst = int(rateLimit)
+	}
+	return l
+}
+
+// tryAcquire takes a token of the store if the number of incremental
+// scans against the store does not exceed the limit.
+func (l *storeScanLimiter) tryAcquire(store string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.concurrencyLimit > 0 && l.tokens[store] >= l.concurrencyLimit {
+		return false
+	}
+	l.tokens[store]++
+	l.gauge(store).Inc()
+	return true
+}
+
+// acquire takes a token of the store regardless of the limit.
+func (l *storeScanLimiter) acquire(store string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens[store]++
+	l.gauge(store).Inc()
+}
+
+// release gives back n tokens of the store.
+func (l *storeScanLimiter) release(store string, n int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens[store] -= n
+	l.gauge(store).Sub(float64(n))
+}
+
+// gauge must be called with mu held.
+func (l *storeScanLimiter) gauge(store string) prometheus.Gauge {
+	g, ok := l.metrics[store]
+	if !ok {
+		g = clientStoreScanTokenGauge.WithLabelValues(store, l.captureAddr)
+		l.metrics[store] = g
+	}
+	return g
+}
+
+// waitBytes blocks until size bytes of incremental scan data can be received
+// from the store.
+func (l *storeScanLimiter) waitBytes(ctx context.Context, store string, size int) error {
+	if l == nil || l.rateLimit == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	limiter, ok := l.limiters[store]
+	if !ok {
+		limiter = rate.NewLimiter(l.rateLimit, l.burst)
+		l.limiters[store] = limiter
+	}
+	l.mu.Unlock()
+
+	if size > l.burst {
+		size = l.burst
+	}
+	reservation := limiter.ReserveN(time.Now(), size)
+	delay := reservation.Delay()
+	if delay == 0 {
+		return nil
+	}
+	clientStoreScanRateLimitDuration.WithLabelValues(store, l.captureAddr).Observe(delay.Seconds())
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		reservation.Cancel()
+		return errors.Trace(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	"github.com/tikv/client-go/v2/tikv"
+)
+
+type storeScanLimiterSuite struct{}
+
+var _ = check.Suite(&storeScanLimiterSuite{})
+
+func (s *storeScanLimiterSuite) TestRoutersShareStoreLimit(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	limiter := newStoreScanLimiter(3, 0, "")
+	routers := []*sizedRegionRouter{
+		NewSizedRegionRouter(context.Background(), 10),
+		NewSizedRegionRouter(context.Background(), 10),
+	}
+	addRegions := func(r *sizedRegionRouter) {
+		r.global = limiter
+		for i := 0; i < 2; i++ {
+			r.AddRegion(singleRegionInfo{ts: uint64(i), rpcCtx: &tikv.RPCContext{Addr: "store-1"}})
+		}
+	}
+	consume := func(r *sizedRegionRouter) int {
+		count := 0
+		for {
+			select {
+			case sri := <-r.Chan():
+				r.Acquire(sri.rpcCtx.Addr)
+				count++
+			default:
+				return count
+			}
+		}
+	}
+	addRegions(routers[0])
+	c.Assert(consume(routers[0]), check.Equals, 2)
+	c.Assert(limiter.tokens["store-1"], check.Equals, 2)
+
+	// only one of the regions of the second table can be scanned,
+	// and regions of another store are not limited.
+	addRegions(routers[1])
+	routers[1].AddRegion(singleRegionInfo{ts: 2, rpcCtx: &tikv.RPCContext{Addr: "store-2"}})
+	c.Assert(consume(routers[1]), check.Equals, 2)
+	c.Assert(limiter.tokens["store-1"], check.Equals, 3)
+	c.Assert(limiter.tokens["store-2"], check.Equals, 1)
+	c.Assert(routers[1].buffer["store-1"], check.HasLen, 1)
+
+	// the buffered region is sent after another table releases a token.
+	go routers[1].Run(ctx) //nolint:errcheck
+	time.Sleep(3 * sizedRegionCheckInterval)
+	c.Assert(routers[1].Chan(), check.HasLen, 0)
+	routers[0].Release("store-1")
+	select {
+	case sri := <-routers[1].Chan():
+		c.Assert(sri.ts, check.Equals, uint64(1))
+	case <-time.After(3 * sizedRegionCheckInterval):
+		c.Fatal("the buffered region is not sent")
+	}
+	c.Assert(limiter.tokens["store-1"], check.Equals, 3)
+
+	// the reserved token is given back if the region is not requested.
+	routers[1].Revoke("store-1")
+	c.Assert(limiter.tokens["store-1"], check.Equals, 2)
+
+	// the tokens of a table are given back after its router exits.
+	cancel()
+	routers[0].close()
+	c.Assert(limiter.tokens["store-1"], check.Equals, 1)
+	routers[0].Release("store-1")
+	c.Assert(limiter.tokens["store-1"], check.Equals, 1)
+	routers[1].close()
+	c.Assert(limiter.tokens["store-1"], check.Equals, 0)
+	c.Assert(limiter.tokens["store-2"], check.Equals, 0)
+}
+
+func (s *storeScanLimiterSuite) TestWaitBytes(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := context.Background()
+
+	// a nil limiter or a zero rate limits nothing.
+	var nilLimiter *storeScanLimiter
+	c.Assert(nilLimiter.tryAcquire("store-1"), check.IsTrue)
+	c.Assert(nilLimiter.waitBytes(ctx, "store-1", 1<<30), check.IsNil)
+	c.Assert(newStoreScanLimiter(0, 0, "").waitBytes(ctx, "store-1", 1<<30), check.IsNil)
+
+	limiter := newStoreScanLimiter(0, 1000, "")
+	start := time.Now()
+	// the burst is consumed at once, even if the event is larger than it.
+	c.Assert(limiter.waitBytes(ctx, "store-1", 2000), check.IsNil)
+	c.Assert(time.Since(start), check.Less, 100*time.Millisecond)
+	// other stores have their own rate limit.
+	c.Assert(limiter.waitBytes(ctx, "store-2", 1000), check.IsNil)
+	c.Assert(time.Since(start), check.Less, 100*time.Millisecond)
+
+	c.Assert(limiter.waitBytes(ctx, "store-1", 200), check.IsNil)
+	c.Assert(time.Since(start), check.GreaterEqual, 150*time.Millisecond)
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	err := limiter.waitBytes(cancelCtx, "store-1", 1000)
+	c.Assert(errors.Cause(err), check.Equals, context.Canceled)
+}
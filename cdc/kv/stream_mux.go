@@ -0,0 +1,493 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/cdcpb"
+	"github.com/pingcap/log"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// muxStreamMaxBufferedBytes is the size of the messages buffered for a
+	// session, the session is dropped from the shared streams once it is
+	// exceeded, and it subscribes its regions again.
+	muxStreamMaxBufferedBytes = 32 * 1024 * 1024
+	// orphanRecycleDelay is the delay before a shared stream carrying orphaned
+	// subscriptions is recycled, so that the sessions closed together only
+	// cause one recycling.
+	orphanRecycleDelay = 5 * time.Second
+
+	errMuxStreamTooSlow     = errors.New("session is too slow to receive from the shared stream")
+	errSharedStreamRecycled = errors.New("shared stream recycled")
+)
+
+// streamMultiplexers maps GrpcPools to their streamMultiplexers. There is
+// one GrpcPool, and hence one streamMultiplexer, on each capture.
+var streamMultiplexers sync.Map
+
+func getStreamMultiplexer(pool GrpcPool) *streamMultiplexer {
+	mux, _ := streamMultiplexers.LoadOrStore(pool, newStreamMultiplexer())
+	return mux.(*streamMultiplexer)
+}
+
+// streamCreator creates a ChangeData stream to a store.
+type streamCreator func(ctx context.Context) (*eventFeedStream, error)
+
+// streamMultiplexer shares ChangeData streams to TiKV stores among all event
+// feed sessions of the capture. Each session uses a muxStream per store, and
+// its requests are sent over the shared streams of the store. The events are
+// routed back to the session by request ID.
+//
+// TiKV does not allow a region to be subscribed twice on a stream, so a region
+// subscribed by several tables or changefeeds is sent over different shared
+// streams, and a new shared stream is created only if all existing ones of the
+// store have subscribed the region.
+//
+// The ChangeData protocol of the kvproto in use has no request to deregister
+// a region, so the subscriptions of a closed muxStream are orphaned, and the
+// only way to deregister them is to close the shared stream. A shared stream
+// carrying orphaned subscriptions takes no more subscriptions, and it is closed
+// shortly after, the muxStreams still using it subscribe their regions again
+// on the other shared streams. A shared stream is also closed after all
+// muxStreams using it are closed.
+//
+// The events are buffered for each muxStream, so that a slow session doesn't
+// block the other sessions on the same shared stream. A session too slow to
+// keep its buffer under muxStreamMaxBufferedBytes is dropped.
+type streamMultiplexer struct {
+	mu     sync.Mutex
+	stores map[string][]*sharedStream
+}
+
+func newStreamMultiplexer() *streamMultiplexer {
+	return &streamMultiplexer{stores: make(map[string][]*sharedStream)}
+}
+
+// sharedStream is a ChangeData stream shared by muxStreams. All fields
+// except stream and sendMu are protected by streamMultiplexer.mu.
+type sharedStream struct {
+	addr    string
+	stream  *eventFeedStream
+	sendMu  sync.Mutex
+	cancel  context.CancelFunc
+	release func()
+
+	// owners maps request IDs to the muxStreams sending the requests,
+	// the muxStream is nil if the subscription is orphaned.
+	owners map[uint64]*muxStream
+	// regions maps subscribed region IDs to the request IDs.
+	regions  map[uint64]uint64
+	orphaned int
+	users    map[*muxStream]struct{}
+	// draining is true if the stream is going to be recycled because of the
+	// orphaned subscriptions, no more regions are subscribed on it.
+	draining bool
+	closed   bool
+}
+
+// newMuxStream creates a muxStream of a session to the store. A shared stream
+// is created by create if there is none to the store, so that errors of
+// connecting to the store are returned as creating a stream without
+// multiplexing.
+func (m *streamMultiplexer) newMuxStream(
+	ctx context.Context, addr string, create streamCreator, release func(*eventFeedStream),
+) (*muxStream, error) {
+	m.mu.Lock()
+	exists := len(m.stores[addr]) > 0
+	m.mu.Unlock()
+	if !exists {
+		if _, err := m.createSharedStream(addr, create, release); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream := &muxStream{
+		mux:    m,
+		addr:   addr,
+		create: create,
+		free:   release,
+		ctx:    ctx,
+		notify: make(chan struct{}, 1),
+		failCh: make(chan struct{}),
+		shared: make(map[*sharedStream]struct{}),
+	}
+	clientMuxStreamGauge.WithLabelValues(addr).Inc()
+	go func() {
+		<-ctx.Done()
+		cancel()
+		m.detach(stream)
+		clientMuxStreamGauge.WithLabelValues(addr).Dec()
+	}()
+	return stream, nil
+}
+
+func (m *streamMultiplexer) createSharedStream(
+	addr string, create streamCreator, release func(*eventFeedStream),
+) (*sharedStream, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := create(ctx)
+	if err != nil {
+		cancel()
+		return nil, errors.Trace(err)
+	}
+	shared := &sharedStream{
+		addr:    addr,
+		stream:  stream,
+		cancel:  cancel,
+		release: func() { release(stream) },
+		owners:  make(map[uint64]*muxStream),
+		regions: make(map[uint64]uint64),
+		users:   make(map[*muxStream]struct{}),
+	}
+
+	m.mu.Lock()
+	m.stores[addr] = append(m.stores[addr], shared)
+	clientSharedStreamGauge.WithLabelValues(addr).Set(float64(len(m.stores[addr])))
+	m.mu.Unlock()
+
+	log.Info("shared stream to store created", zap.String("addr", addr))
+	go m.receive(shared)
+	return shared, nil
+}
+
+// subscribe registers the request to a shared stream which has not
+// subscribed the region, a new shared stream is created if necessary.
+func (m *streamMultiplexer) subscribe(s *muxStream, req *cdcpb.ChangeDataRequest) (*sharedStream, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for {
+		if s.shared == nil {
+			// the muxStream has been detached
+			return nil, status.Error(codes.Canceled, context.Canceled.Error())
+		}
+		for _, shared := range m.stores[s.addr] {
+			if _, ok := shared.regions[req.RegionId]; ok || shared.closed || shared.draining {
+				continue
+			}
+			shared.owners[req.RequestId] = s
+			shared.regions[req.RegionId] = req.RequestId
+			shared.users[s] = struct{}{}
+			s.shared[shared] = struct{}{}
+			return shared, nil
+		}
+		m.mu.Unlock()
+		_, err := m.createSharedStream(s.addr, s.create, s.free)
+		m.mu.Lock()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+}
+
+// detach orphans the subscriptions of a closed muxStream, the shared streams
+// carrying orphaned subscriptions are recycled.
+func (m *streamMultiplexer) detach(s *muxStream) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for shared := range s.shared {
+		delete(shared.users, s)
+		for requestID, owner := range shared.owners {
+			if owner == s {
+				shared.owners[requestID] = nil
+				shared.orphaned++
+			}
+		}
+		if len(shared.users) == 0 {
+			m.closeShared(shared, nil)
+		} else if shared.orphaned > 0 && !shared.draining {
+			shared.draining = true
+			log.Info("shared stream carries orphaned regions, recycle it",
+				zap.String("addr", shared.addr), zap.Int("orphaned", shared.orphaned),
+				zap.Duration("delay", orphanRecycleDelay))
+			shared := shared
+			time.AfterFunc(orphanRecycleDelay, func() {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				m.closeShared(shared, errSharedStreamRecycled)
+			})
+		}
+	}
+	s.shared = nil
+}
+
+// closeShared closes the shared stream, and fails all muxStreams using it
+// with err. m.mu must be held.
+func (m *streamMultiplexer) closeShared(shared *sharedStream, err error) {
+	if shared.closed {
+		return
+	}
+	shared.closed = true
+	shared.cancel()
+	shared.release()
+	streams := m.stores[shared.addr]
+	for i, s := range streams {
+		if s == shared {
+			m.stores[shared.addr] = append(streams[:i:i], streams[i+1:]...)
+			break
+		}
+	}
+	if len(m.stores[shared.addr]) == 0 {
+		delete(m.stores, shared.addr)
+	}
+	clientSharedStreamGauge.WithLabelValues(shared.addr).Set(float64(len(m.stores[shared.addr])))
+	for s := range shared.users {
+		s.fail(err)
+	}
+	log.Info("shared stream to store closed", zap.String("addr", shared.addr), zap.Error(err))
+}
+
+// receive routes the events received from the shared stream to the muxStreams.
+// It never blocks on a muxStream, the slow ones are dropped instead.
+func (m *streamMultiplexer) receive(shared *sharedStream) {
+	for {
+		cevent, err := shared.stream.client.Recv()
+		if err != nil {
+			m.mu.Lock()
+			m.closeShared(shared, err)
+			m.mu.Unlock()
+			return
+		}
+		for s, event := range m.route(shared, cevent) {
+			if !s.push(event) {
+				log.Warn("session is too slow to receive from the shared stream, drop it",
+					zap.String("addr", shared.addr), zap.Int("bufferedBytes", muxStreamMaxBufferedBytes))
+				s.fail(errMuxStreamTooSlow)
+			}
+		}
+	}
+}
+
+// route splits a message received from the shared stream into messages of
+// the muxStreams.
+func (m *streamMultiplexer) route(shared *sharedStream, cevent *cdcpb.ChangeDataEvent) map[*muxStream]*cdcpb.ChangeDataEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	routed := make(map[*muxStream]*cdcpb.ChangeDataEvent)
+	get := func(s *muxStream) *cdcpb.ChangeDataEvent {
+		event, ok := routed[s]
+		if !ok {
+			event = &cdcpb.ChangeDataEvent{}
+			routed[s] = event
+		}
+		return event
+	}
+	for _, event := range cevent.Events {
+		owner := shared.owners[event.RequestId]
+		if _, ok := event.Event.(*cdcpb.Event_Error); ok {
+			// TiKV deregisters the region from the stream after an error.
+			if shared.regions[event.RegionId] == event.RequestId {
+				delete(shared.regions, event.RegionId)
+			}
+			if _, ok := shared.owners[event.RequestId]; ok && owner == nil {
+				shared.orphaned--
+			}
+			delete(shared.owners, event.RequestId)
+		}
+		if owner == nil {
+			continue
+		}
+		routed := get(owner)
+		routed.Events = append(routed.Events, event)
+	}
+	if cevent.ResolvedTs != nil {
+		for _, regionID := range cevent.ResolvedTs.Regions {
+			requestID, ok := shared.regions[regionID]
+			if !ok {
+				continue
+			}
+			owner := shared.owners[requestID]
+			if owner == nil {
+				continue
+			}
+			routed := get(owner)
+			if routed.ResolvedTs == nil {
+				routed.ResolvedTs = &cdcpb.ResolvedTs{Ts: cevent.ResolvedTs.Ts}
+			}
+			routed.ResolvedTs.Regions = append(routed.ResolvedTs.Regions, regionID)
+		}
+	}
+	return routed
+}
+
+// muxStream is the stream of a session to a store, whose requests are sent
+// over the shared streams. It implements cdcpb.ChangeData_EventFeedClient.
+type muxStream struct {
+	mux    *streamMultiplexer
+	addr   string
+	create streamCreator
+	free   func(*eventFeedStream)
+	ctx    context.Context
+
+	// the events buffered for the session, notify is signaled when an event
+	// is buffered.
+	bufferMu      sync.Mutex
+	buffer        []*cdcpb.ChangeDataEvent
+	bufferedBytes int
+	notify        chan struct{}
+
+	failOnce sync.Once
+	failCh   chan struct{}
+	err      error
+
+	// shared is protected by streamMultiplexer.mu
+	shared map[*sharedStream]struct{}
+}
+
+// Send implements cdcpb.ChangeData_EventFeedClient.
+func (s *muxStream) Send(req *cdcpb.ChangeDataRequest) error {
+	select {
+	case <-s.failCh:
+		return s.err
+	case <-s.ctx.Done():
+		return status.Error(codes.Canceled, s.ctx.Err().Error())
+	default:
+	}
+	shared, err := s.mux.subscribe(s, req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	shared.sendMu.Lock()
+	err = shared.stream.client.Send(req)
+	shared.sendMu.Unlock()
+	if err != nil {
+		s.mux.mu.Lock()
+		s.mux.closeShared(shared, err)
+		s.mux.mu.Unlock()
+		return errors.Trace(err)
+	}
+	return nil
+}
+
+// push buffers an event for the session, it returns false if the buffer is
+// full.
+func (s *muxStream) push(event *cdcpb.ChangeDataEvent) bool {
+	size := event.Size()
+	s.bufferMu.Lock()
+	if len(s.buffer) > 0 && s.bufferedBytes+size > muxStreamMaxBufferedBytes {
+		s.bufferMu.Unlock()
+		return false
+	}
+	s.buffer = append(s.buffer, event)
+	s.bufferedBytes += size
+	s.bufferMu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// pop takes the oldest event buffered, it returns nil if there is none.
+func (s *muxStream) pop() *cdcpb.ChangeDataEvent {
+	s.bufferMu.Lock()
+	defer s.bufferMu.Unlock()
+	if len(s.buffer) == 0 {
+		return nil
+	}
+	event := s.buffer[0]
+	s.buffer[0] = nil
+	s.buffer = s.buffer[1:]
+	s.bufferedBytes -= event.Size()
+	return event
+}
+
+// Recv implements cdcpb.ChangeData_EventFeedClient.
+func (s *muxStream) Recv() (*cdcpb.ChangeDataEvent, error) {
+	for {
+		select {
+		case <-s.failCh:
+			return nil, s.err
+		case <-s.ctx.Done():
+			return nil, status.Error(codes.Canceled, s.ctx.Err().Error())
+		default:
+		}
+		if event := s.pop(); event != nil {
+			return event, nil
+		}
+		select {
+		case <-s.notify:
+		case <-s.failCh:
+			return nil, s.err
+		case <-s.ctx.Done():
+			return nil, status.Error(codes.Canceled, s.ctx.Err().Error())
+		}
+	}
+}
+
+var _ cdcpb.ChangeData_EventFeedClient = (*muxStream)(nil)
+
+// Header implements grpc.ClientStream. A muxStream has no header of its own
+// as it may be served by several shared streams.
+func (s *muxStream) Header() (metadata.MD, error) {
+	return nil, status.Error(codes.Unimplemented, "header is not supported by multiplexed streams")
+}
+
+// Trailer implements grpc.ClientStream.
+func (s *muxStream) Trailer() metadata.MD {
+	return nil
+}
+
+// SendMsg implements grpc.ClientStream.
+func (s *muxStream) SendMsg(m interface{}) error {
+	req, ok := m.(*cdcpb.ChangeDataRequest)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message type %T", m)
+	}
+	return s.Send(req)
+}
+
+// RecvMsg implements grpc.ClientStream.
+func (s *muxStream) RecvMsg(m interface{}) error {
+	out, ok := m.(*cdcpb.ChangeDataEvent)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message type %T", m)
+	}
+	event, err := s.Recv()
+	if err != nil {
+		return err
+	}
+	*out = *event
+	return nil
+}
+
+// CloseSend implements grpc.ClientStream. The subscriptions of the stream
+// are orphaned after its context is done.
+func (s *muxStream) CloseSend() error {
+	return nil
+}
+
+// Context implements grpc.ClientStream.
+func (s *muxStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *muxStream) fail(err error) {
+	s.failOnce.Do(func() {
+		if err == nil {
+			err = errors.New("shared stream closed")
+		}
+		s.err = err
+		close(s.failCh)
+	})
+}
@@ -0,0 +1,283 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/kvproto/pkg/cdcpb"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	"google.golang.org/grpc"
+)
+
+type streamMuxSuite struct{}
+
+var _ = check.Suite(&streamMuxSuite{})
+
+// fakeChangeDataStream is a physical ChangeData stream whose requests and
+// events are passed through channels.
+type fakeChangeDataStream struct {
+	grpc.ClientStream
+	ctx      context.Context
+	requests chan *cdcpb.ChangeDataRequest
+	events   chan *cdcpb.ChangeDataEvent
+	errCh    chan error
+}
+
+func (s *fakeChangeDataStream) Send(req *cdcpb.ChangeDataRequest) error {
+	s.requests <- req
+	return nil
+}
+
+func (s *fakeChangeDataStream) Recv() (*cdcpb.ChangeDataEvent, error) {
+	select {
+	case event := <-s.events:
+		return event, nil
+	case err := <-s.errCh:
+		return nil, err
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+}
+
+type fakeStreamFactory struct {
+	mu       sync.Mutex
+	streams  []*fakeChangeDataStream
+	released int
+}
+
+func (f *fakeStreamFactory) create(ctx context.Context) (*eventFeedStream, error) {
+	stream := &fakeChangeDataStream{
+		ctx:      ctx,
+		requests: make(chan *cdcpb.ChangeDataRequest, 16),
+		events:   make(chan *cdcpb.ChangeDataEvent, 16),
+		errCh:    make(chan error, 1),
+	}
+	f.mu.Lock()
+	f.streams = append(f.streams, stream)
+	f.mu.Unlock()
+	return &eventFeedStream{client: stream}, nil
+}
+
+func (f *fakeStreamFactory) release(*eventFeedStream) {
+	f.mu.Lock()
+	f.released++
+	f.mu.Unlock()
+}
+
+func (f *fakeStreamFactory) stats() (created int, released int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.streams), f.released
+}
+
+func (f *fakeStreamFactory) stream(i int) *fakeChangeDataStream {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.streams[i]
+}
+
+func recvWithTimeout(c *check.C, stream *muxStream) *cdcpb.ChangeDataEvent {
+	ch := make(chan *cdcpb.ChangeDataEvent, 1)
+	go func() {
+		event, err := stream.Recv()
+		c.Assert(err, check.IsNil)
+		ch <- event
+	}()
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(5 * time.Second):
+		c.Fatal("no event is received")
+	}
+	return nil
+}
+
+func (s *streamMuxSuite) TestRouteEventsBetweenSessions(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	defer func(delay time.Duration) {
+		orphanRecycleDelay = delay
+	}(orphanRecycleDelay)
+	orphanRecycleDelay = 500 * time.Millisecond
+
+	factory := &fakeStreamFactory{}
+	mux := newStreamMultiplexer()
+	ctx1, cancel1 := context.WithCancel(ctx)
+	stream1, err := mux.newMuxStream(ctx1, "store-1", factory.create, factory.release)
+	c.Assert(err, check.IsNil)
+	stream2, err := mux.newMuxStream(ctx, "store-1", factory.create, factory.release)
+	c.Assert(err, check.IsNil)
+	created, _ := factory.stats()
+	c.Assert(created, check.Equals, 1)
+
+	// different regions of two sessions share one stream.
+	c.Assert(stream1.Send(&cdcpb.ChangeDataRequest{RegionId: 1, RequestId: 1}), check.IsNil)
+	c.Assert(stream2.Send(&cdcpb.ChangeDataRequest{RegionId: 2, RequestId: 2}), check.IsNil)
+	created, _ = factory.stats()
+	c.Assert(created, check.Equals, 1)
+	// a region subscribed by both sessions is sent over another stream.
+	c.Assert(stream2.Send(&cdcpb.ChangeDataRequest{RegionId: 1, RequestId: 3}), check.IsNil)
+	created, _ = factory.stats()
+	c.Assert(created, check.Equals, 2)
+	c.Assert((<-factory.stream(1).requests).RequestId, check.Equals, uint64(3))
+
+	factory.stream(0).events <- &cdcpb.ChangeDataEvent{
+		Events: []*cdcpb.Event{
+			{RegionId: 1, RequestId: 1, Event: &cdcpb.Event_Entries_{}},
+			{RegionId: 2, RequestId: 2, Event: &cdcpb.Event_Entries_{}},
+		},
+		ResolvedTs: &cdcpb.ResolvedTs{Regions: []uint64{1, 2}, Ts: 100},
+	}
+	event := recvWithTimeout(c, stream1)
+	c.Assert(event.Events, check.HasLen, 1)
+	c.Assert(event.Events[0].RequestId, check.Equals, uint64(1))
+	c.Assert(event.ResolvedTs, check.DeepEquals, &cdcpb.ResolvedTs{Regions: []uint64{1}, Ts: 100})
+	event = recvWithTimeout(c, stream2)
+	c.Assert(event.Events, check.HasLen, 1)
+	c.Assert(event.Events[0].RequestId, check.Equals, uint64(2))
+	c.Assert(event.ResolvedTs, check.DeepEquals, &cdcpb.ResolvedTs{Regions: []uint64{2}, Ts: 100})
+
+	// the region can be subscribed again on the stream after an error.
+	factory.stream(0).events <- &cdcpb.ChangeDataEvent{
+		Events: []*cdcpb.Event{{RegionId: 2, RequestId: 2, Event: &cdcpb.Event_Error{}}},
+	}
+	event = recvWithTimeout(c, stream2)
+	c.Assert(event.Events[0].RequestId, check.Equals, uint64(2))
+	c.Assert(stream2.Send(&cdcpb.ChangeDataRequest{RegionId: 2, RequestId: 4}), check.IsNil)
+	created, _ = factory.stats()
+	c.Assert(created, check.Equals, 2)
+
+	// the events of a closed session are dropped, and the first stream is
+	// recycled shortly after to deregister the orphaned region.
+	cancel1()
+	time.Sleep(100 * time.Millisecond)
+	factory.stream(0).events <- &cdcpb.ChangeDataEvent{
+		Events: []*cdcpb.Event{
+			{RegionId: 1, RequestId: 1, Event: &cdcpb.Event_Entries_{}},
+			{RegionId: 2, RequestId: 4, Event: &cdcpb.Event_Entries_{}},
+		},
+	}
+	event = recvWithTimeout(c, stream2)
+	c.Assert(event.Events, check.HasLen, 1)
+	c.Assert(event.Events[0].RequestId, check.Equals, uint64(4))
+	_, released := factory.stats()
+	c.Assert(released, check.Equals, 0)
+	_, err = stream2.Recv()
+	c.Assert(err, check.Equals, errSharedStreamRecycled)
+	_, released = factory.stats()
+	c.Assert(released, check.Equals, 1)
+
+	cancel()
+	time.Sleep(100 * time.Millisecond)
+	_, released = factory.stats()
+	c.Assert(released, check.Equals, 2)
+	mux.mu.Lock()
+	c.Assert(mux.stores, check.HasLen, 0)
+	mux.mu.Unlock()
+}
+
+func (s *streamMuxSuite) TestSharedStreamFailure(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	factory := &fakeStreamFactory{}
+	mux := newStreamMultiplexer()
+	stream1, err := mux.newMuxStream(ctx, "store-1", factory.create, factory.release)
+	c.Assert(err, check.IsNil)
+	stream2, err := mux.newMuxStream(ctx, "store-1", factory.create, factory.release)
+	c.Assert(err, check.IsNil)
+	c.Assert(stream1.Send(&cdcpb.ChangeDataRequest{RegionId: 1, RequestId: 1}), check.IsNil)
+	c.Assert(stream2.Send(&cdcpb.ChangeDataRequest{RegionId: 2, RequestId: 2}), check.IsNil)
+
+	// all sessions using a broken stream receive the error.
+	factory.stream(0).errCh <- errors.New("stream broken")
+	for _, stream := range []*muxStream{stream1, stream2} {
+		_, err := stream.Recv()
+		c.Assert(err, check.ErrorMatches, "stream broken")
+		c.Assert(stream.Send(&cdcpb.ChangeDataRequest{RegionId: 3, RequestId: 3}), check.NotNil)
+	}
+	created, released := factory.stats()
+	c.Assert(created, check.Equals, 1)
+	c.Assert(released, check.Equals, 1)
+}
+
+func (s *streamMuxSuite) TestDropSlowSession(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func(size int) {
+		muxStreamMaxBufferedBytes = size
+	}(muxStreamMaxBufferedBytes)
+	muxStreamMaxBufferedBytes = 1
+
+	factory := &fakeStreamFactory{}
+	mux := newStreamMultiplexer()
+	stream1, err := mux.newMuxStream(ctx, "store-1", factory.create, factory.release)
+	c.Assert(err, check.IsNil)
+	stream2, err := mux.newMuxStream(ctx, "store-1", factory.create, factory.release)
+	c.Assert(err, check.IsNil)
+	c.Assert(stream1.Send(&cdcpb.ChangeDataRequest{RegionId: 1, RequestId: 1}), check.IsNil)
+	c.Assert(stream2.Send(&cdcpb.ChangeDataRequest{RegionId: 2, RequestId: 2}), check.IsNil)
+
+	// the first session never receives, it doesn't block the second one.
+	for i := 0; i < 3; i++ {
+		factory.stream(0).events <- &cdcpb.ChangeDataEvent{
+			Events: []*cdcpb.Event{
+				{RegionId: 1, RequestId: 1, Event: &cdcpb.Event_Entries_{}},
+				{RegionId: 2, RequestId: 2, Event: &cdcpb.Event_Entries_{}},
+			},
+		}
+		event := recvWithTimeout(c, stream2)
+		c.Assert(event.Events[0].RequestId, check.Equals, uint64(2))
+	}
+	_, err = stream1.Recv()
+	c.Assert(err, check.Equals, errMuxStreamTooSlow)
+}
+
+func (s *streamMuxSuite) TestClientStreamMethods(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	factory := &fakeStreamFactory{}
+	mux := newStreamMultiplexer()
+	stream, err := mux.newMuxStream(ctx, "store-1", factory.create, factory.release)
+	c.Assert(err, check.IsNil)
+
+	// the methods of grpc.ClientStream do not panic.
+	_, err = stream.Header()
+	c.Assert(err, check.NotNil)
+	c.Assert(stream.Trailer(), check.IsNil)
+	c.Assert(stream.SendMsg("unexpected"), check.NotNil)
+	c.Assert(stream.RecvMsg("unexpected"), check.NotNil)
+	c.Assert(stream.CloseSend(), check.IsNil)
+	c.Assert(stream.Context(), check.NotNil)
+
+	c.Assert(stream.SendMsg(&cdcpb.ChangeDataRequest{RegionId: 1, RequestId: 1}), check.IsNil)
+	req := <-factory.stream(0).requests
+	c.Assert(req.RegionId, check.Equals, uint64(1))
+	factory.stream(0).events <- &cdcpb.ChangeDataEvent{Events: []*cdcpb.Event{{RegionId: 1, RequestId: 1}}}
+	event := &cdcpb.ChangeDataEvent{}
+	c.Assert(stream.RecvMsg(event), check.IsNil)
+	c.Assert(event.Events, check.HasLen, 1)
+	c.Assert(event.Events[0].RegionId, check.Equals, uint64(1))
+}
@@ -41,6 +41,9 @@ type LimitRegionRouter interface {
 	Acquire(id string)
 	// Release gives back one token, this function is thread-safe
 	Release(id string)
+	// Revoke gives back the token reserved for a region received from Chan,
+	// if no request of the region is sent, this function is thread-safe
+	Revoke(id string)
 	// Run runs in background and does some logic work
 	Run(ctx context.Context) error
 }
@@ -68,6 +71,12 @@ type sizedRegionRouter struct {
 	metrics   *srrMetrics
 	tokens    map[string]int
 	sizeLimit int
+	// global limits the incremental scans of all tables in a store. A token of
+	// global is reserved before a region is sent to output, and it is held
+	// until the token of the region is released.
+	global   *storeScanLimiter
+	reserved map[string]int
+	closed   bool
 }
 
 // NewSizedRegionRouter creates a new sizedRegionRouter
@@ -78,6 +87,8 @@ func NewSizedRegionRouter(ctx context.Context, sizeLimit int) *sizedRegionRouter
 		sizeLimit: sizeLimit,
 		tokens:    make(map[string]int),
 		metrics:   newSrrMetrics(ctx),
+		global:    getStoreScanLimiter(),
+		reserved:  make(map[string]int),
 	}
 }
 
@@ -92,7 +103,7 @@ func (r *sizedRegionRouter) AddRegion(sri singleRegionInfo) {
 	if sri.rpcCtx != nil {
 		id = sri.rpcCtx.Addr
 	}
-	if r.sizeLimit > r.tokens[id] && len(r.output) < regionRouterChanSize {
+	if r.sizeLimit > r.tokens[id] && len(r.output) < regionRouterChanSize && r.reserve(id) {
 		r.output <- sri
 	} else {
 		r.buffer[id] = append(r.buffer[id], sri)
@@ -104,6 +115,11 @@ func (r *sizedRegionRouter) Acquire(id string) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 	r.tokens[id]++
+	if r.reserved[id] > 0 {
+		r.reserved[id]--
+	} else if !r.closed {
+		r.global.acquire(id)
+	}
 	if _, ok := r.metrics.tokens[id]; !ok {
 		r.metrics.tokens[id] = clientRegionTokenSize.WithLabelValues(id, r.metrics.changefeed, r.metrics.capture)
 	}
@@ -114,15 +130,61 @@ func (r *sizedRegionRouter) Release(id string) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
 	r.tokens[id]--
+	if !r.closed {
+		r.global.release(id, 1)
+	}
 	if _, ok := r.metrics.tokens[id]; !ok {
 		r.metrics.tokens[id] = clientRegionTokenSize.WithLabelValues(id, r.metrics.changefeed, r.metrics.capture)
 	}
 	r.metrics.tokens[id].Dec()
 }
 
+func (r *sizedRegionRouter) Revoke(id string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.reserved[id] > 0 {
+		r.reserved[id]--
+		if !r.closed {
+			r.global.release(id, 1)
+		}
+	}
+}
+
+// reserve reserves a token of global for a region sent to output,
+// r.lock must be held.
+func (r *sizedRegionRouter) reserve(id string) bool {
+	if !r.global.tryAcquire(id) {
+		return false
+	}
+	r.reserved[id]++
+	return true
+}
+
+// close gives back all tokens of global held by the router, because
+// the regions will never be released after the router exits.
+func (r *sizedRegionRouter) close() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	for id, n := range r.reserved {
+		if n+r.tokens[id] > 0 {
+			r.global.release(id, n+r.tokens[id])
+		}
+	}
+	for id, n := range r.tokens {
+		if _, ok := r.reserved[id]; !ok && n > 0 {
+			r.global.release(id, n)
+		}
+	}
+}
+
 func (r *sizedRegionRouter) Run(ctx context.Context) error {
 	ticker := time.NewTicker(sizedRegionCheckInterval)
 	defer ticker.Stop()
+	defer r.close()
 	for {
 		select {
 		case <-ctx.Done():
@@ -147,15 +209,16 @@ func (r *sizedRegionRouter) Run(ctx context.Context) error {
 				if available == 0 {
 					continue
 				}
-				for i := 0; i < available; i++ {
+				sent := 0
+				for ; sent < available && r.reserve(id); sent++ {
 					select {
 					case <-ctx.Done():
 						r.lock.Unlock()
 						return errors.Trace(ctx.Err())
-					case r.output <- buf[i]:
+					case r.output <- buf[sent]:
 					}
 				}
-				r.buffer[id] = r.buffer[id][available:]
+				r.buffer[id] = r.buffer[id][sent:]
 			}
 			r.lock.Unlock()
 		}
@@ -15,6 +15,7 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
 	"math"
 	"regexp"
 	"sort"
@@ -25,6 +26,7 @@ import (
 	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/cyclic/mark"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/tikv/client-go/v2/oracle"
 	"go.uber.org/zap"
 )
@@ -37,8 +39,61 @@ const (
 	SortInMemory SortEngine = "memory"
 	SortInFile   SortEngine = "file"
 	SortUnified  SortEngine = "unified"
+	// SortInDB sorts the events of all tables on a capture in one
+	// embedded ordered key-value store.
+	SortInDB SortEngine = "db"
 )
 
+// ChangefeedPriority is the priority of a changefeed. The processors of the
+// changefeeds on a capture are ticked from high to low priority, and the
+// unified sorters of low priority changefeeds spill to disk at half of the
+// memory thresholds. It does not ration the throughput of the sorters or
+// the sinks, use `ChangefeedQuota` to limit the resources of a changefeed.
+type ChangefeedPriority = string
+
+// changefeed priorities
+const (
+	PriorityHigh   ChangefeedPriority = "high"
+	PriorityNormal ChangefeedPriority = "normal"
+	PriorityLow    ChangefeedPriority = "low"
+)
+
+// PriorityValue returns an int for each `ChangefeedPriority`, a higher value
+// means a higher priority. An empty priority is treated as `PriorityNormal`.
+func PriorityValue(priority ChangefeedPriority) int {
+	switch priority {
+	case PriorityHigh:
+		return 2
+	case PriorityLow:
+		return 0
+	}
+	return 1
+}
+
+// ValidateChangefeedPriority returns an error if the priority is not one of
+// "high", "normal" and "low". An empty priority is valid and means "normal".
+func ValidateChangefeedPriority(priority ChangefeedPriority) error {
+	switch priority {
+	case "", PriorityHigh, PriorityNormal, PriorityLow:
+		return nil
+	}
+	return cerror.ErrInvalidChangefeedPriority.GenWithStackByArgs(priority)
+}
+
+// ChangefeedQuota limits the resources a changefeed can use on each capture.
+// A zero value of a field means the resource is not limited at the changefeed level.
+type ChangefeedQuota struct {
+	// MemoryQuota is the max memory in bytes used by all the table pipelines
+	// of the changefeed on a capture.
+	MemoryQuota uint64 `toml:"memory-quota" json:"memory-quota"`
+	// SorterDiskQuota is the max disk space in bytes used by the unified sorter
+	// of the changefeed on a capture.
+	SorterDiskQuota uint64 `toml:"sorter-disk-quota" json:"sorter-disk-quota"`
+	// SinkConcurrency is the max number of workers used by the sink, only the
+	// MySQL and TiDB sinks support it.
+	SinkConcurrency int `toml:"sink-concurrency" json:"sink-concurrency"`
+}
+
 // FeedState represents the running state of a changefeed
 type FeedState string
 
@@ -112,6 +167,9 @@ type ChangeFeedInfo struct {
 	StartTs uint64 `json:"start-ts"`
 	// The ChangeFeed will exits until sync to timestamp TargetTs
 	TargetTs uint64 `json:"target-ts"`
+	// The ChangeFeed will be stopped after all the events with commit ts <= StopTs are synced,
+	// 0 means there is no scheduled stop.
+	StopTs uint64 `json:"stop-ts,omitempty"`
 	// used for admin job notification, trigger watch event in capture
 	AdminJobType AdminJobType `json:"admin-job-type"`
 	Engine       SortEngine   `json:"sort-engine"`
@@ -128,6 +186,9 @@ type ChangeFeedInfo struct {
 	SyncPointEnabled  bool          `json:"sync-point-enabled"`
 	SyncPointInterval time.Duration `json:"sync-point-interval"`
 	CreatorVersion    string        `json:"creator-version"`
+
+	Priority ChangefeedPriority `json:"priority,omitempty"`
+	Quota    *ChangefeedQuota   `json:"quota,omitempty"`
 }
 
 const changeFeedIDMaxLen = 128
@@ -158,6 +219,10 @@ func (info *ChangeFeedInfo) String() (str string) {
 		return
 	}
 	clone.SinkURI = "***"
+	clone.Opts = security.RedactOpts(clone.Opts)
+	if clone.Config != nil && clone.Config.Heartbeat != nil {
+		clone.Config.Heartbeat.UpstreamURI = "***"
+	}
 	str, err = clone.Marshal()
 	if err != nil {
 		log.Error("failed to marshal changefeed info", zap.Error(err))
@@ -165,6 +230,23 @@ func (info *ChangeFeedInfo) String() (str string) {
 	return
 }
 
+// Redacted returns a shallow copy of the info with the plaintext secrets in the
+// sink URI, options and heartbeat upstream URI redacted, it should be used
+// wherever the info is shown to users.
+func (info *ChangeFeedInfo) Redacted() *ChangeFeedInfo {
+	redacted := *info
+	redacted.SinkURI = security.RedactURI(info.SinkURI)
+	redacted.Opts = security.RedactOpts(info.Opts)
+	if info.Config != nil && info.Config.Heartbeat != nil {
+		cfg := *info.Config
+		heartbeat := *cfg.Heartbeat
+		heartbeat.UpstreamURI = security.RedactURI(heartbeat.UpstreamURI)
+		cfg.Heartbeat = &heartbeat
+		redacted.Config = &cfg
+	}
+	return &redacted
+}
+
 // GetStartTs returns StartTs if it's  specified or using the CreateTime of changefeed.
 func (info *ChangeFeedInfo) GetStartTs() uint64 {
 	if info.StartTs > 0 {
@@ -190,6 +272,62 @@ func (info *ChangeFeedInfo) GetTargetTs() uint64 {
 	return uint64(math.MaxUint64)
 }
 
+// VerifyStopTs checks whether the changefeed can be stopped at stopTs,
+// the stop ts must be greater than the checkpoint ts.
+func (info *ChangeFeedInfo) VerifyStopTs(status *ChangeFeedStatus, stopTs uint64) error {
+	checkpointTs := info.GetCheckpointTs(status)
+	if stopTs <= checkpointTs {
+		return cerror.ErrStopTsBeforeCheckpointTs.GenWithStackByArgs(stopTs, checkpointTs)
+	}
+	return nil
+}
+
+// VerifyTargetTs checks whether the target ts of the changefeed can be updated to targetTs,
+// 0 means the changefeed never finishes, otherwise it must be greater than the checkpoint ts.
+func (info *ChangeFeedInfo) VerifyTargetTs(status *ChangeFeedStatus, targetTs uint64) error {
+	checkpointTs := info.GetCheckpointTs(status)
+	if targetTs != 0 && targetTs <= checkpointTs {
+		return cerror.ErrTargetTsBeforeCheckpointTs.GenWithStackByArgs(targetTs, checkpointTs)
+	}
+	return nil
+}
+
+// VerifyOverwriteCheckpointTs checks whether the changefeed can be resumed from checkpointTs,
+// currentTs is the current TSO of the upstream cluster.
+// Note that the safety of checkpointTs against GC is not checked here.
+func (info *ChangeFeedInfo) VerifyOverwriteCheckpointTs(checkpointTs uint64, currentTs uint64) error {
+	if checkpointTs == 0 {
+		return cerror.ErrInvalidOverwriteCheckpointTs.GenWithStackByArgs(checkpointTs, "it must be greater than 0")
+	}
+	if checkpointTs > currentTs {
+		return cerror.ErrInvalidOverwriteCheckpointTs.GenWithStackByArgs(checkpointTs,
+			fmt.Sprintf("it is later than the current ts %d", currentTs))
+	}
+	if checkpointTs >= info.GetTargetTs() {
+		return cerror.ErrInvalidOverwriteCheckpointTs.GenWithStackByArgs(checkpointTs,
+			fmt.Sprintf("it is later than or equal to the target ts %d", info.GetTargetTs()))
+	}
+	return nil
+}
+
+// GetPriority returns the priority of the changefeed, `PriorityNormal` is
+// returned if it's not specified.
+func (info *ChangeFeedInfo) GetPriority() ChangefeedPriority {
+	if info.Priority == "" {
+		return PriorityNormal
+	}
+	return info.Priority
+}
+
+// GetQuota returns the resource quota of the changefeed, a zero quota
+// is returned if it's not specified.
+func (info *ChangeFeedInfo) GetQuota() ChangefeedQuota {
+	if info.Quota == nil {
+		return ChangefeedQuota{}
+	}
+	return *info.Quota
+}
+
 // Marshal returns the json marshal format of a ChangeFeedInfo
 func (info *ChangeFeedInfo) Marshal() (string, error) {
 	data, err := json.Marshal(info)
@@ -15,10 +15,15 @@ package model
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/pingcap/errors"
 	"github.com/pingcap/ticdc/pkg/config"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/r3labs/diff"
 )
 
 // JSONTime used to wrap time into json format
@@ -30,6 +35,14 @@ func (t JSONTime) MarshalJSON() ([]byte, error) {
 	return []byte(stamp), nil
 }
 
+// the headers carrying the identity of the caller when a call is forwarded to
+// another capture, they are only trusted if the call is sent with the client
+// certificate of a capture.
+const (
+	ForwardedIdentityHeader = "TiCDC-Forwarded-Identity"
+	ForwardedRoleHeader     = "TiCDC-Forwarded-Role"
+)
+
 // HTTPError of cdc http api
 type HTTPError struct {
 	Error string `json:"error_msg"`
@@ -78,6 +91,8 @@ type ChangefeedDetail struct {
 	ErrorHis       []int64             `json:"error_history"`
 	CreatorVersion string              `json:"creator_version"`
 	TaskStatus     []CaptureTaskStatus `json:"task_status"`
+	Priority       ChangefeedPriority  `json:"priority"`
+	Quota          *ChangefeedQuota    `json:"quota,omitempty"`
 }
 
 // ChangefeedConfig use to create a changefeed
@@ -95,6 +110,11 @@ type ChangefeedConfig struct {
 	IgnoreTxnStartTs      []uint64           `json:"ignore_txn_start_ts"`
 	MounterWorkerNum      int                `json:"mounter_worker_num" default:"16"`
 	SinkConfig            *config.SinkConfig `json:"sink_config"`
+	// Priority is one of "high", "normal" and "low"
+	Priority        ChangefeedPriority `json:"priority" default:"normal"`
+	MemoryQuota     uint64             `json:"memory_quota"`
+	SorterDiskQuota uint64             `json:"sorter_disk_quota"`
+	SinkConcurrency int                `json:"sink_concurrency"`
 }
 
 // ProcessorCommonInfo holds the common info of a processor
@@ -125,7 +145,196 @@ type CaptureTaskStatus struct {
 
 // Capture holds common information of a capture in cdc
 type Capture struct {
-	ID            string `json:"id"`
-	IsOwner       bool   `json:"is_owner"`
-	AdvertiseAddr string `json:"address"`
+	ID            string            `json:"id"`
+	IsOwner       bool              `json:"is_owner"`
+	AdvertiseAddr string            `json:"address"`
+	Labels        map[string]string `json:"labels,omitempty"`
+}
+
+// DrainCaptureStatus holds the draining progress of a capture
+type DrainCaptureStatus struct {
+	CaptureID string `json:"capture_id"`
+	// the number of tables which are not yet replicating on other captures
+	TableCount int `json:"table_count"`
+	// true means the capture is safe to stop
+	IsDrained bool `json:"is_drained"`
+}
+
+// LogLevelConfig changes the log level of a capture or one of its modules
+type LogLevelConfig struct {
+	Level string `json:"log_level"`
+	// Module is the module whose log level is changed, the global log level is
+	// changed if it is empty. The module uses the global log level again if
+	// the level is empty.
+	Module string `json:"module,omitempty"`
+}
+
+// LogLevels holds the log level of a capture and the levels of the modules
+// set separately
+type LogLevels struct {
+	Level        string            `json:"log_level"`
+	ModuleLevels map[string]string `json:"module_levels"`
+}
+
+// TableReplicationStatus holds the replication progress of a table
+type TableReplicationStatus struct {
+	TableID   int64  `json:"table_id"`
+	TableName string `json:"table_name"`
+	CaptureID string `json:"capture_id"`
+	// Status is the status of the table pipeline, it is "Unknown" if the
+	// capture replicating the table can not be reached
+	Status       string `json:"status"`
+	ResolvedTs   uint64 `json:"resolved_ts"`
+	CheckpointTs uint64 `json:"checkpoint_ts"`
+	// the number of row changed events buffered in the sorter
+	SorterBacklog int64 `json:"sorter_backlog"`
+	// the gap in milliseconds between the resolved ts and the checkpoint ts,
+	// i.e. the time range of the resolved events not yet flushed by the sink
+	ResolvedCheckpointGap int64 `json:"resolved_checkpoint_gap_ms"`
+	// Error is the reason why the status of the table is unknown
+	Error string `json:"error,omitempty"`
+}
+
+// WatchEventType is the type of the state change streamed by the watch API
+type WatchEventType string
+
+// the types of the state changes streamed by the watch API
+const (
+	WatchEventOwner            WatchEventType = "owner"
+	WatchEventCapture          WatchEventType = "capture"
+	WatchEventChangefeedInfo   WatchEventType = "changefeed_info"
+	WatchEventChangefeedStatus WatchEventType = "changefeed_status"
+	WatchEventTaskStatus       WatchEventType = "task_status"
+	WatchEventTaskPosition     WatchEventType = "task_position"
+	// WatchEventSynced is sent after the initial snapshot, the state of the
+	// cluster is complete since then
+	WatchEventSynced WatchEventType = "synced"
+	// WatchEventError is sent before the stream is closed because of an error
+	WatchEventError WatchEventType = "error"
+)
+
+// WatchEvent is a state change streamed by the watch API
+type WatchEvent struct {
+	Type WatchEventType `json:"type"`
+	// Revision is the etcd revision of the change, it can be used as the
+	// resume token to continue the stream after it
+	Revision     int64        `json:"revision"`
+	Deleted      bool         `json:"deleted,omitempty"`
+	ChangefeedID ChangeFeedID `json:"changefeed_id,omitempty"`
+	CaptureID    CaptureID    `json:"capture_id,omitempty"`
+	// Value is the new state, it is empty if the state is deleted
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ChangefeedConfigChange is a change of the changefeed info made by an update
+type ChangefeedConfigChange struct {
+	// Type is one of "create", "update" and "delete"
+	Type string      `json:"type"`
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// ChangefeedDryRunResult holds the effects of creating or updating a changefeed,
+// which are computed without applying them
+type ChangefeedDryRunResult struct {
+	// Config is the effective replica config of the changefeed
+	Config *config.ReplicaConfig `json:"config"`
+	// the tables matched by the filter at the current ts
+	EligibleTables   []TableName `json:"eligible_tables"`
+	IneligibleTables []TableName `json:"ineligible_tables"`
+	// the tables which start or stop being replicated because of the update
+	AddedTables   []TableName `json:"added_tables,omitempty"`
+	RemovedTables []TableName `json:"removed_tables,omitempty"`
+	// Diff is the changes against the running changefeed, it is empty if the
+	// changefeed is created
+	Diff []ChangefeedConfigChange `json:"diff,omitempty"`
+}
+
+// NewChangefeedDryRunResult creates a ChangefeedDryRunResult of a changefeed
+// whose config is cfg.
+func NewChangefeedDryRunResult(
+	cfg *config.ReplicaConfig, ineligibleTables, eligibleTables []TableName,
+) *ChangefeedDryRunResult {
+	return &ChangefeedDryRunResult{
+		Config:           cfg,
+		EligibleTables:   append([]TableName{}, eligibleTables...),
+		IneligibleTables: append([]TableName{}, ineligibleTables...),
+	}
+}
+
+// DiffWith fills the changes made by updating oldInfo to newInfo, the tables
+// are those matched by the filter of oldInfo.
+func (r *ChangefeedDryRunResult) DiffWith(
+	oldInfo, newInfo *ChangeFeedInfo, oldIneligibleTables, oldEligibleTables []TableName,
+) error {
+	changes, err := DiffChangefeedInfo(oldInfo, newInfo)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	r.Diff = changes
+
+	oldTables := replicatedTables(oldInfo.Config, oldIneligibleTables, oldEligibleTables)
+	newTables := replicatedTables(newInfo.Config, r.IneligibleTables, r.EligibleTables)
+	r.AddedTables = subtractTables(newTables, oldTables)
+	r.RemovedTables = subtractTables(oldTables, newTables)
+	return nil
+}
+
+// DiffChangefeedInfo returns the changes made by updating oldInfo to newInfo,
+// the secrets in the sink uri are redacted.
+func DiffChangefeedInfo(oldInfo, newInfo *ChangeFeedInfo) ([]ChangefeedConfigChange, error) {
+	changelog, err := diff.Diff(oldInfo.Redacted(), newInfo.Redacted())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	changes := make([]ChangefeedConfigChange, 0, len(changelog)+1)
+	sinkURIChanged := false
+	for _, change := range changelog {
+		path := strings.Join(change.Path, ".")
+		sinkURIChanged = sinkURIChanged || path == "SinkURI"
+		changes = append(changes, ChangefeedConfigChange{
+			Type: change.Type,
+			Path: path,
+			From: change.From,
+			To:   change.To,
+		})
+	}
+	// a change of the secrets in the sink uri is hidden by the redaction
+	if !sinkURIChanged && oldInfo.SinkURI != newInfo.SinkURI {
+		changes = append(changes, ChangefeedConfigChange{
+			Type: diff.UPDATE,
+			Path: "SinkURI",
+			From: security.RedactURI(oldInfo.SinkURI),
+			To:   security.RedactURI(newInfo.SinkURI),
+		})
+	}
+	return changes, nil
+}
+
+// replicatedTables returns the tables replicated by a changefeed, the
+// ineligible tables are only replicated if they are forced to.
+func replicatedTables(cfg *config.ReplicaConfig, ineligibleTables, eligibleTables []TableName) []TableName {
+	if cfg.ForceReplicate {
+		return append(append([]TableName{}, eligibleTables...), ineligibleTables...)
+	}
+	return eligibleTables
+}
+
+// subtractTables returns the tables in a but not in b, sorted by name.
+func subtractTables(a, b []TableName) []TableName {
+	exists := make(map[string]struct{}, len(b))
+	for _, table := range b {
+		exists[table.String()] = struct{}{}
+	}
+	var tables []TableName
+	for _, table := range a {
+		if _, ok := exists[table.String()]; !ok {
+			tables = append(tables, table)
+		}
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].String() < tables[j].String()
+	})
+	return tables
 }
@@ -38,6 +38,9 @@ const (
 type RegionFeedEvent struct {
 	Val      *RawKVEntry
 	Resolved *ResolvedSpan
+	// OldValueMissing is true if the old value of Val is requested but may
+	// not be supplied by TiKV, and it should be read from a snapshot.
+	OldValueMissing bool
 
 	// Additonal debug info
 	RegionID uint64
@@ -30,6 +30,12 @@ type AdminJobType int
 // AdminJobOption records addition options of an admin job
 type AdminJobOption struct {
 	ForceRemove bool
+	// StopTs is the commit ts at which the changefeed should be paused, used by AdminStopAtTs
+	StopTs uint64
+	// TargetTs is the new target ts of the changefeed, used by AdminUpdateTargetTs
+	TargetTs uint64
+	// OverwriteCheckpointTs is the checkpoint ts which the changefeed is resumed from, used by AdminResume
+	OverwriteCheckpointTs uint64
 }
 
 // AdminJob holds an admin job
@@ -47,6 +53,8 @@ const (
 	AdminResume
 	AdminRemove
 	AdminFinish
+	AdminStopAtTs
+	AdminUpdateTargetTs
 )
 
 // String implements fmt.Stringer interface.
@@ -62,6 +70,10 @@ func (t AdminJobType) String() string {
 		return "remove changefeed"
 	case AdminFinish:
 		return "finish changefeed"
+	case AdminStopAtTs:
+		return "stop changefeed at ts"
+	case AdminUpdateTargetTs:
+		return "update target ts of changefeed"
 	}
 	return "unknown"
 }
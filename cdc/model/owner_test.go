@@ -30,23 +30,27 @@ var _ = check.Suite(&ownerCommonSuite{})
 func (s *ownerCommonSuite) TestAdminJobType(c *check.C) {
 	defer testleak.AfterTest(c)()
 	names := map[AdminJobType]string{
-		AdminNone:         "noop",
-		AdminStop:         "stop changefeed",
-		AdminResume:       "resume changefeed",
-		AdminRemove:       "remove changefeed",
-		AdminFinish:       "finish changefeed",
-		AdminJobType(100): "unknown",
+		AdminNone:           "noop",
+		AdminStop:           "stop changefeed",
+		AdminResume:         "resume changefeed",
+		AdminRemove:         "remove changefeed",
+		AdminFinish:         "finish changefeed",
+		AdminStopAtTs:       "stop changefeed at ts",
+		AdminUpdateTargetTs: "update target ts of changefeed",
+		AdminJobType(100):   "unknown",
 	}
 	for job, name := range names {
 		c.Assert(job.String(), check.Equals, name)
 	}
 
 	isStopped := map[AdminJobType]bool{
-		AdminNone:   false,
-		AdminStop:   true,
-		AdminResume: false,
-		AdminRemove: true,
-		AdminFinish: true,
+		AdminNone:           false,
+		AdminStop:           true,
+		AdminResume:         false,
+		AdminRemove:         true,
+		AdminFinish:         true,
+		AdminStopAtTs:       false,
+		AdminUpdateTargetTs: false,
 	}
 	for job, stopped := range isStopped {
 		c.Assert(job.IsStopState(), check.Equals, stopped)
@@ -23,3 +23,37 @@ const (
 	SorterStatusStopped
 	SorterStatusFinished
 )
+
+// RegionFeedStatus is the state of a region subscribed by a puller
+type RegionFeedStatus = string
+
+// RegionFeedStatus of a subscribed region
+const (
+	// RegionFeedStatusInitializing means the incremental scan of the region
+	// has not finished
+	RegionFeedStatusInitializing RegionFeedStatus = "initializing"
+	// RegionFeedStatusNormal means the resolved ts of the region is advanced
+	// by TiKV
+	RegionFeedStatusNormal RegionFeedStatus = "normal"
+	// RegionFeedStatusResolvingLock means the resolved ts of the region has
+	// not advanced for a long time, and the locks of the region are being resolved
+	RegionFeedStatusResolvingLock RegionFeedStatus = "resolving-lock"
+)
+
+// RegionResolvedTsInfo is the resolved ts diagnostics of a region subscribed
+// by a puller
+type RegionResolvedTsInfo struct {
+	RegionID   uint64           `json:"region_id"`
+	StoreAddr  string           `json:"store_addr"`
+	ResolvedTs uint64           `json:"resolved_ts"`
+	Status     RegionFeedStatus `json:"status"`
+	// the number of times the locks of the region have been resolved since
+	// its resolved ts advanced last time
+	ResolveLockCount int `json:"resolve_lock_count"`
+}
+
+// TableRegionsLag holds the regions with the smallest resolved ts of a table
+type TableRegionsLag struct {
+	TableID TableID                `json:"table_id"`
+	Regions []RegionResolvedTsInfo `json:"slowest_regions"`
+}
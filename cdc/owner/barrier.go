@@ -29,6 +29,8 @@ const (
 	syncPointBarrier
 	// finishBarrier denotes a barrier for changefeed finished.
 	finishBarrier
+	// stopBarrier denotes a barrier for changefeed stopped at a specified ts.
+	stopBarrier
 )
 
 // barriers stores some barrierType and barrierTs, and can calculate the min barrierTs
@@ -23,8 +23,10 @@ import (
 	"github.com/pingcap/log"
 	timodel "github.com/pingcap/parser/model"
 	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
 	cdcContext "github.com/pingcap/ticdc/pkg/context"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/heartbeat"
 	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/pingcap/tidb/sessionctx/binloginfo"
 	"github.com/prometheus/client_golang/prometheus"
@@ -57,7 +59,7 @@ type changefeed struct {
 	// The changefeed will start some backend goroutines in the function `initialize`,
 	// such as DDLPuller, Sink, etc.
 	// `wg` is used to manage those backend goroutines.
-	// But it only manages the DDLPuller for now.
+	// But it only manages the DDLPuller and the heartbeat writer for now.
 	// TODO: manage the Sink and other backend goroutines.
 	wg sync.WaitGroup
 
@@ -155,6 +157,7 @@ func (c *changefeed) tick(ctx cdcContext.Context, state *model.ChangefeedReactor
 	default:
 	}
 
+	c.updateFinishAndStopBarriers(checkpointTs)
 	c.sink.EmitCheckpointTs(ctx, checkpointTs)
 	barrierTs, err := c.handleBarrier(ctx)
 	if err != nil {
@@ -224,6 +227,10 @@ LOOP:
 	if err != nil {
 		return errors.Trace(err)
 	}
+	c.scheduler.placement, err = newPlacement(c.state.Info.Config, c.schema.TableName)
+	if err != nil {
+		return errors.Trace(err)
+	}
 	cancelCtx, cancel := cdcContext.WithCancel(ctx)
 	c.cancel = cancel
 	c.sink, err = c.newSink(cancelCtx)
@@ -244,6 +251,14 @@ LOOP:
 		defer c.wg.Done()
 		ctx.Throw(c.ddlPuller.Run(cancelCtx))
 	}()
+	if c.state.Info.Config.Heartbeat.IsEnabled() {
+		writer := heartbeat.NewWriter(c.id, c.state.Info.Config.Heartbeat, config.GetGlobalServerConfig().Security)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			ctx.Throw(writer.Run(cancelCtx))
+		}()
+	}
 
 	// init metrics
 	c.metricsChangefeedCheckpointTsGauge = changefeedCheckpointTsGauge.WithLabelValues(c.id)
@@ -336,6 +351,18 @@ func (c *changefeed) preflightCheck(captures map[model.CaptureID]*model.CaptureI
 	return
 }
 
+// updateFinishAndStopBarriers keeps the finish barrier and the stop barrier up to date,
+// because the target ts and the stop ts can be changed by admin jobs while the changefeed is running.
+func (c *changefeed) updateFinishAndStopBarriers(checkpointTs model.Ts) {
+	c.barriers.Update(finishBarrier, c.state.Info.GetTargetTs())
+	// a stop ts less than the checkpoint ts is outdated, e.g. the checkpoint ts is overwritten
+	if stopTs := c.state.Info.StopTs; stopTs != 0 && stopTs >= checkpointTs {
+		c.barriers.Update(stopBarrier, stopTs)
+	} else {
+		c.barriers.Remove(stopBarrier)
+	}
+}
+
 func (c *changefeed) handleBarrier(ctx cdcContext.Context) (uint64, error) {
 	barrierTp, barrierTs := c.barriers.Min()
 	blocked := (barrierTs == c.state.Status.CheckpointTs) && (barrierTs == c.state.Status.ResolvedTs)
@@ -378,6 +405,12 @@ func (c *changefeed) handleBarrier(ctx cdcContext.Context) (uint64, error) {
 			return barrierTs, nil
 		}
 		c.feedStateManager.MarkFinished()
+	case stopBarrier:
+		if !blocked {
+			return barrierTs, nil
+		}
+		log.Info("the changefeed reaches the stop ts", zap.String("changefeed", c.state.ID), zap.Uint64("stopTs", barrierTs))
+		c.feedStateManager.MarkStopped()
 	default:
 		log.Panic("Unknown barrier type", zap.Int("barrier type", int(barrierTp)))
 	}
@@ -402,7 +435,13 @@ func (c *changefeed) asyncExecDDL(ctx cdcContext.Context, job *timodel.Job) (don
 		if err != nil {
 			return false, errors.Trace(err)
 		}
+		if c.schema.filter.ShouldIgnoreDDLQuery(ddlEvent.Query) {
+			log.Info("ignore the DDL job by ignore-sql", zap.String("changefeed", c.state.ID),
+				zap.String("query", ddlEvent.Query), zap.Uint64("commitTs", ddlEvent.CommitTs))
+			return true, nil
+		}
 		ddlEvent.Query = binloginfo.AddSpecialComment(ddlEvent.Query)
+		ddlEvent.Query = c.schema.filter.RewriteDDLQuery(ddlEvent.Query)
 		c.ddlEventCache = ddlEvent
 	}
 	if job.BinlogInfo.TableInfo != nil && c.schema.IsIneligibleTableID(job.BinlogInfo.TableInfo.ID) {
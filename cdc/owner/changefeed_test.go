@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/pkg/config"
 	cdcContext "github.com/pingcap/ticdc/pkg/context"
+	"github.com/pingcap/ticdc/pkg/filter"
 	"github.com/pingcap/ticdc/pkg/orchestrator"
 	"github.com/pingcap/ticdc/pkg/util/testleak"
 	"github.com/pingcap/ticdc/pkg/version"
@@ -348,3 +349,98 @@ func (s *changefeedSuite) TestFinished(c *check.C) {
 	c.Assert(state.Status.CheckpointTs, check.Equals, state.Info.TargetTs)
 	c.Assert(state.Info.State, check.Equals, model.StateFinished)
 }
+
+func (s *changefeedSuite) TestStopAtTs(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := cdcContext.NewBackendContext4Test(true)
+	ctx.ChangefeedVars().Info.TargetTs = ctx.ChangefeedVars().Info.StartTs + 1000
+	cf, state, captures, tester := createChangefeed4Test(ctx, c)
+	defer cf.Close()
+
+	// pre check
+	cf.Tick(ctx, state, captures)
+	tester.MustApplyPatches()
+
+	// initialize
+	cf.Tick(ctx, state, captures)
+	tester.MustApplyPatches()
+
+	stopTs := ctx.ChangefeedVars().Info.StartTs + 500
+	cf.feedStateManager.PushAdminJob(&model.AdminJob{
+		CfID: state.ID,
+		Type: model.AdminStopAtTs,
+		Opts: &model.AdminJobOption{StopTs: stopTs},
+	})
+	mockDDLPuller := cf.ddlPuller.(*mockDDLPuller)
+	mockDDLPuller.resolvedTs += 2000
+	// tick many times to make sure the change feed is stopped
+	for i := 0; i <= 10; i++ {
+		cf.Tick(ctx, state, captures)
+		tester.MustApplyPatches()
+	}
+
+	c.Assert(state.Status.CheckpointTs, check.Equals, stopTs)
+	c.Assert(state.Info.State, check.Equals, model.StateStopped)
+	c.Assert(state.Info.StopTs, check.Equals, uint64(0))
+}
+
+func (s *changefeedSuite) TestExecDDLWithIgnoreSQLAndRewrite(c *check.C) {
+	defer testleak.AfterTest(c)()
+
+	helper := entry.NewSchemaTestHelper(c)
+	defer helper.Close()
+	job := helper.DDL2Job("create database test0")
+	startTs := job.BinlogInfo.FinishedTS + 1000
+
+	ctx := cdcContext.NewContext(context.Background(), &cdcContext.GlobalVars{
+		KVStorage: helper.Storage(),
+		CaptureInfo: &model.CaptureInfo{
+			ID:            "capture-id-test",
+			AdvertiseAddr: "127.0.0.1:0000",
+			Version:       version.ReleaseVersion,
+		},
+	})
+	replicaConfig := config.GetDefaultReplicaConfig()
+	replicaConfig.Filter.IgnoreSQL = []string{"^create database"}
+	replicaConfig.Filter.DDLRewriteRules = []*config.DDLRewriteRule{{Builtin: filter.DDLRewriterStripShardRowIDBits}}
+	ctx = cdcContext.WithChangefeedVars(ctx, &cdcContext.ChangefeedVars{
+		ID: "changefeed-id-test",
+		Info: &model.ChangeFeedInfo{
+			StartTs: startTs,
+			Config:  replicaConfig,
+		},
+	})
+
+	cf, state, captures, tester := createChangefeed4Test(ctx, c)
+	defer cf.Close()
+	tickThreeTime := func() {
+		cf.Tick(ctx, state, captures)
+		tester.MustApplyPatches()
+		cf.Tick(ctx, state, captures)
+		tester.MustApplyPatches()
+		cf.Tick(ctx, state, captures)
+		tester.MustApplyPatches()
+	}
+	// pre check and initialize
+	tickThreeTime()
+
+	// the DDL matched by ignore-sql is not sent to the sink
+	mockDDLPuller := cf.ddlPuller.(*mockDDLPuller)
+	mockAsyncSink := cf.sink.(*mockAsyncSink)
+	job = helper.DDL2Job("create database test1")
+	mockDDLPuller.resolvedTs = startTs + 1000
+	job.BinlogInfo.FinishedTS = mockDDLPuller.resolvedTs
+	mockDDLPuller.ddlQueue = append(mockDDLPuller.ddlQueue, job)
+	tickThreeTime()
+	c.Assert(state.Status.CheckpointTs, check.Equals, mockDDLPuller.resolvedTs)
+	c.Assert(mockAsyncSink.ddlExecuting, check.IsNil)
+
+	// the schema is still tracked, and the query is rewritten
+	job = helper.DDL2Job("create table test1.test1(id int primary key) shard_row_id_bits=4")
+	mockDDLPuller.resolvedTs += 1000
+	job.BinlogInfo.FinishedTS = mockDDLPuller.resolvedTs
+	mockDDLPuller.ddlQueue = append(mockDDLPuller.ddlQueue, job)
+	tickThreeTime()
+	c.Assert(state.Status.CheckpointTs, check.Equals, mockDDLPuller.resolvedTs)
+	c.Assert(mockAsyncSink.ddlExecuting.Query, check.Equals, "create table test1.test1(id int primary key)")
+}
@@ -45,8 +45,9 @@ type DDLPuller interface {
 }
 
 type ddlPullerImpl struct {
-	puller puller.Puller
-	filter *filter.Filter
+	puller          puller.Puller
+	filter          *filter.Filter
+	historyRecorder *entry.SchemaHistoryRecorder
 
 	mu             sync.Mutex
 	resolvedTS     uint64
@@ -70,10 +71,11 @@ func newDDLPuller(ctx cdcContext.Context, startTs uint64) (DDLPuller, error) {
 	}
 
 	return &ddlPullerImpl{
-		puller:     plr,
-		resolvedTS: startTs,
-		filter:     f,
-		cancel:     func() {},
+		puller:          plr,
+		resolvedTS:      startTs,
+		filter:          f,
+		historyRecorder: entry.NewSchemaHistoryRecorder(startTs),
+		cancel:          func() {},
 	}, nil
 }
 
@@ -97,6 +99,7 @@ func (h *ddlPullerImpl) Run(ctx cdcContext.Context) error {
 		if rawDDL == nil {
 			return nil
 		}
+		h.historyRecorder.Record(rawDDL)
 		if rawDDL.OpType == model.OpTypeResolved {
 			h.mu.Lock()
 			defer h.mu.Unlock()
@@ -72,9 +72,21 @@ func (m *feedStateManager) MarkFinished() {
 	})
 }
 
+// MarkStopped stops the changefeed after it has synced all the events up to the stop ts
+func (m *feedStateManager) MarkStopped() {
+	if m.state == nil {
+		return
+	}
+	m.pushAdminJob(&model.AdminJob{
+		CfID: m.state.ID,
+		Type: model.AdminStop,
+	})
+}
+
 func (m *feedStateManager) PushAdminJob(job *model.AdminJob) {
 	switch job.Type {
-	case model.AdminStop, model.AdminResume, model.AdminRemove:
+	case model.AdminStop, model.AdminResume, model.AdminRemove,
+		model.AdminStopAtTs, model.AdminUpdateTargetTs:
 	default:
 		log.Panic("Can not handle this job", zap.String("changefeedID", m.state.ID),
 			zap.String("changefeedState", string(m.state.Info.State)), zap.Any("job", job))
@@ -100,6 +112,62 @@ func (m *feedStateManager) handleAdminJob() (jobsPending bool) {
 		m.shouldBeRunning = false
 		jobsPending = true
 		m.patchState(model.StateStopped)
+		// the scheduled stop is useless once the changefeed is stopped
+		m.state.PatchInfo(func(info *model.ChangeFeedInfo) (*model.ChangeFeedInfo, bool, error) {
+			if info == nil || info.StopTs == 0 {
+				return info, false, nil
+			}
+			info.StopTs = 0
+			return info, true, nil
+		})
+	case model.AdminStopAtTs:
+		switch m.state.Info.State {
+		case model.StateNormal, model.StateError:
+		default:
+			log.Warn("can not pause the changefeed at ts in the current state", zap.String("changefeedID", m.state.ID),
+				zap.String("changefeedState", string(m.state.Info.State)), zap.Any("job", job))
+			return
+		}
+		checkpointTs := m.state.Info.GetCheckpointTs(m.state.Status)
+		if job.Opts == nil || job.Opts.StopTs <= checkpointTs {
+			log.Warn("the stop ts must be greater than the checkpoint ts", zap.String("changefeedID", m.state.ID),
+				zap.Uint64("checkpointTs", checkpointTs), zap.Any("job", job))
+			return
+		}
+		// the changefeed keeps running until the stop barrier is reached
+		m.state.PatchInfo(func(info *model.ChangeFeedInfo) (*model.ChangeFeedInfo, bool, error) {
+			if info == nil {
+				return nil, false, nil
+			}
+			info.StopTs = job.Opts.StopTs
+			return info, true, nil
+		})
+	case model.AdminUpdateTargetTs:
+		switch m.state.Info.State {
+		case model.StateNormal, model.StateError, model.StateFailed, model.StateStopped, model.StateFinished:
+		default:
+			log.Warn("can not update the target ts of the changefeed in the current state", zap.String("changefeedID", m.state.ID),
+				zap.String("changefeedState", string(m.state.Info.State)), zap.Any("job", job))
+			return
+		}
+		if job.Opts == nil {
+			log.Warn("the target ts is not specified", zap.String("changefeedID", m.state.ID), zap.Any("job", job))
+			return
+		}
+		checkpointTs := m.state.Info.GetCheckpointTs(m.state.Status)
+		// 0 means the changefeed will never finish
+		if job.Opts.TargetTs != 0 && job.Opts.TargetTs <= checkpointTs {
+			log.Warn("the target ts must be greater than the checkpoint ts", zap.String("changefeedID", m.state.ID),
+				zap.Uint64("checkpointTs", checkpointTs), zap.Any("job", job))
+			return
+		}
+		m.state.PatchInfo(func(info *model.ChangeFeedInfo) (*model.ChangeFeedInfo, bool, error) {
+			if info == nil {
+				return nil, false, nil
+			}
+			info.TargetTs = job.Opts.TargetTs
+			return info, true, nil
+		})
 	case model.AdminRemove:
 		switch m.state.Info.State {
 		case model.StateNormal, model.StateError, model.StateFailed,
@@ -131,9 +199,32 @@ func (m *feedStateManager) handleAdminJob() (jobsPending bool) {
 				zap.String("changefeedState", string(m.state.Info.State)), zap.Any("job", job))
 			return
 		}
+		overwriteCheckpointTs := uint64(0)
+		if job.Opts != nil {
+			overwriteCheckpointTs = job.Opts.OverwriteCheckpointTs
+		}
+		if overwriteCheckpointTs != 0 && overwriteCheckpointTs >= m.state.Info.GetTargetTs() {
+			log.Warn("the overwritten checkpoint ts must be less than the target ts", zap.String("changefeedID", m.state.ID),
+				zap.Uint64("targetTs", m.state.Info.GetTargetTs()), zap.Any("job", job))
+			return
+		}
 		m.shouldBeRunning = true
 		jobsPending = true
 		m.patchState(model.StateNormal)
+		if overwriteCheckpointTs != 0 {
+			// the safety of the overwritten checkpoint ts against GC is checked before the job is pushed,
+			// and it will be checked again when the changefeed is initialized.
+			m.state.PatchStatus(func(status *model.ChangeFeedStatus) (*model.ChangeFeedStatus, bool, error) {
+				if status == nil {
+					return nil, false, nil
+				}
+				status.CheckpointTs = overwriteCheckpointTs
+				status.ResolvedTs = overwriteCheckpointTs
+				return status, true, nil
+			})
+			log.Info("the checkpoint ts of the changefeed is overwritten", zap.String("changefeedID", m.state.ID),
+				zap.Uint64("checkpointTs", overwriteCheckpointTs))
+		}
 		// remove error history to make sure the changefeed can running in next tick
 		m.state.PatchInfo(func(info *model.ChangeFeedInfo) (*model.ChangeFeedInfo, bool, error) {
 			if info.Error != nil || len(info.ErrorHis) != 0 {
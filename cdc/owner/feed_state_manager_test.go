@@ -248,3 +248,93 @@ func (s *feedStateManagerSuite) TestChangefeedStatusNotExist(c *check.C) {
 	c.Assert(state.Info, check.IsNil)
 	c.Assert(state.Exist(), check.IsFalse)
 }
+
+func (s *feedStateManagerSuite) TestHandleJobWithTs(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := cdcContext.NewBackendContext4Test(true)
+	manager := new(feedStateManager)
+	state := model.NewChangefeedReactorState(ctx.ChangefeedVars().ID)
+	tester := orchestrator.NewReactorStateTester(c, state, nil)
+	state.PatchInfo(func(info *model.ChangeFeedInfo) (*model.ChangeFeedInfo, bool, error) {
+		c.Assert(info, check.IsNil)
+		return &model.ChangeFeedInfo{SinkURI: "123", TargetTs: 1000, Config: &config.ReplicaConfig{}}, true, nil
+	})
+	state.PatchStatus(func(status *model.ChangeFeedStatus) (*model.ChangeFeedStatus, bool, error) {
+		c.Assert(status, check.IsNil)
+		return &model.ChangeFeedStatus{CheckpointTs: 200, ResolvedTs: 200}, true, nil
+	})
+	tester.MustApplyPatches()
+	manager.Tick(state)
+	tester.MustApplyPatches()
+	c.Assert(manager.ShouldRunning(), check.IsTrue)
+
+	// the stop ts must be greater than the checkpoint ts
+	manager.PushAdminJob(&model.AdminJob{
+		CfID: ctx.ChangefeedVars().ID,
+		Type: model.AdminStopAtTs,
+		Opts: &model.AdminJobOption{StopTs: 200},
+	})
+	manager.Tick(state)
+	tester.MustApplyPatches()
+	c.Assert(state.Info.StopTs, check.Equals, uint64(0))
+
+	// schedule a stop, the changefeed keeps running
+	manager.PushAdminJob(&model.AdminJob{
+		CfID: ctx.ChangefeedVars().ID,
+		Type: model.AdminStopAtTs,
+		Opts: &model.AdminJobOption{StopTs: 300},
+	})
+	manager.Tick(state)
+	tester.MustApplyPatches()
+	c.Assert(manager.ShouldRunning(), check.IsTrue)
+	c.Assert(state.Info.StopTs, check.Equals, uint64(300))
+	c.Assert(state.Info.State, check.Equals, model.StateNormal)
+
+	// extend and shrink the target ts of a running changefeed
+	manager.PushAdminJob(&model.AdminJob{
+		CfID: ctx.ChangefeedVars().ID,
+		Type: model.AdminUpdateTargetTs,
+		Opts: &model.AdminJobOption{TargetTs: 2000},
+	})
+	manager.Tick(state)
+	tester.MustApplyPatches()
+	c.Assert(manager.ShouldRunning(), check.IsTrue)
+	c.Assert(state.Info.TargetTs, check.Equals, uint64(2000))
+	manager.PushAdminJob(&model.AdminJob{
+		CfID: ctx.ChangefeedVars().ID,
+		Type: model.AdminUpdateTargetTs,
+		Opts: &model.AdminJobOption{TargetTs: 100},
+	})
+	manager.Tick(state)
+	tester.MustApplyPatches()
+	c.Assert(state.Info.TargetTs, check.Equals, uint64(2000))
+	manager.PushAdminJob(&model.AdminJob{
+		CfID: ctx.ChangefeedVars().ID,
+		Type: model.AdminUpdateTargetTs,
+		Opts: &model.AdminJobOption{TargetTs: 0},
+	})
+	manager.Tick(state)
+	tester.MustApplyPatches()
+	c.Assert(state.Info.TargetTs, check.Equals, uint64(0))
+
+	// the stop barrier is reached
+	manager.MarkStopped()
+	manager.Tick(state)
+	tester.MustApplyPatches()
+	c.Assert(manager.ShouldRunning(), check.IsFalse)
+	c.Assert(state.Info.State, check.Equals, model.StateStopped)
+	c.Assert(state.Info.StopTs, check.Equals, uint64(0))
+
+	// resume from an overwritten checkpoint ts
+	manager.PushAdminJob(&model.AdminJob{
+		CfID: ctx.ChangefeedVars().ID,
+		Type: model.AdminResume,
+		Opts: &model.AdminJobOption{OverwriteCheckpointTs: 150},
+	})
+	manager.Tick(state)
+	tester.MustApplyPatches()
+	c.Assert(manager.ShouldRunning(), check.IsTrue)
+	c.Assert(state.Info.State, check.Equals, model.StateNormal)
+	c.Assert(state.Status.CheckpointTs, check.Equals, uint64(150))
+	c.Assert(state.Status.ResolvedTs, check.Equals, uint64(150))
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/pingcap/ticdc/cdc/model"
 	cdcContext "github.com/pingcap/ticdc/pkg/context"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/orchestrator"
 	"github.com/pingcap/ticdc/pkg/version"
 	"go.uber.org/zap"
@@ -40,6 +41,7 @@ const (
 	ownerJobTypeManualSchedule
 	ownerJobTypeAdminJob
 	ownerJobTypeDebugInfo
+	ownerJobTypeDrainCapture
 )
 
 type ownerJob struct {
@@ -57,6 +59,13 @@ type ownerJob struct {
 	// for debug info only
 	debugInfoWriter io.Writer
 
+	// for DrainCapture only
+	drainCaptureID model.CaptureID
+	// for DrainCapture only
+	drainStatus *model.DrainCaptureStatus
+	// for DrainCapture only
+	drainErr error
+
 	done chan struct{}
 }
 
@@ -70,6 +79,10 @@ type Owner struct {
 	ownerJobQueueMu sync.Mutex
 	ownerJobQueue   []*ownerJob
 
+	// drainingCaptures records the captures which are being drained,
+	// the changefeeds created later should also move their tables away from these captures.
+	drainingCaptures map[model.CaptureID]struct{}
+
 	lastTickTime time.Time
 
 	closed int32
@@ -80,10 +93,11 @@ type Owner struct {
 // NewOwner creates a new Owner
 func NewOwner() *Owner {
 	return &Owner{
-		changefeeds:   make(map[model.ChangeFeedID]*changefeed),
-		gcManager:     newGCManager(),
-		lastTickTime:  time.Now(),
-		newChangefeed: newChangefeed,
+		changefeeds:      make(map[model.ChangeFeedID]*changefeed),
+		gcManager:        newGCManager(),
+		drainingCaptures: make(map[model.CaptureID]struct{}),
+		lastTickTime:     time.Now(),
+		newChangefeed:    newChangefeed,
 	}
 }
 
@@ -116,7 +130,8 @@ func (o *Owner) Tick(stdCtx context.Context, rawState orchestrator.ReactorState)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	o.handleJobs()
+	o.cleanUpDrainingCaptures(state.Captures)
+	o.handleJobs(state.Captures)
 	for changefeedID, changefeedState := range state.Changefeeds {
 		if changefeedState.Info == nil {
 			o.cleanUpChangefeed(changefeedState)
@@ -129,6 +144,9 @@ func (o *Owner) Tick(stdCtx context.Context, rawState orchestrator.ReactorState)
 		cfReactor, exist := o.changefeeds[changefeedID]
 		if !exist {
 			cfReactor = o.newChangefeed(changefeedID, o.gcManager)
+			for captureID := range o.drainingCaptures {
+				cfReactor.scheduler.DrainCapture(captureID)
+			}
 			o.changefeeds[changefeedID] = cfReactor
 		}
 		cfReactor.Tick(ctx, changefeedState, state.Captures)
@@ -140,6 +158,7 @@ func (o *Owner) Tick(stdCtx context.Context, rawState orchestrator.ReactorState)
 			}
 			cfReactor.Close()
 			delete(o.changefeeds, changefeedID)
+			logutil.RemoveChangefeed(changefeedID)
 		}
 	}
 	if atomic.LoadInt32(&o.closed) != 0 {
@@ -198,6 +217,27 @@ func (o *Owner) WriteDebugInfo(w io.Writer) {
 	}
 }
 
+// DrainCapture makes all changefeeds move their tables away from the specified capture,
+// and returns the draining progress of the capture.
+// It can be called repeatedly to check whether the capture is safe to stop.
+func (o *Owner) DrainCapture(ctx context.Context, captureID model.CaptureID) (*model.DrainCaptureStatus, error) {
+	job := &ownerJob{
+		tp:             ownerJobTypeDrainCapture,
+		drainCaptureID: captureID,
+		done:           make(chan struct{}),
+	}
+	o.pushOwnerJob(job)
+	select {
+	case <-job.done:
+	case <-ctx.Done():
+		return nil, errors.Trace(ctx.Err())
+	}
+	if job.drainErr != nil {
+		return nil, job.drainErr
+	}
+	return job.drainStatus, nil
+}
+
 // AsyncStop stops the owner asynchronously
 func (o *Owner) AsyncStop() {
 	atomic.StoreInt32(&o.closed, 1)
@@ -262,9 +302,14 @@ func (o *Owner) clusterVersionConsistent(captures map[model.CaptureID]*model.Cap
 	return true
 }
 
-func (o *Owner) handleJobs() {
+func (o *Owner) handleJobs(captures map[model.CaptureID]*model.CaptureInfo) {
 	jobs := o.takeOwnerJobs()
 	for _, job := range jobs {
+		if job.tp == ownerJobTypeDrainCapture {
+			job.drainStatus, job.drainErr = o.drainCapture(job.drainCaptureID, captures)
+			close(job.done)
+			continue
+		}
 		changefeedID := job.changefeedID
 		cfReactor, exist := o.changefeeds[changefeedID]
 		if !exist {
@@ -285,6 +330,44 @@ func (o *Owner) handleJobs() {
 	}
 }
 
+// drainCapture marks the capture as draining for all changefeeds and collects the draining progress.
+func (o *Owner) drainCapture(captureID model.CaptureID, captures map[model.CaptureID]*model.CaptureInfo) (*model.DrainCaptureStatus, error) {
+	if _, exist := captures[captureID]; !exist {
+		return nil, cerror.ErrCaptureNotExist.GenWithStackByArgs(captureID)
+	}
+	hasTarget := false
+	for id := range captures {
+		if _, draining := o.drainingCaptures[id]; id != captureID && !draining {
+			hasTarget = true
+			break
+		}
+	}
+	if !hasTarget {
+		return nil, cerror.ErrDrainCaptureNoTarget.GenWithStackByArgs(captureID)
+	}
+	if _, exist := o.drainingCaptures[captureID]; !exist {
+		log.Info("start draining capture", zap.String("capture-id", captureID))
+		o.drainingCaptures[captureID] = struct{}{}
+	}
+	status := &model.DrainCaptureStatus{CaptureID: captureID}
+	for _, cfReactor := range o.changefeeds {
+		cfReactor.scheduler.DrainCapture(captureID)
+		status.TableCount += cfReactor.scheduler.DrainingTableCount(captureID)
+	}
+	status.IsDrained = status.TableCount == 0
+	return status, nil
+}
+
+// cleanUpDrainingCaptures forgets the draining captures which are already offline.
+func (o *Owner) cleanUpDrainingCaptures(captures map[model.CaptureID]*model.CaptureInfo) {
+	for captureID := range o.drainingCaptures {
+		if _, exist := captures[captureID]; !exist {
+			log.Info("drained capture is offline", zap.String("capture-id", captureID))
+			delete(o.drainingCaptures, captureID)
+		}
+	}
+}
+
 func (o *Owner) takeOwnerJobs() []*ownerJob {
 	o.ownerJobQueueMu.Lock()
 	defer o.ownerJobQueueMu.Unlock()
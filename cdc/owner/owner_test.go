@@ -254,3 +254,33 @@ func (s *ownerSuite) TestAdminJob(c *check.C) {
 	})
 	c.Assert(owner.takeOwnerJobs(), check.HasLen, 0)
 }
+
+func (s *ownerSuite) TestDrainCapture(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := cdcContext.NewBackendContext4Test(false)
+	owner, state, tester := createOwner4Test(ctx, c)
+	captureID := ctx.GlobalVars().CaptureInfo.ID
+
+	_, err := owner.drainCapture("not-exist-capture", state.Captures)
+	c.Assert(cerror.ErrCaptureNotExist.Equal(err), check.IsTrue)
+	// the only capture can not be drained
+	_, err = owner.drainCapture(captureID, state.Captures)
+	c.Assert(cerror.ErrDrainCaptureNoTarget.Equal(err), check.IsTrue)
+
+	tester.MustUpdate("/tidb/cdc/capture/6bbc01c8-0605-4f86-a0f9-b3119109b225",
+		[]byte(`{"id":"6bbc01c8-0605-4f86-a0f9-b3119109b225","address":"127.0.0.1:8300","version":"`+ctx.GlobalVars().CaptureInfo.Version+`"}`))
+	status, err := owner.drainCapture(captureID, state.Captures)
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.DeepEquals, &model.DrainCaptureStatus{CaptureID: captureID, IsDrained: true})
+	c.Assert(owner.drainingCaptures, check.HasKey, captureID)
+
+	// the draining capture is forgotten after it is offline
+	cdcKey := etcd.CDCKey{
+		Tp:        etcd.CDCKeyTypeCapture,
+		CaptureID: captureID,
+	}
+	tester.MustUpdate(cdcKey.String(), nil)
+	_, err = owner.Tick(ctx, state)
+	c.Assert(err, check.IsNil)
+	c.Assert(owner.drainingCaptures, check.HasLen, 0)
+}
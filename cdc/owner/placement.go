@@ -0,0 +1,165 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package owner
+
+import (
+	"math"
+	"sort"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	filter "github.com/pingcap/tidb-tools/pkg/table-filter"
+)
+
+type placementRule struct {
+	filter      filter.Filter
+	constraints []*config.LabelConstraint
+	spreadLabel string
+}
+
+// allows returns true if the tables matched by the rule can be replicated by the capture.
+func (r *placementRule) allows(capture *model.CaptureInfo) bool {
+	for _, constraint := range r.constraints {
+		if !constraint.Match(capture.Labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// placement decides which captures a table can be dispatched to,
+// according to the placement rules of the changefeed and the labels of captures.
+type placement struct {
+	// tableRules are matched in order, the first matched rule takes effect.
+	tableRules  []*placementRule
+	defaultRule *placementRule
+
+	getTableName func(tableID model.TableID) (model.TableName, bool)
+}
+
+// newPlacement creates a placement from the replica config,
+// nil is returned if there is no placement rule in the config.
+func newPlacement(cfg *config.ReplicaConfig, getTableName func(tableID model.TableID) (model.TableName, bool)) (*placement, error) {
+	if cfg.Placement == nil {
+		return nil, nil
+	}
+	if err := cfg.Placement.Validate(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	p := &placement{
+		defaultRule: &placementRule{
+			constraints: cfg.Placement.Constraints,
+			spreadLabel: cfg.Placement.SpreadLabel,
+		},
+		getTableName: getTableName,
+	}
+	for _, ruleCfg := range cfg.Placement.Rules {
+		f, err := filter.Parse(ruleCfg.Matcher)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrInvalidPlacementRule, err)
+		}
+		if !cfg.CaseSensitive {
+			f = filter.CaseInsensitive(f)
+		}
+		p.tableRules = append(p.tableRules, &placementRule{
+			filter:      f,
+			constraints: ruleCfg.Constraints,
+			spreadLabel: ruleCfg.SpreadLabel,
+		})
+	}
+	return p, nil
+}
+
+// ruleOf returns the placement rule which the table should follow.
+func (p *placement) ruleOf(tableID model.TableID) *placementRule {
+	if len(p.tableRules) == 0 || p.getTableName == nil {
+		return p.defaultRule
+	}
+	tableName, ok := p.getTableName(tableID)
+	if !ok {
+		return p.defaultRule
+	}
+	for _, rule := range p.tableRules {
+		if rule.filter.MatchTable(tableName.Schema, tableName.Table) {
+			return rule
+		}
+	}
+	return p.defaultRule
+}
+
+// allows returns true if the table can be replicated by the capture.
+func (p *placement) allows(tableID model.TableID, capture *model.CaptureInfo) bool {
+	if p == nil {
+		return true
+	}
+	return p.ruleOf(tableID).allows(capture)
+}
+
+// pickCapture chooses a capture for the table from the captures in workloads.
+// Only the captures allowed by the placement rule of the table are considered.
+// If the rule has a spread label, the label value with the minimum total workload is chosen first.
+// In the end, the capture with the minimum workload is chosen.
+// false is returned if no capture is allowed.
+func (p *placement) pickCapture(
+	tableID model.TableID,
+	workloads map[model.CaptureID]uint64,
+	captures map[model.CaptureID]*model.CaptureInfo,
+) (model.CaptureID, bool) {
+	if p == nil {
+		return minWorkloadCapture(workloads), true
+	}
+	rule := p.ruleOf(tableID)
+	candidates := make(map[model.CaptureID]uint64, len(workloads))
+	for captureID, workload := range workloads {
+		capture, exist := captures[captureID]
+		if !exist || !rule.allows(capture) {
+			continue
+		}
+		candidates[captureID] = workload
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+	if rule.spreadLabel == "" {
+		return minWorkloadCapture(candidates), true
+	}
+
+	// spread the tables across the values of the label
+	labelWorkloads := make(map[string]uint64)
+	for captureID, workload := range candidates {
+		labelWorkloads[captures[captureID].Labels[rule.spreadLabel]] += workload
+	}
+	labelValues := make([]string, 0, len(labelWorkloads))
+	for value := range labelWorkloads {
+		labelValues = append(labelValues, value)
+	}
+	// make the choice stable
+	sort.Strings(labelValues)
+	minLabelValue := ""
+	minLabelWorkload := uint64(math.MaxUint64)
+	for _, value := range labelValues {
+		if labelWorkloads[value] < minLabelWorkload {
+			minLabelValue = value
+			minLabelWorkload = labelWorkloads[value]
+		}
+	}
+	for captureID := range candidates {
+		if captures[captureID].Labels[rule.spreadLabel] != minLabelValue {
+			delete(candidates, captureID)
+		}
+	}
+	return minWorkloadCapture(candidates), true
+}
@@ -15,6 +15,7 @@ package owner
 
 import (
 	"math"
+	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
@@ -24,6 +25,11 @@ import (
 	"go.uber.org/zap"
 )
 
+// unplacedTableFallbackInterval is the interval after which a table is
+// dispatched to any capture if no capture satisfies its placement rule, so that
+// the changefeed doesn't stall forever.
+var unplacedTableFallbackInterval = 5 * time.Minute
+
 type schedulerJobType string
 
 const (
@@ -54,11 +60,28 @@ type scheduler struct {
 	moveTableJobQueue     []*moveTableJob
 	needRebalanceNextTick bool
 	lastTickCaptureCount  int
+
+	// drainingCaptures records the captures which are being drained,
+	// no table will be dispatched to a draining capture.
+	drainingCaptures map[model.CaptureID]struct{}
+	// drainedTables records the tables moved away from draining captures,
+	// a table is removed from this map once it is replicating on another capture.
+	drainedTables map[model.TableID]model.CaptureID
+
+	// placement decides which captures a table can be dispatched to,
+	// nil means a table can be dispatched to any capture.
+	placement *placement
+	// unplacedTables records since when no capture satisfies the placement
+	// rules of the tables waiting to be dispatched.
+	unplacedTables map[model.TableID]time.Time
 }
 
 func newScheduler() *scheduler {
 	return &scheduler{
 		moveTableTargets: make(map[model.TableID]model.CaptureID),
+		drainingCaptures: make(map[model.CaptureID]struct{}),
+		drainedTables:    make(map[model.TableID]model.CaptureID),
+		unplacedTables:   make(map[model.TableID]time.Time),
 	}
 }
 
@@ -71,6 +94,7 @@ func (s *scheduler) Tick(state *model.ChangefeedReactorState, currentTables []mo
 	s.captures = captures
 
 	s.cleanUpFinishedOperations()
+	s.cleanUpDrainingCaptures()
 	pendingJob, err := s.syncTablesWithCurrentTables()
 	if err != nil {
 		return false, errors.Trace(err)
@@ -85,6 +109,9 @@ func (s *scheduler) Tick(state *model.ChangefeedReactorState, currentTables []mo
 	// can the global resolved ts and checkpoint ts be updated
 	shouldUpdateState = len(pendingJob) == 0
 	shouldUpdateState = s.rebalance() && shouldUpdateState
+	if err := s.drainCaptures(); err != nil {
+		return false, errors.Trace(err)
+	}
 	shouldUpdateStateInMoveTable, err := s.handleMoveTableJob()
 	if err != nil {
 		return false, errors.Trace(err)
@@ -142,6 +169,120 @@ func (s *scheduler) Rebalance() {
 	s.needRebalanceNextTick = true
 }
 
+// DrainCapture marks the capture as draining.
+// All tables replicated by a draining capture will be moved to other captures by the move-table flow,
+// and no table will be dispatched to the draining capture any more.
+func (s *scheduler) DrainCapture(captureID model.CaptureID) {
+	s.drainingCaptures[captureID] = struct{}{}
+}
+
+// DrainingTableCount returns the number of tables which are still replicated by the specified capture,
+// plus the number of tables moved away from the capture but not yet replicating on another capture.
+func (s *scheduler) DrainingTableCount(captureID model.CaptureID) int {
+	count := 0
+	if s.state != nil {
+		if status, exist := s.state.TaskStatuses[captureID]; exist {
+			count += len(status.Tables)
+			for tableID, operation := range status.Operation {
+				if _, tracked := s.drainedTables[tableID]; operation.Delete && !tracked {
+					count++
+				}
+			}
+		}
+	}
+	for _, source := range s.drainedTables {
+		if source == captureID {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *scheduler) isDraining(captureID model.CaptureID) bool {
+	_, exist := s.drainingCaptures[captureID]
+	return exist
+}
+
+// cleanUpDrainingCaptures forgets the draining captures which are already offline.
+func (s *scheduler) cleanUpDrainingCaptures() {
+	for captureID := range s.drainingCaptures {
+		if _, exist := s.captures[captureID]; !exist {
+			delete(s.drainingCaptures, captureID)
+		}
+	}
+}
+
+// drainCaptures moves the tables replicated by the draining captures to the other captures,
+// and tracks the moved tables until they are replicating on their new captures.
+func (s *scheduler) drainCaptures() error {
+	if len(s.drainingCaptures) == 0 && len(s.drainedTables) == 0 {
+		return nil
+	}
+	table2CaptureIndex, err := s.table2CaptureIndex()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	currentTables := make(map[model.TableID]struct{}, len(s.currentTables))
+	for _, tableID := range s.currentTables {
+		currentTables[tableID] = struct{}{}
+	}
+	for tableID, source := range s.drainedTables {
+		if _, exist := currentTables[tableID]; !exist {
+			// the table is dropped, there is no need to wait for it
+			delete(s.drainedTables, tableID)
+			continue
+		}
+		captureID, exist := table2CaptureIndex[tableID]
+		if !exist || captureID == source {
+			continue
+		}
+		operation := s.state.TaskStatuses[captureID].Operation[tableID]
+		if operation == nil || operation.TableApplied() {
+			delete(s.drainedTables, tableID)
+		}
+	}
+
+	workloads := s.captureWorkloads()
+	for captureID := range s.drainingCaptures {
+		if _, exist := workloads[captureID]; exist {
+			// there is no other capture to move the tables to
+			return nil
+		}
+	}
+	for captureID := range s.drainingCaptures {
+		status, exist := s.state.TaskStatuses[captureID]
+		if !exist {
+			continue
+		}
+		for tableID := range status.Tables {
+			if status.Operation != nil && status.Operation[tableID] != nil {
+				// the table is being operated, drain it in the next tick
+				continue
+			}
+			if _, exist := s.moveTableTargets[tableID]; exist {
+				continue
+			}
+			target, ok := s.placement.pickCapture(tableID, workloads, s.captures)
+			if !ok {
+				log.Warn("Drain capture: no capture satisfies the placement rule of the table",
+					zap.Int64("table-id", tableID),
+					zap.String("source", captureID),
+					zap.String("changefeed-id", s.state.ID))
+				continue
+			}
+			workloads[target]++
+			s.drainedTables[tableID] = captureID
+			s.MoveTable(tableID, target)
+			log.Info("Drain capture: move table",
+				zap.Int64("table-id", tableID),
+				zap.String("source", captureID),
+				zap.String("target", target),
+				zap.String("changefeed-id", s.state.ID))
+		}
+	}
+	return nil
+}
+
 func (s *scheduler) table2CaptureIndex() (map[model.TableID]model.CaptureID, error) {
 	table2CaptureIndex := make(map[model.TableID]model.CaptureID)
 	for captureID, taskStatus := range s.state.TaskStatuses {
@@ -165,18 +306,7 @@ func (s *scheduler) table2CaptureIndex() (map[model.TableID]model.CaptureID, err
 // If the TargetCapture of a job is not set, it chooses a capture with the minimum workload(minimum number of tables)
 // and sets the TargetCapture to the capture.
 func (s *scheduler) dispatchToTargetCaptures(pendingJobs []*schedulerJob) {
-	workloads := make(map[model.CaptureID]uint64)
-
-	for captureID := range s.captures {
-		workloads[captureID] = 0
-		taskWorkload := s.state.Workloads[captureID]
-		if taskWorkload == nil {
-			continue
-		}
-		for _, workload := range taskWorkload {
-			workloads[captureID] += workload.Workload
-		}
-	}
+	workloads := s.captureWorkloads()
 
 	for _, pendingJob := range pendingJobs {
 		if pendingJob.TargetCapture == "" {
@@ -184,8 +314,22 @@ func (s *scheduler) dispatchToTargetCaptures(pendingJobs []*schedulerJob) {
 			if !exist {
 				continue
 			}
-			pendingJob.TargetCapture = target
 			delete(s.moveTableTargets, pendingJob.TableID)
+			if s.isDraining(target) {
+				// the target capture is being drained, choose another capture for the table
+				continue
+			}
+			if capture, exist := s.captures[target]; exist && !s.placement.allows(pendingJob.TableID, capture) {
+				log.Warn("the target capture does not satisfy the placement rule of the table, choose another capture",
+					zap.Int64("table-id", pendingJob.TableID),
+					zap.String("target", target),
+					zap.String("changefeed-id", s.state.ID))
+				continue
+			}
+			pendingJob.TargetCapture = target
+			continue
+		}
+		if _, exist := workloads[pendingJob.TargetCapture]; !exist {
 			continue
 		}
 		switch pendingJob.Tp {
@@ -199,30 +343,84 @@ func (s *scheduler) dispatchToTargetCaptures(pendingJobs []*schedulerJob) {
 		}
 	}
 
-	getMinWorkloadCapture := func() model.CaptureID {
-		minCapture := ""
-		minWorkLoad := uint64(math.MaxUint64)
-		for captureID, workload := range workloads {
-			if workload < minWorkLoad {
-				minCapture = captureID
-				minWorkLoad = workload
+	unplacedTables := make(map[model.TableID]time.Time)
+	for _, pendingJob := range pendingJobs {
+		if pendingJob.TargetCapture != "" || len(workloads) == 0 {
+			continue
+		}
+		target, ok := s.placement.pickCapture(pendingJob.TableID, workloads, s.captures)
+		if !ok {
+			since, exist := s.unplacedTables[pendingJob.TableID]
+			if !exist {
+				since = time.Now()
+				log.Warn("no capture satisfies the placement rule of the table, "+
+					"the table will be dispatched to any capture if it lasts",
+					zap.Int64("table-id", pendingJob.TableID),
+					zap.String("changefeed-id", s.state.ID),
+					zap.Duration("fallback-interval", unplacedTableFallbackInterval))
+			}
+			if time.Since(since) < unplacedTableFallbackInterval {
+				// the job is left without a target capture, and it will be generated again in the next tick
+				unplacedTables[pendingJob.TableID] = since
+				continue
 			}
+			target = minWorkloadCapture(workloads)
+			log.Warn("no capture satisfies the placement rule of the table for a long time, dispatch it to any capture",
+				zap.Int64("table-id", pendingJob.TableID),
+				zap.String("changefeed-id", s.state.ID),
+				zap.String("target", target))
 		}
+		pendingJob.TargetCapture = target
+		workloads[target] += 1
+	}
+	s.unplacedTables = unplacedTables
+}
+
+// captureWorkloads returns the workloads of the captures which tables can be dispatched to.
+// The draining captures are excluded, unless all the captures are being drained.
+func (s *scheduler) captureWorkloads() map[model.CaptureID]uint64 {
+	workloads := make(map[model.CaptureID]uint64)
 
-		if minCapture == "" {
-			log.Panic("Unreachable, no capture is found")
+	for captureID := range s.captures {
+		if s.isDraining(captureID) {
+			continue
+		}
+		workloads[captureID] = 0
+		taskWorkload := s.state.Workloads[captureID]
+		if taskWorkload == nil {
+			continue
+		}
+		for _, workload := range taskWorkload {
+			workloads[captureID] += workload.Workload
+		}
+	}
+	if len(workloads) == 0 && len(s.drainingCaptures) != 0 {
+		// all the captures are being drained, the tables have nowhere else to go
+		for captureID := range s.captures {
+			workloads[captureID] = 0
+			for _, workload := range s.state.Workloads[captureID] {
+				workloads[captureID] += workload.Workload
+			}
 		}
-		return minCapture
 	}
+	return workloads
+}
 
-	for _, pendingJob := range pendingJobs {
-		if pendingJob.TargetCapture != "" {
-			continue
+// minWorkloadCapture returns the capture with the minimum workload.
+func minWorkloadCapture(workloads map[model.CaptureID]uint64) model.CaptureID {
+	minCapture := ""
+	minWorkLoad := uint64(math.MaxUint64)
+	for captureID, workload := range workloads {
+		if workload < minWorkLoad {
+			minCapture = captureID
+			minWorkLoad = workload
 		}
-		minCapture := getMinWorkloadCapture()
-		pendingJob.TargetCapture = minCapture
-		workloads[minCapture] += 1
 	}
+
+	if minCapture == "" {
+		log.Panic("Unreachable, no capture is found")
+	}
+	return minCapture
 }
 
 // syncTablesWithCurrentTables iterates all current tables to check whether it should be listened or not.
@@ -267,6 +465,10 @@ func (s *scheduler) syncTablesWithCurrentTables() ([]*schedulerJob, error) {
 func (s *scheduler) handleJobs(jobs []*schedulerJob) {
 	for _, job := range jobs {
 		job := job
+		if job.TargetCapture == "" {
+			// no capture can replicate the table for now
+			continue
+		}
 		s.state.PatchTaskStatus(job.TargetCapture, func(status *model.TaskStatus) (*model.TaskStatus, bool, error) {
 			switch job.Tp {
 			case schedulerJobTypeAddTable:
@@ -340,7 +542,10 @@ func (s *scheduler) shouldRebalance() bool {
 // the removed table will be dispatched again by syncTablesWithCurrentTables function
 func (s *scheduler) rebalanceByTableNum() (shouldUpdateState bool) {
 	totalTableNum := len(s.currentTables)
-	captureNum := len(s.captures)
+	captureNum := len(s.captures) - len(s.drainingCaptures)
+	if captureNum <= 0 {
+		captureNum = len(s.captures)
+	}
 	upperLimitPerCapture := int(math.Ceil(float64(totalTableNum) / float64(captureNum)))
 	shouldUpdateState = true
 
@@ -350,7 +555,17 @@ func (s *scheduler) rebalanceByTableNum() (shouldUpdateState bool) {
 		zap.Int("capture-num", captureNum),
 		zap.Int("target-limit", upperLimitPerCapture))
 
+	tableNums := make(map[model.CaptureID]int, len(s.captures))
+	for captureID := range s.captures {
+		if status, exist := s.state.TaskStatuses[captureID]; exist {
+			tableNums[captureID] = len(status.Tables)
+		}
+	}
 	for captureID, taskStatus := range s.state.TaskStatuses {
+		if s.isDraining(captureID) {
+			// the tables of a draining capture are moved by drainCaptures
+			continue
+		}
 		tableNum2Remove := len(taskStatus.Tables) - upperLimitPerCapture
 		if tableNum2Remove <= 0 {
 			continue
@@ -363,6 +578,15 @@ func (s *scheduler) rebalanceByTableNum() (shouldUpdateState bool) {
 			if tableNum2Remove <= 0 {
 				break
 			}
+			if s.placement != nil {
+				// only remove the table if another capture satisfying its placement rule
+				// has room for it, otherwise the table would be dispatched back
+				target, ok := s.rebalanceTarget(tableID, captureID, tableNums, upperLimitPerCapture)
+				if !ok {
+					continue
+				}
+				tableNums[target]++
+			}
 			shouldUpdateState = false
 			s.state.PatchTaskStatus(captureID, func(status *model.TaskStatus) (*model.TaskStatus, bool, error) {
 				if status == nil {
@@ -385,3 +609,23 @@ func (s *scheduler) rebalanceByTableNum() (shouldUpdateState bool) {
 	}
 	return
 }
+
+// rebalanceTarget returns a capture which satisfies the placement rule of the table,
+// and replicates less tables than upperLimitPerCapture.
+func (s *scheduler) rebalanceTarget(
+	tableID model.TableID, source model.CaptureID,
+	tableNums map[model.CaptureID]int, upperLimitPerCapture int,
+) (model.CaptureID, bool) {
+	for captureID, capture := range s.captures {
+		if captureID == source || s.isDraining(captureID) {
+			continue
+		}
+		if tableNums[captureID] >= upperLimitPerCapture {
+			continue
+		}
+		if s.placement.allows(tableID, capture) {
+			return captureID, true
+		}
+	}
+	return "", false
+}
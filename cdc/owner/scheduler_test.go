@@ -16,9 +16,11 @@ package owner
 import (
 	"fmt"
 	"math/rand"
+	"time"
 
 	"github.com/pingcap/check"
 	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/orchestrator"
 	"github.com/pingcap/ticdc/pkg/util/testleak"
 )
@@ -338,3 +340,199 @@ func (s *schedulerSuite) TestScheduleRebalance(c *check.C) {
 	}
 	c.Assert(tableIDs, check.DeepEquals, map[model.TableID]struct{}{1: {}, 2: {}, 3: {}, 4: {}, 5: {}, 6: {}})
 }
+
+func (s *schedulerSuite) TestScheduleDrainCapture(c *check.C) {
+	defer testleak.AfterTest(c)()
+	s.reset(c)
+	captureID1 := "test-capture-1"
+	captureID2 := "test-capture-2"
+	s.addCapture(captureID1)
+	s.addCapture(captureID2)
+
+	s.state.PatchTaskStatus(captureID1, func(status *model.TaskStatus) (*model.TaskStatus, bool, error) {
+		status.Tables = make(map[model.TableID]*model.TableReplicaInfo)
+		status.Tables[1] = &model.TableReplicaInfo{StartTs: 0}
+		status.Tables[2] = &model.TableReplicaInfo{StartTs: 0}
+		return status, true, nil
+	})
+	s.state.PatchTaskStatus(captureID2, func(status *model.TaskStatus) (*model.TaskStatus, bool, error) {
+		status.Tables = make(map[model.TableID]*model.TableReplicaInfo)
+		status.Tables[3] = &model.TableReplicaInfo{StartTs: 0}
+		return status, true, nil
+	})
+	s.tester.MustApplyPatches()
+
+	s.scheduler.DrainCapture(captureID1)
+	c.Assert(s.scheduler.DrainingTableCount(captureID1), check.Equals, 0)
+
+	// the tables in capture 1 are removed by the move-table flow
+	shouldUpdateState, err := s.scheduler.Tick(s.state, []model.TableID{1, 2, 3}, s.captures)
+	c.Assert(err, check.IsNil)
+	c.Assert(shouldUpdateState, check.IsFalse)
+	s.tester.MustApplyPatches()
+	c.Assert(s.state.TaskStatuses[captureID1].Tables, check.DeepEquals, map[model.TableID]*model.TableReplicaInfo{})
+	c.Assert(s.state.TaskStatuses[captureID1].Operation, check.DeepEquals, map[model.TableID]*model.TableOperation{
+		1: {Delete: true, BoundaryTs: 0, Status: model.OperDispatched},
+		2: {Delete: true, BoundaryTs: 0, Status: model.OperDispatched},
+	})
+	c.Assert(s.scheduler.DrainingTableCount(captureID1), check.Equals, 2)
+
+	s.finishTableOperation(captureID1, 1, 2)
+
+	// clean up the finished operations
+	shouldUpdateState, err = s.scheduler.Tick(s.state, []model.TableID{1, 2, 3}, s.captures)
+	c.Assert(err, check.IsNil)
+	c.Assert(shouldUpdateState, check.IsTrue)
+	s.tester.MustApplyPatches()
+	c.Assert(s.state.TaskStatuses[captureID1].Operation, check.DeepEquals, map[model.TableID]*model.TableOperation{})
+	c.Assert(s.scheduler.DrainingTableCount(captureID1), check.Equals, 2)
+
+	// the removed tables and a new table are all dispatched to capture 2
+	shouldUpdateState, err = s.scheduler.Tick(s.state, []model.TableID{1, 2, 3, 4}, s.captures)
+	c.Assert(err, check.IsNil)
+	c.Assert(shouldUpdateState, check.IsFalse)
+	s.tester.MustApplyPatches()
+	c.Assert(s.state.TaskStatuses[captureID1].Tables, check.DeepEquals, map[model.TableID]*model.TableReplicaInfo{})
+	c.Assert(s.state.TaskStatuses[captureID2].Tables, check.DeepEquals, map[model.TableID]*model.TableReplicaInfo{
+		1: {StartTs: 0}, 2: {StartTs: 0}, 3: {StartTs: 0}, 4: {StartTs: 0},
+	})
+	c.Assert(s.scheduler.DrainingTableCount(captureID1), check.Equals, 2)
+
+	s.finishTableOperation(captureID2, 1, 2, 4)
+
+	shouldUpdateState, err = s.scheduler.Tick(s.state, []model.TableID{1, 2, 3, 4}, s.captures)
+	c.Assert(err, check.IsNil)
+	c.Assert(shouldUpdateState, check.IsTrue)
+	s.tester.MustApplyPatches()
+	c.Assert(s.scheduler.DrainingTableCount(captureID1), check.Equals, 0)
+
+	// the draining capture is forgotten after it is offline
+	delete(s.captures, captureID1)
+	_, err = s.scheduler.Tick(s.state, []model.TableID{1, 2, 3, 4}, s.captures)
+	c.Assert(err, check.IsNil)
+	c.Assert(s.scheduler.drainingCaptures, check.HasLen, 0)
+}
+
+func (s *schedulerSuite) TestSchedulePlacement(c *check.C) {
+	defer testleak.AfterTest(c)()
+	s.reset(c)
+	captureID1 := "test-capture-1"
+	captureID2 := "test-capture-2"
+	captureID3 := "test-capture-3"
+	s.addCapture(captureID1)
+	s.addCapture(captureID2)
+	s.addCapture(captureID3)
+	s.captures[captureID1].Labels = map[string]string{"zone": "a"}
+	s.captures[captureID2].Labels = map[string]string{"zone": "b"}
+	s.captures[captureID3].Labels = map[string]string{"zone": "b"}
+
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.Placement = &config.PlacementConfig{
+		Constraints: []*config.LabelConstraint{{Key: "zone", Op: config.LabelConstraintOpIn, Values: []string{"b"}}},
+		Rules: []*config.PlacementRule{
+			{
+				Matcher:     []string{"test.t1"},
+				Constraints: []*config.LabelConstraint{{Key: "zone", Op: config.LabelConstraintOpIn, Values: []string{"a"}}},
+			},
+			{
+				Matcher:     []string{"test.t6"},
+				Constraints: []*config.LabelConstraint{{Key: "zone", Op: config.LabelConstraintOpIn, Values: []string{"c"}}},
+			},
+		},
+	}
+	tableNames := map[model.TableID]model.TableName{}
+	for tableID := model.TableID(1); tableID <= 6; tableID++ {
+		tableNames[tableID] = model.TableName{Schema: "test", Table: fmt.Sprintf("t%d", tableID)}
+	}
+	var err error
+	s.scheduler.placement, err = newPlacement(cfg, func(tableID model.TableID) (model.TableName, bool) {
+		name, ok := tableNames[tableID]
+		return name, ok
+	})
+	c.Assert(err, check.IsNil)
+
+	// table 1 is dispatched to zone a, and the other tables are dispatched to zone b
+	shouldUpdateState, err := s.scheduler.Tick(s.state, []model.TableID{1, 2, 3, 4, 5}, s.captures)
+	c.Assert(err, check.IsNil)
+	c.Assert(shouldUpdateState, check.IsFalse)
+	s.tester.MustApplyPatches()
+	c.Assert(s.state.TaskStatuses[captureID1].Tables, check.DeepEquals, map[model.TableID]*model.TableReplicaInfo{
+		1: {StartTs: 0},
+	})
+	c.Assert(s.state.TaskStatuses[captureID2].Tables, check.HasLen, 2)
+	c.Assert(s.state.TaskStatuses[captureID3].Tables, check.HasLen, 2)
+	s.finishTableOperation(captureID1, 1)
+	for _, captureID := range []model.CaptureID{captureID2, captureID3} {
+		for tableID := range s.state.TaskStatuses[captureID].Tables {
+			s.finishTableOperation(captureID, tableID)
+		}
+	}
+
+	// the tables are not moved to the captures violating their placement rules
+	s.scheduler.MoveTable(2, captureID1)
+	s.scheduler.Rebalance()
+	_, err = s.scheduler.Tick(s.state, []model.TableID{1, 2, 3, 4, 5}, s.captures)
+	c.Assert(err, check.IsNil)
+	s.tester.MustApplyPatches()
+	_, err = s.scheduler.Tick(s.state, []model.TableID{1, 2, 3, 4, 5}, s.captures)
+	c.Assert(err, check.IsNil)
+	s.tester.MustApplyPatches()
+	c.Assert(s.state.TaskStatuses[captureID1].Tables, check.DeepEquals, map[model.TableID]*model.TableReplicaInfo{
+		1: {StartTs: 0},
+	})
+
+	// no capture satisfies the placement rule of table 6, so the state can not be updated
+	shouldUpdateState, err = s.scheduler.Tick(s.state, []model.TableID{1, 2, 3, 4, 5, 6}, s.captures)
+	c.Assert(err, check.IsNil)
+	c.Assert(shouldUpdateState, check.IsFalse)
+	s.tester.MustApplyPatches()
+	for _, status := range s.state.TaskStatuses {
+		c.Assert(status.Tables, check.Not(check.HasKey), model.TableID(6))
+	}
+	c.Assert(s.scheduler.unplacedTables, check.HasKey, model.TableID(6))
+
+	// table 6 is dispatched to any capture after the fallback interval
+	defer func(interval time.Duration) {
+		unplacedTableFallbackInterval = interval
+	}(unplacedTableFallbackInterval)
+	unplacedTableFallbackInterval = 0
+	_, err = s.scheduler.Tick(s.state, []model.TableID{1, 2, 3, 4, 5, 6}, s.captures)
+	c.Assert(err, check.IsNil)
+	s.tester.MustApplyPatches()
+	dispatched := false
+	for _, status := range s.state.TaskStatuses {
+		if _, exist := status.Tables[6]; exist {
+			dispatched = true
+		}
+	}
+	c.Assert(dispatched, check.IsTrue)
+	c.Assert(s.scheduler.unplacedTables, check.HasLen, 0)
+}
+
+func (s *schedulerSuite) TestSchedulePlacementSpread(c *check.C) {
+	defer testleak.AfterTest(c)()
+	s.reset(c)
+	captureID1 := "test-capture-1"
+	captureID2 := "test-capture-2"
+	captureID3 := "test-capture-3"
+	s.addCapture(captureID1)
+	s.addCapture(captureID2)
+	s.addCapture(captureID3)
+	s.captures[captureID1].Labels = map[string]string{"zone": "a"}
+	s.captures[captureID2].Labels = map[string]string{"zone": "b"}
+	s.captures[captureID3].Labels = map[string]string{"zone": "b"}
+
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.Placement = &config.PlacementConfig{SpreadLabel: "zone"}
+	var err error
+	s.scheduler.placement, err = newPlacement(cfg, nil)
+	c.Assert(err, check.IsNil)
+
+	// the tables are spread evenly across the zones
+	_, err = s.scheduler.Tick(s.state, []model.TableID{1, 2, 3, 4}, s.captures)
+	c.Assert(err, check.IsNil)
+	s.tester.MustApplyPatches()
+	c.Assert(s.state.TaskStatuses[captureID1].Tables, check.HasLen, 2)
+	c.Assert(s.state.TaskStatuses[captureID2].Tables, check.HasLen, 1)
+	c.Assert(s.state.TaskStatuses[captureID3].Tables, check.HasLen, 1)
+}
@@ -38,15 +38,13 @@ type schemaWrap4Owner struct {
 }
 
 func newSchemaWrap4Owner(kvStorage tidbkv.Storage, startTs model.Ts, config *config.ReplicaConfig) (*schemaWrap4Owner, error) {
-	var meta *timeta.Meta
-	if kvStorage != nil {
-		var err error
-		meta, err = kv.GetSnapshotMeta(kvStorage, startTs)
-		if err != nil {
-			return nil, errors.Trace(err)
+	schemaSnap, err := entry.LoadSchemaSnapshot(startTs, config.ForceReplicate, func() (*timeta.Meta, error) {
+		// kvStorage is nil only in unit tests
+		if kvStorage == nil {
+			return nil, nil
 		}
-	}
-	schemaSnap, err := entry.NewSingleSchemaSnapshotFromMeta(meta, startTs, config.ForceReplicate)
+		return kv.GetSnapshotMeta(kvStorage, startTs)
+	})
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -85,6 +83,11 @@ func (s *schemaWrap4Owner) AllPhysicalTables() []model.TableID {
 	return s.allPhysicalTablesCache
 }
 
+// TableName returns the name of the table or the partition table.
+func (s *schemaWrap4Owner) TableName(tableID model.TableID) (model.TableName, bool) {
+	return s.schemaSnapshot.GetTableNameByID(tableID)
+}
+
 func (s *schemaWrap4Owner) HandleDDL(job *timodel.Job) error {
 	if job.BinlogInfo.FinishedTS <= s.ddlHandledTs {
 		return nil
@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -26,6 +27,7 @@ import (
 	tablepipeline "github.com/pingcap/ticdc/cdc/processor/pipeline"
 	cdcContext "github.com/pingcap/ticdc/pkg/context"
 	cerrors "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/orchestrator"
 	"go.uber.org/zap"
 )
@@ -36,6 +38,7 @@ const (
 	commandTpUnknow commandTp = iota //nolint:varcheck,deadcode
 	commandTpClose
 	commandTpWriteDebugInfo
+	commandTpQueryTables
 )
 
 type command struct {
@@ -84,7 +87,8 @@ func (m *Manager) Tick(stdCtx context.Context, state orchestrator.ReactorState)
 	}
 	captureID := ctx.GlobalVars().CaptureInfo.ID
 	var inactiveChangefeedCount int
-	for changefeedID, changefeedState := range globalState.Changefeeds {
+	for _, changefeedID := range changefeedIDsByPriority(globalState.Changefeeds) {
+		changefeedState := globalState.Changefeeds[changefeedID]
 		if !changefeedState.Active(captureID) {
 			inactiveChangefeedCount++
 			m.closeProcessor(changefeedID)
@@ -127,6 +131,31 @@ func (m *Manager) Tick(stdCtx context.Context, state orchestrator.ReactorState)
 	return state, nil
 }
 
+// changefeedIDsByPriority returns the IDs of the changefeeds, ordered by the
+// priority of changefeeds from high to low, so that processors of high priority
+// changefeeds are ticked first and acquire the shared resources first.
+func changefeedIDsByPriority(changefeeds map[model.ChangeFeedID]*model.ChangefeedReactorState) []model.ChangeFeedID {
+	ids := make([]model.ChangeFeedID, 0, len(changefeeds))
+	for changefeedID := range changefeeds {
+		ids = append(ids, changefeedID)
+	}
+	priority := func(changefeedID model.ChangeFeedID) int {
+		info := changefeeds[changefeedID].Info
+		if info == nil {
+			return model.PriorityValue(model.PriorityNormal)
+		}
+		return model.PriorityValue(info.GetPriority())
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		pi, pj := priority(ids[i]), priority(ids[j])
+		if pi != pj {
+			return pi > pj
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
 func (m *Manager) closeProcessor(changefeedID model.ChangeFeedID) {
 	if processor, exist := m.processors[changefeedID]; exist {
 		err := processor.Close()
@@ -134,6 +163,7 @@ func (m *Manager) closeProcessor(changefeedID model.ChangeFeedID) {
 			log.Warn("failed to close processor", zap.Error(err))
 		}
 		delete(m.processors, changefeedID)
+		logutil.RemoveChangefeed(changefeedID)
 	}
 }
 
@@ -154,6 +184,25 @@ func (m *Manager) WriteDebugInfo(w io.Writer) {
 	}
 }
 
+// tablesQuery is the payload of commandTpQueryTables
+type tablesQuery struct {
+	changefeedID model.ChangeFeedID
+	tables       []*model.TableReplicationStatus
+}
+
+// QueryTables returns the replication status of the tables of the changefeed
+// replicated by this capture
+func (m *Manager) QueryTables(ctx context.Context, changefeedID model.ChangeFeedID) ([]*model.TableReplicationStatus, error) {
+	query := &tablesQuery{changefeedID: changefeedID}
+	done := m.sendCommand(commandTpQueryTables, query)
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, errors.Trace(ctx.Err())
+	}
+	return query.tables, nil
+}
+
 func (m *Manager) sendCommand(tp commandTp, payload interface{}) chan struct{} {
 	timeout := time.Second * 3
 	cmd := &command{tp: tp, payload: payload, done: make(chan struct{})}
@@ -183,6 +232,11 @@ func (m *Manager) handleCommand() error {
 	case commandTpWriteDebugInfo:
 		w := cmd.payload.(io.Writer)
 		m.writeDebugInfo(w)
+	case commandTpQueryTables:
+		query := cmd.payload.(*tablesQuery)
+		if processor, exist := m.processors[query.changefeedID]; exist {
+			query.tables = processor.tableReplicationStatus()
+		}
 	default:
 		log.Warn("Unknown command in processor manager", zap.Any("command", cmd))
 	}
@@ -167,6 +167,72 @@ func (s *managerSuite) TestDebugInfo(c *check.C) {
 	<-done
 }
 
+func (s *managerSuite) TestQueryTables(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := cdcContext.NewBackendContext4Test(false)
+	s.resetSuit(ctx, c)
+	var err error
+
+	s.state.Changefeeds["test-changefeed"] = model.NewChangefeedReactorState("test-changefeed")
+	s.state.Changefeeds["test-changefeed"].PatchInfo(func(info *model.ChangeFeedInfo) (*model.ChangeFeedInfo, bool, error) {
+		return &model.ChangeFeedInfo{
+			SinkURI:    "blackhole://",
+			CreateTime: time.Now(),
+			StartTs:    0,
+			TargetTs:   math.MaxUint64,
+			Config:     config.GetDefaultReplicaConfig(),
+		}, true, nil
+	})
+	s.state.Changefeeds["test-changefeed"].PatchStatus(func(status *model.ChangeFeedStatus) (*model.ChangeFeedStatus, bool, error) {
+		return &model.ChangeFeedStatus{}, true, nil
+	})
+	s.state.Changefeeds["test-changefeed"].PatchTaskStatus(ctx.GlobalVars().CaptureInfo.ID, func(status *model.TaskStatus) (*model.TaskStatus, bool, error) {
+		return &model.TaskStatus{
+			Tables: map[int64]*model.TableReplicaInfo{1: {StartTs: 10}},
+		}, true, nil
+	})
+	s.tester.MustApplyPatches()
+	_, err = s.manager.Tick(ctx, s.state)
+	c.Assert(err, check.IsNil)
+	s.tester.MustApplyPatches()
+	c.Assert(s.manager.processors, check.HasLen, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			_, err = s.manager.Tick(ctx, s.state)
+			if err != nil {
+				c.Assert(cerrors.ErrReactorFinished.Equal(errors.Cause(err)), check.IsTrue)
+				return
+			}
+			c.Assert(err, check.IsNil)
+			s.tester.MustApplyPatches()
+		}
+	}()
+
+	var tables []*model.TableReplicationStatus
+	for i := 0; i < 100; i++ {
+		tables, err = s.manager.QueryTables(ctx, "test-changefeed")
+		c.Assert(err, check.IsNil)
+		if len(tables) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(tables, check.HasLen, 1)
+	c.Assert(tables[0].TableID, check.Equals, int64(1))
+	c.Assert(tables[0].TableName, check.Equals, "`test`.`table1`")
+	c.Assert(tables[0].CaptureID, check.Equals, ctx.GlobalVars().CaptureInfo.ID)
+	c.Assert(tables[0].Status, check.Equals, "Running")
+
+	// an unknown changefeed has no tables
+	tables, err = s.manager.QueryTables(ctx, "unknown-changefeed")
+	c.Assert(err, check.IsNil)
+	c.Assert(tables, check.HasLen, 0)
+	s.manager.AsyncClose()
+	<-done
+}
+
 func (s *managerSuite) TestClose(c *check.C) {
 	defer testleak.AfterTest(c)()
 	ctx := cdcContext.NewBackendContext4Test(false)
@@ -208,3 +274,17 @@ func (s *managerSuite) TestClose(c *check.C) {
 	s.tester.MustApplyPatches()
 	c.Assert(s.manager.processors, check.HasLen, 0)
 }
+
+func (s *managerSuite) TestChangefeedIDsByPriority(c *check.C) {
+	defer testleak.AfterTest(c)()
+	changefeeds := map[model.ChangeFeedID]*model.ChangefeedReactorState{
+		"cf-low":     {Info: &model.ChangeFeedInfo{Priority: model.PriorityLow}},
+		"cf-default": {Info: &model.ChangeFeedInfo{}},
+		"cf-high":    {Info: &model.ChangeFeedInfo{Priority: model.PriorityHigh}},
+		"cf-normal":  {Info: &model.ChangeFeedInfo{Priority: model.PriorityNormal}},
+		"cf-no-info": {},
+	}
+	c.Assert(changefeedIDsByPriority(changefeeds), check.DeepEquals, []model.ChangeFeedID{
+		"cf-high", "cf-default", "cf-no-info", "cf-normal", "cf-low",
+	})
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/cdc/puller"
+	"github.com/pingcap/ticdc/cdc/puller/sorter"
 	cdcContext "github.com/pingcap/ticdc/pkg/context"
 	"github.com/pingcap/ticdc/pkg/pipeline"
 	"github.com/pingcap/ticdc/pkg/regionspan"
@@ -32,16 +33,28 @@ type pullerNode struct {
 
 	tableID     model.TableID
 	replicaInfo *model.TableReplicaInfo
-	cancel      context.CancelFunc
-	wg          errgroup.Group
+	// startTs is the ts from which the table is pulled from TiKV, it can be
+	// greater than the start ts of the table if the sorter has persisted data.
+	startTs         model.Ts
+	requestOldValue bool
+	// diskQuotaLimiter pauses the puller when the sorter is running out of
+	// disk space, it is shared with the sorter node.
+	diskQuotaLimiter *sorter.DiskQuotaLimiter
+	cancel           context.CancelFunc
+	wg               errgroup.Group
 }
 
 func newPullerNode(
-	tableID model.TableID, replicaInfo *model.TableReplicaInfo, tableName string) pipeline.Node {
+	tableID model.TableID, replicaInfo *model.TableReplicaInfo, tableName string, startTs model.Ts, requestOldValue bool,
+	diskQuotaLimiter *sorter.DiskQuotaLimiter,
+) pipeline.Node {
 	return &pullerNode{
-		tableID:     tableID,
-		replicaInfo: replicaInfo,
-		tableName:   tableName,
+		tableID:          tableID,
+		replicaInfo:      replicaInfo,
+		tableName:        tableName,
+		startTs:          startTs,
+		requestOldValue:  requestOldValue,
+		diskQuotaLimiter: diskQuotaLimiter,
 	}
 }
 
@@ -63,10 +76,8 @@ func (n *pullerNode) Init(ctx pipeline.NodeContext) error {
 	ctxC = util.PutTableInfoInCtx(ctxC, n.tableID, n.tableName)
 	ctxC = util.PutCaptureAddrInCtx(ctxC, ctx.GlobalVars().CaptureInfo.AdvertiseAddr)
 	ctxC = util.PutChangefeedIDInCtx(ctxC, ctx.ChangefeedVars().ID)
-	// NOTICE: always pull the old value internally
-	// See also: TODO(hi-rustin): add issue link here.
 	plr := puller.NewPuller(ctxC, ctx.GlobalVars().PDClient, ctx.GlobalVars().GrpcPool, ctx.GlobalVars().KVStorage,
-		n.replicaInfo.StartTs, n.tableSpan(ctx), true)
+		n.startTs, n.tableSpan(ctx), n.requestOldValue)
 	n.wg.Go(func() error {
 		ctx.Throw(errors.Trace(plr.Run(ctxC)))
 		return nil
@@ -80,11 +91,20 @@ func (n *pullerNode) Init(ctx pipeline.NodeContext) error {
 				if rawKV == nil {
 					continue
 				}
-				if rawKV.OpType == model.OpTypeResolved {
-					metricTableResolvedTsGauge.Set(float64(oracle.ExtractPhysical(rawKV.CRTs)))
-				}
 				pEvent := model.NewPolymorphicEvent(rawKV)
 				ctx.SendToNextNode(pipeline.PolymorphicEventMessage(pEvent))
+				if rawKV.OpType != model.OpTypeResolved {
+					continue
+				}
+				metricTableResolvedTsGauge.Set(float64(oracle.ExtractPhysical(rawKV.CRTs)))
+				// Pause pulling from TiKV when the sorter is running out of disk
+				// space. It is only paused after a resolved event is sent, so that
+				// the sorter can flush the data below the resolved ts.
+				if n.diskQuotaLimiter != nil {
+					if err := n.diskQuotaLimiter.Wait(ctxC, rawKV.CRTs); err != nil {
+						return nil
+					}
+				}
 			}
 		}
 	})
@@ -14,6 +14,7 @@
 package pipeline
 
 import (
+	"context"
 	"sync/atomic"
 	"time"
 
@@ -24,11 +25,15 @@ import (
 	"github.com/pingcap/ticdc/cdc/sink"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/pipeline"
+	"github.com/pingcap/ticdc/pkg/tracing"
 	"go.uber.org/zap"
 )
 
 const (
 	defaultSyncResolvedBatch = 64
+	// maxTracedEvents is the max number of the sampled events waiting to be
+	// flushed, the flush spans of the events exceeding it are not recorded.
+	maxTracedEvents = 1024
 )
 
 // TableStatus is status of the table pipeline
@@ -76,9 +81,16 @@ type sinkNode struct {
 	rowBuffer   []*model.RowChangedEvent
 
 	flowController tableFlowController
+	enableOldValue bool
+
+	// tracedEvents are the keys of the sampled events emitted to the sink, the
+	// flush spans of them are recorded once they are flushed.
+	tracedEvents []tracing.EventKey
 }
 
-func newSinkNode(sink sink.Sink, startTs model.Ts, targetTs model.Ts, flowController tableFlowController) *sinkNode {
+func newSinkNode(
+	sink sink.Sink, startTs model.Ts, targetTs model.Ts, flowController tableFlowController, enableOldValue bool,
+) *sinkNode {
 	return &sinkNode{
 		sink:         sink,
 		status:       TableStatusInitializing,
@@ -88,6 +100,7 @@ func newSinkNode(sink sink.Sink, startTs model.Ts, targetTs model.Ts, flowContro
 		barrierTs:    startTs,
 
 		flowController: flowController,
+		enableOldValue: enableOldValue,
 	}
 }
 
@@ -133,6 +146,7 @@ func (n *sinkNode) flushSink(ctx pipeline.NodeContext, resolvedTs model.Ts) (err
 	if resolvedTs <= n.checkpointTs {
 		return nil
 	}
+	flushStart := time.Now()
 	if err := n.emitRow2Sink(ctx); err != nil {
 		return errors.Trace(err)
 	}
@@ -144,6 +158,7 @@ func (n *sinkNode) flushSink(ctx pipeline.NodeContext, resolvedTs model.Ts) (err
 		return nil
 	}
 	atomic.StoreUint64(&n.checkpointTs, checkpointTs)
+	n.traceFlushedEvents(ctx, checkpointTs, flushStart)
 
 	n.flowController.Release(checkpointTs)
 	return nil
@@ -154,15 +169,26 @@ func (n *sinkNode) emitEvent(ctx pipeline.NodeContext, event *model.PolymorphicE
 		log.Warn("skip emit empty rows", zap.Any("event", event))
 		return nil
 	}
+	if tracing.Enabled() {
+		key := tracing.EventKey{
+			ChangefeedID: ctx.ChangefeedVars().ID,
+			TableID:      event.Row.Table.TableID,
+			CommitTs:     event.CRTs,
+		}
+		span := tracing.StartEventSpan(ctx, tracing.StageSinkEmit, key)
+		defer span.End()
+		if span.SpanContext().IsSampled() {
+			n.traceEvent(key)
+		}
+	}
 
 	colLen := len(event.Row.Columns)
 	preColLen := len(event.Row.PreColumns)
-	config := ctx.ChangefeedVars().Info.Config
 
 	// This indicates that it is an update event,
 	// and after enable old value internally by default(but disable in the configuration).
 	// We need to handle the update event to be compatible with the old format.
-	if !config.EnableOldValue && colLen != 0 && preColLen != 0 && colLen == preColLen {
+	if !n.enableOldValue && colLen != 0 && preColLen != 0 && colLen == preColLen {
 		if shouldSplitUpdateEvent(event) {
 			deleteEvent, insertEvent, err := splitUpdateEvent(event)
 			if err != nil {
@@ -187,6 +213,32 @@ func (n *sinkNode) emitEvent(ctx pipeline.NodeContext, event *model.PolymorphicE
 	return nil
 }
 
+// traceEvent remembers the key of a sampled event, the rows of a transaction
+// are emitted in a row, so they share the same key.
+func (n *sinkNode) traceEvent(key tracing.EventKey) {
+	if len(n.tracedEvents) > 0 && n.tracedEvents[len(n.tracedEvents)-1] == key {
+		return
+	}
+	if len(n.tracedEvents) >= maxTracedEvents {
+		return
+	}
+	n.tracedEvents = append(n.tracedEvents, key)
+}
+
+// traceFlushedEvents records the flush spans of the sampled events which have
+// been flushed to the downstream.
+func (n *sinkNode) traceFlushedEvents(ctx context.Context, checkpointTs model.Ts, flushStart time.Time) {
+	if len(n.tracedEvents) == 0 {
+		return
+	}
+	flushEnd := time.Now()
+	i := 0
+	for ; i < len(n.tracedEvents) && n.tracedEvents[i].CommitTs <= checkpointTs; i++ {
+		tracing.RecordEventSpan(ctx, tracing.StageSinkFlush, n.tracedEvents[i], flushStart, flushEnd)
+	}
+	n.tracedEvents = append(n.tracedEvents[:0], n.tracedEvents[i:]...)
+}
+
 // shouldSplitUpdateEvent determines if the split event is needed to align the old format based on
 // whether the handle key column has been modified.
 // If the handle key column is modified,
@@ -15,6 +15,7 @@ package pipeline
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/errors"
@@ -45,23 +46,48 @@ type sorterNode struct {
 
 	mounter entry.Mounter
 
+	// startTs, resumeTs and fingerprint are used by the db sorter to resume
+	// the table from the persisted data, see psorter.DBSorterResumeTs.
+	startTs      model.Ts
+	resumeTs     model.Ts
+	fingerprint  string
+	checkpointTs func() model.Ts
+
+	// the number of row changed events sent to the sorter but not yet output
+	backlog int64
+
+	// diskQuotaLimiter is shared with the puller node, which is paused until
+	// the sorter outputs the resolved ts if the disk quota is close.
+	diskQuotaLimiter *psorter.DiskQuotaLimiter
+
 	wg     errgroup.Group
 	cancel context.CancelFunc
 }
 
-func newSorterNode(tableName string, tableID model.TableID, flowController tableFlowController, mounter entry.Mounter) pipeline.Node {
+func newSorterNode(
+	tableName string, tableID model.TableID, flowController tableFlowController, mounter entry.Mounter,
+	startTs, resumeTs model.Ts, fingerprint string, checkpointTs func() model.Ts,
+	diskQuotaLimiter *psorter.DiskQuotaLimiter) *sorterNode {
 	return &sorterNode{
-		tableName:      tableName,
-		tableID:        tableID,
-		flowController: flowController,
-		mounter:        mounter,
+		tableName:        tableName,
+		tableID:          tableID,
+		flowController:   flowController,
+		mounter:          mounter,
+		startTs:          startTs,
+		resumeTs:         resumeTs,
+		fingerprint:      fingerprint,
+		checkpointTs:     checkpointTs,
+		diskQuotaLimiter: diskQuotaLimiter,
 	}
 }
 
 func (n *sorterNode) Init(ctx pipeline.NodeContext) error {
 	stdCtx, cancel := context.WithCancel(ctx)
 	n.cancel = cancel
-	var sorter puller.EventSorter
+	var (
+		sorter   puller.EventSorter
+		dbSorter *psorter.DBSorter
+	)
 	sortEngine := ctx.ChangefeedVars().Info.Engine
 	switch sortEngine {
 	case model.SortInMemory:
@@ -76,10 +102,25 @@ func (n *sorterNode) Init(ctx pipeline.NodeContext) error {
 		if err != nil {
 			return errors.Trace(err)
 		}
-		sorter, err = psorter.NewUnifiedSorter(sortDir, ctx.ChangefeedVars().ID, n.tableName, n.tableID, ctx.GlobalVars().CaptureInfo.AdvertiseAddr)
+		unifiedSorter, err := psorter.NewUnifiedSorter(sortDir, ctx.ChangefeedVars().ID, n.tableName, n.tableID, ctx.GlobalVars().CaptureInfo.AdvertiseAddr)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		info := ctx.ChangefeedVars().Info
+		unifiedSorter.SetPriority(info.GetPriority())
+		sorter = unifiedSorter
+	case model.SortInDB:
+		sortDir := ctx.ChangefeedVars().Info.SortDir
+		err := psorter.UnifiedSorterCheckDir(sortDir)
 		if err != nil {
 			return errors.Trace(err)
 		}
+		dbSorter, err = psorter.NewDBSorter(sortDir, ctx.ChangefeedVars().ID, n.tableName, n.tableID, ctx.GlobalVars().CaptureInfo.AdvertiseAddr)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		dbSorter.SetResumeTs(n.startTs, n.resumeTs, n.fingerprint)
+		sorter = dbSorter
 	default:
 		return cerror.ErrUnknownSortEngine.GenWithStackByArgs(sortEngine)
 	}
@@ -122,6 +163,7 @@ func (n *sorterNode) Init(ctx pipeline.NodeContext) error {
 					log.Panic("unexpected empty msg", zap.Reflect("msg", msg))
 				}
 				if msg.RawKV.OpType != model.OpTypeResolved {
+					n.onRowEventOutput(msg.CRTs)
 					size := uint64(msg.RawKV.ApproximateSize())
 					commitTs := msg.CRTs
 					// We interpolate a resolved-ts if none has been sent for some time.
@@ -171,6 +213,13 @@ func (n *sorterNode) Init(ctx pipeline.NodeContext) error {
 					}
 				} else {
 					// handle OpTypeResolved
+					if n.diskQuotaLimiter != nil {
+						n.diskQuotaLimiter.OnSorterOutput(msg.CRTs)
+					}
+					if dbSorter != nil {
+						// the persisted data which has been replicated can be deleted
+						dbSorter.SetCheckpointTs(n.checkpointTs())
+					}
 					if msg.CRTs < lastSentResolvedTs {
 						continue
 					}
@@ -190,6 +239,9 @@ func (n *sorterNode) Receive(ctx pipeline.NodeContext) error {
 	msg := ctx.Message()
 	switch msg.Tp {
 	case pipeline.MessageTypePolymorphicEvent:
+		if msg.PolymorphicEvent.RawKV.OpType != model.OpTypeResolved {
+			atomic.AddInt64(&n.backlog, 1)
+		}
 		n.sorter.AddEntry(ctx, msg.PolymorphicEvent)
 	default:
 		ctx.SendToNextNode(msg)
@@ -197,6 +249,21 @@ func (n *sorterNode) Receive(ctx pipeline.NodeContext) error {
 	return nil
 }
 
+// onRowEventOutput updates the backlog when a row changed event is output by
+// the sorter. The events resumed from the persisted data of the db sorter are
+// not received from the puller, which starts from resumeTs, so the events not
+// newer than resumeTs are not counted on either side.
+func (n *sorterNode) onRowEventOutput(commitTs model.Ts) {
+	if commitTs > n.resumeTs {
+		atomic.AddInt64(&n.backlog, -1)
+	}
+}
+
+// Backlog returns the number of row changed events buffered in the sorter
+func (n *sorterNode) Backlog() int64 {
+	return atomic.LoadInt64(&n.backlog)
+}
+
 func (n *sorterNode) Destroy(ctx pipeline.NodeContext) error {
 	defer tableMemoryHistogram.DeleteLabelValues(ctx.ChangefeedVars().ID, ctx.GlobalVars().CaptureInfo.AdvertiseAddr)
 	n.cancel()
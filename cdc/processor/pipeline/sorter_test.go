@@ -14,6 +14,8 @@
 package pipeline
 
 import (
+	"context"
+
 	"github.com/pingcap/check"
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/cdc/puller/sorter"
@@ -47,3 +49,63 @@ func (s *sorterSuite) TestUnifiedSorterFileLockConflict(c *check.C) {
 	err = sorter.Init(pipeline.MockNodeContext4Test(ctx, pipeline.Message{}, nil))
 	c.Assert(err, check.ErrorMatches, ".*file lock conflict.*")
 }
+
+type mockEventSorter struct {
+	entries []*model.PolymorphicEvent
+}
+
+func (s *mockEventSorter) Run(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (s *mockEventSorter) AddEntry(ctx context.Context, entry *model.PolymorphicEvent) {
+	s.entries = append(s.entries, entry)
+}
+
+func (s *mockEventSorter) Output() <-chan *model.PolymorphicEvent {
+	return nil
+}
+
+func (s *sorterSuite) TestSorterBacklog(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := cdcContext.NewBackendContext4Test(true)
+	mockSorter := &mockEventSorter{}
+	sorter := newSorterNode("`test`.`t`", 1, nil, nil, 0, 0, "", nil, nil)
+	sorter.sorter = mockSorter
+
+	events := []*model.PolymorphicEvent{
+		model.NewPolymorphicEvent(&model.RawKVEntry{OpType: model.OpTypePut, CRTs: 1}),
+		model.NewPolymorphicEvent(&model.RawKVEntry{OpType: model.OpTypeDelete, CRTs: 2}),
+		model.NewResolvedPolymorphicEvent(0, 2),
+	}
+	for _, event := range events {
+		err := sorter.Receive(pipeline.MockNodeContext4Test(ctx, pipeline.PolymorphicEventMessage(event), nil))
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(mockSorter.entries, check.HasLen, 3)
+	// resolved events are not counted in the backlog
+	c.Assert(sorter.Backlog(), check.Equals, int64(2))
+	sorter.onRowEventOutput(1)
+	c.Assert(sorter.Backlog(), check.Equals, int64(1))
+}
+
+func (s *sorterSuite) TestSorterBacklogResumed(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := cdcContext.NewBackendContext4Test(true)
+	mockSorter := &mockEventSorter{}
+	// the table is resumed from the persisted events in (1, 5]
+	sorter := newSorterNode("`test`.`t`", 1, nil, nil, 1, 5, "", nil, nil)
+	sorter.sorter = mockSorter
+
+	event := model.NewPolymorphicEvent(&model.RawKVEntry{OpType: model.OpTypePut, CRTs: 6})
+	err := sorter.Receive(pipeline.MockNodeContext4Test(ctx, pipeline.PolymorphicEventMessage(event), nil))
+	c.Assert(err, check.IsNil)
+	c.Assert(sorter.Backlog(), check.Equals, int64(1))
+	// the resumed events are not received from the puller
+	sorter.onRowEventOutput(3)
+	sorter.onRowEventOutput(5)
+	c.Assert(sorter.Backlog(), check.Equals, int64(1))
+	sorter.onRowEventOutput(6)
+	c.Assert(sorter.Backlog(), check.Equals, int64(0))
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/entry"
 	"github.com/pingcap/ticdc/cdc/model"
+	psorter "github.com/pingcap/ticdc/cdc/puller/sorter"
 	"github.com/pingcap/ticdc/cdc/sink"
 	"github.com/pingcap/ticdc/cdc/sink/common"
 	serverConfig "github.com/pingcap/ticdc/pkg/config"
@@ -35,6 +36,15 @@ const (
 	resolvedTsInterpolateInterval = 200 * time.Millisecond
 )
 
+// OldValueOptions describes how the old values of the rows of a table are handled.
+type OldValueOptions struct {
+	// Enable is true if the rows sent to the sink carry old values.
+	Enable bool
+	// Request is true if the old values are requested from TiKV, they are also
+	// needed to identify the deleted rows of the tables without clustered index.
+	Request bool
+}
+
 // TablePipeline is a pipeline which capture the change log from tikv in a table
 type TablePipeline interface {
 	// ID returns the ID of source table and mark table
@@ -53,6 +63,8 @@ type TablePipeline interface {
 	Workload() model.WorkloadInfo
 	// Status returns the status of this table pipeline
 	Status() TableStatus
+	// SorterBacklog returns the number of row changed events buffered in the sorter
+	SorterBacklog() int64
 	// Cancel stops this table pipeline immediately and destroy all resources created by this table pipeline
 	Cancel()
 	// Wait waits for table pipeline destroyed
@@ -66,8 +78,9 @@ type tablePipelineImpl struct {
 	markTableID int64
 	tableName   string // quoted schema and table, used in metircs only
 
-	sinkNode *sinkNode
-	cancel   context.CancelFunc
+	sorterNode *sorterNode
+	sinkNode   *sinkNode
+	cancel     context.CancelFunc
 }
 
 // TODO find a better name or avoid using an interface
@@ -129,6 +142,11 @@ func (t *tablePipelineImpl) Status() TableStatus {
 	return t.sinkNode.Status()
 }
 
+// SorterBacklog returns the number of row changed events buffered in the sorter
+func (t *tablePipelineImpl) SorterBacklog() int64 {
+	return t.sorterNode.Backlog()
+}
+
 // ID returns the ID of source table and mark table
 func (t *tablePipelineImpl) ID() (tableID, markTableID int64) {
 	return t.tableID, t.markTableID
@@ -164,8 +182,10 @@ func NewTablePipeline(ctx cdcContext.Context,
 	tableID model.TableID,
 	tableName string,
 	replicaInfo *model.TableReplicaInfo,
+	oldValue OldValueOptions,
 	sink sink.Sink,
-	targetTs model.Ts) TablePipeline {
+	targetTs model.Ts,
+	changefeedMemoryQuota *common.ChangefeedMemoryQuota) TablePipeline {
 	ctx, cancel := cdcContext.WithCancel(ctx)
 	tablePipeline := &tablePipelineImpl{
 		tableID:     tableID,
@@ -180,21 +200,40 @@ func NewTablePipeline(ctx cdcContext.Context,
 		zap.String("table-name", tableName),
 		zap.Int64("table-id", tableID),
 		zap.Uint64("quota", perTableMemoryQuota))
-	flowController := common.NewTableFlowController(perTableMemoryQuota)
+	var flowController *common.TableFlowController
+	if changefeedMemoryQuota != nil {
+		flowController = common.NewTableFlowControllerWithChangefeedQuota(perTableMemoryQuota, changefeedMemoryQuota)
+	} else {
+		flowController = common.NewTableFlowController(perTableMemoryQuota)
+	}
 	config := ctx.ChangefeedVars().Info.Config
 	cyclicEnabled := config.Cyclic != nil && config.Cyclic.IsEnabled()
 	runnerSize := defaultRunnersSize
 	if cyclicEnabled {
 		runnerSize++
 	}
+	// the table is pulled from TiKV after the persisted data of the sorter, if any.
+	pullerStartTs := replicaInfo.StartTs
+	sorterFingerprint := ""
+	if ctx.ChangefeedVars().Info.Engine == model.SortInDB {
+		sorterFingerprint = psorter.DBSorterFingerprint(oldValue.Request, config)
+		pullerStartTs = psorter.DBSorterResumeTs(ctx.ChangefeedVars().Info.SortDir,
+			ctx.ChangefeedVars().ID, tableID, replicaInfo.StartTs, sorterFingerprint)
+	}
+	tablePipeline.sinkNode = newSinkNode(sink, replicaInfo.StartTs, targetTs, flowController, oldValue.Enable)
+
+	diskQuotaLimiter := psorter.NewDiskQuotaLimiter(ctx.GlobalVars().CaptureInfo.AdvertiseAddr, ctx.ChangefeedVars().ID,
+		ctx.ChangefeedVars().Info.GetQuota().SorterDiskQuota)
+
 	p := pipeline.NewPipeline(ctx, 500*time.Millisecond, runnerSize, defaultOutputChannelSize)
-	p.AppendNode(ctx, "puller", newPullerNode(tableID, replicaInfo, tableName))
-	p.AppendNode(ctx, "sorter", newSorterNode(tableName, tableID, flowController, mounter))
+	p.AppendNode(ctx, "puller", newPullerNode(tableID, replicaInfo, tableName, pullerStartTs, oldValue.Request, diskQuotaLimiter))
+	tablePipeline.sorterNode = newSorterNode(tableName, tableID, flowController, mounter,
+		replicaInfo.StartTs, pullerStartTs, sorterFingerprint, tablePipeline.sinkNode.CheckpointTs, diskQuotaLimiter)
+	p.AppendNode(ctx, "sorter", tablePipeline.sorterNode)
 	p.AppendNode(ctx, "mounter", newMounterNode())
 	if cyclicEnabled {
 		p.AppendNode(ctx, "cyclic", newCyclicMarkNode(replicaInfo.MarkTableID))
 	}
-	tablePipeline.sinkNode = newSinkNode(sink, replicaInfo.StartTs, targetTs, flowController)
 	p.AppendNode(ctx, "sink", tablePipeline.sinkNode)
 	tablePipeline.p = p
 	return tablePipeline
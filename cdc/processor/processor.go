@@ -31,6 +31,7 @@ import (
 	tablepipeline "github.com/pingcap/ticdc/cdc/processor/pipeline"
 	"github.com/pingcap/ticdc/cdc/puller"
 	"github.com/pingcap/ticdc/cdc/sink"
+	"github.com/pingcap/ticdc/cdc/sink/common"
 	cdcContext "github.com/pingcap/ticdc/pkg/context"
 	"github.com/pingcap/ticdc/pkg/cyclic/mark"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
@@ -39,6 +40,7 @@ import (
 	"github.com/pingcap/ticdc/pkg/regionspan"
 	"github.com/pingcap/ticdc/pkg/retry"
 	"github.com/pingcap/ticdc/pkg/util"
+	timeta "github.com/pingcap/tidb/meta"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/tikv/client-go/v2/oracle"
 	"go.uber.org/zap"
@@ -60,8 +62,20 @@ type processor struct {
 
 	schemaStorage entry.SchemaStorage
 	filter        *filter.Filter
+	oldValue      *filter.OldValueMatcher
 	mounter       entry.Mounter
 	sinkManager   *sink.Manager
+	// memoryQuota is shared by all the table pipelines of the changefeed,
+	// it is nil if the changefeed has no memory quota.
+	memoryQuota *common.ChangefeedMemoryQuota
+	// targetTs is the target ts of the table pipelines, the processor is
+	// restarted if the target ts of the changefeed is changed.
+	targetTs model.Ts
+
+	// corruptedTables are the tables whose sorter data is corrupted, they are
+	// rebuilt from their checkpoint ts in the next tick.
+	corruptedTables   map[model.TableID]struct{}
+	corruptedTablesMu sync.Mutex
 
 	initialized bool
 	errCh       chan error
@@ -161,6 +175,14 @@ func (p *processor) tick(ctx cdcContext.Context, state *model.ChangefeedReactorS
 	if !p.checkChangefeedNormal() {
 		return nil, cerror.ErrAdminStopProcessor.GenWithStackByArgs()
 	}
+	if p.initialized && p.targetTs != p.changefeed.Info.GetTargetTs() {
+		// the table pipelines must be recreated to replicate with the new target ts
+		log.Info("target ts of the changefeed is changed, restart the processor",
+			cdcContext.ZapFieldChangefeed(ctx),
+			zap.Uint64("oldTargetTs", p.targetTs),
+			zap.Uint64("newTargetTs", p.changefeed.Info.GetTargetTs()))
+		return nil, cerror.ErrReactorFinished.GenWithStackByArgs()
+	}
 	if skip := p.checkPosition(); skip {
 		return p.changefeed, nil
 	}
@@ -170,6 +192,9 @@ func (p *processor) tick(ctx cdcContext.Context, state *model.ChangefeedReactorS
 	if err := p.lazyInit(ctx); err != nil {
 		return nil, errors.Trace(err)
 	}
+	if err := p.rebuildCorruptedTables(ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
 	if err := p.handleTableOperation(ctx); err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -251,6 +276,10 @@ func (p *processor) lazyInitImpl(ctx cdcContext.Context) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	p.oldValue, err = filter.NewOldValueMatcher(p.changefeed.Info.Config)
+	if err != nil {
+		return errors.Trace(err)
+	}
 
 	p.schemaStorage, err = p.createAndDriveSchemaStorage(ctx)
 	if err != nil {
@@ -259,13 +288,6 @@ func (p *processor) lazyInitImpl(ctx cdcContext.Context) error {
 
 	stdCtx := util.PutChangefeedIDInCtx(ctx, p.changefeed.ID)
 
-	p.mounter = entry.NewMounter(p.schemaStorage, p.changefeed.Info.Config.Mounter.WorkerNum, p.changefeed.Info.Config.EnableOldValue)
-	p.wg.Add(1)
-	go func() {
-		defer p.wg.Done()
-		p.sendError(p.mounter.Run(stdCtx))
-	}()
-
 	opts := make(map[string]string, len(p.changefeed.Info.Opts)+2)
 	for k, v := range p.changefeed.Info.Opts {
 		opts[k] = v
@@ -281,13 +303,35 @@ func (p *processor) lazyInitImpl(ctx cdcContext.Context) error {
 	}
 	opts[sink.OptChangefeedID] = p.changefeed.ID
 	opts[sink.OptCaptureAddr] = ctx.GlobalVars().CaptureInfo.AdvertiseAddr
+	quota := p.changefeed.Info.GetQuota()
+	if quota.SinkConcurrency > 0 {
+		opts[sink.OptSinkConcurrency] = strconv.Itoa(quota.SinkConcurrency)
+	}
+	if quota.MemoryQuota > 0 {
+		p.memoryQuota = common.NewChangefeedMemoryQuota(quota.MemoryQuota)
+	}
 	s, err := sink.NewSink(stdCtx, p.changefeed.ID, p.changefeed.Info.SinkURI, p.filter, p.changefeed.Info.Config, opts, errCh)
 	if err != nil {
 		return errors.Trace(err)
 	}
+
+	// the mounter does not decode the columns not needed by the sink
+	columnSelector, _ := s.(entry.ColumnSelector)
+	p.mounter = entry.NewMounter(p.schemaStorage, p.changefeed.Info.Config.Mounter.WorkerNum,
+		p.oldValue, columnSelector)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.sendError(p.mounter.Run(stdCtx))
+	}()
+
 	checkpointTs := p.changefeed.Info.GetCheckpointTs(p.changefeed.Status)
 	captureAddr := ctx.GlobalVars().CaptureInfo.AdvertiseAddr
+	if p.changefeed.Info.Config.Heartbeat.IsEnabled() {
+		s = sink.NewHeartbeatSink(s, captureAddr, p.changefeedID)
+	}
 	p.sinkManager = sink.NewManager(stdCtx, s, errCh, checkpointTs, captureAddr, p.changefeedID)
+	p.targetTs = p.changefeed.Info.GetTargetTs()
 	p.initialized = true
 	log.Info("run processor", cdcContext.ZapFieldCapture(ctx), cdcContext.ZapFieldChangefeed(ctx))
 	return nil
@@ -313,6 +357,58 @@ func (p *processor) handleErrorCh(ctx cdcContext.Context) error {
 	return cerror.ErrReactorFinished
 }
 
+// markTableCorrupted records a table whose sorter data is corrupted.
+func (p *processor) markTableCorrupted(tableID model.TableID, err error) {
+	log.Warn("the sorter data of the table is corrupted, the table will be rebuilt",
+		zap.String("changefeed", p.changefeedID),
+		zap.Int64("tableID", tableID),
+		zap.Error(err))
+	p.corruptedTablesMu.Lock()
+	defer p.corruptedTablesMu.Unlock()
+	if p.corruptedTables == nil {
+		p.corruptedTables = make(map[model.TableID]struct{})
+	}
+	p.corruptedTables[tableID] = struct{}{}
+}
+
+// rebuildCorruptedTables recreates the table pipelines whose sorter data is
+// corrupted. The new table pipelines start from the checkpoint ts of the old
+// ones, so that no data is lost.
+func (p *processor) rebuildCorruptedTables(ctx cdcContext.Context) error {
+	p.corruptedTablesMu.Lock()
+	corruptedTables := p.corruptedTables
+	p.corruptedTables = nil
+	p.corruptedTablesMu.Unlock()
+
+	taskStatus := p.changefeed.TaskStatuses[p.captureInfo.ID]
+	for tableID := range corruptedTables {
+		table, exist := p.tables[tableID]
+		if !exist {
+			continue
+		}
+		checkpointTs := table.CheckpointTs()
+		table.Cancel()
+		table.Wait()
+		delete(p.tables, tableID)
+
+		replicaInfo, exist := taskStatus.Tables[tableID]
+		if !exist {
+			// the table is being removed, there is no need to rebuild it.
+			continue
+		}
+		replicaInfo = replicaInfo.Clone()
+		replicaInfo.StartTs = checkpointTs
+		log.Info("rebuild the table pipeline",
+			cdcContext.ZapFieldChangefeed(ctx),
+			zap.Int64("tableID", tableID),
+			zap.Uint64("startTs", checkpointTs))
+		if err := p.addTable(ctx, tableID, replicaInfo); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
 // handleTableOperation handles the operation of `TaskStatus`(add table operation and remove table operation)
 func (p *processor) handleTableOperation(ctx cdcContext.Context) error {
 	patchOperation := func(tableID model.TableID, fn func(operation *model.TableOperation) error) {
@@ -444,14 +540,14 @@ func (p *processor) createAndDriveSchemaStorage(ctx cdcContext.Context) (entry.S
 		ctx.GlobalVars().GrpcPool,
 		ctx.GlobalVars().KVStorage,
 		checkpointTs, ddlspans, false)
-	meta, err := kv.GetSnapshotMeta(kvStorage, checkpointTs)
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	schemaStorage, err := entry.NewSchemaStorage(meta, checkpointTs, p.filter, p.changefeed.Info.Config.ForceReplicate)
+	schemaSnap, err := entry.LoadSchemaSnapshot(checkpointTs, p.changefeed.Info.Config.ForceReplicate, func() (*timeta.Meta, error) {
+		return kv.GetSnapshotMeta(kvStorage, checkpointTs)
+	})
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	schemaStorage := entry.NewSchemaStorageFromSnapshot(schemaSnap, p.filter)
+	historyRecorder := entry.NewSchemaHistoryRecorder(checkpointTs)
 	p.wg.Add(1)
 	go func() {
 		defer p.wg.Done()
@@ -472,6 +568,7 @@ func (p *processor) createAndDriveSchemaStorage(ctx cdcContext.Context) (entry.S
 				continue
 			}
 			failpoint.Inject("processorDDLResolved", nil)
+			historyRecorder.Record(ddlRawKV)
 			if ddlRawKV.OpType == model.OpTypeResolved {
 				schemaStorage.AdvanceResolvedTs(ddlRawKV.CRTs)
 			}
@@ -670,6 +767,10 @@ func (p *processor) createTablePipelineImpl(ctx cdcContext.Context, tableID mode
 			errors.Cause(errors.Cause(err)) == context.Canceled {
 			return nil
 		}
+		if cerror.ErrUnifiedSorterDataCorrupted.Equal(errors.Cause(err)) {
+			p.markTableCorrupted(tableID, err)
+			return nil
+		}
 		p.sendError(err)
 		return nil
 	})
@@ -720,8 +821,10 @@ func (p *processor) createTablePipelineImpl(ctx cdcContext.Context, tableID mode
 		tableID,
 		tableNameStr,
 		replicaInfo,
+		p.tableOldValueOptions(tableID, tableName),
 		sink,
-		p.changefeed.Info.GetTargetTs(),
+		p.targetTs,
+		p.memoryQuota,
 	)
 	p.wg.Add(1)
 	p.metricSyncTableNumGauge.Inc()
@@ -744,6 +847,21 @@ func (p *processor) createTablePipelineImpl(ctx cdcContext.Context, tableID mode
 	return table, nil
 }
 
+// tableOldValueOptions returns how the old values of a table are handled,
+// the old values are requested from TiKV if the table is not found.
+func (p *processor) tableOldValueOptions(tableID model.TableID, tableName *model.TableName) tablepipeline.OldValueOptions {
+	opts := tablepipeline.OldValueOptions{Enable: p.changefeed.Info.Config.EnableOldValue, Request: true}
+	if tableName != nil {
+		opts.Enable = p.oldValue.IsOldValueEnabled(tableName.Schema, tableName.Table)
+	}
+	if tableInfo, ok := p.schemaStorage.GetLastSnapshot().PhysicalTableByID(tableID); ok && !opts.Enable {
+		// the handle key columns of the deleted rows are decoded from the
+		// row keys if the table is clustered.
+		opts.Request = !tableInfo.PKIsHandle && !tableInfo.IsCommonHandle
+	}
+	return opts
+}
+
 // doGCSchemaStorage trigger the schema storage GC
 func (p *processor) doGCSchemaStorage() {
 	if p.schemaStorage == nil {
@@ -783,6 +901,30 @@ func (p *processor) Close() error {
 	return nil
 }
 
+// tableReplicationStatus returns the replication status of all the tables
+// replicated by the processor
+func (p *processor) tableReplicationStatus() []*model.TableReplicationStatus {
+	statuses := make([]*model.TableReplicationStatus, 0, len(p.tables))
+	for tableID, table := range p.tables {
+		resolvedTs, checkpointTs := table.ResolvedTs(), table.CheckpointTs()
+		var gap int64
+		if resolvedTs > checkpointTs {
+			gap = oracle.ExtractPhysical(resolvedTs) - oracle.ExtractPhysical(checkpointTs)
+		}
+		statuses = append(statuses, &model.TableReplicationStatus{
+			TableID:               tableID,
+			TableName:             table.Name(),
+			CaptureID:             p.captureInfo.ID,
+			Status:                table.Status().String(),
+			ResolvedTs:            resolvedTs,
+			CheckpointTs:          checkpointTs,
+			SorterBacklog:         table.SorterBacklog(),
+			ResolvedCheckpointGap: gap,
+		})
+	}
+	return statuses
+}
+
 // WriteDebugInfo write the debug info to Writer
 func (p *processor) WriteDebugInfo(w io.Writer) {
 	fmt.Fprintf(w, "%+v\n", *p.changefeed)
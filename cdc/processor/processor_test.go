@@ -27,6 +27,7 @@ import (
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/orchestrator"
 	"github.com/pingcap/ticdc/pkg/util/testleak"
+	"github.com/tikv/client-go/v2/oracle"
 )
 
 func Test(t *testing.T) { check.TestingT(t) }
@@ -63,6 +64,8 @@ type mockTablePipeline struct {
 	stopTs       model.Ts
 	status       tablepipeline.TableStatus
 	canceled     bool
+
+	sorterBacklog int64
 }
 
 func (m *mockTablePipeline) ID() (tableID int64, markTableID int64) {
@@ -98,6 +101,10 @@ func (m *mockTablePipeline) Status() tablepipeline.TableStatus {
 	return m.status
 }
 
+func (m *mockTablePipeline) SorterBacklog() int64 {
+	return m.sorterBacklog
+}
+
 func (m *mockTablePipeline) Cancel() {
 	if m.canceled {
 		log.Panic("cancel a canceled table pipeline")
@@ -466,6 +473,81 @@ func (s *processorSuite) TestInitTable(c *check.C) {
 	c.Assert(p.tables[2], check.Not(check.IsNil))
 }
 
+func (s *processorSuite) TestTableReplicationStatus(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := cdcContext.NewBackendContext4Test(true)
+	p, tester := initProcessor4Test(ctx, c)
+	var err error
+	// init tick
+	_, err = p.Tick(ctx, p.changefeed)
+	c.Assert(err, check.IsNil)
+	tester.MustApplyPatches()
+	c.Assert(p.tableReplicationStatus(), check.HasLen, 0)
+
+	p.changefeed.PatchTaskStatus(p.captureInfo.ID, func(status *model.TaskStatus) (*model.TaskStatus, bool, error) {
+		status.Tables[1] = &model.TableReplicaInfo{StartTs: 20}
+		return status, true, nil
+	})
+	tester.MustApplyPatches()
+	_, err = p.Tick(ctx, p.changefeed)
+	c.Assert(err, check.IsNil)
+	tester.MustApplyPatches()
+
+	table1 := p.tables[1].(*mockTablePipeline)
+	table1.checkpointTs = oracle.ComposeTS(1000, 0)
+	table1.resolvedTs = oracle.ComposeTS(1500, 1)
+	table1.sorterBacklog = 12
+	c.Assert(p.tableReplicationStatus(), check.DeepEquals, []*model.TableReplicationStatus{{
+		TableID:               1,
+		TableName:             "`test`.`table1`",
+		CaptureID:             p.captureInfo.ID,
+		Status:                "Running",
+		ResolvedTs:            oracle.ComposeTS(1500, 1),
+		CheckpointTs:          oracle.ComposeTS(1000, 0),
+		SorterBacklog:         12,
+		ResolvedCheckpointGap: 500,
+	}})
+
+	// the gap is zero if the sink has caught up
+	table1.checkpointTs = table1.resolvedTs
+	c.Assert(p.tableReplicationStatus()[0].ResolvedCheckpointGap, check.Equals, int64(0))
+}
+
+func (s *processorSuite) TestRebuildCorruptedTables(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := cdcContext.NewBackendContext4Test(true)
+	p, tester := initProcessor4Test(ctx, c)
+	var err error
+	// init tick
+	_, err = p.Tick(ctx, p.changefeed)
+	c.Assert(err, check.IsNil)
+	tester.MustApplyPatches()
+
+	p.changefeed.PatchTaskStatus(p.captureInfo.ID, func(status *model.TaskStatus) (*model.TaskStatus, bool, error) {
+		status.Tables[1] = &model.TableReplicaInfo{StartTs: 20}
+		status.Tables[2] = &model.TableReplicaInfo{StartTs: 30}
+		return status, true, nil
+	})
+	tester.MustApplyPatches()
+	_, err = p.Tick(ctx, p.changefeed)
+	c.Assert(err, check.IsNil)
+	tester.MustApplyPatches()
+	table1 := p.tables[1].(*mockTablePipeline)
+	table1.checkpointTs = 25
+	table2 := p.tables[2]
+
+	p.markTableCorrupted(1, cerror.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs("test"))
+	_, err = p.Tick(ctx, p.changefeed)
+	c.Assert(err, check.IsNil)
+	tester.MustApplyPatches()
+	// only the corrupted table is rebuilt from its checkpoint ts
+	c.Assert(table1.canceled, check.IsTrue)
+	c.Assert(p.tables[1], check.Not(check.Equals), table1)
+	c.Assert(p.tables[1].CheckpointTs(), check.Equals, uint64(25))
+	c.Assert(p.tables[2], check.Equals, table2)
+	c.Assert(p.changefeed.TaskStatuses[p.captureInfo.ID].Tables[1].StartTs, check.Equals, uint64(20))
+}
+
 func (s *processorSuite) TestProcessorError(c *check.C) {
 	defer testleak.AfterTest(c)()
 	ctx := cdcContext.NewBackendContext4Test(true)
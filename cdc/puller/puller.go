@@ -37,6 +37,10 @@ import (
 const (
 	defaultPullerEventChanSize  = 128
 	defaultPullerOutputChanSize = 128
+	// the rows missing old values are buffered until the next resolved ts or
+	// the buffer is full, and their old values are read in batches.
+	maxPendingOldValueRows  = 1024
+	oldValueReadConcurrency = 16
 )
 
 // Puller pull data from tikv and push changes into a buffer
@@ -59,6 +63,9 @@ type pullerImpl struct {
 	resolvedTs     uint64
 	initialized    int64
 	enableOldValue bool
+	// getOldValues reads the values of the keys from a snapshot at the ts, it
+	// is used if the old values of the rows are not supplied by TiKV.
+	getOldValues func(ctx context.Context, keys []tidbkv.Key, ts uint64) (map[string][]byte, error)
 }
 
 // NewPuller create a new Puller fetch event start from checkpointTs
@@ -96,6 +103,10 @@ func NewPuller(
 		resolvedTs:     checkpointTs,
 		initialized:    0,
 		enableOldValue: enableOldValue,
+		getOldValues: func(ctx context.Context, keys []tidbkv.Key, ts uint64) (map[string][]byte, error) {
+			values, err := kvStorage.GetSnapshot(tidbkv.NewVersion(ts)).BatchGet(ctx, keys)
+			return values, errors.Trace(err)
+		},
 	}
 	return p
 }
@@ -130,6 +141,7 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 	metricPullerResolvedTs := pullerResolvedTsGauge.WithLabelValues(captureAddr, changefeedID)
 	metricTxnCollectCounterKv := txnCollectCounter.WithLabelValues(captureAddr, changefeedID, "kv")
 	metricTxnCollectCounterResolved := txnCollectCounter.WithLabelValues(captureAddr, changefeedID, "resolved")
+	metricOldValueFetchCounter := oldValueFetchCounter.WithLabelValues(captureAddr, changefeedID)
 	defer func() {
 		outputChanSizeHistogram.DeleteLabelValues(captureAddr, changefeedID)
 		eventChanSizeHistogram.DeleteLabelValues(captureAddr, changefeedID)
@@ -139,6 +151,7 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 		kvEventCounter.DeleteLabelValues(captureAddr, changefeedID, "resolved")
 		txnCollectCounter.DeleteLabelValues(captureAddr, changefeedID, "kv")
 		txnCollectCounter.DeleteLabelValues(captureAddr, changefeedID, "resolved")
+		oldValueFetchCounter.DeleteLabelValues(captureAddr, changefeedID)
 	}()
 	g.Go(func() error {
 		for {
@@ -177,6 +190,26 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 			return nil
 		}
 
+		// pendingRows are the rows missing old values, they are sent before
+		// the next resolved ts.
+		var pendingRows []*model.RawKVEntry
+		flushPendingRows := func() error {
+			if len(pendingRows) == 0 {
+				return nil
+			}
+			if err := p.fillOldValues(ctx, pendingRows); err != nil {
+				return errors.Trace(err)
+			}
+			metricOldValueFetchCounter.Add(float64(len(pendingRows)))
+			for _, raw := range pendingRows {
+				if err := output(raw); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			pendingRows = pendingRows[:0]
+			return nil
+		}
+
 		start := time.Now()
 		initialized := false
 		for {
@@ -188,6 +221,15 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 			}
 			if e.Val != nil {
 				metricTxnCollectCounterKv.Inc()
+				if e.OldValueMissing {
+					pendingRows = append(pendingRows, e.Val)
+					if len(pendingRows) >= maxPendingOldValueRows {
+						if err := flushPendingRows(); err != nil {
+							return errors.Trace(err)
+						}
+					}
+					continue
+				}
 				if err := output(e.Val); err != nil {
 					return errors.Trace(err)
 				}
@@ -224,6 +266,9 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 					continue
 				}
 				lastResolvedTs = resolvedTs
+				if err := flushPendingRows(); err != nil {
+					return errors.Trace(err)
+				}
 				err := output(&model.RawKVEntry{CRTs: resolvedTs, OpType: model.OpTypeResolved, RegionID: e.RegionID})
 				if err != nil {
 					return errors.Trace(err)
@@ -235,6 +280,45 @@ func (p *pullerImpl) Run(ctx context.Context) error {
 	return g.Wait()
 }
 
+// fillOldValues reads the old values of the rows, the old value of a row is
+// the latest version before the row is committed. It is read at the commit ts
+// rather than the start ts, because the row can be changed by others after the
+// start ts of a pessimistic transaction. The rows committed at the same ts are
+// read by one batch get, and the batches are read concurrently.
+func (p *pullerImpl) fillOldValues(ctx context.Context, rows []*model.RawKVEntry) error {
+	txns := make(map[uint64][]*model.RawKVEntry)
+	for _, raw := range rows {
+		txns[raw.CRTs] = append(txns[raw.CRTs], raw)
+	}
+	g, ctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, oldValueReadConcurrency)
+	for commitTs, txnRows := range txns {
+		commitTs, txnRows := commitTs, txnRows
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return errors.Trace(g.Wait())
+		}
+		g.Go(func() error {
+			defer func() { <-sem }()
+			keys := make([]tidbkv.Key, 0, len(txnRows))
+			for _, raw := range txnRows {
+				keys = append(keys, raw.Key)
+			}
+			values, err := p.getOldValues(ctx, keys, commitTs-1)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			// a row belongs to only one batch, no lock is needed
+			for _, raw := range txnRows {
+				raw.OldValue = values[string(raw.Key)]
+			}
+			return nil
+		})
+	}
+	return errors.Trace(g.Wait())
+}
+
 func (p *pullerImpl) GetResolvedTs() uint64 {
 	return atomic.LoadUint64(&p.resolvedTs)
 }
@@ -31,6 +31,7 @@ import (
 	"github.com/pingcap/ticdc/pkg/util/testleak"
 	tidbkv "github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/mockstore"
+	"github.com/tikv/client-go/v2/oracle"
 	"github.com/tikv/client-go/v2/tikv"
 	pd "github.com/tikv/pd/client"
 )
@@ -223,3 +224,81 @@ func (s *pullerSuite) TestPullerRawKV(c *check.C) {
 	cancel()
 	wg.Wait()
 }
+
+func (s *pullerSuite) TestPullerFetchMissingOldValue(c *check.C) {
+	defer testleak.AfterTest(c)()
+	spans := []regionspan.Span{
+		{Start: []byte("c"), End: []byte("e")},
+	}
+	checkpointTs := uint64(996)
+	plr, cancel, wg, store := s.newPullerForTest(c, spans, checkpointTs)
+
+	ctx := context.Background()
+	txn, err := store.Begin()
+	c.Assert(err, check.IsNil)
+	c.Assert(txn.Set([]byte("c1"), []byte("old-value")), check.IsNil)
+	c.Assert(txn.Commit(ctx), check.IsNil)
+	startVer, err := store.CurrentVersion(oracle.GlobalTxnScope)
+	c.Assert(err, check.IsNil)
+	// the row is changed after the start ts of the pessimistic transaction
+	// deleting it, the old value is the one before the commit ts.
+	txn, err = store.Begin()
+	c.Assert(err, check.IsNil)
+	c.Assert(txn.Set([]byte("c1"), []byte("locked-value")), check.IsNil)
+	c.Assert(txn.Commit(ctx), check.IsNil)
+	ver, err := store.CurrentVersion(oracle.GlobalTxnScope)
+	c.Assert(err, check.IsNil)
+
+	plr.cli.Returns(model.RegionFeedEvent{
+		Val: &model.RawKVEntry{
+			OpType:  model.OpTypeDelete,
+			Key:     []byte("c1"),
+			StartTs: startVer.Ver + 1,
+			CRTs:    ver.Ver + 2,
+		},
+		OldValueMissing: true,
+	})
+	plr.cli.Returns(model.RegionFeedEvent{
+		Val: &model.RawKVEntry{
+			OpType:  model.OpTypePut,
+			Key:     []byte("c2"),
+			Value:   []byte("test-value"),
+			StartTs: startVer.Ver + 1,
+			CRTs:    ver.Ver + 2,
+		},
+		OldValueMissing: true,
+	})
+	plr.cli.Returns(model.RegionFeedEvent{
+		Val: &model.RawKVEntry{
+			OpType:   model.OpTypePut,
+			Key:      []byte("c3"),
+			Value:    []byte("test-value"),
+			OldValue: []byte("old-value"),
+			StartTs:  ver.Ver + 3,
+			CRTs:     ver.Ver + 4,
+		},
+	})
+	// the rows missing old values are sent before the next resolved ts
+	ev := <-plr.Output()
+	c.Assert(ev.Key, check.DeepEquals, []byte("c3"))
+	c.Assert(ev.OldValue, check.DeepEquals, []byte("old-value"))
+	plr.cli.Returns(model.RegionFeedEvent{
+		Resolved: &model.ResolvedSpan{
+			Span:       regionspan.ToComparableSpan(spans[0]),
+			ResolvedTs: ver.Ver + 5,
+		},
+	})
+	ev = <-plr.Output()
+	c.Assert(ev.Key, check.DeepEquals, []byte("c1"))
+	c.Assert(ev.OldValue, check.DeepEquals, []byte("locked-value"))
+	ev = <-plr.Output()
+	c.Assert(ev.Key, check.DeepEquals, []byte("c2"))
+	c.Assert(ev.OldValue, check.IsNil)
+	ev = <-plr.Output()
+	c.Assert(ev.OpType, check.Equals, model.OpTypeResolved)
+	c.Assert(ev.CRTs, check.Equals, ver.Ver+5)
+
+	store.Close()
+	cancel()
+	wg.Wait()
+}
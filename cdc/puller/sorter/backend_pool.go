@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/pkg/config"
 	cerrors "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filelock"
@@ -55,6 +56,9 @@ type backEndPool struct {
 	dir               string
 	filePrefix        string
 
+	// changefeedDiskUsage maps changefeed IDs to their on disk data size (*int64).
+	changefeedDiskUsage sync.Map
+
 	// to prevent `dir` from being accidentally used by another TiCDC server process.
 	fileLock *filelock.FileLock
 
@@ -105,7 +109,7 @@ func newBackEndPool(dir string, captureAddr string) (*backEndPool, error) {
 			}
 
 			metricSorterInMemoryDataSizeGauge.Set(float64(atomic.LoadInt64(&ret.memoryUseEstimate)))
-			metricSorterOnDiskDataSizeGauge.Set(float64(atomic.LoadInt64(&ret.onDiskDataSize)))
+			metricSorterOnDiskDataSizeGauge.Set(float64(ret.onDiskDataSizeUsage()))
 			metricSorterOpenFileCountGauge.Set(float64(atomic.LoadInt64(&openFDCount)))
 
 			// update memPressure
@@ -160,13 +164,47 @@ func newBackEndPool(dir string, captureAddr string) (*backEndPool, error) {
 
 func (p *backEndPool) alloc(ctx context.Context) (backEnd, error) {
 	sorterConfig := config.GetGlobalServerConfig().Sorter
-	if p.sorterMemoryUsage() < int64(sorterConfig.MaxMemoryConsumption) &&
-		p.memoryPressure() < int32(sorterConfig.MaxMemoryPressure) {
+	maxMemoryConsumption := int64(sorterConfig.MaxMemoryConsumption)
+	maxMemoryPressure := int32(sorterConfig.MaxMemoryPressure)
+
+	var (
+		changefeedDiskUsage *int64
+		tableDiskUsage      *int64
+		diskQuotaExceeded   error
+	)
+	if p.diskConsumptionExceeded(sorterConfig.MaxDiskConsumption, 1) {
+		diskQuotaExceeded = cerrors.ErrUnifiedSorterDiskQuotaExceeded.GenWithStackByArgs(
+			fmt.Sprintf("max-disk-consumption %d bytes reached", sorterConfig.MaxDiskConsumption))
+	}
+	if sorter, ok := ctx.Value(ctxKey{}).(*UnifiedSorter); ok {
+		if sorter.priority == model.PriorityLow {
+			// Low priority changefeeds start spilling to disk earlier, leaving
+			// the memory to the changefeeds with higher priority.
+			maxMemoryConsumption /= 2
+			maxMemoryPressure /= 2
+		}
+		changefeedDiskUsage = p.getChangefeedDiskUsage(sorter.metricsInfo.changeFeedID)
+		tableDiskUsage = &sorter.diskUsage
+	}
+
+	if p.sorterMemoryUsage() < maxMemoryConsumption &&
+		p.memoryPressure() < maxMemoryPressure {
 
 		ret := newMemoryBackEnd()
 		return ret, nil
 	}
 
+	// NOTE the pullers are paused by the DiskQuotaLimiter before the disk
+	// quotas are reached. The disk quota of a changefeed can still be
+	// exceeded by the data not resolved yet, e.g. a transaction larger than
+	// the quota, which must be spilled for the changefeed to make progress.
+	// If max-disk-consumption is reached nevertheless, we return an error
+	// instead of falling back to the memory backEnd, which would risk running
+	// out of memory.
+	if diskQuotaExceeded != nil {
+		return nil, diskQuotaExceeded
+	}
+
 	p.cancelRWLock.RLock()
 	defer p.cancelRWLock.RUnlock()
 
@@ -178,7 +216,10 @@ func (p *backEndPool) alloc(ctx context.Context) (backEnd, error) {
 		ptr := &p.cache[i]
 		ret := atomic.SwapPointer(ptr, nil)
 		if ret != nil {
-			return (*fileBackEnd)(ret), nil
+			backEnd := (*fileBackEnd)(ret)
+			backEnd.changefeedDiskUsage = changefeedDiskUsage
+			backEnd.tableDiskUsage = tableDiskUsage
+			return backEnd, nil
 		}
 	}
 
@@ -193,14 +234,29 @@ func (p *backEndPool) alloc(ctx context.Context) (backEnd, error) {
 		return nil, errors.Trace(err)
 	}
 
-	ret, err := newFileBackEnd(fname, &msgPackGenSerde{})
+	compression, err := parseCompression(config.GetGlobalServerConfig().Sorter.Compression)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	ret, err := newFileBackEnd(fname, &msgPackGenSerde{}, compression)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	ret.changefeedDiskUsage = changefeedDiskUsage
+	ret.tableDiskUsage = tableDiskUsage
 
 	return ret, nil
 }
 
+// getChangefeedDiskUsage returns the pointer to the on disk data size of the changefeed.
+func (p *backEndPool) getChangefeedDiskUsage(changefeedID model.ChangeFeedID) *int64 {
+	if usage, ok := p.changefeedDiskUsage.Load(changefeedID); ok {
+		return usage.(*int64)
+	}
+	usage, _ := p.changefeedDiskUsage.LoadOrStore(changefeedID, new(int64))
+	return usage.(*int64)
+}
+
 func (p *backEndPool) dealloc(backEnd backEnd) error {
 	switch b := backEnd.(type) {
 	case *memoryBackEnd:
@@ -306,6 +362,43 @@ func (p *backEndPool) sorterMemoryUsage() int64 {
 	return atomic.LoadInt64(&p.memoryUseEstimate)
 }
 
+func (p *backEndPool) onDiskDataSizeUsage() int64 {
+	failpoint.Inject("onDiskDataSizeInjectPoint", func(val failpoint.Value) {
+		failpoint.Return(int64(val.(int)))
+	})
+	return atomic.LoadInt64(&p.onDiskDataSize)
+}
+
+// addOnDiskDataSize adds delta to the on disk data size.
+func (p *backEndPool) addOnDiskDataSize(delta int64) {
+	atomic.AddInt64(&p.onDiskDataSize, delta)
+}
+
+// changefeedsOnDisk returns the number of the changefeeds having data on disk,
+// it is at least 1.
+func (p *backEndPool) changefeedsOnDisk() int {
+	n := 0
+	p.changefeedDiskUsage.Range(func(_, usage interface{}) bool {
+		if atomic.LoadInt64(usage.(*int64)) > 0 {
+			n++
+		}
+		return true
+	})
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
+
+// diskConsumptionExceeded returns whether the on disk data size reaches the
+// given ratio of maxDiskConsumption, 0 means no limit.
+func (p *backEndPool) diskConsumptionExceeded(maxDiskConsumption uint64, ratio float64) bool {
+	if maxDiskConsumption == 0 {
+		return false
+	}
+	return float64(p.onDiskDataSizeUsage()) >= float64(maxDiskConsumption)*ratio
+}
+
 func (p *backEndPool) memoryPressure() int32 {
 	failpoint.Inject("memoryPressureInjectPoint", func(val failpoint.Value) {
 		failpoint.Return(int32(val.(int)))
@@ -19,11 +19,14 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/pingcap/check"
 	"github.com/pingcap/failpoint"
+	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/pkg/config"
+	cerrors "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filelock"
 	"github.com/pingcap/ticdc/pkg/util/testleak"
 )
@@ -106,6 +109,62 @@ func (s *backendPoolSuite) TestBasicFunction(c *check.C) {
 	c.Assert(os.IsNotExist(err), check.IsTrue)
 }
 
+func (s *backendPoolSuite) TestChangefeedQuota(c *check.C) {
+	defer testleak.AfterTest(c)()
+
+	dataDir := c.MkDir()
+	sortDir := filepath.Join(dataDir, config.DefaultSortDir)
+	err := os.MkdirAll(sortDir, 0o755)
+	c.Assert(err, check.IsNil)
+
+	conf := config.GetDefaultServerConfig()
+	conf.DataDir = dataDir
+	conf.Sorter.SortDir = sortDir
+	conf.Sorter.MaxMemoryPressure = 90                         // 90%
+	conf.Sorter.MaxMemoryConsumption = 16 * 1024 * 1024 * 1024 // 16G
+	config.StoreGlobalServerConfig(conf)
+
+	err = failpoint.Enable("github.com/pingcap/ticdc/cdc/puller/sorter/memoryPressureInjectPoint", "return(60)")
+	c.Assert(err, check.IsNil)
+	defer failpoint.Disable("github.com/pingcap/ticdc/cdc/puller/sorter/memoryPressureInjectPoint") //nolint:errcheck
+
+	backEndPool, err := newBackEndPool(sortDir, "")
+	c.Assert(err, check.IsNil)
+	defer backEndPool.terminate()
+
+	normalSorter := &UnifiedSorter{metricsInfo: &metricsInfo{changeFeedID: "cf-normal"}}
+	normalSorter.SetPriority(model.PriorityNormal)
+	lowSorter := &UnifiedSorter{metricsInfo: &metricsInfo{changeFeedID: "cf-low"}}
+	lowSorter.SetPriority(model.PriorityLow)
+
+	// the memory pressure is acceptable for a normal priority changefeed.
+	backEnd, err := backEndPool.alloc(context.WithValue(context.Background(), ctxKey{}, normalSorter))
+	c.Assert(err, check.IsNil)
+	c.Assert(backEnd, check.FitsTypeOf, &memoryBackEnd{})
+	c.Assert(backEndPool.dealloc(backEnd), check.IsNil)
+
+	// a low priority changefeed spills to disk earlier.
+	lowCtx := context.WithValue(context.Background(), ctxKey{}, lowSorter)
+	backEnd, err = backEndPool.alloc(lowCtx)
+	c.Assert(err, check.IsNil)
+	c.Assert(backEnd, check.FitsTypeOf, &fileBackEnd{})
+	c.Assert(backEnd.(*fileBackEnd).changefeedDiskUsage, check.Equals, backEndPool.getChangefeedDiskUsage("cf-low"))
+	c.Assert(backEndPool.dealloc(backEnd), check.IsNil)
+
+	// the allocation doesn't fail once the disk quota of the changefeed is
+	// exceeded, which is enforced by pausing the puller.
+	atomic.StoreInt64(backEndPool.getChangefeedDiskUsage("cf-low"), 1024)
+	backEnd, err = backEndPool.alloc(lowCtx)
+	c.Assert(err, check.IsNil)
+	c.Assert(backEnd, check.FitsTypeOf, &fileBackEnd{})
+	c.Assert(backEndPool.dealloc(backEnd), check.IsNil)
+	// the memory backEnd is still used if the memory is sufficient.
+	backEnd, err = backEndPool.alloc(context.WithValue(context.Background(), ctxKey{}, normalSorter))
+	c.Assert(err, check.IsNil)
+	c.Assert(backEnd, check.FitsTypeOf, &memoryBackEnd{})
+	c.Assert(backEndPool.dealloc(backEnd), check.IsNil)
+}
+
 // TestDirectoryBadPermission verifies that no permission to ls the directory does not prevent using it
 // as a temporary file directory.
 func (s *backendPoolSuite) TestDirectoryBadPermission(c *check.C) {
@@ -348,3 +407,52 @@ func (s *backendPoolSuite) TestGetMemoryPressureFailure(c *check.C) {
 		}
 	}
 }
+
+func (s *backendPoolSuite) TestMaxDiskConsumption(c *check.C) {
+	defer testleak.AfterTest(c)()
+
+	dataDir := c.MkDir()
+	sortDir := filepath.Join(dataDir, config.DefaultSortDir)
+	err := os.MkdirAll(sortDir, 0o755)
+	c.Assert(err, check.IsNil)
+
+	conf := config.GetDefaultServerConfig()
+	conf.DataDir = dataDir
+	conf.Sorter.SortDir = sortDir
+	conf.Sorter.MaxMemoryPressure = 90                         // 90%
+	conf.Sorter.MaxMemoryConsumption = 16 * 1024 * 1024 * 1024 // 16G
+	conf.Sorter.MaxDiskConsumption = 4096
+	config.StoreGlobalServerConfig(conf)
+
+	err = failpoint.Enable("github.com/pingcap/ticdc/cdc/puller/sorter/memoryPressureInjectPoint", "return(100)")
+	c.Assert(err, check.IsNil)
+	defer failpoint.Disable("github.com/pingcap/ticdc/cdc/puller/sorter/memoryPressureInjectPoint") //nolint:errcheck
+
+	backEndPool, err := newBackEndPool(sortDir, "")
+	c.Assert(err, check.IsNil)
+	defer backEndPool.terminate()
+
+	sorter := &UnifiedSorter{metricsInfo: &metricsInfo{changeFeedID: "cf"}}
+	ctx := context.WithValue(context.Background(), ctxKey{}, sorter)
+
+	// the data written is accounted to the table.
+	backEnd, err := backEndPool.alloc(ctx)
+	c.Assert(err, check.IsNil)
+	c.Assert(backEnd, check.FitsTypeOf, &fileBackEnd{})
+	w, err := backEnd.writer()
+	c.Assert(err, check.IsNil)
+	err = w.writeNext(model.NewPolymorphicEvent(generateMockRawKV(1)))
+	c.Assert(err, check.IsNil)
+	c.Assert(w.flushAndClose(), check.IsNil)
+	c.Assert(atomic.LoadInt64(&sorter.diskUsage), check.Greater, int64(0))
+	c.Assert(atomic.LoadInt64(&sorter.diskUsage), check.Equals, atomic.LoadInt64(backEndPool.getChangefeedDiskUsage("cf")))
+	c.Assert(backEndPool.dealloc(backEnd), check.IsNil)
+	c.Assert(atomic.LoadInt64(&sorter.diskUsage), check.Equals, int64(0))
+
+	// the allocation fails instead of falling back to the memory backEnd
+	// once max-disk-consumption is reached.
+	atomic.StoreInt64(&backEndPool.onDiskDataSize, 4096)
+	_, err = backEndPool.alloc(ctx)
+	c.Assert(cerrors.ErrUnifiedSorterDiskQuotaExceeded.Equal(err), check.IsTrue)
+	atomic.StoreInt64(&backEndPool.onDiskDataSize, 0)
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sorter
+
+import (
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerrors "github.com/pingcap/ticdc/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// compressionType is stored in the header of a file, so that the blocks
+// can be decompressed without knowing the config used by the writer.
+type compressionType = byte
+
+const (
+	compressionNone compressionType = iota
+	compressionSnappy
+	compressionZstd
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+// initZstd creates the zstd encoder and decoder shared by all files.
+// Both of them are safe for concurrent use by EncodeAll and DecodeAll.
+func initZstd() {
+	zstdOnce.Do(func() {
+		var err error
+		zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+		if err != nil {
+			log.Panic("failed to create zstd encoder", zap.Error(err))
+		}
+		zstdDecoder, err = zstd.NewReader(nil)
+		if err != nil {
+			log.Panic("failed to create zstd decoder", zap.Error(err))
+		}
+	})
+}
+
+// parseCompression converts the compression in the sorter config to compressionType.
+func parseCompression(compression string) (compressionType, error) {
+	switch compression {
+	case "", config.SorterCompressionNone:
+		return compressionNone, nil
+	case config.SorterCompressionSnappy:
+		return compressionSnappy, nil
+	case config.SorterCompressionZstd:
+		initZstd()
+		return compressionZstd, nil
+	default:
+		return compressionNone, cerrors.ErrIllegalUnifiedSorterParameter.GenWithStackByArgs(
+			"unknown compression " + compression)
+	}
+}
+
+// compressBlock compresses src into dst. It returns src itself if
+// the compression is disabled.
+func compressBlock(tp compressionType, dst, src []byte) []byte {
+	switch tp {
+	case compressionSnappy:
+		return snappy.Encode(dst[:cap(dst)], src)
+	case compressionZstd:
+		return zstdEncoder.EncodeAll(src, dst[:0])
+	default:
+		return src
+	}
+}
+
+// decompressBlock decompresses src into dst. It returns src itself if
+// the compression is disabled.
+func decompressBlock(tp compressionType, dst, src []byte) ([]byte, error) {
+	switch tp {
+	case compressionNone:
+		return src, nil
+	case compressionSnappy:
+		ret, err := snappy.Decode(dst[:cap(dst)], src)
+		return ret, errors.Trace(err)
+	case compressionZstd:
+		initZstd()
+		ret, err := zstdDecoder.DecodeAll(src, dst[:0])
+		return ret, errors.Trace(err)
+	default:
+		return nil, errors.Errorf("unknown compression type %d", tp)
+	}
+}
@@ -0,0 +1,738 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sorter
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/util"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	lutil "github.com/syndtr/goleveldb/leveldb/util"
+	"go.uber.org/zap"
+)
+
+const (
+	dbSorterDirName = "db-sorter"
+	// dbSorterBatchSize is the number of writes or deletes buffered
+	// before they are committed to the db.
+	dbSorterBatchSize = 256
+	// dbSorterKeyPrefixLen is the length of sorterID | commitTs | startTs | opType.
+	dbSorterKeyPrefixLen = 8 + 8 + 8 + 1
+	// dbSorterManifestTTL is how long the persisted data of a table is kept
+	// after the table was last sorted on the capture.
+	dbSorterManifestTTL = 24 * time.Hour
+)
+
+var (
+	// sharedDB is the db shared by all DBSorters on the capture.
+	sharedDB   *leveldb.DB
+	sharedDBMu sync.Mutex
+
+	// dbSorterIDAllocator allocates the key space of each DBSorter. Table IDs
+	// are not unique on a capture, because a table can be replicated by more
+	// than one changefeed, so a DBSorter is identified by the allocated ID.
+	dbSorterIDAllocator uint64
+
+	// activeDBSorters records the manifest keys of the running DBSorters
+	// which persist their data. It is protected by sharedDBMu.
+	activeDBSorters = make(map[string]struct{})
+
+	// dbSorterManifestUpdateInterval is the min interval between two writes
+	// of the manifest of a table, the manifest is also written when the
+	// DBSorter exits.
+	dbSorterManifestUpdateInterval = time.Second
+)
+
+// dbSorterManifestID is the key space of the manifests. The IDs allocated to
+// DBSorters start from 1.
+const dbSorterManifestID = 0
+
+// dbSorterManifest records the persisted data of a table. All events with
+// commitTs in (StartTs, ResolvedTs] are persisted in the key space of SorterID.
+type dbSorterManifest struct {
+	SorterID   uint64 `json:"sorter-id"`
+	StartTs    uint64 `json:"start-ts"`
+	ResolvedTs uint64 `json:"resolved-ts"`
+	UpdateTime int64  `json:"update-time"`
+	// Fingerprint is the DBSorterFingerprint of the changefeed when the
+	// events were pulled.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// covers returns whether the table can be resumed from the persisted data
+// if it is replicated from startTs with the fingerprint.
+func (m *dbSorterManifest) covers(startTs uint64, fingerprint string) bool {
+	return m.StartTs <= startTs && startTs <= m.ResolvedTs && m.Fingerprint == fingerprint
+}
+
+// DBSorterFingerprint returns the fingerprint of the changefeed settings
+// deciding the events pulled from TiKV, which are whether the old values are
+// requested and the filter. The persisted data of a table is discarded if it
+// was pulled with another fingerprint.
+func DBSorterFingerprint(oldValue bool, cfg *config.ReplicaConfig) string {
+	// the filter config only contains strings, numbers and bools, so it can
+	// always be encoded.
+	data, _ := json.Marshal(struct {
+		OldValue      bool                 `json:"old-value"`
+		CaseSensitive bool                 `json:"case-sensitive"`
+		Filter        *config.FilterConfig `json:"filter"`
+	}{
+		OldValue:      oldValue,
+		CaseSensitive: cfg.CaseSensitive,
+		Filter:        cfg.Filter,
+	})
+	h := fnv.New64a()
+	h.Write(data) //nolint:errcheck
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// DBSorter sorts the events of a table in an embedded ordered key-value store,
+// which is shared by all tables on the capture. Events are written as
+// `sorterID | commitTs | startTs | opType | key`, so that the events of a
+// table can be read by a range scan up to the resolved ts.
+//
+// If persistence is enabled, the events are kept until the checkpoint ts of
+// the table passes them, and a manifest records the ts range of the persisted
+// events, so that the table can be resumed from the persisted data after
+// the capture restarts.
+type DBSorter struct {
+	id          uint64
+	db          *leveldb.DB
+	inputCh     chan *model.PolymorphicEvent
+	outputCh    chan *model.PolymorphicEvent
+	metricsInfo *metricsInfo
+	closeCh     chan struct{}
+
+	startTs     uint64
+	resumeTs    uint64
+	fingerprint string
+	// lastOutputTs is the resolved ts up to which the events have been sent.
+	lastOutputTs uint64
+	checkpointTs uint64
+
+	// manifestKey is nil if the data of the sorter is not persisted.
+	manifestKey []byte
+	manifest    dbSorterManifest
+	// manifestDirty is true if the manifest has changed since it was written.
+	manifestDirty     bool
+	manifestWrittenAt time.Time
+}
+
+// NewDBSorter creates a new DBSorter. The shared db is created in
+// the sort dir the first time a DBSorter is created.
+func NewDBSorter(
+	dir string,
+	changeFeedID model.ChangeFeedID,
+	tableName string,
+	tableID model.TableID,
+	captureAddr string) (*DBSorter, error) {
+	sharedDBMu.Lock()
+	defer sharedDBMu.Unlock()
+
+	db, err := getSharedDB(dir)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return &DBSorter{
+		id:       atomic.AddUint64(&dbSorterIDAllocator, 1),
+		db:       db,
+		inputCh:  make(chan *model.PolymorphicEvent, inputChSize),
+		outputCh: make(chan *model.PolymorphicEvent, outputChSize),
+		metricsInfo: &metricsInfo{
+			changeFeedID: changeFeedID,
+			tableName:    tableName,
+			tableID:      tableID,
+			captureAddr:  captureAddr,
+		},
+		closeCh: make(chan struct{}, 1),
+	}, nil
+}
+
+// SetResumeTs sets the start ts of the table and the ts returned by
+// DBSorterResumeTs, from which the table is pulled from TiKV. If resumeTs
+// is greater than startTs, the persisted events in (startTs, resumeTs] are
+// sent before any other events. The fingerprint must be the one passed to
+// DBSorterResumeTs. It must be called before Run.
+func (s *DBSorter) SetResumeTs(startTs, resumeTs uint64, fingerprint string) {
+	s.startTs = startTs
+	s.resumeTs = resumeTs
+	s.fingerprint = fingerprint
+}
+
+// SetCheckpointTs sets the checkpoint ts of the table, the persisted events
+// not newer than it are deleted.
+func (s *DBSorter) SetCheckpointTs(checkpointTs uint64) {
+	atomic.StoreUint64(&s.checkpointTs, checkpointTs)
+}
+
+// DBSorterResumeTs returns the ts from which a table replicated from startTs
+// should be pulled from TiKV. It is the resolved ts of the persisted data of
+// the table if the data covers startTs and has the same fingerprint,
+// otherwise startTs itself.
+func DBSorterResumeTs(
+	dir string, changeFeedID model.ChangeFeedID, tableID model.TableID, startTs uint64, fingerprint string,
+) uint64 {
+	if !config.GetGlobalServerConfig().Sorter.EnablePersistence {
+		return startTs
+	}
+	key := encodeManifestKey(changeFeedID, tableID)
+
+	sharedDBMu.Lock()
+	db, err := getSharedDB(dir)
+	_, active := activeDBSorters[string(key)]
+	sharedDBMu.Unlock()
+	if err != nil {
+		log.Warn("DB Sorter: failed to open the shared db, the table is not resumed",
+			zap.String("changefeed", changeFeedID), zap.Int64("tableID", tableID), zap.Error(err))
+		return startTs
+	}
+	if active {
+		return startTs
+	}
+
+	manifest, found, err := loadManifest(db, key)
+	if err != nil {
+		log.Warn("DB Sorter: failed to load the manifest, the table is not resumed",
+			zap.String("changefeed", changeFeedID), zap.Int64("tableID", tableID), zap.Error(err))
+		return startTs
+	}
+	if !found || !manifest.covers(startTs, fingerprint) {
+		return startTs
+	}
+	return manifest.ResolvedTs
+}
+
+// getSharedDB opens the shared db if it is not opened. sharedDBMu must be held.
+func getSharedDB(dir string) (*leveldb.DB, error) {
+	if sharedDB != nil {
+		return sharedDB, nil
+	}
+	sorterConfig := config.GetGlobalServerConfig().Sorter
+	if sorterConfig.SortDir != "" {
+		// Let the local setting override the changefeed setting
+		dir = sorterConfig.SortDir
+	}
+	db, err := openSharedDB(filepath.Join(dir, dbSorterDirName), sorterConfig.EnablePersistence)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	sharedDB = db
+	return sharedDB, nil
+}
+
+func openSharedDB(dir string, persistent bool) (*leveldb.DB, error) {
+	if !persistent {
+		// The events left by the last run of the capture are useless, because
+		// all tables are replicated from the checkpoint again after a restart.
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+		}
+	}
+	db, err := leveldb.OpenFile(dir, &opt.Options{
+		// Events are written in batches and deleted soon after they are
+		// resolved, a larger write buffer reduces the data being compacted.
+		WriteBuffer: 32 * opt.MiB,
+	})
+	if err != nil {
+		return nil, cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+	if persistent {
+		if err := cleanUpStaleDBData(db); err != nil {
+			db.Close() //nolint:errcheck
+			return nil, errors.Trace(err)
+		}
+	}
+	log.Info("DB Sorter: shared db opened", zap.String("dir", dir), zap.Bool("persistent", persistent))
+	return db, nil
+}
+
+// cleanUpStaleDBData deletes the expired manifests and the events which are
+// not referred to by any manifest. It also makes sure that the IDs allocated
+// later do not conflict with the persisted data.
+func cleanUpStaleDBData(db *leveldb.DB) error {
+	liveIDs := make(map[uint64]struct{})
+	maxID := uint64(dbSorterManifestID)
+	batch := new(leveldb.Batch)
+
+	iter := db.NewIterator(lutil.BytesPrefix(encodeDBKeyPrefix(nil, dbSorterManifestID, 0)[:8]), nil)
+	for iter.Next() {
+		var manifest dbSorterManifest
+		if err := json.Unmarshal(iter.Value(), &manifest); err != nil {
+			iter.Release()
+			return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+		}
+		if manifest.SorterID > maxID {
+			maxID = manifest.SorterID
+		}
+		if time.Since(time.Unix(manifest.UpdateTime, 0)) > dbSorterManifestTTL {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+			continue
+		}
+		liveIDs[manifest.SorterID] = struct{}{}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+	if err := db.Write(batch, nil); err != nil {
+		return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+
+	// skip the key space of each live sorter, and delete the others.
+	iter = db.NewIterator(&lutil.Range{Start: encodeDBKeyPrefix(nil, dbSorterManifestID+1, 0)}, nil)
+	defer iter.Release()
+	batch.Reset()
+	deleted := 0
+	for ok := iter.First(); ok; {
+		id := binary.BigEndian.Uint64(iter.Key())
+		if id > maxID {
+			maxID = id
+		}
+		if _, live := liveIDs[id]; live {
+			ok = iter.Seek(encodeDBKeyPrefix(nil, id+1, 0))
+			continue
+		}
+		batch.Delete(append([]byte(nil), iter.Key()...))
+		deleted++
+		if batch.Len() >= dbSorterBatchSize {
+			if err := db.Write(batch, nil); err != nil {
+				return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+			}
+			batch.Reset()
+		}
+		ok = iter.Next()
+	}
+	if err := iter.Error(); err != nil {
+		return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+	if err := db.Write(batch, nil); err != nil {
+		return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+
+	for {
+		current := atomic.LoadUint64(&dbSorterIDAllocator)
+		if current >= maxID || atomic.CompareAndSwapUint64(&dbSorterIDAllocator, current, maxID) {
+			break
+		}
+	}
+	log.Info("DB Sorter: stale data cleaned up",
+		zap.Int("live-tables", len(liveIDs)), zap.Int("deleted-events", deleted))
+	return nil
+}
+
+// DBSorterCleanUp closes the shared db. The files are removed unless the
+// persistence is enabled.
+func DBSorterCleanUp() {
+	sharedDBMu.Lock()
+	defer sharedDBMu.Unlock()
+
+	if sharedDB == nil {
+		return
+	}
+	log.Info("DB Sorter: starting cleaning up files")
+	if err := sharedDB.Close(); err != nil {
+		log.Warn("DB Sorter: failed to close the shared db", zap.Error(err))
+	}
+	sharedDB = nil
+	sorterConfig := config.GetGlobalServerConfig().Sorter
+	if sorterConfig.SortDir != "" && !sorterConfig.EnablePersistence {
+		if err := os.RemoveAll(filepath.Join(sorterConfig.SortDir, dbSorterDirName)); err != nil {
+			log.Warn("DB Sorter: failed to remove files", zap.Error(err))
+		}
+	}
+}
+
+// encodeManifestKey encodes the key of the manifest of a table.
+func encodeManifestKey(changeFeedID model.ChangeFeedID, tableID model.TableID) []byte {
+	buf := make([]byte, 8, 8+len(changeFeedID)+1+8)
+	binary.BigEndian.PutUint64(buf, dbSorterManifestID)
+	buf = append(buf, changeFeedID...)
+	buf = append(buf, 0)
+	buf = append(buf, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(buf[len(buf)-8:], uint64(tableID))
+	return buf
+}
+
+// loadManifest reads the manifest of a table from the db.
+func loadManifest(db *leveldb.DB, key []byte) (dbSorterManifest, bool, error) {
+	var manifest dbSorterManifest
+	value, err := db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return manifest, false, nil
+	}
+	if err != nil {
+		return manifest, false, cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+	if err := json.Unmarshal(value, &manifest); err != nil {
+		return manifest, false, cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+	return manifest, true, nil
+}
+
+// encodeDBKeyPrefix encodes the part of the key that sorts events of a sorter
+// by commitTs. It is also used as the boundary of range scans.
+func encodeDBKeyPrefix(buf []byte, sorterID uint64, commitTs uint64) []byte {
+	buf = buf[:0]
+	buf = append(buf, make([]byte, 16)...)
+	binary.BigEndian.PutUint64(buf, sorterID)
+	binary.BigEndian.PutUint64(buf[8:], commitTs)
+	return buf
+}
+
+// encodeDBKey encodes the key of a row changed event. Deletions are placed
+// before the other events of the same transaction, the same as the order
+// produced by the Unified Sorter.
+func encodeDBKey(sorterID uint64, raw *model.RawKVEntry) []byte {
+	buf := make([]byte, 0, dbSorterKeyPrefixLen+len(raw.Key))
+	buf = encodeDBKeyPrefix(buf, sorterID, raw.CRTs)
+	buf = append(buf, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(buf[16:], raw.StartTs)
+	if raw.OpType == model.OpTypeDelete {
+		buf = append(buf, 0)
+	} else {
+		buf = append(buf, 1)
+	}
+	return append(buf, raw.Key...)
+}
+
+// Run implements the EventSorter interface
+func (s *DBSorter) Run(ctx context.Context) error {
+	defer close(s.closeCh)
+	defer s.cleanUp()
+
+	finish := util.MonitorCancelLatency(ctx, "DB Sorter")
+	defer finish()
+
+	if err := s.resume(ctx); err != nil {
+		return errors.Trace(err)
+	}
+
+	captureAddr := s.metricsInfo.captureAddr
+	changefeedID := s.metricsInfo.changeFeedID
+	metricSorterConsumeCount := sorterConsumeCount.MustCurryWith(map[string]string{
+		"capture":    captureAddr,
+		"changefeed": changefeedID,
+	})
+	metricSorterResolvedTsGauge := sorterResolvedTsGauge.WithLabelValues(captureAddr, changefeedID)
+
+	serde := &msgPackGenSerde{}
+	batch := new(leveldb.Batch)
+	for {
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case event := <-s.inputCh:
+			if event.RawKV.OpType == model.OpTypeResolved {
+				metricSorterConsumeCount.WithLabelValues("resolved").Inc()
+				if err := s.write(batch); err != nil {
+					return errors.Trace(err)
+				}
+				if err := s.output(ctx, event.CRTs); err != nil {
+					return errors.Trace(err)
+				}
+				if err := s.updateManifest(event.CRTs); err != nil {
+					return errors.Trace(err)
+				}
+				select {
+				case <-ctx.Done():
+					return errors.Trace(ctx.Err())
+				case s.outputCh <- event:
+				}
+				metricSorterResolvedTsGauge.Set(float64(event.CRTs))
+				continue
+			}
+
+			metricSorterConsumeCount.WithLabelValues("kv").Inc()
+			value, err := serde.marshal(event, nil)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			batch.Put(encodeDBKey(s.id, event.RawKV), value)
+			if batch.Len() >= dbSorterBatchSize {
+				if err := s.write(batch); err != nil {
+					return errors.Trace(err)
+				}
+			}
+		}
+	}
+}
+
+// output sends all events with commitTs in (lastOutputTs, resolvedTs] in
+// order. The events are deleted from the db unless they are persisted.
+func (s *DBSorter) output(ctx context.Context, resolvedTs uint64) error {
+	metricSorterEventCount := sorterEventCount.MustCurryWith(map[string]string{
+		"capture":    s.metricsInfo.captureAddr,
+		"changefeed": s.metricsInfo.changeFeedID,
+	})
+	metricSorterEventCount.WithLabelValues("resolved").Inc()
+	if resolvedTs <= s.lastOutputTs {
+		return nil
+	}
+
+	iter := s.db.NewIterator(&lutil.Range{
+		Start: encodeDBKeyPrefix(nil, s.id, s.lastOutputTs+1),
+		Limit: encodeDBKeyPrefix(nil, s.id, resolvedTs+1),
+	}, nil)
+	defer iter.Release()
+
+	serde := &msgPackGenSerde{}
+	gc := new(leveldb.Batch)
+	count := 0
+	for iter.Next() {
+		event := new(model.PolymorphicEvent)
+		if _, err := serde.unmarshal(event, iter.Value()); err != nil {
+			return errors.Trace(err)
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case s.outputCh <- event:
+		}
+		count++
+		if s.manifestKey != nil {
+			continue
+		}
+		gc.Delete(append([]byte(nil), iter.Key()...))
+		if gc.Len() >= dbSorterBatchSize {
+			if err := s.write(gc); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+	metricSorterEventCount.WithLabelValues("kv").Add(float64(count))
+	s.lastOutputTs = resolvedTs
+	return errors.Trace(s.write(gc))
+}
+
+// resume sets up the persistence of the sorter, and sends the persisted
+// events if the table is resumed from them.
+func (s *DBSorter) resume(ctx context.Context) error {
+	s.lastOutputTs = s.startTs
+	if !config.GetGlobalServerConfig().Sorter.EnablePersistence {
+		return nil
+	}
+
+	key := encodeManifestKey(s.metricsInfo.changeFeedID, s.metricsInfo.tableID)
+	sharedDBMu.Lock()
+	_, active := activeDBSorters[string(key)]
+	if !active {
+		activeDBSorters[string(key)] = struct{}{}
+	}
+	sharedDBMu.Unlock()
+	if active {
+		if s.resumeTs > s.startTs {
+			return cerror.ErrDBSorterIOError.GenWithStackByArgs("the persisted data of the table is in use")
+		}
+		log.Warn("DB Sorter: the table is being sorted by another sorter, the data is not persisted",
+			zap.String("changefeed", s.metricsInfo.changeFeedID),
+			zap.String("table", s.metricsInfo.tableName))
+		return nil
+	}
+	s.manifestKey = key
+
+	manifest, found, err := loadManifest(s.db, key)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if s.resumeTs > s.startTs {
+		// The puller has started from resumeTs, so the persisted data must be used.
+		if !found || !manifest.covers(s.startTs, s.fingerprint) || manifest.ResolvedTs != s.resumeTs {
+			return cerror.ErrDBSorterIOError.GenWithStackByArgs("the persisted data of the table has changed")
+		}
+		s.id = manifest.SorterID
+		s.manifest = manifest
+		log.Info("DB Sorter: resuming the table from the persisted data",
+			zap.String("changefeed", s.metricsInfo.changeFeedID),
+			zap.String("table", s.metricsInfo.tableName),
+			zap.Uint64("startTs", s.startTs),
+			zap.Uint64("resolvedTs", manifest.ResolvedTs))
+		if err := s.output(ctx, manifest.ResolvedTs); err != nil {
+			return errors.Trace(err)
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case s.outputCh <- model.NewResolvedPolymorphicEvent(0, manifest.ResolvedTs):
+		}
+		return nil
+	}
+
+	if found {
+		// The persisted data can not be used, reuse the key space after
+		// deleting the data.
+		s.id = manifest.SorterID
+		if err := s.deleteRange(encodeDBKeyPrefix(nil, s.id, 0)[:8], nil); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	s.manifest = dbSorterManifest{
+		SorterID:    s.id,
+		StartTs:     s.startTs,
+		ResolvedTs:  s.startTs,
+		Fingerprint: s.fingerprint,
+	}
+	return errors.Trace(s.writeManifest())
+}
+
+// updateManifest advances the resolved ts of the persisted data, the manifest
+// is written at most once per dbSorterManifestUpdateInterval.
+func (s *DBSorter) updateManifest(resolvedTs uint64) error {
+	if s.manifestKey == nil {
+		return nil
+	}
+	if resolvedTs > s.manifest.ResolvedTs {
+		s.manifest.ResolvedTs = resolvedTs
+		s.manifestDirty = true
+	}
+	if time.Since(s.manifestWrittenAt) < dbSorterManifestUpdateInterval {
+		return nil
+	}
+	return errors.Trace(s.flushManifest())
+}
+
+// flushManifest writes the manifest, and deletes the events not newer than
+// the checkpoint ts.
+func (s *DBSorter) flushManifest() error {
+	checkpointTs := atomic.LoadUint64(&s.checkpointTs)
+	if checkpointTs > s.manifest.ResolvedTs {
+		checkpointTs = s.manifest.ResolvedTs
+	}
+	if checkpointTs <= s.manifest.StartTs {
+		if !s.manifestDirty {
+			return nil
+		}
+		return errors.Trace(s.writeManifest())
+	}
+	// The manifest is written before the events are deleted, so that it
+	// never covers any deleted events.
+	s.manifest.StartTs = checkpointTs
+	if err := s.writeManifest(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.deleteRange(
+		encodeDBKeyPrefix(nil, s.id, 0), encodeDBKeyPrefix(nil, s.id, checkpointTs+1)))
+}
+
+func (s *DBSorter) writeManifest() error {
+	s.manifest.UpdateTime = time.Now().Unix()
+	value, err := json.Marshal(&s.manifest)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := s.db.Put(s.manifestKey, value, nil); err != nil {
+		return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+	s.manifestDirty = false
+	s.manifestWrittenAt = time.Now()
+	return nil
+}
+
+// write commits the batch to the db and resets it.
+func (s *DBSorter) write(batch *leveldb.Batch) error {
+	if batch.Len() == 0 {
+		return nil
+	}
+	err := s.db.Write(batch, nil)
+	batch.Reset()
+	if err != nil {
+		return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+	return nil
+}
+
+// cleanUp deletes all events of the sorter from the db, unless they are
+// persisted, in which case the manifest is written if it has changed.
+func (s *DBSorter) cleanUp() {
+	if s.manifestKey != nil {
+		if s.manifestDirty {
+			if err := s.flushManifest(); err != nil {
+				log.Warn("DB Sorter: failed to write the manifest",
+					zap.String("changefeed", s.metricsInfo.changeFeedID),
+					zap.String("table", s.metricsInfo.tableName),
+					zap.Error(err))
+			}
+		}
+		sharedDBMu.Lock()
+		delete(activeDBSorters, string(s.manifestKey))
+		sharedDBMu.Unlock()
+		return
+	}
+	prefix := make([]byte, 8)
+	binary.BigEndian.PutUint64(prefix, s.id)
+	if err := s.deleteRange(prefix, nil); err != nil {
+		log.Warn("DB Sorter: failed to clean up table events",
+			zap.String("changefeed", s.metricsInfo.changeFeedID),
+			zap.String("table", s.metricsInfo.tableName),
+			zap.Error(err))
+	}
+}
+
+// deleteRange deletes the events in [start, limit) from the db. If limit is
+// nil, start is used as a key prefix.
+func (s *DBSorter) deleteRange(start, limit []byte) error {
+	r := &lutil.Range{Start: start, Limit: limit}
+	if limit == nil {
+		r = lutil.BytesPrefix(start)
+	}
+	iter := s.db.NewIterator(r, nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+		if batch.Len() >= dbSorterBatchSize {
+			if err := s.write(batch); err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return cerror.ErrDBSorterIOError.GenWithStackByArgs(err.Error())
+	}
+	return errors.Trace(s.write(batch))
+}
+
+// AddEntry implements the EventSorter interface
+func (s *DBSorter) AddEntry(ctx context.Context, entry *model.PolymorphicEvent) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-s.closeCh:
+	case s.inputCh <- entry:
+	}
+}
+
+// Output implements the EventSorter interface
+func (s *DBSorter) Output() <-chan *model.PolymorphicEvent {
+	return s.outputCh
+}
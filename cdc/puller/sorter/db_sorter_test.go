@@ -0,0 +1,270 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sorter
+
+import (
+	"context"
+	"encoding/binary"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	lutil "github.com/syndtr/goleveldb/leveldb/util"
+	"golang.org/x/sync/errgroup"
+)
+
+type dbSorterSuite struct{}
+
+var _ = check.SerialSuites(&dbSorterSuite{})
+
+func (s *dbSorterSuite) TestEncodeDBKey(c *check.C) {
+	defer testleak.AfterTest(c)()
+
+	put := &model.RawKVEntry{OpType: model.OpTypePut, Key: []byte("a"), StartTs: 1, CRTs: 3}
+	del := &model.RawKVEntry{OpType: model.OpTypeDelete, Key: []byte("b"), StartTs: 1, CRTs: 3}
+	later := &model.RawKVEntry{OpType: model.OpTypeDelete, Key: []byte("a"), StartTs: 2, CRTs: 4}
+
+	c.Assert(encodeDBKey(1, put)[:16], check.DeepEquals, encodeDBKeyPrefix(nil, 1, 3))
+	// deletions go first in a transaction.
+	c.Assert(string(encodeDBKey(1, del)) < string(encodeDBKey(1, put)), check.IsTrue)
+	c.Assert(string(encodeDBKey(1, put)) < string(encodeDBKey(1, later)), check.IsTrue)
+	// events of different sorters never interleave.
+	c.Assert(string(encodeDBKey(1, later)) < string(encodeDBKey(2, put)), check.IsTrue)
+	c.Assert(binary.BigEndian.Uint64(encodeDBKey(1, put)[16:24]), check.Equals, uint64(1))
+}
+
+func (s *dbSorterSuite) TestDBSorterBasic(c *check.C) {
+	defer testleak.AfterTest(c)()
+	defer DBSorterCleanUp()
+
+	conf := config.GetDefaultServerConfig()
+	conf.DataDir = c.MkDir()
+	conf.Sorter.SortDir = filepath.Join(conf.DataDir, config.DefaultSortDir)
+	config.StoreGlobalServerConfig(conf)
+	c.Assert(UnifiedSorterCheckDir(conf.Sorter.SortDir), check.IsNil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errg, ctx := errgroup.WithContext(ctx)
+
+	// two sorters of the same table ID share the db without interfering.
+	sorters := make([]*DBSorter, 2)
+	for i := range sorters {
+		sorter, err := NewDBSorter(conf.Sorter.SortDir, "test-cf", "test", 1, "0.0.0.0:0")
+		c.Assert(err, check.IsNil)
+		sorters[i] = sorter
+		errg.Go(func() error {
+			return sorter.Run(ctx)
+		})
+	}
+	c.Assert(sorters[0].db, check.Equals, sorters[1].db)
+
+	for _, sorter := range sorters {
+		for _, ts := range []uint64{7, 3, 5, 9, 3, 1} {
+			raw := generateMockRawKV(ts)
+			raw.Key = []byte{byte(ts)}
+			sorter.AddEntry(ctx, model.NewPolymorphicEvent(raw))
+		}
+		deletion := generateMockRawKV(5)
+		deletion.OpType = model.OpTypeDelete
+		deletion.Key = []byte{0xff}
+		sorter.AddEntry(ctx, model.NewPolymorphicEvent(deletion))
+		sorter.AddEntry(ctx, model.NewResolvedPolymorphicEvent(0, 5))
+	}
+
+	for _, sorter := range sorters {
+		var output []*model.PolymorphicEvent
+		for {
+			event := <-sorter.Output()
+			output = append(output, event)
+			if event.RawKV.OpType == model.OpTypeResolved {
+				break
+			}
+		}
+		// the duplicated event at ts 3 is written only once.
+		c.Assert(output, check.HasLen, 5)
+		expected := []uint64{1, 3, 5, 5, 5}
+		for i, event := range output {
+			c.Assert(event.CRTs, check.Equals, expected[i])
+		}
+		c.Assert(output[2].RawKV.OpType, check.Equals, model.OpTypeDelete)
+		c.Assert(output[3].RawKV.Key, check.DeepEquals, []byte{5})
+		c.Assert(output[4].RawKV.OpType, check.Equals, model.OpTypeResolved)
+	}
+
+	// the output events are deleted from the db.
+	prefix := make([]byte, 8)
+	binary.BigEndian.PutUint64(prefix, sorters[0].id)
+	iter := sorters[0].db.NewIterator(lutil.BytesPrefix(prefix), nil)
+	remaining := 0
+	for iter.Next() {
+		remaining++
+	}
+	iter.Release()
+	c.Assert(remaining, check.Equals, 2)
+
+	cancel()
+	err := errg.Wait()
+	c.Assert(errors.Cause(err), check.Equals, context.Canceled)
+
+	// all events are cleaned up after the sorters exit.
+	iter = sorters[0].db.NewIterator(nil, nil)
+	c.Assert(iter.Next(), check.IsFalse)
+	iter.Release()
+}
+
+func (s *dbSorterSuite) TestDBSorterPersistence(c *check.C) {
+	defer testleak.AfterTest(c)()
+	defer DBSorterCleanUp()
+
+	conf := config.GetDefaultServerConfig()
+	conf.DataDir = c.MkDir()
+	conf.Sorter.SortDir = filepath.Join(conf.DataDir, config.DefaultSortDir)
+	conf.Sorter.EnablePersistence = true
+	config.StoreGlobalServerConfig(conf)
+	c.Assert(UnifiedSorterCheckDir(conf.Sorter.SortDir), check.IsNil)
+
+	fingerprint := DBSorterFingerprint(true, config.GetDefaultReplicaConfig())
+	// runSorter runs a sorter of the table until the resolved ts in the output
+	// reaches resolvedTs, and returns the output row changed events.
+	runSorter := func(startTs, resumeTs, resolvedTs uint64, inputs ...*model.PolymorphicEvent) (*DBSorter, []uint64, func()) {
+		sorter, err := NewDBSorter(conf.Sorter.SortDir, "test-cf", "test", 1, "0.0.0.0:0")
+		c.Assert(err, check.IsNil)
+		sorter.SetResumeTs(startTs, resumeTs, fingerprint)
+		ctx, cancel := context.WithCancel(context.Background())
+		errg, ctx := errgroup.WithContext(ctx)
+		errg.Go(func() error {
+			return sorter.Run(ctx)
+		})
+		for _, event := range inputs {
+			sorter.AddEntry(ctx, event)
+		}
+		var output []uint64
+		for {
+			event := <-sorter.Output()
+			if event.RawKV.OpType != model.OpTypeResolved {
+				output = append(output, event.CRTs)
+				continue
+			}
+			if event.CRTs >= resolvedTs {
+				break
+			}
+		}
+		return sorter, output, func() {
+			cancel()
+			c.Assert(errors.Cause(errg.Wait()), check.Equals, context.Canceled)
+		}
+	}
+	kv := func(ts uint64) *model.PolymorphicEvent {
+		raw := generateMockRawKV(ts)
+		raw.Key = []byte{byte(ts)}
+		return model.NewPolymorphicEvent(raw)
+	}
+
+	sorter, output, stop := runSorter(1, 1, 5,
+		kv(3), kv(7), kv(5), model.NewResolvedPolymorphicEvent(0, 5))
+	c.Assert(output, check.DeepEquals, []uint64{3, 5})
+	// the events are kept until the checkpoint ts passes them.
+	sorter.SetCheckpointTs(3)
+	sorter.AddEntry(context.Background(), model.NewResolvedPolymorphicEvent(0, 6))
+	c.Assert((<-sorter.Output()).CRTs, check.Equals, uint64(6))
+	stop()
+	persistedID := sorter.id
+
+	// restart the capture.
+	DBSorterCleanUp()
+	c.Assert(DBSorterResumeTs(conf.Sorter.SortDir, "test-cf", 1, 3, fingerprint), check.Equals, uint64(6))
+	c.Assert(DBSorterResumeTs(conf.Sorter.SortDir, "test-cf", 1, 4, fingerprint), check.Equals, uint64(6))
+	// the events not newer than the checkpoint ts have been deleted.
+	c.Assert(DBSorterResumeTs(conf.Sorter.SortDir, "test-cf", 1, 2, fingerprint), check.Equals, uint64(2))
+	c.Assert(DBSorterResumeTs(conf.Sorter.SortDir, "test-cf", 2, 3, fingerprint), check.Equals, uint64(3))
+	c.Assert(atomic.LoadUint64(&dbSorterIDAllocator) >= persistedID, check.IsTrue)
+
+	// the persisted events are sent before the events pulled from TiKV.
+	sorter, output, stop = runSorter(3, 6, 8, kv(8), model.NewResolvedPolymorphicEvent(0, 8))
+	c.Assert(sorter.id, check.Equals, persistedID)
+	c.Assert(output, check.DeepEquals, []uint64{5, 7, 8})
+	stop()
+
+	// the persisted data is discarded if it can not be used.
+	c.Assert(DBSorterResumeTs(conf.Sorter.SortDir, "test-cf", 1, 2, fingerprint), check.Equals, uint64(2))
+	_, output, stop = runSorter(2, 2, 9, model.NewResolvedPolymorphicEvent(0, 9))
+	c.Assert(output, check.HasLen, 0)
+	stop()
+	// the data is persisted again from the new start ts, without the discarded events.
+	c.Assert(DBSorterResumeTs(conf.Sorter.SortDir, "test-cf", 1, 5, fingerprint), check.Equals, uint64(9))
+	_, output, stop = runSorter(5, 9, 9)
+	c.Assert(output, check.HasLen, 0)
+	stop()
+
+	// the persisted data is discarded if the events were pulled with
+	// another old value setting or filter.
+	cfg := config.GetDefaultReplicaConfig()
+	c.Assert(DBSorterFingerprint(false, cfg), check.Not(check.Equals), fingerprint)
+	cfg.Filter.Rules = []string{"test.*"}
+	fingerprint = DBSorterFingerprint(true, cfg)
+	c.Assert(DBSorterResumeTs(conf.Sorter.SortDir, "test-cf", 1, 5, fingerprint), check.Equals, uint64(5))
+	_, output, stop = runSorter(5, 5, 10, model.NewResolvedPolymorphicEvent(0, 10))
+	c.Assert(output, check.HasLen, 0)
+	stop()
+	c.Assert(DBSorterResumeTs(conf.Sorter.SortDir, "test-cf", 1, 5, fingerprint), check.Equals, uint64(10))
+}
+
+func (s *dbSorterSuite) TestDBSorterManifestUpdateInterval(c *check.C) {
+	defer testleak.AfterTest(c)()
+	defer DBSorterCleanUp()
+
+	conf := config.GetDefaultServerConfig()
+	conf.DataDir = c.MkDir()
+	conf.Sorter.SortDir = filepath.Join(conf.DataDir, config.DefaultSortDir)
+	conf.Sorter.EnablePersistence = true
+	config.StoreGlobalServerConfig(conf)
+	c.Assert(UnifiedSorterCheckDir(conf.Sorter.SortDir), check.IsNil)
+
+	originInterval := dbSorterManifestUpdateInterval
+	dbSorterManifestUpdateInterval = time.Hour
+	defer func() {
+		dbSorterManifestUpdateInterval = originInterval
+	}()
+
+	sorter, err := NewDBSorter(conf.Sorter.SortDir, "test-cf", "test", 1, "0.0.0.0:0")
+	c.Assert(err, check.IsNil)
+	sorter.SetResumeTs(1, 1, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	errg, ctx := errgroup.WithContext(ctx)
+	errg.Go(func() error {
+		return sorter.Run(ctx)
+	})
+	for ts := uint64(2); ts <= 5; ts++ {
+		sorter.AddEntry(ctx, model.NewResolvedPolymorphicEvent(0, ts))
+		c.Assert((<-sorter.Output()).CRTs, check.Equals, ts)
+	}
+	// the manifest is not written again within the interval.
+	manifest, found, err := loadManifest(sorter.db, sorter.manifestKey)
+	c.Assert(err, check.IsNil)
+	c.Assert(found, check.IsTrue)
+	c.Assert(manifest.ResolvedTs, check.Equals, uint64(1))
+
+	// the manifest is written when the sorter exits.
+	cancel()
+	c.Assert(errors.Cause(errg.Wait()), check.Equals, context.Canceled)
+	manifest, found, err = loadManifest(sorter.db, sorter.manifestKey)
+	c.Assert(err, check.IsNil)
+	c.Assert(found, check.IsTrue)
+	c.Assert(manifest.ResolvedTs, check.Equals, uint64(5))
+}
@@ -0,0 +1,158 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sorter
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// diskQuotaHighWatermark is the ratio of the disk quota of the changefeed,
+	// or of its share of max-disk-consumption, at which the puller is paused.
+	diskQuotaHighWatermark = 0.8
+	diskQuotaCheckInterval = 100 * time.Millisecond
+	// diskQuotaWarnInterval is the interval of the warnings logged while a
+	// puller is paused or the quota is exceeded.
+	diskQuotaWarnInterval = 30 * time.Second
+)
+
+// DiskQuotaLimiter applies back-pressure to the puller of a table when the on
+// disk data size of the changefeed in the Unified Sorter is close to the disk
+// quota of the changefeed, or to its share of max-disk-consumption.
+//
+// The puller is only paused right after a resolved event is sent to the
+// sorter, and only while the sorter has not output that resolved ts. So the
+// sorter can always flush the data below the resolved ts, and the puller is
+// not paused if the data on disk can't be flushed before more events are
+// pulled, e.g. a transaction larger than the quota.
+// Wait must be called by the puller only, and OnSorterOutput by the sorter only.
+type DiskQuotaLimiter struct {
+	captureAddr  string
+	changefeedID string
+
+	// quota is the disk quota of the changefeed, 0 means no limit.
+	quota uint64
+	// usage is the on disk data size of the changefeed, it is taken from
+	// the backEndPool lazily as the pool may not be created yet.
+	usage *int64
+
+	// the last resolved ts sent to and output by the sorter
+	sentResolvedTs   uint64
+	outputResolvedTs uint64
+
+	lastWarnTime time.Time
+	waitDuration prometheus.Observer
+}
+
+// NewDiskQuotaLimiter creates a DiskQuotaLimiter.
+func NewDiskQuotaLimiter(captureAddr, changefeedID string, quota uint64) *DiskQuotaLimiter {
+	return &DiskQuotaLimiter{
+		captureAddr:  captureAddr,
+		changefeedID: changefeedID,
+		quota:        quota,
+		waitDuration: sorterDiskQuotaWaitDuration.WithLabelValues(captureAddr, changefeedID),
+	}
+}
+
+// OnSorterOutput records the resolved ts output by the sorter.
+func (l *DiskQuotaLimiter) OnSorterOutput(resolvedTs uint64) {
+	atomic.StoreUint64(&l.outputResolvedTs, resolvedTs)
+}
+
+// Wait is called after the resolved event of resolvedTs is sent to the sorter.
+// If the disk quota is close, it blocks until the on disk data size drops
+// below the high watermark, which happens as the sinks catch up with the
+// resolved ts, or until the sorter outputs resolvedTs and there is nothing
+// left to flush.
+func (l *DiskQuotaLimiter) Wait(ctx context.Context, resolvedTs uint64) error {
+	atomic.StoreUint64(&l.sentResolvedTs, resolvedTs)
+	if !l.exceeded() {
+		return nil
+	}
+
+	startTime := time.Now()
+	defer func() {
+		l.waitDuration.Observe(time.Since(startTime).Seconds())
+	}()
+
+	ticker := time.NewTicker(diskQuotaCheckInterval)
+	defer ticker.Stop()
+	for l.flushable() {
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-ticker.C:
+		}
+		if !l.exceeded() {
+			return nil
+		}
+		if time.Since(l.lastWarnTime) >= diskQuotaWarnInterval {
+			log.Warn("Unified Sorter: the puller is paused as the on disk data size is close to the limit",
+				zap.String("changefeed", l.changefeedID),
+				zap.Uint64("changefeed-disk-quota", l.quota),
+				zap.Duration("paused", time.Since(startTime)))
+			l.lastWarnTime = time.Now()
+		}
+	}
+	// The data on disk is above the resolved ts, pausing the puller would
+	// prevent the sorter from ever flushing it.
+	if time.Since(l.lastWarnTime) >= diskQuotaWarnInterval {
+		log.Warn("Unified Sorter: the disk quota is exceeded by the data not resolved yet, the puller is not paused",
+			zap.String("changefeed", l.changefeedID),
+			zap.Uint64("changefeed-disk-quota", l.quota),
+			zap.Uint64("resolved-ts", resolvedTs))
+		l.lastWarnTime = time.Now()
+	}
+	return nil
+}
+
+// flushable returns whether the sorter has not output the last resolved ts
+// sent to it, i.e. whether it still has data to flush.
+func (l *DiskQuotaLimiter) flushable() bool {
+	return atomic.LoadUint64(&l.outputResolvedTs) < atomic.LoadUint64(&l.sentResolvedTs)
+}
+
+// exceeded checks the on disk data size of the changefeed against the high
+// watermarks of its disk quota and of its share of max-disk-consumption.
+func (l *DiskQuotaLimiter) exceeded() bool {
+	poolMu.Lock()
+	p := pool
+	poolMu.Unlock()
+	if p == nil {
+		return false
+	}
+	if l.usage == nil {
+		l.usage = p.getChangefeedDiskUsage(l.changefeedID)
+	}
+	usage := float64(atomic.LoadInt64(l.usage))
+	if l.quota != 0 && usage >= float64(l.quota)*diskQuotaHighWatermark {
+		return true
+	}
+	// Only the changefeeds using more than their share of max-disk-consumption
+	// are paused, so that a lagging changefeed doesn't pause the others.
+	maxDiskConsumption := config.GetGlobalServerConfig().Sorter.MaxDiskConsumption
+	if !p.diskConsumptionExceeded(maxDiskConsumption, diskQuotaHighWatermark) {
+		return false
+	}
+	share := float64(maxDiskConsumption) * diskQuotaHighWatermark / float64(p.changefeedsOnDisk())
+	return usage >= share
+}
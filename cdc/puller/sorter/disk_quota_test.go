@@ -0,0 +1,172 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sorter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+)
+
+type diskQuotaSuite struct{}
+
+var _ = check.SerialSuites(&diskQuotaSuite{})
+
+func (s *diskQuotaSuite) TestDiskQuotaLimiter(c *check.C) {
+	defer testleak.AfterTest(c)()
+	defer UnifiedSorterCleanUp()
+
+	dataDir := c.MkDir()
+	sortDir := filepath.Join(dataDir, config.DefaultSortDir)
+	c.Assert(os.MkdirAll(sortDir, 0o755), check.IsNil)
+
+	conf := config.GetDefaultServerConfig()
+	conf.DataDir = dataDir
+	conf.Sorter.SortDir = sortDir
+	conf.Sorter.MaxDiskConsumption = 1000
+	config.StoreGlobalServerConfig(conf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the limiter does nothing before the Unified Sorter is used.
+	limiter := NewDiskQuotaLimiter("0.0.0.0:0", "cf", 0)
+	c.Assert(limiter.Wait(ctx, 1), check.IsNil)
+
+	_, err := NewUnifiedSorter(sortDir, "cf", "test", 1, "0.0.0.0:0")
+	c.Assert(err, check.IsNil)
+	c.Assert(limiter.Wait(ctx, 2), check.IsNil)
+
+	// the puller is paused until the on disk data size drops below the watermark.
+	pool.addOnDiskDataSize(900)
+	atomic.StoreInt64(pool.getChangefeedDiskUsage("cf"), 900)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		pool.addOnDiskDataSize(-200)
+		atomic.AddInt64(pool.getChangefeedDiskUsage("cf"), -200)
+	}()
+	start := time.Now()
+	c.Assert(limiter.Wait(ctx, 3), check.IsNil)
+	c.Assert(time.Since(start), check.Greater, 200*time.Millisecond)
+
+	// the puller is paused as long as the size does not decrease and the
+	// sorter has data to flush.
+	pool.addOnDiskDataSize(200)
+	atomic.AddInt64(pool.getChangefeedDiskUsage("cf"), 200)
+	waitCtx, cancelWait := context.WithTimeout(ctx, 500*time.Millisecond)
+	err = limiter.Wait(waitCtx, 4)
+	cancelWait()
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+
+	// the puller is resumed once the sorter outputs the resolved ts, even if
+	// the size is still over the watermark.
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		limiter.OnSorterOutput(5)
+	}()
+	start = time.Now()
+	c.Assert(limiter.Wait(ctx, 5), check.IsNil)
+	c.Assert(time.Since(start), check.Greater, 200*time.Millisecond)
+
+	// only the changefeeds over their share of max-disk-consumption are paused.
+	otherLimiter := NewDiskQuotaLimiter("0.0.0.0:0", "cf-other", 0)
+	atomic.StoreInt64(pool.getChangefeedDiskUsage("cf-other"), 100)
+	c.Assert(otherLimiter.Wait(ctx, 6), check.IsNil)
+	c.Assert(limiter.exceeded(), check.IsTrue)
+	c.Assert(otherLimiter.exceeded(), check.IsFalse)
+	atomic.StoreInt64(pool.getChangefeedDiskUsage("cf-other"), 0)
+	pool.addOnDiskDataSize(-900)
+	atomic.StoreInt64(pool.getChangefeedDiskUsage("cf"), 0)
+	c.Assert(limiter.Wait(ctx, 7), check.IsNil)
+
+	// the puller is paused when the disk quota of the changefeed is close.
+	cfLimiter := NewDiskQuotaLimiter("0.0.0.0:0", "cf", 100)
+	c.Assert(cfLimiter.Wait(ctx, 1), check.IsNil)
+	usage := pool.getChangefeedDiskUsage("cf")
+	atomic.StoreInt64(usage, 80)
+	waitCtx, cancelWait = context.WithTimeout(ctx, 300*time.Millisecond)
+	err = cfLimiter.Wait(waitCtx, 2)
+	cancelWait()
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+	// other changefeeds are not affected.
+	c.Assert(otherLimiter.Wait(ctx, 8), check.IsNil)
+	atomic.StoreInt64(usage, 0)
+	c.Assert(cfLimiter.Wait(ctx, 3), check.IsNil)
+}
+
+func (s *diskQuotaSuite) TestDiskQuotaLimiterLargeTxn(c *check.C) {
+	defer testleak.AfterTest(c)()
+	defer UnifiedSorterCleanUp()
+
+	dataDir := c.MkDir()
+	sortDir := filepath.Join(dataDir, config.DefaultSortDir)
+	c.Assert(os.MkdirAll(sortDir, 0o755), check.IsNil)
+
+	conf := config.GetDefaultServerConfig()
+	conf.DataDir = dataDir
+	conf.Sorter.SortDir = sortDir
+	conf.Sorter.MaxMemoryConsumption = 0
+	config.StoreGlobalServerConfig(conf)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sorter, err := NewUnifiedSorter(sortDir, "cf", "test", 1, "0.0.0.0:0")
+	c.Assert(err, check.IsNil)
+	limiter := NewDiskQuotaLimiter("0.0.0.0:0", "cf", 100)
+	c.Assert(limiter.Wait(ctx, 1), check.IsNil)
+	limiter.OnSorterOutput(1)
+
+	// a transaction larger than the quota is spilled, as it is not resolved yet.
+	sorterCtx := context.WithValue(ctx, ctxKey{}, sorter)
+	backEnd, err := pool.alloc(sorterCtx)
+	c.Assert(err, check.IsNil)
+	c.Assert(backEnd, check.FitsTypeOf, &fileBackEnd{})
+	w, err := backEnd.writer()
+	c.Assert(err, check.IsNil)
+	for i := 0; i < 100; i++ {
+		err = w.writeNext(model.NewPolymorphicEvent(generateMockRawKV(2)))
+		c.Assert(err, check.IsNil)
+	}
+	c.Assert(w.flushAndClose(), check.IsNil)
+	c.Assert(atomic.LoadInt64(pool.getChangefeedDiskUsage("cf")), check.Greater, int64(100))
+
+	// the puller is not paused, otherwise the resolved event committing the
+	// transaction would never reach the sorter.
+	limiter.OnSorterOutput(1)
+	c.Assert(limiter.exceeded(), check.IsTrue)
+	c.Assert(limiter.Wait(ctx, 1), check.IsNil)
+	// more data of the changefeed can be spilled over the quota.
+	backEnd2, err := pool.alloc(sorterCtx)
+	c.Assert(err, check.IsNil)
+	c.Assert(backEnd2, check.FitsTypeOf, &fileBackEnd{})
+
+	// once the transaction is resolved, the puller is paused until the sorter
+	// flushes it.
+	waitCtx, cancelWait := context.WithTimeout(ctx, 300*time.Millisecond)
+	err = limiter.Wait(waitCtx, 3)
+	cancelWait()
+	c.Assert(errors.Cause(err), check.Equals, context.DeadlineExceeded)
+	c.Assert(pool.dealloc(backEnd), check.IsNil)
+	c.Assert(pool.dealloc(backEnd2), check.IsNil)
+	c.Assert(limiter.Wait(ctx, 3), check.IsNil)
+}
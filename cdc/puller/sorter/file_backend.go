@@ -16,6 +16,8 @@ package sorter
 import (
 	"bufio"
 	"encoding/binary"
+	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
 	"sync/atomic"
@@ -30,21 +32,45 @@ import (
 
 const (
 	fileBufferSize       = 4 * 1024 // 4KB
-	fileMagic            = 0x12345678
+	fileMagic            = 0x12345679
 	numFileEntriesOffset = 4
 	blockMagic           = 0xbeefbeef
+	// blockSizeLimit is the size of serialized events that triggers writing a block.
+	blockSizeLimit = 64 * 1024 // 64KB
+	// fileHeaderSize is the size of fileMagic | number of events | compression type.
+	fileHeaderSize = 13
+	// blockHeaderSize is the size of blockMagic | raw size | data size | crc32.
+	blockHeaderSize = 16
 )
 
-var openFDCount int64
+var (
+	openFDCount int64
 
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// fileBackEnd stores events in a file, the layout of which is:
+//
+//	fileMagic | number of events (uint64) | compression type (uint8) | block...
+//
+// A block consists of a header and the (compressed) data, which are a series of
+// events prefixed by their sizes (uint32). The sizes and the data of each block are
+// validated by the crc32 in the header, to detect a corrupted or truncated file.
 type fileBackEnd struct {
-	fileName string
-	serde    serializerDeserializer
-	borrowed int32
-	size     int64
+	fileName    string
+	serde       serializerDeserializer
+	compression compressionType
+	borrowed    int32
+	size        int64
+	// changefeedDiskUsage is the on disk data size of the changefeed that
+	// is using the backEnd, it can be nil.
+	changefeedDiskUsage *int64
+	// tableDiskUsage is the on disk data size of the table that is using
+	// the backEnd, it can be nil.
+	tableDiskUsage *int64
 }
 
-func newFileBackEnd(fileName string, serde serializerDeserializer) (*fileBackEnd, error) {
+func newFileBackEnd(fileName string, serde serializerDeserializer, compression compressionType) (*fileBackEnd, error) {
 	f, err := os.Create(fileName)
 	if err != nil {
 		return nil, errors.Trace(wrapIOError(err))
@@ -57,9 +83,10 @@ func newFileBackEnd(fileName string, serde serializerDeserializer) (*fileBackEnd
 
 	log.Debug("new FileSorterBackEnd created", zap.String("filename", fileName))
 	return &fileBackEnd{
-		fileName: fileName,
-		serde:    serde,
-		borrowed: 0,
+		fileName:    fileName,
+		serde:       serde,
+		compression: compression,
+		borrowed:    0,
 	}, nil
 }
 
@@ -71,14 +98,13 @@ func (f *fileBackEnd) reader() (backEndReader, error) {
 
 	atomic.AddInt64(&openFDCount, 1)
 
-	var totalSize int64
-	failpoint.Inject("sorterDebug", func() {
-		info, err := fd.Stat()
-		if err != nil {
-			failpoint.Return(nil, errors.Trace(wrapIOError(err)))
-		}
-		totalSize = info.Size()
-	})
+	// the size of the file is used to validate the sizes of blocks.
+	info, err := fd.Stat()
+	if err != nil {
+		_ = fd.Close()
+		atomic.AddInt64(&openFDCount, -1)
+		return nil, errors.Trace(wrapIOError(err))
+	}
 
 	failpoint.Inject("sorterDebug", func() {
 		if atomic.SwapInt32(&f.borrowed, 1) != 0 {
@@ -90,12 +116,12 @@ func (f *fileBackEnd) reader() (backEndReader, error) {
 		backEnd:   f,
 		f:         fd,
 		reader:    bufio.NewReaderSize(fd, fileBufferSize),
-		totalSize: totalSize,
+		totalSize: info.Size(),
 	}
 
 	err = ret.readHeader()
 	if err != nil {
-		return nil, errors.Trace(wrapIOError(err))
+		return nil, errors.Trace(wrapCorruptionError(f.fileName, wrapIOError(err)))
 	}
 
 	return ret, nil
@@ -152,24 +178,50 @@ func (f *fileBackEnd) free() error {
 	return nil
 }
 
+// addSize adds the size of the data written to the file to the stats.
+func (f *fileBackEnd) addSize(size int64) {
+	f.size += size
+	if pool != nil {
+		pool.addOnDiskDataSize(size)
+	}
+	if f.changefeedDiskUsage != nil {
+		atomic.AddInt64(f.changefeedDiskUsage, size)
+	}
+	if f.tableDiskUsage != nil {
+		atomic.AddInt64(f.tableDiskUsage, size)
+	}
+}
+
 func (f *fileBackEnd) cleanStats() {
 	if pool != nil {
-		atomic.AddInt64(&pool.onDiskDataSize, -f.size)
+		pool.addOnDiskDataSize(-f.size)
+	}
+	if f.changefeedDiskUsage != nil {
+		atomic.AddInt64(f.changefeedDiskUsage, -f.size)
+		f.changefeedDiskUsage = nil
+	}
+	if f.tableDiskUsage != nil {
+		atomic.AddInt64(f.tableDiskUsage, -f.size)
+		f.tableDiskUsage = nil
 	}
 	f.size = 0
 }
 
 type fileBackEndReader struct {
-	backEnd *fileBackEnd
-	f       *os.File
-	reader  *bufio.Reader
-	isEOF   bool
+	backEnd     *fileBackEnd
+	f           *os.File
+	reader      *bufio.Reader
+	isEOF       bool
+	compression compressionType
+
+	// block holds the events of the current block that have not been read.
+	block []byte
 
 	// to prevent truncation-like corruption
 	totalEvents uint64
 	readEvents  uint64
 
-	// debug only fields
+	// to prevent reading beyond the end of the file with corrupted sizes
 	readBytes int64
 	totalSize int64
 }
@@ -192,7 +244,8 @@ func (r *fileBackEndReader) readHeader() error {
 		return errors.Trace(err)
 	}
 	if m != fileMagic {
-		log.Panic("fileSorterBackEnd: wrong fileMagic. Damaged file or bug?", zap.Uint32("actual", m))
+		return cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+			fmt.Sprintf("wrong fileMagic %x in file %s", m, r.backEnd.fileName))
 	}
 
 	err = binary.Read(r.reader, binary.LittleEndian, &r.totalEvents)
@@ -200,6 +253,12 @@ func (r *fileBackEndReader) readHeader() error {
 		return errors.Trace(err)
 	}
 
+	err = binary.Read(r.reader, binary.LittleEndian, &r.compression)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	r.readBytes = fileHeaderSize
 	return nil
 }
 
@@ -209,63 +268,117 @@ func (r *fileBackEndReader) readNext() (*model.PolymorphicEvent, error) {
 		return nil, nil
 	}
 
-	var m uint32
-	err := binary.Read(r.reader, binary.LittleEndian, &m)
-	if err != nil {
-		if err == io.EOF {
+	for len(r.block) == 0 {
+		eof, err := r.readBlock()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if eof {
 			r.isEOF = true
 			// verifies that the file has not been truncated unexpectedly.
 			if r.totalEvents != r.readEvents {
-				log.Panic("unexpected EOF",
-					zap.String("file", r.backEnd.fileName),
-					zap.Uint64("expected-num-events", r.totalEvents),
-					zap.Uint64("actual-num-events", r.readEvents))
+				return nil, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+					fmt.Sprintf("unexpected EOF of file %s, expected %d events, actually read %d events",
+						r.backEnd.fileName, r.totalEvents, r.readEvents))
 			}
 			return nil, nil
 		}
-		return nil, errors.Trace(wrapIOError(err))
 	}
 
-	if m != blockMagic {
-		log.Panic("fileSorterBackEnd: wrong blockMagic. Damaged file or bug?", zap.Uint32("actual", m))
+	if len(r.block) < 4 {
+		return nil, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+			fmt.Sprintf("incomplete event size in file %s", r.backEnd.fileName))
+	}
+	size := binary.LittleEndian.Uint32(r.block)
+	if uint64(len(r.block)-4) < uint64(size) {
+		return nil, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+			fmt.Sprintf("incomplete event in file %s, expected %d bytes, actually %d bytes",
+				r.backEnd.fileName, size, len(r.block)-4))
 	}
+	rawBytesBuf := r.block[4 : 4+size]
+	r.block = r.block[4+size:]
 
-	var size uint32
-	err = binary.Read(r.reader, binary.LittleEndian, &size)
+	event := new(model.PolymorphicEvent)
+	// the unmarshalled event does not reference rawBytesBuf, so the block can be reused.
+	_, err := r.backEnd.serde.unmarshal(event, rawBytesBuf)
 	if err != nil {
-		return nil, errors.Trace(wrapIOError(err))
+		return nil, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+			fmt.Sprintf("failed to unmarshal event in file %s: %s", r.backEnd.fileName, err.Error()))
 	}
 
-	// Note, do not hold the buffer in reader to avoid hogging memory.
-	rawBytesBuf := make([]byte, size)
+	r.readEvents++
+	return event, nil
+}
 
-	// short reads are possible with bufio, hence the need for io.ReadFull
-	n, err := io.ReadFull(r.reader, rawBytesBuf)
+// readBlock reads the next block into r.block, it returns true if the end of
+// the file is reached.
+func (r *fileBackEndReader) readBlock() (bool, error) {
+	var header [blockHeaderSize]byte
+	n, err := io.ReadFull(r.reader, header[:])
 	if err != nil {
-		return nil, errors.Trace(wrapIOError(err))
+		if err == io.EOF {
+			return true, nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			return false, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+				fmt.Sprintf("incomplete block header in file %s, read %d bytes", r.backEnd.fileName, n))
+		}
+		return false, errors.Trace(wrapIOError(err))
 	}
 
-	if n != int(size) {
-		return nil, errors.Errorf("fileSorterBackEnd: expected %d bytes, actually read %d bytes", size, n)
+	if m := binary.LittleEndian.Uint32(header[0:]); m != blockMagic {
+		return false, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+			fmt.Sprintf("wrong blockMagic %x in file %s", m, r.backEnd.fileName))
+	}
+	rawSize := binary.LittleEndian.Uint32(header[4:])
+	dataSize := binary.LittleEndian.Uint32(header[8:])
+	checksum := binary.LittleEndian.Uint32(header[12:])
+	r.readBytes += blockHeaderSize
+	if int64(dataSize) > r.totalSize-r.readBytes {
+		return false, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+			fmt.Sprintf("block of %d bytes exceeds the end of file %s", dataSize, r.backEnd.fileName))
 	}
 
-	event := new(model.PolymorphicEvent)
-	_, err = r.backEnd.serde.unmarshal(event, rawBytesBuf)
+	// Note, do not hold the buffer in reader across blocks to avoid hogging memory.
+	data := make([]byte, dataSize)
+	// short reads are possible with bufio, hence the need for io.ReadFull
+	n, err = io.ReadFull(r.reader, data)
 	if err != nil {
-		return nil, errors.Trace(err)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+				fmt.Sprintf("incomplete block in file %s, expected %d bytes, actually read %d bytes",
+					r.backEnd.fileName, dataSize, n))
+		}
+		return false, errors.Trace(wrapIOError(err))
 	}
 
-	r.readEvents++
+	r.readBytes += int64(dataSize)
+	if actual := blockChecksum(header[4:12], data); actual != checksum {
+		return false, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+			fmt.Sprintf("checksum mismatch in file %s, expected %x, actual %x",
+				r.backEnd.fileName, checksum, actual))
+	}
 
-	failpoint.Inject("sorterDebug", func() {
-		r.readBytes += int64(4 + 4 + int(size))
-		if r.readBytes > r.totalSize {
-			log.Panic("fileSorterBackEnd: read more bytes than expected, check concurrent use of file",
-				zap.String("fileName", r.backEnd.fileName))
+	raw := data
+	if r.compression != compressionNone {
+		raw, err = decompressBlock(r.compression, make([]byte, 0, rawSize), data)
+		if err != nil {
+			return false, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+				fmt.Sprintf("failed to decompress block in file %s: %s", r.backEnd.fileName, err.Error()))
 		}
-	})
+	}
+	if len(raw) != int(rawSize) {
+		return false, cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+			fmt.Sprintf("block size mismatch in file %s, expected %d bytes, actually %d bytes",
+				r.backEnd.fileName, rawSize, len(raw)))
+	}
+	r.block = raw
+	return false, nil
+}
 
-	return event, nil
+// blockChecksum calculates the crc32 of the sizes and the data of a block.
+func blockChecksum(sizes []byte, data []byte) uint32 {
+	return crc32.Update(crc32.Checksum(sizes, crcTable), crcTable, data)
 }
 
 func (r *fileBackEndReader) resetAndClose() error {
@@ -323,6 +436,9 @@ type fileBackEndWriter struct {
 	f       *os.File
 	writer  *bufio.Writer
 
+	// block buffers the serialized events until it is written as a block.
+	block []byte
+
 	bytesWritten  int64
 	eventsWritten int64
 }
@@ -339,6 +455,11 @@ func (w *fileBackEndWriter) writeFileHeader() error {
 		return errors.Trace(err)
 	}
 
+	err = binary.Write(w.writer, binary.LittleEndian, w.backEnd.compression)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
 	return nil
 }
 
@@ -356,31 +477,50 @@ func (w *fileBackEndWriter) writeNext(event *model.PolymorphicEvent) error {
 		log.Panic("fileSorterBackEnd: serialized to empty byte array. Bug?")
 	}
 
-	err = binary.Write(w.writer, binary.LittleEndian, uint32(blockMagic))
-	if err != nil {
-		return errors.Trace(wrapIOError(err))
+	var sizeBuf [4]byte
+	binary.LittleEndian.PutUint32(sizeBuf[:], uint32(size))
+	w.block = append(w.block, sizeBuf[:]...)
+	w.block = append(w.block, rawBytesBuf...)
+	w.eventsWritten++
+
+	if len(w.block) >= blockSizeLimit {
+		return errors.Trace(w.writeBlock())
 	}
+	return nil
+}
 
-	err = binary.Write(w.writer, binary.LittleEndian, uint32(size))
-	if err != nil {
-		return errors.Trace(wrapIOError(err))
+// writeBlock compresses the buffered events and writes them as a block.
+func (w *fileBackEndWriter) writeBlock() error {
+	if len(w.block) == 0 {
+		return nil
 	}
 
+	data := compressBlock(w.backEnd.compression, nil, w.block)
+
+	var header [blockHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[0:], blockMagic)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(w.block)))
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(header[12:], blockChecksum(header[4:12], data))
+
 	// short writes are possible with bufio
-	offset := 0
-	for offset < size {
-		n, err := w.writer.Write(rawBytesBuf[offset:])
-		if err != nil {
-			return errors.Trace(wrapIOError(err))
+	for _, buf := range [][]byte{header[:], data} {
+		offset := 0
+		for offset < len(buf) {
+			n, err := w.writer.Write(buf[offset:])
+			if err != nil {
+				return errors.Trace(wrapIOError(err))
+			}
+			offset += n
 		}
-		offset += n
-	}
-	if offset != size {
-		return errors.Errorf("fileSorterBackEnd: expected to write %d bytes, actually wrote %d bytes", size, offset)
 	}
 
-	w.eventsWritten++
-	w.bytesWritten += int64(size)
+	w.bytesWritten += int64(blockHeaderSize + len(data))
+	// the stats are updated as soon as the block is written, so that
+	// max-disk-consumption can be checked against the files being written.
+	w.backEnd.addSize(int64(blockHeaderSize + len(data)))
+	// the block is not reused, to avoid holding large buffers of large events.
+	w.block = nil
 	return nil
 }
 
@@ -398,7 +538,12 @@ func (w *fileBackEndWriter) flushAndClose() error {
 		w.f = nil
 	}()
 
-	err := w.writer.Flush()
+	err := w.writeBlock()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	err = w.writer.Flush()
 	if err != nil {
 		return errors.Trace(wrapIOError(err))
 	}
@@ -424,8 +569,6 @@ func (w *fileBackEndWriter) flushAndClose() error {
 	}
 
 	atomic.AddInt64(&openFDCount, -1)
-	w.backEnd.size = w.bytesWritten
-	atomic.AddInt64(&pool.onDiskDataSize, w.bytesWritten)
 
 	failpoint.Inject("sorterDebug", func() {
 		atomic.StoreInt32(&w.backEnd.borrowed, 0)
@@ -446,3 +589,13 @@ func wrapIOError(err error) error {
 		return err
 	}
 }
+
+// wrapCorruptionError converts the error of reading an incomplete file to ErrUnifiedSorterDataCorrupted.
+func wrapCorruptionError(fileName string, err error) error {
+	cause := errors.Cause(err)
+	if cause == io.EOF || cause == io.ErrUnexpectedEOF {
+		return cerrors.ErrUnifiedSorterDataCorrupted.GenWithStackByArgs(
+			fmt.Sprintf("incomplete file %s: %s", fileName, err.Error()))
+	}
+	return err
+}
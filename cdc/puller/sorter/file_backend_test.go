@@ -14,11 +14,14 @@
 package sorter
 
 import (
+	"bytes"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/pingcap/check"
 	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
 	cerrors "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/util/testleak"
 )
@@ -62,3 +65,105 @@ func (s *fileBackendSuite) TestNoSpace(c *check.C) {
 	c.Assert(err, check.ErrorMatches, ".*review the settings.*no space.*")
 	c.Assert(cerrors.ErrUnifiedSorterIOError.Equal(err), check.IsTrue)
 }
+
+func (s *fileBackendSuite) TestCompression(c *check.C) {
+	defer testleak.AfterTest(c)()
+
+	dir := c.MkDir()
+	for _, compression := range []string{config.SorterCompressionNone, config.SorterCompressionSnappy, config.SorterCompressionZstd} {
+		tp, err := parseCompression(compression)
+		c.Assert(err, check.IsNil)
+		fb, err := newFileBackEnd(filepath.Join(dir, compression), &msgPackGenSerde{}, tp)
+		c.Assert(err, check.IsNil)
+
+		w, err := fb.writer()
+		c.Assert(err, check.IsNil)
+		// writes enough events to fill multiple blocks
+		numEvents := 10000
+		for i := 1; i <= numEvents; i++ {
+			rawKV := generateMockRawKV(uint64(i))
+			rawKV.Value = bytes.Repeat([]byte{'v'}, 32)
+			err = w.writeNext(model.NewPolymorphicEvent(rawKV))
+			c.Assert(err, check.IsNil)
+		}
+		c.Assert(w.flushAndClose(), check.IsNil)
+		if compression != config.SorterCompressionNone {
+			c.Assert(fb.size, check.Less, int64(numEvents*32))
+		}
+
+		r, err := fb.reader()
+		c.Assert(err, check.IsNil)
+		for i := 1; i <= numEvents; i++ {
+			event, err := r.readNext()
+			c.Assert(err, check.IsNil)
+			c.Assert(event.CRTs, check.Equals, uint64(i))
+			c.Assert(event.RawKV.Value, check.HasLen, 32)
+		}
+		event, err := r.readNext()
+		c.Assert(err, check.IsNil)
+		c.Assert(event, check.IsNil)
+		c.Assert(r.resetAndClose(), check.IsNil)
+		c.Assert(fb.free(), check.IsNil)
+	}
+
+	_, err := parseCompression("lz4")
+	c.Assert(cerrors.ErrIllegalUnifiedSorterParameter.Equal(err), check.IsTrue)
+}
+
+func (s *fileBackendSuite) TestCorruption(c *check.C) {
+	defer testleak.AfterTest(c)()
+
+	dir := c.MkDir()
+	writeFile := func(name string) *fileBackEnd {
+		fb, err := newFileBackEnd(filepath.Join(dir, name), &msgPackGenSerde{}, compressionSnappy)
+		c.Assert(err, check.IsNil)
+		w, err := fb.writer()
+		c.Assert(err, check.IsNil)
+		for i := 1; i <= 100; i++ {
+			err = w.writeNext(model.NewPolymorphicEvent(generateMockRawKV(uint64(i))))
+			c.Assert(err, check.IsNil)
+		}
+		c.Assert(w.flushAndClose(), check.IsNil)
+		return fb
+	}
+	readAll := func(fb *fileBackEnd) error {
+		r, err := fb.reader()
+		if err != nil {
+			return err
+		}
+		defer r.resetAndClose() //nolint:errcheck
+		for {
+			event, err := r.readNext()
+			if err != nil || event == nil {
+				return err
+			}
+		}
+	}
+
+	// flips a byte in the data of the block
+	fb := writeFile("flipped")
+	f, err := os.OpenFile(fb.fileName, os.O_RDWR, 0o644)
+	c.Assert(err, check.IsNil)
+	buf := make([]byte, 1)
+	_, err = f.ReadAt(buf, fb.size)
+	c.Assert(err, check.IsNil)
+	buf[0] ^= 0xff
+	_, err = f.WriteAt(buf, fb.size)
+	c.Assert(err, check.IsNil)
+	c.Assert(f.Close(), check.IsNil)
+	err = readAll(fb)
+	c.Assert(cerrors.ErrUnifiedSorterDataCorrupted.Equal(err), check.IsTrue, check.Commentf("%s", err))
+	c.Assert(err, check.ErrorMatches, ".*checksum mismatch.*")
+
+	// truncates the file
+	fb = writeFile("truncated")
+	c.Assert(os.Truncate(fb.fileName, fileHeaderSize+fb.size/2), check.IsNil)
+	err = readAll(fb)
+	c.Assert(cerrors.ErrUnifiedSorterDataCorrupted.Equal(err), check.IsTrue, check.Commentf("%s", err))
+
+	// truncates the header of the file
+	fb = writeFile("no-header")
+	c.Assert(os.Truncate(fb.fileName, 4), check.IsNil)
+	err = readAll(fb)
+	c.Assert(cerrors.ErrUnifiedSorterDataCorrupted.Equal(err), check.IsTrue, check.Commentf("%s", err))
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/ticdc/cdc/model"
 	cerrors "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/notify"
+	"github.com/pingcap/ticdc/pkg/tracing"
 	"github.com/pingcap/ticdc/pkg/util"
 	"github.com/tikv/client-go/v2/oracle"
 	"go.uber.org/zap"
@@ -308,10 +309,14 @@ func runMerger(ctx context.Context, numSorters int, in <-chan *flushTask, out ch
 				lastOutputTs = event.CRTs
 				lastEvent = event
 				lastTask = task
+				span := tracing.StartKVEventSpan(ctx, tracing.StageSorterOutput,
+					changefeedID, event.RawKV.Key, event.CRTs)
 				select {
 				case <-ctx.Done():
+					span.End()
 					return ctx.Err()
 				case out <- event:
+					span.End()
 					metricSorterEventCount.WithLabelValues("kv").Inc()
 				}
 			}
@@ -60,6 +60,21 @@ var (
 		Help:      "the amount of pending data stored on-disk by the sorter",
 	}, []string{"capture"})
 
+	sorterTableOnDiskDataSizeGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "ticdc",
+		Subsystem: "sorter",
+		Name:      "table_on_disk_data_size_gauge",
+		Help:      "the amount of pending data of each table stored on-disk by the sorter",
+	}, []string{"capture", "changefeed", "table"})
+
+	sorterDiskQuotaWaitDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "ticdc",
+		Subsystem: "sorter",
+		Name:      "disk_quota_wait_duration",
+		Help:      "Bucketed histogram of the time pullers are paused for by max-disk-consumption of the sorter",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 16),
+	}, []string{"capture", "changefeed"})
+
 	sorterOpenFileCountGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: "ticdc",
 		Subsystem: "sorter",
@@ -92,6 +107,8 @@ func InitMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(sorterMergerStartTsGauge)
 	registry.MustRegister(sorterInMemoryDataSizeGauge)
 	registry.MustRegister(sorterOnDiskDataSizeGauge)
+	registry.MustRegister(sorterTableOnDiskDataSizeGauge)
+	registry.MustRegister(sorterDiskQuotaWaitDuration)
 	registry.MustRegister(sorterOpenFileCountGauge)
 	registry.MustRegister(sorterFlushCountHistogram)
 	registry.MustRegister(sorterMergeCountHistogram)
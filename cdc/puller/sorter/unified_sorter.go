@@ -17,6 +17,8 @@ import (
 	"context"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/failpoint"
@@ -24,6 +26,7 @@ import (
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/pkg/config"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/tracing"
 	"github.com/pingcap/ticdc/pkg/util"
 	"golang.org/x/sync/errgroup"
 )
@@ -32,6 +35,8 @@ const (
 	inputChSize       = 128
 	outputChSize      = 128
 	heapCollectChSize = 128 // this should be not be too small, to guarantee IO concurrency
+
+	tableMetricsInterval = 15 * time.Second
 )
 
 // UnifiedSorter provides both sorting in memory and in file. Memory pressure is used to determine which one to use.
@@ -42,6 +47,13 @@ type UnifiedSorter struct {
 	pool        *backEndPool
 	metricsInfo *metricsInfo
 
+	// priority comes from the changefeed, it is used by the backEndPool to
+	// decide which backEnd to allocate.
+	priority model.ChangefeedPriority
+
+	// diskUsage is the on disk data size of the table.
+	diskUsage int64
+
 	closeCh chan struct{}
 }
 
@@ -122,6 +134,12 @@ func NewUnifiedSorter(
 	}, nil
 }
 
+// SetPriority sets the priority of the changefeed.
+// It must be called before Run.
+func (s *UnifiedSorter) SetPriority(priority model.ChangefeedPriority) {
+	s.priority = priority
+}
+
 // UnifiedSorterCleanUp cleans up the files that might have been used.
 func UnifiedSorterCleanUp() {
 	poolMu.Lock()
@@ -210,6 +228,24 @@ func (s *UnifiedSorter) Run(ctx context.Context) error {
 		return printError(runMerger(subctx, numConcurrentHeaps, heapSorterCollectCh, s.outputCh, ioCancelFunc))
 	})
 
+	errg.Go(func() error {
+		metricTableOnDiskDataSizeGauge := sorterTableOnDiskDataSizeGauge.WithLabelValues(
+			s.metricsInfo.captureAddr, s.metricsInfo.changeFeedID, s.metricsInfo.tableName)
+		defer sorterTableOnDiskDataSizeGauge.DeleteLabelValues(
+			s.metricsInfo.captureAddr, s.metricsInfo.changeFeedID, s.metricsInfo.tableName)
+
+		ticker := time.NewTicker(tableMetricsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-subctx.Done():
+				return nil
+			case <-ticker.C:
+				metricTableOnDiskDataSizeGauge.Set(float64(atomic.LoadInt64(&s.diskUsage)))
+			}
+		}
+	})
+
 	errg.Go(func() error {
 		captureAddr := util.CaptureAddrFromCtx(ctx)
 		changefeedID := util.ChangefeedIDFromCtx(ctx)
@@ -274,6 +310,11 @@ func (s *UnifiedSorter) Run(ctx context.Context) error {
 
 // AddEntry implements the EventSorter interface
 func (s *UnifiedSorter) AddEntry(ctx context.Context, entry *model.PolymorphicEvent) {
+	if entry.RawKV.OpType != model.OpTypeResolved {
+		span := tracing.StartKVEventSpan(ctx, tracing.StageSorterInput,
+			s.metricsInfo.changeFeedID, entry.RawKV.Key, entry.CRTs)
+		defer span.End()
+	}
 	select {
 	case <-ctx.Done():
 		return
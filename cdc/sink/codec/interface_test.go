@@ -119,3 +119,27 @@ func (s *codecInterfaceSuite) TestCreate(c *check.C) {
 	c.Assert(msg.Table, check.IsNil)
 	c.Assert(msg.Protocol, check.Equals, ProtocolCanal)
 }
+
+func (s *codecInterfaceSuite) TestEncodePrunedColumns(c *check.C) {
+	defer testleak.AfterTest(c)()
+	// the columns pruned by the column selectors are nil
+	columns := []*model.Column{
+		{Name: "a", Type: mysql.TypeLong, Value: int64(1), Flag: model.HandleKeyFlag | model.PrimaryKeyFlag},
+		nil,
+		{Name: "c", Type: mysql.TypeVarchar, Value: []byte("c")},
+	}
+	table := &model.TableName{Schema: "test", Table: "t1"}
+	rows := []*model.RowChangedEvent{
+		{Table: table, CommitTs: 1, Columns: columns},
+		{Table: table, CommitTs: 2, Columns: columns, PreColumns: columns},
+		{Table: table, CommitTs: 3, PreColumns: columns},
+	}
+	// avro is not covered as it requires a schema registry
+	for _, protocol := range []Protocol{ProtocolDefault, ProtocolCanal, ProtocolMaxwell, ProtocolCanalJSON, ProtocolCraft} {
+		encoder := NewEventBatchEncoder(protocol)()
+		for _, row := range rows {
+			_, err := encoder.AppendRowChangedEvent(row)
+			c.Assert(err, check.IsNil, check.Commentf("protocol: %d", protocol))
+		}
+	}
+}
@@ -102,7 +102,7 @@ func NewAvroSchemaManager(
 		return nil, cerror.ErrAvroSchemaAPIError.GenWithStack("Unexpected response from Schema Registry")
 	}
 
-	log.Info("Successfully tested connectivity to Schema Registry", zap.String("registryURL", registryURL))
+	log.Info("Successfully tested connectivity to Schema Registry", zap.String("registryURL", security.RedactURI(registryURL)))
 
 	return &AvroSchemaManager{
 		registryURL:   registryURL,
@@ -129,7 +129,7 @@ func (m *AvroSchemaManager) Register(ctx context.Context, tableName model.TableN
 			cerror.WrapError(cerror.ErrAvroSchemaAPIError, err), "Could not marshal request to the Registry")
 	}
 	uri := m.registryURL + "/subjects/" + url.QueryEscape(m.tableNameToSchemaSubject(tableName)) + "/versions"
-	log.Debug("Registering schema", zap.String("uri", uri), zap.ByteString("payload", payload))
+	log.Debug("Registering schema", zap.String("uri", security.RedactURI(uri)), zap.ByteString("payload", payload))
 
 	req, err := http.NewRequestWithContext(ctx, "POST", uri, bytes.NewReader(payload))
 	if err != nil {
@@ -150,7 +150,7 @@ func (m *AvroSchemaManager) Register(ctx context.Context, tableName model.TableN
 	if resp.StatusCode != 200 {
 		log.Warn("Failed to register schema to the Registry, HTTP error",
 			zap.Int("status", resp.StatusCode),
-			zap.String("uri", uri),
+			zap.String("uri", security.RedactURI(uri)),
 			zap.ByteString("requestBody", payload),
 			zap.ByteString("responseBody", body))
 		return 0, cerror.ErrAvroSchemaAPIError.GenWithStack("Failed to register schema to the Registry, HTTP error")
@@ -170,7 +170,7 @@ func (m *AvroSchemaManager) Register(ctx context.Context, tableName model.TableN
 
 	log.Info("Registered schema successfully",
 		zap.Int("id", jsonResp.ID),
-		zap.String("uri", uri),
+		zap.String("uri", security.RedactURI(uri)),
 		zap.ByteString("body", body))
 
 	return jsonResp.ID, nil
@@ -199,7 +199,7 @@ func (m *AvroSchemaManager) Lookup(ctx context.Context, tableName model.TableNam
 		zap.Uint64("tiSchemaID", tiSchemaID))
 
 	uri := m.registryURL + "/subjects/" + url.QueryEscape(m.tableNameToSchemaSubject(tableName)) + "/versions/latest"
-	log.Debug("Querying for latest schema", zap.String("uri", uri))
+	log.Debug("Querying for latest schema", zap.String("uri", security.RedactURI(uri)))
 
 	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
 	if err != nil {
@@ -223,7 +223,7 @@ func (m *AvroSchemaManager) Lookup(ctx context.Context, tableName model.TableNam
 	if resp.StatusCode != 200 && resp.StatusCode != 404 {
 		log.Warn("Failed to query schema from the Registry, HTTP error",
 			zap.Int("status", resp.StatusCode),
-			zap.String("uri", uri),
+			zap.String("uri", security.RedactURI(uri)),
 
 			zap.ByteString("responseBody", body))
 		return nil, 0, cerror.ErrAvroSchemaAPIError.GenWithStack("Failed to query schema from the Registry, HTTP error")
@@ -329,7 +329,7 @@ func (m *AvroSchemaManager) ClearRegistry(ctx context.Context, tableName model.T
 	uri := m.registryURL + "/subjects/" + url.QueryEscape(m.tableNameToSchemaSubject(tableName))
 	req, err := http.NewRequestWithContext(ctx, "DELETE", uri, nil)
 	if err != nil {
-		log.Error("Could not construct request for clearRegistry", zap.String("uri", uri))
+		log.Error("Could not construct request for clearRegistry", zap.String("uri", security.RedactURI(uri)))
 		return cerror.WrapError(cerror.ErrAvroSchemaAPIError, err)
 	}
 	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json, application/vnd.schemaregistry+json, application/json")
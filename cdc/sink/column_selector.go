@@ -0,0 +1,89 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	filter "github.com/pingcap/tidb-tools/pkg/table-filter"
+)
+
+// columnSelectorSchemes are the schemes of the sinks supporting the column
+// selectors, the encoders of all their protocols skip the pruned columns. The
+// other sinks need all columns to replicate the rows.
+var columnSelectorSchemes = map[string]struct{}{
+	"kafka":      {},
+	"kafka+ssl":  {},
+	"pulsar":     {},
+	"pulsar+ssl": {},
+}
+
+// validateColumnSelectors checks whether the column selectors in the config
+// are supported by the sink of the scheme.
+func validateColumnSelectors(scheme string, cfg *config.ReplicaConfig) error {
+	if cfg == nil || cfg.Sink == nil || len(cfg.Sink.ColumnSelectors) == 0 {
+		return nil
+	}
+	if _, ok := columnSelectorSchemes[scheme]; !ok {
+		return cerror.ErrSinkInvalidConfig.Wrap(errors.Errorf("column-selectors is not supported by the %s sink", scheme))
+	}
+	for _, selector := range cfg.Sink.ColumnSelectors {
+		if len(selector.Matcher) == 0 {
+			return cerror.ErrSinkInvalidConfig.Wrap(errors.New("matcher of column selector is empty"))
+		}
+	}
+	return nil
+}
+
+// columnSelector selects the columns sent to the downstream by the column
+// selectors in the sink config, the first selector matching a table is used.
+type columnSelector struct {
+	rules []struct {
+		filter.Filter
+		columns []string
+	}
+}
+
+func newColumnSelector(cfg *config.ReplicaConfig) (*columnSelector, error) {
+	s := &columnSelector{}
+	for _, selector := range cfg.Sink.ColumnSelectors {
+		f, err := filter.Parse(selector.Matcher)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrFilterRuleInvalid, err)
+		}
+		if !cfg.CaseSensitive {
+			f = filter.CaseInsensitive(f)
+		}
+		columns := selector.Columns
+		if columns == nil {
+			columns = []string{}
+		}
+		s.rules = append(s.rules, struct {
+			filter.Filter
+			columns []string
+		}{Filter: f, columns: columns})
+	}
+	return s, nil
+}
+
+// NeededColumns implements entry.ColumnSelector.
+func (s *columnSelector) NeededColumns(schema, table string) []string {
+	for _, rule := range s.rules {
+		if rule.MatchTable(schema, table) {
+			return rule.columns
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+)
+
+type columnSelectorSuite struct{}
+
+var _ = check.Suite(&columnSelectorSuite{})
+
+func (s columnSelectorSuite) TestNeededColumns(c *check.C) {
+	defer testleak.AfterTest(c)()
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.CaseSensitive = false
+	cfg.Sink.ColumnSelectors = []*config.ColumnSelector{
+		{Matcher: []string{"test.t1"}, Columns: []string{"a", "b"}},
+		{Matcher: []string{"test.*"}, Columns: []string{"c"}},
+		{Matcher: []string{"test1.*"}},
+	}
+	selector, err := newColumnSelector(cfg)
+	c.Assert(err, check.IsNil)
+	c.Assert(selector.NeededColumns("test", "t1"), check.DeepEquals, []string{"a", "b"})
+	c.Assert(selector.NeededColumns("TEST", "t2"), check.DeepEquals, []string{"c"})
+	c.Assert(selector.NeededColumns("test1", "t1"), check.DeepEquals, []string{})
+	c.Assert(selector.NeededColumns("test2", "t1"), check.IsNil)
+
+	cfg.Sink.ColumnSelectors = []*config.ColumnSelector{{Matcher: []string{"test.t1["}}}
+	_, err = newColumnSelector(cfg)
+	c.Assert(err, check.ErrorMatches, ".*CDC:ErrFilterRuleInvalid.*")
+}
+
+func (s columnSelectorSuite) TestValidateColumnSelectors(c *check.C) {
+	defer testleak.AfterTest(c)()
+	cfg := config.GetDefaultReplicaConfig()
+	for _, scheme := range []string{"kafka", "mysql", "blackhole"} {
+		c.Assert(validateColumnSelectors(scheme, cfg), check.IsNil)
+	}
+
+	cfg.Sink.ColumnSelectors = []*config.ColumnSelector{{Matcher: []string{"test.*"}, Columns: []string{"a"}}}
+	c.Assert(validateColumnSelectors("kafka", cfg), check.IsNil)
+	c.Assert(validateColumnSelectors("pulsar+ssl", cfg), check.IsNil)
+	c.Assert(validateColumnSelectors("mysql", cfg), check.ErrorMatches, ".*not supported by the mysql sink.*")
+
+	cfg.Sink.ColumnSelectors = []*config.ColumnSelector{{Columns: []string{"a"}}}
+	c.Assert(validateColumnSelectors("kafka", cfg), check.ErrorMatches, ".*matcher of column selector is empty.*")
+}
@@ -138,12 +138,98 @@ func (c *TableMemoryQuota) GetConsumption() uint64 {
 	return c.Consumed
 }
 
+// ChangefeedMemoryQuota is a memory quota shared by all the tables of a changefeed.
+// Unlike TableMemoryQuota, it is never aborted as a whole, because aborting one
+// table must not interrupt the other tables of the same changefeed.
+type ChangefeedMemoryQuota struct {
+	Quota uint64 // should not be changed once intialized
+
+	mu       sync.Mutex
+	Consumed uint64
+
+	cond *sync.Cond
+}
+
+// NewChangefeedMemoryQuota creates a new ChangefeedMemoryQuota
+// quota: max advised memory consumption in bytes of all tables in a changefeed.
+func NewChangefeedMemoryQuota(quota uint64) *ChangefeedMemoryQuota {
+	ret := &ChangefeedMemoryQuota{
+		Quota: quota,
+	}
+	ret.cond = sync.NewCond(&ret.mu)
+	return ret
+}
+
+// consumeWithBlocking blocks until there is enough memory in the quota,
+// or isAborted returns true.
+func (c *ChangefeedMemoryQuota) consumeWithBlocking(nBytes uint64, isAborted func() bool) error {
+	if nBytes >= c.Quota {
+		return cerrors.ErrFlowControllerEventLargerThanQuota.GenWithStackByArgs(nBytes, c.Quota)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for {
+		if isAborted() {
+			return cerrors.ErrFlowControllerAborted.GenWithStackByArgs()
+		}
+		if c.Consumed+nBytes < c.Quota {
+			break
+		}
+		c.cond.Wait()
+	}
+	c.Consumed += nBytes
+	return nil
+}
+
+func (c *ChangefeedMemoryQuota) forceConsume(nBytes uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Consumed += nBytes
+}
+
+func (c *ChangefeedMemoryQuota) release(nBytes uint64) {
+	if nBytes == 0 {
+		return
+	}
+	c.mu.Lock()
+	if c.Consumed < nBytes {
+		c.mu.Unlock()
+		log.Panic("ChangefeedMemoryQuota: releasing more than consumed, report a bug",
+			zap.Uint64("consumed", c.Consumed),
+			zap.Uint64("released", nBytes))
+	}
+	c.Consumed -= nBytes
+	c.mu.Unlock()
+	// Several tables may be waiting for the quota, wake them all up.
+	c.cond.Broadcast()
+}
+
+// wakeUp wakes up all the waiters, so that the aborted ones can quit.
+func (c *ChangefeedMemoryQuota) wakeUp() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cond.Broadcast()
+}
+
+// GetConsumption returns the current memory consumption
+func (c *ChangefeedMemoryQuota) GetConsumption() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.Consumed
+}
+
 // TableFlowController provides a convenient interface to control the memory consumption of a per table event stream
 type TableFlowController struct {
 	memoryQuota *TableMemoryQuota
+	// changefeedQuota is shared by all tables of the changefeed, it can be nil.
+	changefeedQuota *ChangefeedMemoryQuota
 
 	mu    sync.Mutex
 	queue deque.Deque
+	// changefeedConsumed is the memory consumed from changefeedQuota and not released yet.
+	changefeedConsumed uint64
 
 	lastCommitTs uint64
 }
@@ -161,6 +247,14 @@ func NewTableFlowController(quota uint64) *TableFlowController {
 	}
 }
 
+// NewTableFlowControllerWithChangefeedQuota creates a new TableFlowController whose
+// memory consumption is limited by both the table quota and the shared changefeed quota.
+func NewTableFlowControllerWithChangefeedQuota(quota uint64, changefeedQuota *ChangefeedMemoryQuota) *TableFlowController {
+	ret := NewTableFlowController(quota)
+	ret.changefeedQuota = changefeedQuota
+	return ret
+}
+
 // Consume is called when an event has arrived for being processed by the sink.
 // It will handle transaction boundaries automatically, and will not block intra-transaction.
 func (c *TableFlowController) Consume(commitTs uint64, size uint64, blockCallBack func() error) error {
@@ -178,6 +272,13 @@ func (c *TableFlowController) Consume(commitTs uint64, size uint64, blockCallBac
 		if err != nil {
 			return errors.Trace(err)
 		}
+		if c.changefeedQuota != nil {
+			err := c.changefeedQuota.consumeWithBlocking(size, c.isAborted)
+			if err != nil {
+				c.memoryQuota.Release(size)
+				return errors.Trace(err)
+			}
+		}
 	} else {
 		// Here commitTs == lastCommitTs, which means that we are not crossing
 		// a transaction boundary. In this situation, we use `ForceConsume` because
@@ -188,10 +289,22 @@ func (c *TableFlowController) Consume(commitTs uint64, size uint64, blockCallBac
 		if err != nil {
 			return errors.Trace(err)
 		}
+		if c.changefeedQuota != nil {
+			c.changefeedQuota.forceConsume(size)
+		}
 	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.changefeedQuota != nil {
+		if c.isAborted() {
+			// Abort has returned the consumption of this table to the changefeed
+			// quota, so we must not hold any more memory from it.
+			c.changefeedQuota.release(size)
+			return cerrors.ErrFlowControllerAborted.GenWithStackByArgs()
+		}
+		c.changefeedConsumed += size
+	}
 	c.queue.PushBack(&commitTsSizeEntry{
 		CommitTs: commitTs,
 		Size:     size,
@@ -213,14 +326,35 @@ func (c *TableFlowController) Release(resolvedTs uint64) {
 			break
 		}
 	}
+	if c.changefeedQuota != nil {
+		c.changefeedConsumed -= nBytesToRelease
+	}
 	c.mu.Unlock()
 
 	c.memoryQuota.Release(nBytesToRelease)
+	if c.changefeedQuota != nil {
+		c.changefeedQuota.release(nBytesToRelease)
+	}
 }
 
 // Abort interrupts any ongoing Consume call
 func (c *TableFlowController) Abort() {
 	c.memoryQuota.Abort()
+	if c.changefeedQuota != nil {
+		// Return the memory held by this table to the changefeed quota,
+		// so that the other tables of the changefeed are not starved.
+		c.mu.Lock()
+		nBytesToRelease := c.changefeedConsumed
+		c.changefeedConsumed = 0
+		c.queue = deque.NewDeque()
+		c.mu.Unlock()
+		c.changefeedQuota.release(nBytesToRelease)
+		c.changefeedQuota.wakeUp()
+	}
+}
+
+func (c *TableFlowController) isAborted() bool {
+	return atomic.LoadUint32(&c.memoryQuota.IsAborted) == 1
 }
 
 // GetConsumption returns the current memory consumption
@@ -500,6 +500,73 @@ func (s *flowControlSuite) TestFlowControlConsumeLargerThanQuota(c *check.C) {
 	c.Assert(err, check.ErrorMatches, ".*ErrFlowControllerEventLargerThanQuota.*")
 }
 
+func (s *flowControlSuite) TestFlowControlChangefeedQuota(c *check.C) {
+	defer testleak.AfterTest(c)()
+
+	changefeedQuota := NewChangefeedMemoryQuota(1024)
+	controller1 := NewTableFlowControllerWithChangefeedQuota(1024, changefeedQuota)
+	controller2 := NewTableFlowControllerWithChangefeedQuota(1024, changefeedQuota)
+
+	// table 1 takes most of the changefeed quota.
+	err := controller1.Consume(1, 1000, dummyCallBack)
+	c.Assert(err, check.IsNil)
+	c.Assert(changefeedQuota.GetConsumption(), check.Equals, uint64(1000))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// table 2 is blocked by the changefeed quota, though its own quota is enough.
+		err := controller2.Consume(1, 100, dummyCallBack)
+		c.Assert(err, check.IsNil)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	c.Assert(controller2.GetConsumption(), check.Equals, uint64(100))
+	c.Assert(changefeedQuota.GetConsumption(), check.Equals, uint64(1000))
+
+	controller1.Release(1)
+	wg.Wait()
+	c.Assert(changefeedQuota.GetConsumption(), check.Equals, uint64(100))
+
+	// aborting a table returns its consumption to the changefeed quota.
+	err = controller1.Consume(2, 500, dummyCallBack)
+	c.Assert(err, check.IsNil)
+	c.Assert(changefeedQuota.GetConsumption(), check.Equals, uint64(600))
+	controller1.Abort()
+	c.Assert(changefeedQuota.GetConsumption(), check.Equals, uint64(100))
+	err = controller1.Consume(3, 10, dummyCallBack)
+	c.Assert(err, check.ErrorMatches, ".*ErrFlowControllerAborted.*")
+	c.Assert(changefeedQuota.GetConsumption(), check.Equals, uint64(100))
+
+	controller2.Release(1)
+	c.Assert(changefeedQuota.GetConsumption(), check.Equals, uint64(0))
+}
+
+func (s *flowControlSuite) TestFlowControlChangefeedQuotaAbort(c *check.C) {
+	defer testleak.AfterTest(c)()
+
+	changefeedQuota := NewChangefeedMemoryQuota(1024)
+	controller1 := NewTableFlowControllerWithChangefeedQuota(1024, changefeedQuota)
+	controller2 := NewTableFlowControllerWithChangefeedQuota(1024, changefeedQuota)
+
+	err := controller1.Consume(1, 1000, dummyCallBack)
+	c.Assert(err, check.IsNil)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		err := controller2.Consume(1, 100, dummyCallBack)
+		c.Assert(err, check.ErrorMatches, ".*ErrFlowControllerAborted.*")
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	controller2.Abort()
+	wg.Wait()
+	c.Assert(changefeedQuota.GetConsumption(), check.Equals, uint64(1000))
+}
+
 func BenchmarkTableFlowController(B *testing.B) {
 	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*5)
 	defer cancel()
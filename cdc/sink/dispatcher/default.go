@@ -15,6 +15,7 @@ package dispatcher
 
 import (
 	"github.com/pingcap/ticdc/cdc/model"
+	tifilter "github.com/pingcap/ticdc/pkg/filter"
 )
 
 type defaultDispatcher struct {
@@ -22,6 +23,8 @@ type defaultDispatcher struct {
 	tbd            *tableDispatcher
 	ivd            *indexValueDispatcher
 	enableOldValue bool
+	// oldValue overrides enableOldValue for the tables if it is not nil
+	oldValue *tifilter.OldValueMatcher
 }
 
 func newDefaultDispatcher(partitionNum int32, enableOldValue bool) *defaultDispatcher {
@@ -34,7 +37,11 @@ func newDefaultDispatcher(partitionNum int32, enableOldValue bool) *defaultDispa
 }
 
 func (d *defaultDispatcher) Dispatch(row *model.RowChangedEvent) int32 {
-	if d.enableOldValue {
+	enableOldValue := d.enableOldValue
+	if d.oldValue != nil {
+		enableOldValue = d.oldValue.IsOldValueEnabled(row.Table.Schema, row.Table.Table)
+	}
+	if enableOldValue {
 		return d.tbd.Dispatch(row)
 	}
 	if len(row.IndexColumns) != 1 {
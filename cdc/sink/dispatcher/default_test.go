@@ -16,6 +16,7 @@ package dispatcher
 import (
 	"github.com/pingcap/check"
 	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/util/testleak"
 )
 
@@ -200,3 +201,28 @@ func (s DefaultDispatcherSuite) TestDefaultDispatcher(c *check.C) {
 		c.Assert(p.Dispatch(tc.row), check.Equals, tc.exceptPartition)
 	}
 }
+
+func (s DefaultDispatcherSuite) TestDefaultDispatcherWithOldValueRules(c *check.C) {
+	defer testleak.AfterTest(c)()
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.EnableOldValue = false
+	cfg.OldValueRules = []*config.OldValueRule{{Matcher: []string{"test.t1"}, Enable: true}}
+	d, err := NewDispatcher(cfg, 16)
+	c.Assert(err, check.IsNil)
+	newRow := func(table string) *model.RowChangedEvent {
+		return &model.RowChangedEvent{
+			Table: &model.TableName{Schema: "test", Table: table},
+			Columns: []*model.Column{{
+				Name:  "id",
+				Value: 1,
+				Flag:  model.HandleKeyFlag | model.PrimaryKeyFlag,
+			}},
+			IndexColumns: [][]int{{0}},
+		}
+	}
+	// the rows of the tables with old values are dispatched by table
+	row := newRow("t1")
+	c.Assert(d.Dispatch(row), check.Equals, newTableDispatcher(16).Dispatch(row))
+	row = newRow("t2")
+	c.Assert(d.Dispatch(row), check.Equals, newIndexValueDispatcher(16).Dispatch(row))
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/pkg/config"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	tifilter "github.com/pingcap/ticdc/pkg/filter"
 	filter "github.com/pingcap/tidb-tools/pkg/table-filter"
 	"go.uber.org/zap"
 )
@@ -90,6 +91,10 @@ func NewDispatcher(cfg *config.ReplicaConfig, partitionNum int32) (Dispatcher, e
 		Dispatcher
 		filter.Filter
 	}, 0, len(ruleConfigs))
+	oldValue, err := tifilter.NewOldValueMatcher(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, ruleConfig := range ruleConfigs {
 		f, err := filter.Parse(ruleConfig.Matcher)
@@ -104,7 +109,7 @@ func NewDispatcher(cfg *config.ReplicaConfig, partitionNum int32) (Dispatcher, e
 		rule.fromString(ruleConfig.Dispatcher)
 		switch rule {
 		case dispatchRuleRowID, dispatchRuleIndexValue:
-			if cfg.EnableOldValue {
+			if cfg.AnyOldValueEnabled() {
 				log.Warn("This index-value distribution mode " +
 					"does not guarantee row-level orderliness when " +
 					"switching on the old value, so please use caution!")
@@ -115,7 +120,11 @@ func NewDispatcher(cfg *config.ReplicaConfig, partitionNum int32) (Dispatcher, e
 		case dispatchRuleTable:
 			d = newTableDispatcher(partitionNum)
 		case dispatchRuleDefault:
-			d = newDefaultDispatcher(partitionNum, cfg.EnableOldValue)
+			defaultDispatcher := newDefaultDispatcher(partitionNum, cfg.EnableOldValue)
+			if len(cfg.OldValueRules) > 0 {
+				defaultDispatcher.oldValue = oldValue
+			}
+			d = defaultDispatcher
 		}
 		rules = append(rules, struct {
 			Dispatcher
@@ -0,0 +1,170 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/heartbeat"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// maxPendingHeartbeats limits the heartbeats waiting to be flushed, the oldest
+// ones are dropped as only the latest flushed heartbeat is reported.
+const maxPendingHeartbeats = 1024
+
+// heartbeatLagUpdateInterval is the interval to update the end-to-end lag if
+// nothing is flushed, so that the lag keeps growing when the replication
+// stalls.
+var heartbeatLagUpdateInterval = 5 * time.Second
+
+// tableRemover is implemented by the backend sinks keeping states for tables,
+// RemoveTable is called once a table is removed from the processor.
+type tableRemover interface {
+	RemoveTable(tableID model.TableID)
+}
+
+type pendingHeartbeat struct {
+	commitTs model.Ts
+	ts       time.Time
+}
+
+// heartbeatSink recognizes the heartbeats of the changefeed in the row changed
+// events, and reports the end-to-end lag once they are flushed by the backend
+// sink, i.e. applied to the downstream or published to the MQ.
+type heartbeatSink struct {
+	Sink
+	changefeedID model.ChangeFeedID
+	captureAddr  string
+
+	mu sync.Mutex
+	// tableID is the ID of the heartbeat table, it is zero if no heartbeat
+	// has been received.
+	tableID model.TableID
+	pending []pendingHeartbeat
+	// lastFlushed is the ts of the latest flushed heartbeat, it is zero if no
+	// heartbeat has been flushed.
+	lastFlushed time.Time
+
+	metricEndToEndLag prometheus.Gauge
+	now               func() time.Time
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewHeartbeatSink wraps the backend sink to report the end-to-end lag of the
+// changefeed measured by the heartbeats.
+func NewHeartbeatSink(backendSink Sink, captureAddr string, changefeedID model.ChangeFeedID) Sink {
+	s := &heartbeatSink{
+		Sink:              backendSink,
+		changefeedID:      changefeedID,
+		captureAddr:       captureAddr,
+		metricEndToEndLag: endToEndLagGauge.WithLabelValues(captureAddr, changefeedID),
+		now:               time.Now,
+		closeCh:           make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(heartbeatLagUpdateInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.closeCh:
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				s.updateLag()
+				s.mu.Unlock()
+			}
+		}
+	}()
+	return s
+}
+
+func (s *heartbeatSink) EmitRowChangedEvents(ctx context.Context, rows ...*model.RowChangedEvent) error {
+	for _, row := range rows {
+		changefeedID, ts, ok := heartbeat.ParseRow(row)
+		if !ok || changefeedID != s.changefeedID {
+			continue
+		}
+		s.mu.Lock()
+		s.tableID = row.Table.TableID
+		if len(s.pending) >= maxPendingHeartbeats {
+			s.pending = s.pending[1:]
+		}
+		s.pending = append(s.pending, pendingHeartbeat{commitTs: row.CommitTs, ts: ts})
+		s.mu.Unlock()
+	}
+	return s.Sink.EmitRowChangedEvents(ctx, rows...)
+}
+
+func (s *heartbeatSink) FlushRowChangedEvents(ctx context.Context, resolvedTs uint64) (uint64, error) {
+	checkpointTs, err := s.Sink.FlushRowChangedEvents(ctx, resolvedTs)
+	if err != nil {
+		return checkpointTs, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := 0
+	for i < len(s.pending) && s.pending[i].commitTs <= checkpointTs {
+		i++
+	}
+	if i > 0 {
+		if s.lastFlushed.IsZero() {
+			// the gauge is deleted if the heartbeat table has been removed.
+			s.metricEndToEndLag = endToEndLagGauge.WithLabelValues(s.captureAddr, s.changefeedID)
+		}
+		s.lastFlushed = s.pending[i-1].ts
+		s.pending = append(s.pending[:0], s.pending[i:]...)
+	}
+	s.updateLag()
+	return checkpointTs, nil
+}
+
+// updateLag sets the end-to-end lag to the time elapsed since the latest
+// flushed heartbeat, it must be called with mu held.
+func (s *heartbeatSink) updateLag() {
+	if s.lastFlushed.IsZero() {
+		return
+	}
+	s.metricEndToEndLag.Set(s.now().Sub(s.lastFlushed).Seconds())
+}
+
+// RemoveTable implements tableRemover. The end-to-end lag is not reported any
+// more once the heartbeat table is moved to another capture, otherwise it
+// would grow forever.
+func (s *heartbeatSink) RemoveTable(tableID model.TableID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tableID == 0 || tableID != s.tableID {
+		return
+	}
+	s.tableID = 0
+	s.pending = nil
+	s.lastFlushed = time.Time{}
+	endToEndLagGauge.DeleteLabelValues(s.captureAddr, s.changefeedID)
+}
+
+func (s *heartbeatSink) Close(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.closeCh) })
+	s.wg.Wait()
+	endToEndLagGauge.DeleteLabelValues(s.captureAddr, s.changefeedID)
+	return s.Sink.Close(ctx)
+}
@@ -0,0 +1,190 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sink
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/heartbeat"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type heartbeatSinkSuite struct{}
+
+var _ = check.Suite(&heartbeatSinkSuite{})
+
+// flushSink is a backend sink whose checkpoint ts is set manually
+type flushSink struct {
+	Sink
+	rows         []*model.RowChangedEvent
+	checkpointTs uint64
+}
+
+func (s *flushSink) EmitRowChangedEvents(ctx context.Context, rows ...*model.RowChangedEvent) error {
+	s.rows = append(s.rows, rows...)
+	return nil
+}
+
+func (s *flushSink) FlushRowChangedEvents(ctx context.Context, resolvedTs uint64) (uint64, error) {
+	return s.checkpointTs, nil
+}
+
+func (s *flushSink) Close(ctx context.Context) error {
+	return nil
+}
+
+const heartbeatTableID = 42
+
+func newHeartbeatRow(changefeedID string, commitTs uint64, ts time.Time) *model.RowChangedEvent {
+	return &model.RowChangedEvent{
+		CommitTs: commitTs,
+		Table:    &model.TableName{Schema: heartbeat.SchemaName, Table: heartbeat.TableName, TableID: heartbeatTableID},
+		Columns: []*model.Column{
+			{Name: "changefeed_id", Value: []byte(changefeedID)},
+			{Name: "ts", Value: ts.UnixNano() / int64(time.Millisecond)},
+		},
+	}
+}
+
+func (s *heartbeatSinkSuite) TestEndToEndLag(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := context.Background()
+	backend := &flushSink{}
+	sink := NewHeartbeatSink(backend, "127.0.0.1:8300", "test-cf").(*heartbeatSink)
+	now := time.Unix(1634567890, 0)
+	sink.now = func() time.Time { return now }
+
+	rows := []*model.RowChangedEvent{
+		{CommitTs: 100, Table: &model.TableName{Schema: "test", Table: "t"}},
+		newHeartbeatRow("test-cf", 101, now.Add(-5*time.Second)),
+		// heartbeats of other changefeeds are ignored
+		newHeartbeatRow("other-cf", 102, now.Add(-time.Second)),
+		newHeartbeatRow("test-cf", 103, now.Add(-3*time.Second)),
+	}
+	c.Assert(sink.EmitRowChangedEvents(ctx, rows...), check.IsNil)
+	c.Assert(backend.rows, check.DeepEquals, rows)
+	c.Assert(sink.pending, check.HasLen, 2)
+
+	// no heartbeat is flushed
+	backend.checkpointTs = 100
+	checkpointTs, err := sink.FlushRowChangedEvents(ctx, 103)
+	c.Assert(err, check.IsNil)
+	c.Assert(checkpointTs, check.Equals, uint64(100))
+	c.Assert(testutil.ToFloat64(sink.metricEndToEndLag), check.Equals, float64(0))
+
+	backend.checkpointTs = 102
+	_, err = sink.FlushRowChangedEvents(ctx, 103)
+	c.Assert(err, check.IsNil)
+	c.Assert(testutil.ToFloat64(sink.metricEndToEndLag), check.Equals, float64(5))
+	c.Assert(sink.pending, check.HasLen, 1)
+
+	now = now.Add(time.Second)
+	backend.checkpointTs = 103
+	_, err = sink.FlushRowChangedEvents(ctx, 103)
+	c.Assert(err, check.IsNil)
+	c.Assert(testutil.ToFloat64(sink.metricEndToEndLag), check.Equals, float64(4))
+	c.Assert(sink.pending, check.HasLen, 0)
+
+	// the lag keeps growing if no new heartbeat is flushed
+	now = now.Add(10 * time.Second)
+	_, err = sink.FlushRowChangedEvents(ctx, 103)
+	c.Assert(err, check.IsNil)
+	c.Assert(testutil.ToFloat64(sink.metricEndToEndLag), check.Equals, float64(14))
+
+	c.Assert(sink.Close(ctx), check.IsNil)
+}
+
+func (s *heartbeatSinkSuite) TestEndToEndLagGrowsWithoutFlush(c *check.C) {
+	defer testleak.AfterTest(c)()
+	originalInterval := heartbeatLagUpdateInterval
+	heartbeatLagUpdateInterval = 10 * time.Millisecond
+	defer func() { heartbeatLagUpdateInterval = originalInterval }()
+
+	ctx := context.Background()
+	backend := &flushSink{checkpointTs: 101}
+	sink := NewHeartbeatSink(backend, "127.0.0.1:8300", "test-cf").(*heartbeatSink)
+	defer sink.Close(ctx) //nolint:errcheck
+	now := time.Unix(1634567890, 0)
+	setNow := func(t time.Time) {
+		sink.mu.Lock()
+		defer sink.mu.Unlock()
+		now = t
+	}
+	sink.mu.Lock()
+	sink.now = func() time.Time { return now }
+	sink.mu.Unlock()
+
+	err := sink.EmitRowChangedEvents(ctx, newHeartbeatRow("test-cf", 101, now.Add(-2*time.Second)))
+	c.Assert(err, check.IsNil)
+	_, err = sink.FlushRowChangedEvents(ctx, 101)
+	c.Assert(err, check.IsNil)
+	c.Assert(testutil.ToFloat64(sink.metricEndToEndLag), check.Equals, float64(2))
+
+	// the replication stalls, the lag is updated by the timer
+	setNow(now.Add(30 * time.Second))
+	for i := 0; i < 100 && testutil.ToFloat64(sink.metricEndToEndLag) != 32; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(testutil.ToFloat64(sink.metricEndToEndLag), check.Equals, float64(32))
+}
+
+func (s *heartbeatSinkSuite) TestHeartbeatTableRemoved(c *check.C) {
+	defer testleak.AfterTest(c)()
+	originalInterval := heartbeatLagUpdateInterval
+	heartbeatLagUpdateInterval = 10 * time.Millisecond
+	defer func() { heartbeatLagUpdateInterval = originalInterval }()
+
+	ctx := context.Background()
+	backend := &flushSink{checkpointTs: 101}
+	sink := NewHeartbeatSink(backend, "127.0.0.1:8300", "test-cf").(*heartbeatSink)
+	defer sink.Close(ctx) //nolint:errcheck
+	now := time.Unix(1634567890, 0)
+	sink.mu.Lock()
+	sink.now = func() time.Time { return now }
+	sink.mu.Unlock()
+
+	err := sink.EmitRowChangedEvents(ctx, newHeartbeatRow("test-cf", 101, now.Add(-2*time.Second)))
+	c.Assert(err, check.IsNil)
+	_, err = sink.FlushRowChangedEvents(ctx, 101)
+	c.Assert(err, check.IsNil)
+	c.Assert(testutil.ToFloat64(sink.metricEndToEndLag), check.Equals, float64(2))
+
+	// removing other tables changes nothing.
+	sink.RemoveTable(heartbeatTableID + 1)
+	sink.mu.Lock()
+	c.Assert(sink.lastFlushed.IsZero(), check.IsFalse)
+	sink.mu.Unlock()
+
+	// the lag is not reported after the heartbeat table is moved to another
+	// capture.
+	sink.RemoveTable(heartbeatTableID)
+	time.Sleep(50 * time.Millisecond)
+	sink.mu.Lock()
+	c.Assert(sink.lastFlushed.IsZero(), check.IsTrue)
+	c.Assert(sink.pending, check.HasLen, 0)
+	sink.mu.Unlock()
+	c.Assert(endToEndLagGauge.DeleteLabelValues("127.0.0.1:8300", "test-cf"), check.IsFalse)
+
+	// the lag is reported again once the heartbeat table is added back.
+	backend.checkpointTs = 102
+	err = sink.EmitRowChangedEvents(ctx, newHeartbeatRow("test-cf", 102, now.Add(-time.Second)))
+	c.Assert(err, check.IsNil)
+	_, err = sink.FlushRowChangedEvents(ctx, 102)
+	c.Assert(err, check.IsNil)
+	c.Assert(testutil.ToFloat64(endToEndLagGauge.WithLabelValues("127.0.0.1:8300", "test-cf")), check.Equals, float64(1))
+}
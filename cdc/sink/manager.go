@@ -269,6 +269,9 @@ func (b *bufferSink) run(ctx context.Context, errCh chan error) {
 			b.bufferMu.Lock()
 			delete(b.buffer, drawback.tableID)
 			b.bufferMu.Unlock()
+			if remover, ok := b.Sink.(tableRemover); ok {
+				remover.RemoveTable(drawback.tableID)
+			}
 			close(drawback.callback)
 		case resolvedTs := <-b.flushTsChan:
 			b.bufferMu.Lock()
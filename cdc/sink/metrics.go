@@ -109,6 +109,14 @@ var (
 			Name:      "buffer_sink_total_rows_count",
 			Help:      "The total count of rows that are processed by buffer sink",
 		}, []string{"capture", "changefeed"})
+
+	endToEndLagGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "ticdc",
+			Subsystem: "sink",
+			Name:      "end_to_end_lag",
+			Help:      "end-to-end replication lag (s) of changefeed measured by the heartbeats flushed by sink",
+		}, []string{"capture", "changefeed"})
 )
 
 // InitMetrics registers all metrics in this file
@@ -125,4 +133,5 @@ func InitMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(bufferChanSizeGauge)
 	registry.MustRegister(tableSinkTotalRowsCountCounter)
 	registry.MustRegister(bufferSinkTotalRowsCountCounter)
+	registry.MustRegister(endToEndLagGauge)
 }
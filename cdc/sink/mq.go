@@ -45,6 +45,9 @@ type mqSink struct {
 	newEncoder func() codec.EventBatchEncoder
 	filter     *filter.Filter
 	protocol   codec.Protocol
+	// columnSelector selects the columns to send, the other columns are
+	// not decoded by the mounter.
+	columnSelector *columnSelector
 
 	partitionNum   int32
 	partitionInput []chan struct {
@@ -78,6 +81,10 @@ func newMqSink(
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	selector, err := newColumnSelector(config)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	notifier := new(notify.Notifier)
 	var protocol codec.Protocol
 	protocol.FromString(config.Sink.Protocol)
@@ -108,7 +115,7 @@ func newMqSink(
 			avroEncoder.SetTimeZone(util.TimezoneFromCtx(ctx))
 			return avroEncoder
 		}
-	} else if (protocol == codec.ProtocolCanal || protocol == codec.ProtocolCanalJSON) && !config.EnableOldValue {
+	} else if (protocol == codec.ProtocolCanal || protocol == codec.ProtocolCanalJSON) && !config.AnyOldValueEnabled() {
 		log.Error("Old value is not enabled when using Canal protocol. Please update changefeed config")
 		return nil, cerror.WrapError(cerror.ErrKafkaInvalidConfig, errors.New("Canal requires old value to be enabled"))
 	}
@@ -139,6 +146,8 @@ func newMqSink(
 		filter:     filter,
 		protocol:   protocol,
 
+		columnSelector: selector,
+
 		partitionNum:        partitionNum,
 		partitionInput:      partitionInput,
 		partitionResolvedTs: make([]uint64, partitionNum),
@@ -162,6 +171,11 @@ func newMqSink(
 	return k, nil
 }
 
+// NeededColumns implements entry.ColumnSelector.
+func (k *mqSink) NeededColumns(schema, table string) []string {
+	return k.columnSelector.NeededColumns(schema, table)
+}
+
 func (k *mqSink) EmitRowChangedEvents(ctx context.Context, rows ...*model.RowChangedEvent) error {
 	rowsCount := 0
 	for _, row := range rows {
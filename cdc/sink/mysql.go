@@ -39,6 +39,7 @@ import (
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filter"
 	tifilter "github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/heartbeat"
 	"github.com/pingcap/ticdc/pkg/notify"
 	"github.com/pingcap/ticdc/pkg/quotes"
 	"github.com/pingcap/ticdc/pkg/retry"
@@ -91,6 +92,8 @@ type mysqlSink struct {
 
 	filter *filter.Filter
 	cyclic *cyclic.Cyclic
+	// oldValue is nil if enable-old-value is used for all tables
+	oldValue *filter.OldValueMatcher
 
 	txnCache      *common.UnresolvedTxnCache
 	workers       []*mysqlSinkWorker
@@ -411,6 +414,16 @@ func parseSinkURI(ctx context.Context, sinkURI *url.URL, opts map[string]string)
 			params.workerCount = c
 		}
 	}
+	if s, ok := opts[OptSinkConcurrency]; ok {
+		c, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrMySQLInvalidConfig, err)
+		}
+		// the sink concurrency quota of the changefeed caps the worker count
+		if c > 0 && c < params.workerCount {
+			params.workerCount = c
+		}
+	}
 	s = sinkURI.Query().Get("max-txn-row")
 	if s != "" {
 		c, err := strconv.Atoi(s)
@@ -584,6 +597,14 @@ func newMySQLSink(
 		return nil, err
 	}
 
+	// the heartbeat table may not be created by the replicated DDLs, if it
+	// exists in the upstream before the changefeed starts
+	if replicaConfig.Heartbeat.IsEnabled() {
+		if err := heartbeat.CreateTable(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+
 	log.Info("Start mysql sink")
 
 	db.SetMaxIdleConns(params.workerCount)
@@ -608,6 +629,12 @@ func newMySQLSink(
 		errCh:                           make(chan error, 1),
 		forceReplicate:                  replicaConfig.ForceReplicate,
 	}
+	if len(replicaConfig.OldValueRules) > 0 {
+		sink.oldValue, err = tifilter.NewOldValueMatcher(replicaConfig)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 
 	if val, ok := opts[mark.OptCyclicConfig]; ok {
 		cfg := new(config.CyclicConfig)
@@ -1010,13 +1037,20 @@ type preparedDMLs struct {
 	rowCount int
 }
 
+// isOldValueEnabled returns whether the rows of the table carry old values.
+func (s *mysqlSink) isOldValueEnabled(table *model.TableName) bool {
+	if s.oldValue == nil {
+		return s.params.enableOldValue
+	}
+	return s.oldValue.IsOldValueEnabled(table.Schema, table.Table)
+}
+
 // prepareDMLs converts model.RowChangedEvent list to query string list and args list
 func (s *mysqlSink) prepareDMLs(rows []*model.RowChangedEvent, replicaID uint64, bucket int) *preparedDMLs {
 	sqls := make([]string, 0, len(rows))
 	values := make([][]interface{}, 0, len(rows))
 	replaces := make(map[string][][]interface{})
 	rowCount := 0
-	translateToInsert := s.params.enableOldValue && !s.params.safeMode
 
 	// flush cached batch replace or insert, to keep the sequence of DMLs
 	flushCacheDMLs := func() {
@@ -1032,6 +1066,7 @@ func (s *mysqlSink) prepareDMLs(rows []*model.RowChangedEvent, replicaID uint64,
 		var query string
 		var args []interface{}
 		quoteTable := quotes.QuoteSchema(row.Table.Schema, row.Table.Table)
+		translateToInsert := s.isOldValueEnabled(row.Table) && !s.params.safeMode
 
 		// Translate to UPDATE if old value is enabled, not in safe mode and is update event
 		if translateToInsert && len(row.PreColumns) != 0 && len(row.Columns) != 0 {
@@ -401,6 +401,37 @@ func (s MySQLSinkSuite) TestPrepareDML(c *check.C) {
 	}
 }
 
+func (s MySQLSinkSuite) TestPrepareDMLWithOldValueRules(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ms := newMySQLSink4Test(ctx, c)
+	ms.params.safeMode = false
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.EnableOldValue = false
+	cfg.OldValueRules = []*config.OldValueRule{{Matcher: []string{"test.t1"}, Enable: true}}
+	var err error
+	ms.oldValue, err = filter.NewOldValueMatcher(cfg)
+	c.Assert(err, check.IsNil)
+
+	newRow := func(table string) *model.RowChangedEvent {
+		return &model.RowChangedEvent{
+			Table: &model.TableName{Schema: "test", Table: table},
+			Columns: []*model.Column{{
+				Name:  "a",
+				Type:  mysql.TypeLong,
+				Flag:  model.HandleKeyFlag | model.PrimaryKeyFlag,
+				Value: 1,
+			}},
+		}
+	}
+	// the inserted rows of the tables with old values are not replaced
+	dmls := ms.prepareDMLs([]*model.RowChangedEvent{newRow("t1")}, 0, 0)
+	c.Assert(dmls.sqls[0], check.Equals, "INSERT INTO `test`.`t1`(`a`) VALUES (?);")
+	dmls = ms.prepareDMLs([]*model.RowChangedEvent{newRow("t2")}, 0, 0)
+	c.Assert(dmls.sqls[0], check.Equals, "REPLACE INTO `test`.`t2`(`a`) VALUES (?);")
+}
+
 func (s MySQLSinkSuite) TestPrepareUpdate(c *check.C) {
 	defer testleak.AfterTest(c)()
 	testCases := []struct {
@@ -862,6 +893,38 @@ func (s MySQLSinkSuite) TestParseSinkURI(c *check.C) {
 	c.Assert(params, check.DeepEquals, expected)
 }
 
+func (s MySQLSinkSuite) TestParseSinkURISinkConcurrency(c *check.C) {
+	defer testleak.AfterTest(c)()
+	uri, err := url.Parse("mysql://127.0.0.1:3306/?worker-count=64")
+	c.Assert(err, check.IsNil)
+	params, err := parseSinkURI(context.TODO(), uri, map[string]string{OptSinkConcurrency: "8"})
+	c.Assert(err, check.IsNil)
+	c.Assert(params.workerCount, check.Equals, 8)
+
+	// the sink concurrency quota never increases the worker count
+	params, err = parseSinkURI(context.TODO(), uri, map[string]string{OptSinkConcurrency: "128"})
+	c.Assert(err, check.IsNil)
+	c.Assert(params.workerCount, check.Equals, 64)
+
+	_, err = parseSinkURI(context.TODO(), uri, map[string]string{OptSinkConcurrency: "abc"})
+	c.Assert(err, check.ErrorMatches, ".*ErrMySQLInvalidConfig.*")
+}
+
+func (s MySQLSinkSuite) TestValidateSinkConcurrency(c *check.C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(ValidateSinkConcurrency("mysql://root:${env:MYSQL_PASSWORD}@127.0.0.1:3306/", 8), check.IsNil)
+	c.Assert(ValidateSinkConcurrency("TiDB+SSL://127.0.0.1:4000/", 8), check.IsNil)
+	c.Assert(ValidateSinkConcurrency("kafka://127.0.0.1:9092/topic", 0), check.IsNil)
+	c.Assert(ValidateSinkConcurrency("kafka://127.0.0.1:9092/topic", 8), check.ErrorMatches,
+		".*sink-concurrency is not supported by the kafka sink.*")
+
+	// NewSink rejects the sink concurrency instead of ignoring it
+	errCh := make(chan error, 1)
+	_, err := NewSink(context.TODO(), "changefeed-01", "blackhole://", nil, config.GetDefaultReplicaConfig(),
+		map[string]string{OptSinkConcurrency: "8"}, errCh)
+	c.Assert(err, check.ErrorMatches, ".*sink-concurrency is not supported by the blackhole sink.*")
+}
+
 func (s MySQLSinkSuite) TestParseSinkURITimezone(c *check.C) {
 	defer testleak.AfterTest(c)()
 	uris := []string{
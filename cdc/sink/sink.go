@@ -18,17 +18,23 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/pingcap/errors"
 	"github.com/pingcap/ticdc/cdc/model"
 	"github.com/pingcap/ticdc/cdc/sink/cdclog"
 	"github.com/pingcap/ticdc/pkg/config"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/security"
 )
 
 // Sink options keys
 const (
 	OptChangefeedID = "_changefeed_id"
 	OptCaptureAddr  = "_capture_addr"
+	// OptSinkConcurrency is the max number of workers of the sink, it comes
+	// from the resource quota of the changefeed. Only the MySQL and TiDB sinks
+	// support it, NewSink fails for the other sinks if it is set.
+	OptSinkConcurrency = "_sink_concurrency"
 )
 
 // Sink is an abstraction for anything that a changefeed may emit into.
@@ -108,13 +114,83 @@ func init() {
 
 // NewSink creates a new sink with the sink-uri
 func NewSink(ctx context.Context, changefeedID model.ChangeFeedID, sinkURIStr string, filter *filter.Filter, config *config.ReplicaConfig, opts map[string]string, errCh chan error) (Sink, error) {
-	// parse sinkURI as a URI
-	sinkURI, err := url.Parse(sinkURIStr)
+	sinkURI, err := resolveSinkURI(sinkURIStr)
 	if err != nil {
-		return nil, cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+		return nil, err
+	}
+	opts, err = resolveSecretsInOpts(opts)
+	if err != nil {
+		return nil, err
 	}
-	if newSink, ok := sinkIniterMap[strings.ToLower(sinkURI.Scheme)]; ok {
+	scheme := strings.ToLower(sinkURI.Scheme)
+	if newSink, ok := sinkIniterMap[scheme]; ok {
+		if err := validateColumnSelectors(scheme, config); err != nil {
+			return nil, err
+		}
+		if _, ok := opts[OptSinkConcurrency]; ok {
+			if err := validateSinkConcurrency(scheme); err != nil {
+				return nil, err
+			}
+		}
 		return newSink(ctx, changefeedID, sinkURI, filter, config, opts, errCh)
 	}
 	return nil, cerror.ErrSinkURIInvalid.GenWithStack("the sink scheme (%s) is not supported", sinkURI.Scheme)
 }
+
+// sinkConcurrencySchemes are the schemes of the sinks honouring
+// OptSinkConcurrency, the other sinks have no workers to limit.
+var sinkConcurrencySchemes = map[string]struct{}{
+	"mysql":     {},
+	"tidb":      {},
+	"mysql+ssl": {},
+	"tidb+ssl":  {},
+}
+
+// validateSinkConcurrency checks whether the sink of the scheme supports the
+// sink concurrency, it is rejected rather than ignored by the other sinks.
+func validateSinkConcurrency(scheme string) error {
+	if _, ok := sinkConcurrencySchemes[scheme]; !ok {
+		return cerror.ErrSinkInvalidConfig.Wrap(errors.Errorf("sink-concurrency is not supported by the %s sink", scheme))
+	}
+	return nil
+}
+
+// ValidateSinkConcurrency checks whether the sink of the sink URI supports
+// the sink concurrency of a changefeed quota, zero means no limit and is
+// supported by all sinks. The secrets referenced by the sink URI are not
+// resolved, so it can be called where the secrets are unavailable.
+func ValidateSinkConcurrency(sinkURIStr string, sinkConcurrency int) error {
+	if sinkConcurrency == 0 {
+		return nil
+	}
+	sinkURI, err := security.ParseURIWithSecretReferences(sinkURIStr)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+	}
+	return validateSinkConcurrency(strings.ToLower(sinkURI.Scheme))
+}
+
+// resolveSinkURI resolves the secrets referenced by the sink URI and parses
+// it, the secrets are resolved by the security config of the capture.
+func resolveSinkURI(sinkURIStr string) (*url.URL, error) {
+	resolved, err := config.GetGlobalServerConfig().Security.ResolveSecrets(sinkURIStr)
+	if err != nil {
+		return nil, err
+	}
+	sinkURI, err := url.Parse(resolved)
+	if err != nil {
+		// the error of url.Parse contains the URI, which may contain secrets
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return nil, cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+	}
+	return sinkURI, nil
+}
+
+func resolveSecretsInOpts(opts map[string]string) (map[string]string, error) {
+	return config.GetGlobalServerConfig().Security.ResolveSecretsInOpts(opts)
+}
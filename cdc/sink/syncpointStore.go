@@ -15,7 +15,6 @@ package sink
 
 import (
 	"context"
-	"net/url"
 	"strings"
 
 	"github.com/pingcap/ticdc/cdc/model"
@@ -36,10 +35,9 @@ type SyncpointStore interface {
 
 // NewSyncpointStore creates a new Spyncpoint sink with the sink-uri
 func NewSyncpointStore(ctx context.Context, changefeedID model.ChangeFeedID, sinkURIStr string) (SyncpointStore, error) {
-	// parse sinkURI as a URI
-	sinkURI, err := url.Parse(sinkURIStr)
+	sinkURI, err := resolveSinkURI(sinkURIStr)
 	if err != nil {
-		return nil, cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+		return nil, err
 	}
 	switch strings.ToLower(sinkURI.Scheme) {
 	case "mysql", "tidb", "mysql+ssl", "tidb+ssl":
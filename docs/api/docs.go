@@ -72,6 +72,50 @@ var doc = `{
                 }
             }
         },
+        "/api/v1/captures/{capture_id}/drain": {
+            "post": {
+                "description": "move all tables away from the capture, so that the capture can be stopped safely",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "capture"
+                ],
+                "summary": "Drain a capture",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "capture_id",
+                        "name": "capture_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/model.DrainCaptureStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/changefeeds": {
             "get": {
                 "description": "list all changefeeds in cdc cluster",
@@ -132,9 +176,21 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/model.ChangefeedConfig"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "validate the config and return the effects of creating the changefeed without creating it",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ChangefeedDryRunResult"
+                        }
+                    },
                     "202": {
                         "description": ""
                     },
@@ -266,9 +322,21 @@ var doc = `{
                         "schema": {
                             "$ref": "#/definitions/config.SinkConfig"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "validate the config and return the effects of updating the changefeed without updating it, the changefeed is not required to be stopped",
+                        "name": "dry_run",
+                        "in": "query"
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ChangefeedDryRunResult"
+                        }
+                    },
                     "202": {
                         "description": ""
                     },
@@ -346,6 +414,12 @@ var doc = `{
                         "name": "changefeed_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "pause the changefeed after all the events with commit ts \u003c= stop_ts are replicated",
+                        "name": "stop_ts",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -387,6 +461,12 @@ var doc = `{
                         "name": "changefeed-id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "resume the changefeed from the specified checkpoint ts",
+                        "name": "overwrite_checkpoint_ts",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -408,6 +488,53 @@ var doc = `{
                 }
             }
         },
+        "/api/v1/changefeeds/{changefeed_id}/tables": {
+            "get": {
+                "description": "list the replication status of all the tables of a changefeed, which are gathered from the captures replicating them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "changefeed"
+                ],
+                "summary": "List the replication status of tables",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "changefeed_id",
+                        "name": "changefeed_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.TableReplicationStatus"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/changefeeds/{changefeed_id}/tables/move_table": {
             "post": {
                 "description": "move one table to the target capture",
@@ -508,6 +635,56 @@ var doc = `{
                 }
             }
         },
+        "/api/v1/changefeeds/{changefeed_id}/target_ts": {
+            "post": {
+                "description": "Extend or shrink the target ts of a changefeed without stopping it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "changefeed"
+                ],
+                "summary": "Update the target ts of a changefeed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "changefeed_id",
+                        "name": "changefeed_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new target ts, 0 means the changefeed never finishes",
+                        "name": "target_ts",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/health": {
             "get": {
                 "description": "check if CDC cluster is health",
@@ -536,7 +713,7 @@ var doc = `{
         },
         "/api/v1/log": {
             "post": {
-                "description": "change TiCDC log level dynamically",
+                "description": "change the log level of the capture or one of its modules dynamically",
                 "consumes": [
                     "application/json"
                 ],
@@ -554,7 +731,7 @@ var doc = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/model.LogLevelConfig"
                         }
                     }
                 ],
@@ -569,6 +746,24 @@ var doc = `{
                         }
                     }
                 }
+            },
+            "get": {
+                "description": "get the log level of the capture and the levels of the modules set separately",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "common"
+                ],
+                "summary": "Get TiCDC log level",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.LogLevels"
+                        }
+                    }
+                }
             }
         },
         "/api/v1/owner/resign": {
@@ -676,6 +871,60 @@ var doc = `{
                 }
             }
         },
+        "/api/v1/processors/{changefeed_id}/{capture_id}/tables": {
+            "get": {
+                "description": "get the replication status of the tables replicated by a processor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "processor"
+                ],
+                "summary": "Get the replication status of the tables of a processor",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "changefeed_id",
+                        "name": "changefeed_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "capture_id",
+                        "name": "capture_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.TableReplicationStatus"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/status": {
             "get": {
                 "description": "get the status of a server(capture)",
@@ -710,6 +959,58 @@ var doc = `{
                     }
                 }
             }
+        },
+        "/api/v1/watch": {
+            "get": {
+                "description": "stream the state changes of the changefeeds, captures and tables as server-sent events.\nThe stream starts with a snapshot of the current state which ends with a synced event,\nthe id of the events is the resume token to continue the stream after a reconnection.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "common"
+                ],
+                "summary": "Watch the state changes of the cluster",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only stream the changes of this changefeed and the captures",
+                        "name": "changefeed_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "continue the stream after this token instead of starting with a snapshot, the Last-Event-ID header is used if it is empty",
+                        "name": "resume_token",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.WatchEvent"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/model.HTTPError"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -727,6 +1028,9 @@ var doc = `{
                 }
             }
         },
+        "config.ReplicaConfig": {
+            "type": "object"
+        },
         "config.SinkConfig": {
             "type": "object",
             "properties": {
@@ -752,6 +1056,12 @@ var doc = `{
                 },
                 "is_owner": {
                     "type": "boolean"
+                },
+                "labels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
                 }
             }
         },
@@ -823,16 +1133,30 @@ var doc = `{
                         "type": "integer"
                     }
                 },
+                "memory_quota": {
+                    "type": "integer"
+                },
                 "mounter_worker_num": {
                     "type": "integer",
                     "default": 16
                 },
+                "priority": {
+                    "description": "Priority is one of \"high\", \"normal\" and \"low\"",
+                    "type": "string",
+                    "default": "normal"
+                },
+                "sink_concurrency": {
+                    "type": "integer"
+                },
                 "sink_config": {
                     "$ref": "#/definitions/config.SinkConfig"
                 },
                 "sink_uri": {
                     "type": "string"
                 },
+                "sorter_disk_quota": {
+                    "type": "integer"
+                },
                 "start_ts": {
                     "type": "integer"
                 },
@@ -846,6 +1170,24 @@ var doc = `{
                 }
             }
         },
+        "model.ChangefeedConfigChange": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "object"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "object"
+                },
+                "type": {
+                    "description": "Type is one of \"create\", \"update\" and \"delete\"",
+                    "type": "string"
+                }
+            }
+        },
         "model.ChangefeedDetail": {
             "type": "object",
             "properties": {
@@ -873,6 +1215,12 @@ var doc = `{
                 "id": {
                     "type": "string"
                 },
+                "priority": {
+                    "type": "string"
+                },
+                "quota": {
+                    "$ref": "#/definitions/model.ChangefeedQuota"
+                },
                 "sink_uri": {
                     "type": "string"
                 },
@@ -896,6 +1244,81 @@ var doc = `{
                 }
             }
         },
+        "model.ChangefeedDryRunResult": {
+            "type": "object",
+            "properties": {
+                "added_tables": {
+                    "description": "the tables which start or stop being replicated because of the update",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TableName"
+                    }
+                },
+                "config": {
+                    "description": "Config is the effective replica config of the changefeed",
+                    "$ref": "#/definitions/config.ReplicaConfig"
+                },
+                "diff": {
+                    "description": "Diff is the changes against the running changefeed, it is empty if the\nchangefeed is created",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ChangefeedConfigChange"
+                    }
+                },
+                "eligible_tables": {
+                    "description": "the tables matched by the filter at the current ts",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TableName"
+                    }
+                },
+                "ineligible_tables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TableName"
+                    }
+                },
+                "removed_tables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.TableName"
+                    }
+                }
+            }
+        },
+        "model.ChangefeedQuota": {
+            "type": "object",
+            "properties": {
+                "memory-quota": {
+                    "description": "MemoryQuota is the max memory in bytes used by all the table pipelines\nof the changefeed on a capture.",
+                    "type": "integer"
+                },
+                "sink-concurrency": {
+                    "description": "SinkConcurrency is the max number of workers used by the sink.",
+                    "type": "integer"
+                },
+                "sorter-disk-quota": {
+                    "description": "SorterDiskQuota is the max disk space in bytes used by the unified sorter\nof the changefeed on a capture.",
+                    "type": "integer"
+                }
+            }
+        },
+        "model.DrainCaptureStatus": {
+            "type": "object",
+            "properties": {
+                "capture_id": {
+                    "type": "string"
+                },
+                "is_drained": {
+                    "description": "true means the capture is safe to stop",
+                    "type": "boolean"
+                },
+                "table_count": {
+                    "description": "the number of tables which are not yet replicating on other captures",
+                    "type": "integer"
+                }
+            }
+        },
         "model.HTTPError": {
             "type": "object",
             "properties": {
@@ -907,6 +1330,32 @@ var doc = `{
                 }
             }
         },
+        "model.LogLevelConfig": {
+            "type": "object",
+            "properties": {
+                "log_level": {
+                    "type": "string"
+                },
+                "module": {
+                    "description": "Module is the module whose log level is changed, the global log level is\nchanged if it is empty. The module uses the global log level again if\nthe level is empty.",
+                    "type": "string"
+                }
+            }
+        },
+        "model.LogLevels": {
+            "type": "object",
+            "properties": {
+                "log_level": {
+                    "type": "string"
+                },
+                "module_levels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "model.ProcessorCommonInfo": {
             "type": "object",
             "properties": {
@@ -976,6 +1425,23 @@ var doc = `{
                 }
             }
         },
+        "model.TableName": {
+            "type": "object",
+            "properties": {
+                "db-name": {
+                    "type": "string"
+                },
+                "is-partition": {
+                    "type": "boolean"
+                },
+                "tbl-id": {
+                    "type": "integer"
+                },
+                "tbl-name": {
+                    "type": "string"
+                }
+            }
+        },
         "model.TableOperation": {
             "type": "object",
             "properties": {
@@ -997,6 +1463,67 @@ var doc = `{
                     "type": "integer"
                 }
             }
+        },
+        "model.TableReplicationStatus": {
+            "type": "object",
+            "properties": {
+                "capture_id": {
+                    "type": "string"
+                },
+                "checkpoint_ts": {
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Error is the reason why the status of the table is unknown",
+                    "type": "string"
+                },
+                "resolved_checkpoint_gap_ms": {
+                    "description": "the gap in milliseconds between the resolved ts and the checkpoint ts,\ni.e. the time range of the resolved events not yet flushed by the sink",
+                    "type": "integer"
+                },
+                "resolved_ts": {
+                    "type": "integer"
+                },
+                "sorter_backlog": {
+                    "description": "the number of row changed events buffered in the sorter",
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status is the status of the table pipeline, it is \"Unknown\" if the\ncapture replicating the table can not be reached",
+                    "type": "string"
+                },
+                "table_id": {
+                    "type": "integer"
+                },
+                "table_name": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.WatchEvent": {
+            "type": "object",
+            "properties": {
+                "capture_id": {
+                    "type": "string"
+                },
+                "changefeed_id": {
+                    "type": "string"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "revision": {
+                    "description": "Revision is the etcd revision of the change, it can be used as the\nresume token to continue the stream after it",
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "description": "Value is the new state, it is empty if the state is deleted",
+                    "type": "object"
+                }
+            }
         }
     }
 }`
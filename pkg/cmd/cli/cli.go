@@ -79,7 +79,9 @@ func NewCmdCli() *cobra.Command {
 	// Add subcommands.
 	cmds.AddCommand(newCmdCapture(f))
 	cmds.AddCommand(newCmdChangefeed(f))
+	cmds.AddCommand(newCmdDebug(f))
 	cmds.AddCommand(newCmdProcessor(f))
+	cmds.AddCommand(newCmdSecret())
 	cmds.AddCommand(newCmdTso(f))
 	cmds.AddCommand(newCmdUnsafe(f))
 
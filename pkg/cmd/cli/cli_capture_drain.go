@@ -0,0 +1,163 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	cmdcontext "github.com/pingcap/ticdc/pkg/cmd/context"
+	"github.com/pingcap/ticdc/pkg/cmd/factory"
+	"github.com/pingcap/ticdc/pkg/cmd/util"
+	"github.com/pingcap/ticdc/pkg/httputil"
+	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/spf13/cobra"
+)
+
+// drainCaptureCheckInterval is the interval to check whether a capture is drained.
+const drainCaptureCheckInterval = time.Second
+
+// drainCaptureOptions defines flags for the `cli capture drain` command.
+type drainCaptureOptions struct {
+	etcdClient *kv.CDCEtcdClient
+
+	credential *security.Credential
+
+	captureID string
+	noWait    bool
+}
+
+// newDrainCaptureOptions creates new drainCaptureOptions for the `cli capture drain` command.
+func newDrainCaptureOptions() *drainCaptureOptions {
+	return &drainCaptureOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *drainCaptureOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&o.captureID, "capture-id", "", "Capture ID")
+	cmd.PersistentFlags().BoolVar(&o.noWait, "no-wait", false, "Do not wait until all tables are replicated by other captures")
+	_ = cmd.MarkPersistentFlagRequired("capture-id")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *drainCaptureOptions) complete(f factory.Factory) error {
+	etcdClient, err := f.EtcdClient()
+	if err != nil {
+		return err
+	}
+
+	o.etcdClient = etcdClient
+
+	o.credential = f.GetCredential()
+
+	return nil
+}
+
+// run the `cli capture drain` command.
+func (o *drainCaptureOptions) run(cmd *cobra.Command) error {
+	ctx := cmdcontext.GetDefaultContext()
+
+	ticker := time.NewTicker(drainCaptureCheckInterval)
+	defer ticker.Stop()
+	for {
+		status, err := sendOwnerDrainCaptureQuery(ctx, o.etcdClient, o.captureID, o.credential)
+		if err != nil {
+			return err
+		}
+		if status.IsDrained || o.noWait {
+			if status.IsDrained {
+				cmd.Printf("Capture %s is drained, it is safe to stop it now\n", o.captureID)
+			}
+			return util.JSONPrint(cmd, status)
+		}
+		cmd.Printf("Draining capture %s, %d table(s) left\n", o.captureID, status.TableCount)
+
+		select {
+		case <-ctx.Done():
+			return errors.Trace(ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// newCmdDrainCapture creates the `cli capture drain` command.
+func newCmdDrainCapture(f factory.Factory) *cobra.Command {
+	o := newDrainCaptureOptions()
+
+	command := &cobra.Command{
+		Use:   "drain",
+		Short: "Move all tables away from a capture, so that the capture can be stopped safely",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.complete(f)
+			if err != nil {
+				return err
+			}
+
+			return o.run(cmd)
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
+
+// sendOwnerDrainCaptureQuery sends the drain capture request to the owner.
+func sendOwnerDrainCaptureQuery(ctx context.Context, etcdClient *kv.CDCEtcdClient,
+	captureID model.CaptureID, credential *security.Credential,
+) (*model.DrainCaptureStatus, error) {
+	owner, err := getOwnerCapture(ctx, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := util.HTTP
+	if credential.IsTLSEnabled() {
+		scheme = util.HTTPS
+	}
+
+	url := fmt.Sprintf("%s://%s/api/v1/captures/%s/drain", scheme, owner.AdvertiseAddr, captureID)
+	httpClient, err := httputil.NewClient(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Post(url, "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.BadRequestf("drain capture failed")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.BadRequestf("%s", string(body))
+	}
+
+	status := &model.DrainCaptureStatus{}
+	if err := json.Unmarshal(body, status); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return status, nil
+}
@@ -28,9 +28,10 @@ import (
 
 // capture holds capture information.
 type capture struct {
-	ID            string `json:"id"`
-	IsOwner       bool   `json:"is-owner"`
-	AdvertiseAddr string `json:"address"`
+	ID            string            `json:"id"`
+	IsOwner       bool              `json:"is-owner"`
+	AdvertiseAddr string            `json:"address"`
+	Labels        map[string]string `json:"labels,omitempty"`
 }
 
 // listCaptureOptions defines flags for the `cli capture list` command.
@@ -103,7 +104,7 @@ func listCaptures(ctx context.Context, etcdClient *kv.CDCEtcdClient) ([]*capture
 	for _, c := range raw {
 		isOwner := c.ID == ownerID
 		captures = append(captures,
-			&capture{ID: c.ID, IsOwner: isOwner, AdvertiseAddr: c.AdvertiseAddr})
+			&capture{ID: c.ID, IsOwner: isOwner, AdvertiseAddr: c.AdvertiseAddr, Labels: c.Labels})
 	}
 
 	return captures, nil
@@ -71,8 +71,10 @@ func newCmdChangefeed(f factory.Factory) *cobra.Command {
 		},
 	}
 
+	cmds.AddCommand(newCmdApplyChangefeed(f))
 	cmds.AddCommand(newCmdCreateChangefeed(f))
 	cmds.AddCommand(newCmdUpdateChangefeed(f))
+	cmds.AddCommand(newCmdUpdateTargetTs(f))
 	cmds.AddCommand(newCmdStatisticsChangefeed(f))
 	cmds.AddCommand(newCmdCyclicChangefeed(f))
 	cmds.AddCommand(newCmdListChangefeed(f))
@@ -80,6 +82,7 @@ func newCmdChangefeed(f factory.Factory) *cobra.Command {
 	cmds.AddCommand(newCmdQueryChangefeed(f))
 	cmds.AddCommand(newCmdRemoveChangefeed(f))
 	cmds.AddCommand(newCmdResumeChangefeed(f))
+	cmds.AddCommand(newCmdTablesChangefeed(f))
 
 	o.addFlags(cmds)
 
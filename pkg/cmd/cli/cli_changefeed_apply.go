@@ -0,0 +1,682 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	cmdcontext "github.com/pingcap/ticdc/pkg/cmd/context"
+	"github.com/pingcap/ticdc/pkg/cmd/factory"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/filter"
+	"github.com/pingcap/ticdc/pkg/security"
+	ticdcutil "github.com/pingcap/ticdc/pkg/util"
+	"github.com/pingcap/ticdc/pkg/version"
+	"github.com/spf13/cobra"
+	"github.com/tikv/client-go/v2/oracle"
+	pd "github.com/tikv/pd/client"
+	"go.uber.org/zap"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// changefeedStateCheckInterval is the interval to check the state of a
+	// changefeed when it is paused or resumed by `cli changefeed apply`.
+	changefeedStateCheckInterval = time.Second
+	// changefeedStateCheckTimeout is the max time to wait for a changefeed to
+	// be paused by `cli changefeed apply`.
+	changefeedStateCheckTimeout = 30 * time.Second
+)
+
+// changefeedManifest is the declarative description of a changefeed. The
+// opts, sort engine, priority and quota of an existing changefeed are kept if
+// they are not set.
+type changefeedManifest struct {
+	ID       string                `toml:"changefeed-id" json:"changefeed-id"`
+	SinkURI  string                `toml:"sink-uri" json:"sink-uri"`
+	StartTs  uint64                `toml:"start-ts" json:"start-ts"`
+	TargetTs uint64                `toml:"target-ts" json:"target-ts"`
+	Config   *config.ReplicaConfig `toml:"-" json:"-"`
+	// Opts are the extra options of the sink, e.g. the schema registry of avro.
+	Opts       map[string]string        `toml:"opts" json:"opts"`
+	SortEngine string                   `toml:"sort-engine" json:"sort-engine"`
+	Priority   model.ChangefeedPriority `toml:"priority" json:"priority"`
+	Quota      *model.ChangefeedQuota   `toml:"quota" json:"quota"`
+
+	// path is the file the manifest is loaded from.
+	path string
+}
+
+// tomlChangefeedManifest is the toml representation of a changefeedManifest,
+// the replica config is decoded later on top of the default config.
+type tomlChangefeedManifest struct {
+	changefeedManifest
+	Config toml.Primitive `toml:"config"`
+}
+
+// jsonChangefeedManifest is the json representation of a changefeedManifest,
+// yaml manifests are converted to json before decoding.
+type jsonChangefeedManifest struct {
+	changefeedManifest
+	Config json.RawMessage `json:"config"`
+}
+
+// isManifestFile returns true if the file is a toml or yaml manifest.
+func isManifestFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// loadChangefeedManifests loads the manifests from a file or the manifest
+// files directly under a directory, sorted by the changefeed IDs.
+func loadChangefeedManifests(path string) ([]*changefeedManifest, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	files := []string{path}
+	if stat.IsDir() {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if !entry.IsDir() && isManifestFile(entry.Name()) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, errors.Errorf("no changefeed manifest is found in %s", path)
+		}
+	}
+
+	manifests := make([]*changefeedManifest, 0, len(files))
+	paths := make(map[string]string, len(files))
+	for _, file := range files {
+		manifest, err := decodeChangefeedManifest(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := manifest.validate(); err != nil {
+			return nil, errors.Annotatef(err, "invalid changefeed manifest %s", file)
+		}
+		if other, ok := paths[manifest.ID]; ok {
+			return nil, errors.Errorf("changefeed %s is declared by both %s and %s", manifest.ID, other, file)
+		}
+		paths[manifest.ID] = file
+		manifests = append(manifests, manifest)
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].ID < manifests[j].ID
+	})
+	return manifests, nil
+}
+
+// decodeChangefeedManifest decodes a toml or yaml manifest file strictly,
+// the unknown fields are rejected.
+func decodeChangefeedManifest(path string) (*changefeedManifest, error) {
+	cfg := config.GetDefaultReplicaConfig()
+	var manifest changefeedManifest
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		var m tomlChangefeedManifest
+		metaData, err := toml.DecodeFile(path, &m)
+		if err != nil {
+			return nil, errors.Annotatef(err, "decode changefeed manifest %s", path)
+		}
+		if metaData.IsDefined("config") {
+			if err := metaData.PrimitiveDecode(m.Config, cfg); err != nil {
+				return nil, errors.Annotatef(err, "decode changefeed manifest %s", path)
+			}
+		}
+		if undecoded := metaData.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, 0, len(undecoded))
+			for _, key := range undecoded {
+				keys = append(keys, key.String())
+			}
+			return nil, errors.Errorf("changefeed manifest %s contained unknown configuration options: %s",
+				path, strings.Join(keys, ", "))
+		}
+		manifest = m.changefeedManifest
+	default:
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		data, err = yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, errors.Annotatef(err, "decode changefeed manifest %s", path)
+		}
+		var m jsonChangefeedManifest
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&m); err != nil {
+			return nil, errors.Annotatef(err, "decode changefeed manifest %s", path)
+		}
+		if len(m.Config) != 0 && string(m.Config) != "null" {
+			if err := cfg.UnmarshalJSONStrict(m.Config); err != nil {
+				return nil, errors.Annotatef(err, "decode changefeed manifest %s", path)
+			}
+		}
+		manifest = m.changefeedManifest
+	}
+	manifest.Config = cfg
+	manifest.path = path
+	return &manifest, nil
+}
+
+// validate checks the manifest like `cli changefeed create` checks its flags.
+func (m *changefeedManifest) validate() error {
+	if err := model.ValidateChangefeedID(m.ID); err != nil {
+		return err
+	}
+	if m.SinkURI == "" {
+		return errors.New("sink-uri is required")
+	}
+	if m.TargetTs > 0 && m.TargetTs <= m.StartTs {
+		return errors.Errorf("target-ts %d must be larger than start-ts: %d", m.TargetTs, m.StartTs)
+	}
+	if _, err := filter.VerifyRules(m.Config); err != nil {
+		return err
+	}
+	if err := m.Config.Placement.Validate(); err != nil {
+		return err
+	}
+	if err := m.Config.Heartbeat.Validate(); err != nil {
+		return err
+	}
+	sortEngine := m.SortEngine
+	if sortEngine == "" {
+		sortEngine = model.SortUnified
+	}
+	sinkConcurrency := 0
+	if m.Quota != nil {
+		sinkConcurrency = m.Quota.SinkConcurrency
+	}
+	return validateChangefeedSettings(m.SinkURI, sortEngine, m.Priority, sinkConcurrency)
+}
+
+// newInfo constructs the information of the changefeed to create, the start
+// ts is resolved from PD when the changefeed is created if it is not set.
+func (m *changefeedManifest) newInfo() *model.ChangeFeedInfo {
+	info := &model.ChangeFeedInfo{
+		Opts:              make(map[string]string),
+		StartTs:           m.StartTs,
+		Engine:            model.SortUnified,
+		State:             model.StateNormal,
+		SyncPointInterval: 10 * time.Minute,
+		CreatorVersion:    version.ReleaseVersion,
+	}
+	m.patch(info)
+	return info
+}
+
+// applyTo returns a copy of the existing changefeed with the manifest applied.
+// The start ts of an existing changefeed can not be changed, so it is ignored.
+func (m *changefeedManifest) applyTo(oldInfo *model.ChangeFeedInfo) (*model.ChangeFeedInfo, error) {
+	newInfo, err := oldInfo.Clone()
+	if err != nil {
+		return nil, err
+	}
+	m.patch(newInfo)
+	return newInfo, nil
+}
+
+// patch sets the fields of the changefeed declared by the manifest, the
+// fields managed by the owner, e.g. the state and the error, are untouched.
+func (m *changefeedManifest) patch(info *model.ChangeFeedInfo) {
+	info.SinkURI = m.SinkURI
+	info.TargetTs = m.TargetTs
+	info.Config = m.Config.Clone()
+	if m.Opts != nil {
+		info.Opts = make(map[string]string, len(m.Opts))
+		for key, value := range m.Opts {
+			info.Opts[key] = value
+		}
+	}
+	if m.SortEngine != "" {
+		info.Engine = m.SortEngine
+	}
+	if m.Priority != "" {
+		info.Priority = m.Priority
+	}
+	if m.Quota != nil {
+		quota := *m.Quota
+		info.Quota = &quota
+	}
+}
+
+// applyAction is the action taken on a changefeed by `cli changefeed apply`.
+type applyAction string
+
+// All applyActions
+const (
+	applyActionCreate    applyAction = "create"
+	applyActionUpdate    applyAction = "update"
+	applyActionRemove    applyAction = "remove"
+	applyActionUnchanged applyAction = "unchanged"
+)
+
+// applyPlanItem is the planned action of a changefeed.
+type applyPlanItem struct {
+	action       applyAction
+	changefeedID string
+	manifest     *changefeedManifest
+	oldInfo      *model.ChangeFeedInfo
+	newInfo      *model.ChangeFeedInfo
+	changes      []model.ChangefeedConfigChange
+	// restart is true if the changefeed has to be paused before it is
+	// updated, and resumed afterwards. Only the normal changefeeds are
+	// restarted, the stopped, error and failed ones are updated in place.
+	restart bool
+}
+
+// buildApplyPlan compares the manifests with the existing changefeeds, and
+// plans the actions to take. The changefeeds not declared by any manifest
+// are removed only if prune is true.
+func buildApplyPlan(
+	manifests []*changefeedManifest, infos map[string]*model.ChangeFeedInfo, prune bool,
+) ([]*applyPlanItem, error) {
+	plan := make([]*applyPlanItem, 0, len(manifests))
+	declared := make(map[string]struct{}, len(manifests))
+	for _, manifest := range manifests {
+		declared[manifest.ID] = struct{}{}
+		oldInfo, ok := infos[manifest.ID]
+		if !ok {
+			plan = append(plan, &applyPlanItem{
+				action:       applyActionCreate,
+				changefeedID: manifest.ID,
+				manifest:     manifest,
+				newInfo:      manifest.newInfo(),
+			})
+			continue
+		}
+		switch oldInfo.State {
+		case model.StateRemoved, model.StateFinished:
+			return nil, errors.Errorf("changefeed %s is %s and can not be updated, "+
+				"please remove it with `cdc cli changefeed remove --force` first", manifest.ID, oldInfo.State)
+		}
+		newInfo, err := manifest.applyTo(oldInfo)
+		if err != nil {
+			return nil, err
+		}
+		changes, err := model.DiffChangefeedInfo(oldInfo, newInfo)
+		if err != nil {
+			return nil, err
+		}
+		item := &applyPlanItem{
+			action:       applyActionUnchanged,
+			changefeedID: manifest.ID,
+			manifest:     manifest,
+			oldInfo:      oldInfo,
+			newInfo:      newInfo,
+			changes:      changes,
+		}
+		if len(changes) != 0 {
+			item.action = applyActionUpdate
+			item.restart = oldInfo.State == model.StateNormal
+		}
+		plan = append(plan, item)
+	}
+
+	if prune {
+		for id, info := range infos {
+			if _, ok := declared[id]; ok {
+				continue
+			}
+			// the soft removed changefeeds have been removed
+			if info.State == model.StateRemoved {
+				continue
+			}
+			plan = append(plan, &applyPlanItem{
+				action:       applyActionRemove,
+				changefeedID: id,
+				oldInfo:      info,
+			})
+		}
+	}
+
+	sort.Slice(plan, func(i, j int) bool {
+		return plan[i].changefeedID < plan[j].changefeedID
+	})
+	return plan, nil
+}
+
+// hasChanges returns true if any action other than unchanged is planned.
+func hasChanges(plan []*applyPlanItem) bool {
+	for _, item := range plan {
+		if item.action != applyActionUnchanged {
+			return true
+		}
+	}
+	return false
+}
+
+// printApplyPlan prints the plan in a human readable format.
+func printApplyPlan(cmd *cobra.Command, plan []*applyPlanItem) {
+	counts := make(map[applyAction]int)
+	for _, item := range plan {
+		counts[item.action]++
+		switch item.action {
+		case applyActionCreate:
+			cmd.Printf("+ create changefeed %s (%s)\n", item.changefeedID, item.manifest.path)
+			cmd.Printf("    sink-uri: %s\n", security.RedactURI(item.newInfo.SinkURI))
+			if item.newInfo.StartTs != 0 {
+				cmd.Printf("    start-ts: %d\n", item.newInfo.StartTs)
+			}
+			if item.newInfo.TargetTs != 0 {
+				cmd.Printf("    target-ts: %d\n", item.newInfo.TargetTs)
+			}
+		case applyActionUpdate:
+			switch {
+			case item.restart:
+				cmd.Printf("~ update changefeed %s (%s), it will be paused and resumed\n", item.changefeedID, item.manifest.path)
+			case item.oldInfo.State == model.StateStopped:
+				cmd.Printf("~ update changefeed %s (%s)\n", item.changefeedID, item.manifest.path)
+			default:
+				cmd.Printf("~ update changefeed %s (%s), it is %s and will not be resumed\n",
+					item.changefeedID, item.manifest.path, item.oldInfo.State)
+			}
+			for _, change := range item.changes {
+				cmd.Printf("    %s %s: %v -> %v\n", change.Type, change.Path, change.From, change.To)
+			}
+		case applyActionRemove:
+			cmd.Printf("- remove changefeed %s\n", item.changefeedID)
+		case applyActionUnchanged:
+			cmd.Printf("= changefeed %s is unchanged\n", item.changefeedID)
+		}
+	}
+	cmd.Printf("Plan: %d to create, %d to update, %d to remove, %d unchanged.\n",
+		counts[applyActionCreate], counts[applyActionUpdate], counts[applyActionRemove], counts[applyActionUnchanged])
+}
+
+// applyChangefeedOptions defines flags for the `cli changefeed apply` command.
+type applyChangefeedOptions struct {
+	etcdClient *kv.CDCEtcdClient
+	pdClient   pd.Client
+
+	pdAddr     string
+	credential *security.Credential
+
+	path                    string
+	prune                   bool
+	noConfirm               bool
+	dryRun                  bool
+	disableGCSafePointCheck bool
+	timezone                string
+}
+
+// newApplyChangefeedOptions creates new options for the `cli changefeed apply` command.
+func newApplyChangefeedOptions() *applyChangefeedOptions {
+	return &applyChangefeedOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *applyChangefeedOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.path, "file", "f", "", "Path of a changefeed manifest file or a directory of manifest files")
+	cmd.PersistentFlags().BoolVar(&o.prune, "prune", false, "Remove the changefeeds which are not declared by any manifest")
+	cmd.PersistentFlags().BoolVar(&o.noConfirm, "no-confirm", false, "Don't ask user whether to apply the plan")
+	cmd.PersistentFlags().BoolVar(&o.dryRun, "dry-run", false, "Print the plan without applying it")
+	cmd.PersistentFlags().BoolVarP(&o.disableGCSafePointCheck, "disable-gc-check", "", false, "Disable GC safe point check")
+	cmd.PersistentFlags().StringVar(&o.timezone, "tz", "SYSTEM", "timezone used when checking sink uri (changefeed timezone is determined by cdc server)")
+	_ = cmd.MarkPersistentFlagRequired("file")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *applyChangefeedOptions) complete(f factory.Factory) error {
+	etcdClient, err := f.EtcdClient()
+	if err != nil {
+		return err
+	}
+	o.etcdClient = etcdClient
+
+	pdClient, err := f.PdClient()
+	if err != nil {
+		return err
+	}
+	o.pdClient = pdClient
+
+	o.pdAddr = f.GetPdAddr()
+	o.credential = f.GetCredential()
+
+	return nil
+}
+
+// run the `cli changefeed apply` command.
+func (o *applyChangefeedOptions) run(ctx context.Context, cmd *cobra.Command) error {
+	manifests, err := loadChangefeedManifests(o.path)
+	if err != nil {
+		return err
+	}
+
+	infos, err := o.etcdClient.GetAllChangeFeedInfo(ctx)
+	if err != nil {
+		return err
+	}
+
+	plan, err := buildApplyPlan(manifests, infos, o.prune)
+	if err != nil {
+		return err
+	}
+	printApplyPlan(cmd, plan)
+	if o.dryRun || !hasChanges(plan) {
+		return nil
+	}
+
+	tz, err := ticdcutil.GetTimezone(o.timezone)
+	if err != nil {
+		return errors.Annotate(err, "can not load timezone, Please specify the time zone through environment variable `TZ` or command line parameters `--tz`")
+	}
+	ctx = ticdcutil.PutTimezoneInCtx(ctx, tz)
+	for _, item := range plan {
+		if item.action == applyActionCreate {
+			if err := o.completeNewChangefeed(ctx, cmd, item); err != nil {
+				return errors.Annotatef(err, "verify changefeed %s", item.changefeedID)
+			}
+		}
+		if item.action != applyActionCreate && item.action != applyActionUpdate {
+			continue
+		}
+		if err := verifySink(ctx, item.newInfo.SinkURI, item.newInfo.Config, item.newInfo.Opts); err != nil {
+			return errors.Annotatef(err, "verify the sink of changefeed %s", item.changefeedID)
+		}
+	}
+
+	if !o.noConfirm {
+		cmd.Printf("Could you agree to apply the plan above [Y/N]\n")
+		var yOrN string
+		_, err = fmt.Scan(&yOrN)
+		if err != nil {
+			return err
+		}
+		if strings.ToLower(strings.TrimSpace(yOrN)) != "y" {
+			cmd.Printf("No changefeed is applied.\n")
+			return nil
+		}
+	}
+
+	for _, item := range plan {
+		switch item.action {
+		case applyActionCreate:
+			err = o.createChangefeed(ctx, item)
+		case applyActionUpdate:
+			err = o.updateChangefeed(ctx, item)
+		case applyActionRemove:
+			err = o.removeChangefeed(ctx, item)
+		default:
+			continue
+		}
+		if err != nil {
+			return errors.Annotatef(err, "%s changefeed %s", item.action, item.changefeedID)
+		}
+		cmd.Printf("Apply changefeed %s successfully, action: %s\n", item.changefeedID, item.action)
+		if item.action == applyActionUpdate && !item.restart && item.oldInfo.State != model.StateStopped {
+			cmd.Printf("Changefeed %s is %s, it is not resumed, please check its error and resume it "+
+				"with `cdc cli changefeed resume`\n", item.changefeedID, item.oldInfo.State)
+		}
+	}
+	return nil
+}
+
+// completeNewChangefeed resolves the start ts of a changefeed to create, and
+// completes and checks its config like `cli changefeed create` does.
+func (o *applyChangefeedOptions) completeNewChangefeed(ctx context.Context, cmd *cobra.Command, item *applyPlanItem) error {
+	info := item.newInfo
+	if info.StartTs == 0 {
+		ts, logical, err := o.pdClient.GetTS(ctx)
+		if err != nil {
+			return err
+		}
+		info.StartTs = oracle.ComposeTS(ts, logical)
+	}
+	if info.TargetTs > 0 && info.TargetTs <= info.StartTs {
+		return errors.Errorf("target-ts %d must be larger than start-ts: %d", info.TargetTs, info.StartTs)
+	}
+
+	_, captureInfos, err := o.etcdClient.GetCaptures(ctx)
+	if err != nil {
+		return err
+	}
+	cdcClusterVer, err := version.GetTiCDCClusterVersion(captureInfos)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := completeReplicaConfig(cmd, info.Config, info.SinkURI, cdcClusterVer); err != nil {
+		return err
+	}
+	if info.Engine == model.SortUnified && !cdcClusterVer.ShouldEnableUnifiedSorterByDefault() {
+		info.Engine = model.SortInMemory
+		log.Warn("The TiCDC cluster is built from an older version, disabling Unified Sorter by default",
+			zap.String("version", cdcClusterVer.String()))
+	}
+	if o.disableGCSafePointCheck {
+		info.Config.CheckGCSafePoint = false
+	}
+	return checkTables(cmd, o.pdAddr, o.credential, info.Config, info.StartTs, !o.noConfirm)
+}
+
+// createChangefeed creates the changefeed declared by a manifest.
+func (o *applyChangefeedOptions) createChangefeed(ctx context.Context, item *applyPlanItem) error {
+	info := item.newInfo
+	if !o.disableGCSafePointCheck {
+		if err := ticdcutil.CheckSafetyOfStartTs(ctx, o.pdClient, item.changefeedID, info.StartTs); err != nil {
+			return err
+		}
+	}
+	info.CreateTime = time.Now()
+	return o.etcdClient.CreateChangefeedInfo(ctx, info, item.changefeedID)
+}
+
+// updateChangefeed updates the changefeed to the manifest, the changefeed is
+// paused before the update and resumed afterwards if it is normal. Only the
+// fields declared by the manifest are updated, atomically, so that the changes
+// made by the owner in the meantime are kept.
+func (o *applyChangefeedOptions) updateChangefeed(ctx context.Context, item *applyPlanItem) error {
+	if item.restart {
+		job := model.AdminJob{CfID: item.changefeedID, Type: model.AdminStop}
+		if err := sendOwnerAdminChangeQuery(ctx, o.etcdClient, job, o.credential); err != nil {
+			return err
+		}
+		if err := o.waitChangefeedStopped(ctx, item.changefeedID); err != nil {
+			return err
+		}
+	}
+
+	_, err := o.etcdClient.UpdateChangeFeedInfo(ctx, item.changefeedID, func(info *model.ChangeFeedInfo) error {
+		// a running changefeed can not be updated in place
+		if info.State == model.StateNormal {
+			return errors.Errorf("changefeed %s is resumed concurrently, please apply the manifest again", item.changefeedID)
+		}
+		item.manifest.patch(info)
+		return nil
+	})
+	if err != nil || !item.restart {
+		return err
+	}
+
+	job := model.AdminJob{CfID: item.changefeedID, Type: model.AdminResume}
+	return sendOwnerAdminChangeQuery(ctx, o.etcdClient, job, o.credential)
+}
+
+// waitChangefeedStopped waits until the changefeed is stopped by the owner.
+func (o *applyChangefeedOptions) waitChangefeedStopped(ctx context.Context, changefeedID string) error {
+	ctx, cancel := context.WithTimeout(ctx, changefeedStateCheckTimeout)
+	defer cancel()
+	ticker := time.NewTicker(changefeedStateCheckInterval)
+	defer ticker.Stop()
+	for {
+		info, err := o.etcdClient.GetChangeFeedInfo(ctx, changefeedID)
+		if err != nil {
+			return err
+		}
+		if info.State == model.StateStopped {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("changefeed %s is not paused in %s, its state is %s",
+				changefeedID, changefeedStateCheckTimeout, info.State)
+		case <-ticker.C:
+		}
+	}
+}
+
+// removeChangefeed removes a changefeed which is not declared by any manifest.
+func (o *applyChangefeedOptions) removeChangefeed(ctx context.Context, item *applyPlanItem) error {
+	job := model.AdminJob{CfID: item.changefeedID, Type: model.AdminRemove}
+	return sendOwnerAdminChangeQuery(ctx, o.etcdClient, job, o.credential)
+}
+
+// newCmdApplyChangefeed creates the `cli changefeed apply` command.
+func newCmdApplyChangefeed(f factory.Factory) *cobra.Command {
+	o := newApplyChangefeedOptions()
+
+	command := &cobra.Command{
+		Use:   "apply",
+		Short: "Create, update or remove replication tasks (changefeeds) to match the manifest files",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmdcontext.GetDefaultContext()
+
+			err := o.complete(f)
+			if err != nil {
+				return err
+			}
+
+			return o.run(ctx, cmd)
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
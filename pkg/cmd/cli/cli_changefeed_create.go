@@ -15,7 +15,6 @@ package cli
 
 import (
 	"context"
-	"net/url"
 	"strings"
 	"time"
 
@@ -51,6 +50,7 @@ var forceEnableOldValueProtocols = []string{
 // changefeedCommonOptions defines common changefeed flags.
 type changefeedCommonOptions struct {
 	noConfirm              bool
+	dryRun                 bool
 	targetTs               uint64
 	sinkURI                string
 	configFile             string
@@ -63,6 +63,10 @@ type changefeedCommonOptions struct {
 	cyclicSyncDDL          bool
 	syncPointEnabled       bool
 	syncPointInterval      time.Duration
+	priority               string
+	memoryQuota            uint64
+	sorterDiskQuota        uint64
+	sinkConcurrency        int
 }
 
 // newChangefeedCommonOptions creates new changefeed common options.
@@ -78,6 +82,7 @@ func (o *changefeedCommonOptions) addFlags(cmd *cobra.Command) {
 	}
 
 	cmd.PersistentFlags().BoolVar(&o.noConfirm, "no-confirm", false, "Don't ask user whether to ignore ineligible table")
+	cmd.PersistentFlags().BoolVar(&o.dryRun, "dry-run", false, "Validate the changefeed and print the effective config, the tables to replicate and the diff without applying them")
 	cmd.PersistentFlags().Uint64Var(&o.targetTs, "target-ts", 0, "Target ts of changefeed")
 	cmd.PersistentFlags().StringVar(&o.sinkURI, "sink-uri", "", "sink uri")
 	cmd.PersistentFlags().StringVar(&o.configFile, "config", "", "Path of the configuration file")
@@ -90,9 +95,25 @@ func (o *changefeedCommonOptions) addFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().BoolVar(&o.cyclicSyncDDL, "cyclic-sync-ddl", true, "(Experimental) Cyclic replication sync DDL of changefeed")
 	cmd.PersistentFlags().BoolVar(&o.syncPointEnabled, "sync-point", false, "(Experimental) Set and Record syncpoint in replication(default off)")
 	cmd.PersistentFlags().DurationVar(&o.syncPointInterval, "sync-interval", 10*time.Minute, "(Experimental) Set the interval for syncpoint in replication(default 10min)")
+	cmd.PersistentFlags().StringVar(&o.priority, "priority", model.PriorityNormal, "Priority of changefeed, one of \"high\", \"normal\" and \"low\", the sorters of low priority changefeeds spill to disk earlier")
+	cmd.PersistentFlags().Uint64Var(&o.memoryQuota, "memory-quota", 0, "Max memory in bytes used by the changefeed on each capture, 0 means no limit")
+	cmd.PersistentFlags().Uint64Var(&o.sorterDiskQuota, "sorter-disk-quota", 0, "Max disk space in bytes used by the sorter of the changefeed on each capture, 0 means no limit")
+	cmd.PersistentFlags().IntVar(&o.sinkConcurrency, "sink-concurrency", 0, "Max number of sink workers of the changefeed on each capture, 0 means no limit, only supported by the MySQL and TiDB sinks")
 	_ = cmd.PersistentFlags().MarkHidden("sort-dir")
 }
 
+// getQuota returns the resource quota specified by the flags, nil is returned if no quota is specified.
+func (o *changefeedCommonOptions) getQuota() *model.ChangefeedQuota {
+	if o.memoryQuota == 0 && o.sorterDiskQuota == 0 && o.sinkConcurrency == 0 {
+		return nil
+	}
+	return &model.ChangefeedQuota{
+		MemoryQuota:     o.memoryQuota,
+		SorterDiskQuota: o.sorterDiskQuota,
+		SinkConcurrency: o.sinkConcurrency,
+	}
+}
+
 // strictDecodeConfig do strictDecodeFile check and only verify the rules for now.
 func (o *changefeedCommonOptions) strictDecodeConfig(component string, cfg *config.ReplicaConfig) error {
 	err := util.StrictDecodeFile(o.configFile, component, cfg)
@@ -101,8 +122,16 @@ func (o *changefeedCommonOptions) strictDecodeConfig(component string, cfg *conf
 	}
 
 	_, err = filter.VerifyRules(cfg)
+	if err != nil {
+		return err
+	}
+
+	err = cfg.Placement.Validate()
+	if err != nil {
+		return err
+	}
 
-	return err
+	return cfg.Heartbeat.Validate()
 }
 
 // createChangefeedOptions defines common flags for the `cli changefeed crate` command.
@@ -191,42 +220,8 @@ func (o *createChangefeedOptions) completeCfg(ctx context.Context, cmd *cobra.Co
 		}
 	}
 
-	if !cdcClusterVer.ShouldEnableOldValueByDefault() {
-		cfg.EnableOldValue = false
-		log.Warn("The TiCDC cluster is built from an older version, disabling old value by default.",
-			zap.String("version", cdcClusterVer.String()))
-	}
-
-	if !cfg.EnableOldValue {
-		sinkURIParsed, err := url.Parse(o.commonChangefeedOptions.sinkURI)
-		if err != nil {
-			return cerror.WrapError(cerror.ErrSinkURIInvalid, err)
-		}
-
-		protocol := sinkURIParsed.Query().Get("protocol")
-		for _, fp := range forceEnableOldValueProtocols {
-			if protocol == fp {
-				log.Warn("Attempting to replicate without old value enabled. CDC will enable old value and continue.", zap.String("protocol", protocol))
-				cfg.EnableOldValue = true
-				break
-			}
-		}
-
-		if cfg.ForceReplicate {
-			log.Error("if use force replicate, old value feature must be enabled")
-			return cerror.ErrOldValueNotEnabled.GenWithStackByArgs()
-		}
-	}
-
-	for _, rules := range cfg.Sink.DispatchRules {
-		switch strings.ToLower(rules.Dispatcher) {
-		case "rowid", "index-value":
-			if cfg.EnableOldValue {
-				cmd.Printf("[WARN] This index-value distribution mode "+
-					"does not guarantee row-level orderliness when "+
-					"switching on the old value, so please use caution! dispatch-rules: %#v", rules)
-			}
-		}
+	if err := completeReplicaConfig(cmd, cfg, o.commonChangefeedOptions.sinkURI, cdcClusterVer); err != nil {
+		return err
 	}
 
 	if o.commonChangefeedOptions.sortEngine == model.SortUnified && !cdcClusterVer.ShouldEnableUnifiedSorterByDefault() {
@@ -265,6 +260,53 @@ func (o *createChangefeedOptions) completeCfg(ctx context.Context, cmd *cobra.Co
 	return nil
 }
 
+// completeReplicaConfig adjusts the replica config to the version of the
+// cluster and the protocol of the sink.
+func completeReplicaConfig(
+	cmd *cobra.Command, cfg *config.ReplicaConfig, sinkURI string, cdcClusterVer version.TiCDCClusterVersion,
+) error {
+	if !cdcClusterVer.ShouldEnableOldValueByDefault() {
+		cfg.EnableOldValue = false
+		log.Warn("The TiCDC cluster is built from an older version, disabling old value by default.",
+			zap.String("version", cdcClusterVer.String()))
+	}
+
+	if !cfg.EnableOldValue {
+		sinkURIParsed, err := security.ParseURIWithSecretReferences(sinkURI)
+		if err != nil {
+			return cerror.WrapError(cerror.ErrSinkURIInvalid, err)
+		}
+
+		protocol := sinkURIParsed.Query().Get("protocol")
+		for _, fp := range forceEnableOldValueProtocols {
+			// the old value rules are kept if old values are enabled for some tables
+			if protocol == fp && !cfg.AnyOldValueEnabled() {
+				log.Warn("Attempting to replicate without old value enabled. CDC will enable old value and continue.", zap.String("protocol", protocol))
+				cfg.EnableOldValue = true
+				break
+			}
+		}
+
+		if cfg.ForceReplicate {
+			log.Error("if use force replicate, old value feature must be enabled")
+			return cerror.ErrOldValueNotEnabled.GenWithStackByArgs()
+		}
+	}
+
+	for _, rules := range cfg.Sink.DispatchRules {
+		switch strings.ToLower(rules.Dispatcher) {
+		case "rowid", "index-value":
+			if cfg.AnyOldValueEnabled() {
+				cmd.Printf("[WARN] This index-value distribution mode "+
+					"does not guarantee row-level orderliness when "+
+					"switching on the old value, so please use caution! dispatch-rules: %#v", rules)
+			}
+		}
+	}
+
+	return nil
+}
+
 // validate checks that the provided attach options are specified.
 func (o *createChangefeedOptions) validate(ctx context.Context, cmd *cobra.Command) error {
 	if o.commonChangefeedOptions.sinkURI == "" {
@@ -287,14 +329,34 @@ func (o *createChangefeedOptions) validate(ctx context.Context, cmd *cobra.Comma
 		return errors.New("Creating changefeed with `--sort-dir`, it's invalid")
 	}
 
-	switch o.commonChangefeedOptions.sortEngine {
-	case model.SortUnified, model.SortInMemory:
+	return validateChangefeedSettings(o.commonChangefeedOptions.sinkURI, o.commonChangefeedOptions.sortEngine,
+		o.commonChangefeedOptions.priority, o.commonChangefeedOptions.sinkConcurrency)
+}
+
+// validateChangefeedSettings checks the sort engine, the priority and the
+// sink concurrency of a changefeed.
+func validateChangefeedSettings(
+	sinkURI string, sortEngine string, priority model.ChangefeedPriority, sinkConcurrency int,
+) error {
+	if err := model.ValidateChangefeedPriority(priority); err != nil {
+		return err
+	}
+
+	if sinkConcurrency < 0 {
+		return errors.New("Creating changefeed with a negative sink-concurrency, it's invalid")
+	}
+	if err := sink.ValidateSinkConcurrency(sinkURI, sinkConcurrency); err != nil {
+		return err
+	}
+
+	switch sortEngine {
+	case model.SortUnified, model.SortInMemory, model.SortInDB:
 	case model.SortInFile:
 		// obsolete. But we keep silent here. We create a Unified Sorter when the owner/processor sees this option
 		// for backward-compatibility.
 	default:
 		return errors.Errorf("Creating changefeed with an invalid sort engine(%s), "+
-			"`%s` and `%s` are the only valid options.", o.commonChangefeedOptions.sortEngine, model.SortUnified, model.SortInMemory)
+			"`%s`, `%s` and `%s` are the only valid options.", sortEngine, model.SortUnified, model.SortInMemory, model.SortInDB)
 	}
 
 	return nil
@@ -314,6 +376,8 @@ func (o *createChangefeedOptions) getInfo(cmd *cobra.Command) *model.ChangeFeedI
 		SyncPointEnabled:  o.commonChangefeedOptions.syncPointEnabled,
 		SyncPointInterval: o.commonChangefeedOptions.syncPointInterval,
 		CreatorVersion:    version.ReleaseVersion,
+		Priority:          o.commonChangefeedOptions.priority,
+		Quota:             o.commonChangefeedOptions.getQuota(),
 	}
 
 	if info.Engine == model.SortInFile {
@@ -322,6 +386,11 @@ func (o *createChangefeedOptions) getInfo(cmd *cobra.Command) *model.ChangeFeedI
 			"Adjust \"sort-engine\" to make use of the right sorter.\n")
 	}
 
+	if security.RedactURI(info.SinkURI) != info.SinkURI {
+		cmd.Printf("[WARN] the sink URI contains plaintext secrets, which are stored in etcd. " +
+			"Consider referencing them by ${env:NAME}, ${file:PATH} or ${enc:BLOB} instead.\n")
+	}
+
 	for _, opt := range o.commonChangefeedOptions.opts {
 		s := strings.SplitN(opt, "=", 2)
 		if len(s) <= 0 {
@@ -347,6 +416,9 @@ func (o *createChangefeedOptions) validateStartTs(ctx context.Context) error {
 	if o.disableGCSafePointCheck {
 		return nil
 	}
+	if o.commonChangefeedOptions.dryRun {
+		return ticdcutil.CheckStartTsForDryRun(ctx, o.pdClient, o.changefeedID, o.startTs)
+	}
 
 	return ticdcutil.CheckSafetyOfStartTs(ctx, o.pdClient, o.changefeedID, o.startTs)
 }
@@ -362,6 +434,13 @@ func (o *createChangefeedOptions) validateTargetTs() error {
 // validateSink will create a sink and verify that the configuration is correct.
 func (o *createChangefeedOptions) validateSink(
 	ctx context.Context, cfg *config.ReplicaConfig, opts map[string]string,
+) error {
+	return verifySink(ctx, o.commonChangefeedOptions.sinkURI, cfg, opts)
+}
+
+// verifySink will create a sink and verify that the configuration is correct.
+func verifySink(
+	ctx context.Context, sinkURI string, cfg *config.ReplicaConfig, opts map[string]string,
 ) error {
 	filter, err := filter.NewFilter(cfg)
 	if err != nil {
@@ -369,8 +448,14 @@ func (o *createChangefeedOptions) validateSink(
 	}
 
 	errCh := make(chan error)
-	s, err := sink.NewSink(ctx, "cli-verify", o.commonChangefeedOptions.sinkURI, filter, cfg, opts, errCh)
+	s, err := sink.NewSink(ctx, "cli-verify", sinkURI, filter, cfg, opts, errCh)
 	if err != nil {
+		if cerror.ErrResolveSecret.Equal(err) {
+			// the secrets are resolved by the captures, they may be unavailable
+			// on the machine running the cli
+			log.Warn("skip verifying the sink as the secrets referenced by it can not be resolved locally", zap.Error(err))
+			return nil
+		}
 		return err
 	}
 	err = s.Close(ctx)
@@ -399,7 +484,7 @@ func (o *createChangefeedOptions) run(ctx context.Context, cmd *cobra.Command) e
 		return err
 	}
 
-	if !o.commonChangefeedOptions.noConfirm {
+	if !o.commonChangefeedOptions.noConfirm && !o.commonChangefeedOptions.dryRun {
 		currentPhysical, _, err := o.pdClient.GetTS(ctx)
 		if err != nil {
 			return err
@@ -410,29 +495,11 @@ func (o *createChangefeedOptions) run(ctx context.Context, cmd *cobra.Command) e
 		}
 	}
 
-	ineligibleTables, eligibleTables, err := getTables(o.pdAddr, o.credential, o.cfg, o.startTs)
-	if err != nil {
+	confirm := !o.commonChangefeedOptions.noConfirm && !o.commonChangefeedOptions.dryRun
+	if err := checkTables(cmd, o.pdAddr, o.credential, o.cfg, o.startTs, confirm); err != nil {
 		return err
 	}
 
-	if len(ineligibleTables) != 0 {
-		if o.cfg.ForceReplicate {
-			cmd.Printf("[WARN] force to replicate some ineligible tables, %#v\n", ineligibleTables)
-		} else {
-			cmd.Printf("[WARN] some tables are not eligible to replicate, %#v\n", ineligibleTables)
-			if !o.commonChangefeedOptions.noConfirm {
-				if err := confirmIgnoreIneligibleTables(cmd); err != nil {
-					return err
-				}
-			}
-		}
-	}
-
-	if o.cfg.Cyclic.IsEnabled() && !cyclic.IsTablesPaired(eligibleTables) {
-		return errors.New("normal tables and mark tables are not paired, " +
-			"please run `cdc cli changefeed cyclic create-marktables`")
-	}
-
 	info := o.getInfo(cmd)
 
 	tz, err := ticdcutil.GetTimezone(o.commonChangefeedOptions.timezone)
@@ -446,7 +513,11 @@ func (o *createChangefeedOptions) run(ctx context.Context, cmd *cobra.Command) e
 		return err
 	}
 
-	infoStr, err := info.Marshal()
+	if o.commonChangefeedOptions.dryRun {
+		return dryRunChangefeed(ctx, cmd, o.pdClient, o.pdAddr, o.credential, nil, info)
+	}
+
+	infoStr, err := info.Redacted().Marshal()
 	if err != nil {
 		return err
 	}
@@ -461,6 +532,38 @@ func (o *createChangefeedOptions) run(ctx context.Context, cmd *cobra.Command) e
 	return nil
 }
 
+// checkTables warns about the ineligible tables, and asks user whether to
+// ignore them if confirm is true. The mark tables must be paired with the
+// normal tables if cyclic replication is enabled.
+func checkTables(
+	cmd *cobra.Command, pdAddr string, credential *security.Credential,
+	cfg *config.ReplicaConfig, startTs uint64, confirm bool,
+) error {
+	ineligibleTables, eligibleTables, err := getTables(pdAddr, credential, cfg, startTs)
+	if err != nil {
+		return err
+	}
+
+	if len(ineligibleTables) != 0 {
+		if cfg.ForceReplicate {
+			cmd.Printf("[WARN] force to replicate some ineligible tables, %#v\n", ineligibleTables)
+		} else {
+			cmd.Printf("[WARN] some tables are not eligible to replicate, %#v\n", ineligibleTables)
+			if confirm {
+				if err := confirmIgnoreIneligibleTables(cmd); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if cfg.Cyclic.IsEnabled() && !cyclic.IsTablesPaired(eligibleTables) {
+		return errors.New("normal tables and mark tables are not paired, " +
+			"please run `cdc cli changefeed cyclic create-marktables`")
+	}
+	return nil
+}
+
 // newCmdCreateChangefeed creates the `cli changefeed create` command.
 func newCmdCreateChangefeed(f factory.Factory) *cobra.Command {
 	commonChangefeedOptions := newChangefeedCommonOptions()
@@ -15,6 +15,7 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"strings"
@@ -32,6 +33,7 @@ import (
 	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/spf13/cobra"
 	"github.com/tikv/client-go/v2/oracle"
+	pd "github.com/tikv/pd/client"
 )
 
 const (
@@ -114,6 +116,34 @@ func getTables(cliPdAddr string, credential *security.Credential, cfg *config.Re
 	return
 }
 
+// dryRunChangefeed prints the effects of creating or updating a changefeed to
+// newInfo at the current ts, oldInfo is nil if the changefeed is created.
+func dryRunChangefeed(ctx context.Context, cmd *cobra.Command, pdClient pd.Client, pdAddr string,
+	credential *security.Credential, oldInfo, newInfo *model.ChangeFeedInfo,
+) error {
+	ts, logical, err := pdClient.GetTS(ctx)
+	if err != nil {
+		return err
+	}
+	currentTs := oracle.ComposeTS(ts, logical)
+
+	ineligibleTables, eligibleTables, err := getTables(pdAddr, credential, newInfo.Config, currentTs)
+	if err != nil {
+		return err
+	}
+	result := model.NewChangefeedDryRunResult(newInfo.Config, ineligibleTables, eligibleTables)
+	if oldInfo != nil {
+		oldIneligibleTables, oldEligibleTables, err := getTables(pdAddr, credential, oldInfo.Config, currentTs)
+		if err != nil {
+			return err
+		}
+		if err := result.DiffWith(oldInfo, newInfo, oldIneligibleTables, oldEligibleTables); err != nil {
+			return err
+		}
+	}
+	return util.JSONPrint(cmd, result)
+}
+
 // sendOwnerChangefeedQuery sends owner changefeed query request.
 func sendOwnerChangefeedQuery(ctx context.Context, etcdClient *kv.CDCEtcdClient,
 	id model.ChangeFeedID, credential *security.Credential,
@@ -176,11 +206,24 @@ func sendOwnerAdminChangeQuery(ctx context.Context, etcdClient *kv.CDCEtcdClient
 		forceRemoveOpt = "true"
 	}
 
-	resp, err := httpClient.PostForm(url, map[string][]string{
+	form := map[string][]string{
 		cdc.APIOpVarAdminJob:           {fmt.Sprint(int(job.Type))},
 		cdc.APIOpVarChangefeedID:       {job.CfID},
 		cdc.APIOpForceRemoveChangefeed: {forceRemoveOpt},
-	})
+	}
+	if job.Opts != nil {
+		if job.Opts.StopTs != 0 {
+			form[cdc.APIOpVarStopTs] = []string{fmt.Sprint(job.Opts.StopTs)}
+		}
+		if job.Type == model.AdminUpdateTargetTs {
+			form[cdc.APIOpVarTargetTs] = []string{fmt.Sprint(job.Opts.TargetTs)}
+		}
+		if job.Opts.OverwriteCheckpointTs != 0 {
+			form[cdc.APIOpVarOverwriteCheckpointTs] = []string{fmt.Sprint(job.Opts.OverwriteCheckpointTs)}
+		}
+	}
+
+	resp, err := httpClient.PostForm(url, form)
 	if err != nil {
 		return err
 	}
@@ -195,3 +238,45 @@ func sendOwnerAdminChangeQuery(ctx context.Context, etcdClient *kv.CDCEtcdClient
 
 	return nil
 }
+
+// sendOwnerTablesQuery queries the replication status of all tables of a changefeed from the owner.
+func sendOwnerTablesQuery(ctx context.Context, etcdClient *kv.CDCEtcdClient,
+	id model.ChangeFeedID, credential *security.Credential,
+) ([]model.TableReplicationStatus, error) {
+	owner, err := getOwnerCapture(ctx, etcdClient)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := util.HTTP
+	if credential.IsTLSEnabled() {
+		scheme = util.HTTPS
+	}
+
+	url := fmt.Sprintf("%s://%s/api/v1/changefeeds/%s/tables", scheme, owner.AdvertiseAddr, id)
+	httpClient, err := httputil.NewClient(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.BadRequestf("query changefeed tables")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.BadRequestf("%s", string(body))
+	}
+
+	var tables []model.TableReplicationStatus
+	if err := json.Unmarshal(body, &tables); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return tables, nil
+}
@@ -14,9 +14,11 @@
 package cli
 
 import (
+	"context"
+
 	"github.com/pingcap/ticdc/cdc/kv"
 	"github.com/pingcap/ticdc/cdc/model"
-	"github.com/pingcap/ticdc/pkg/cmd/context"
+	cmdcontext "github.com/pingcap/ticdc/pkg/cmd/context"
 	"github.com/pingcap/ticdc/pkg/cmd/factory"
 	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/spf13/cobra"
@@ -29,6 +31,7 @@ type pauseChangefeedOptions struct {
 	credential *security.Credential
 
 	changefeedID string
+	stopTs       uint64
 }
 
 // newPauseChangefeedOptions creates new options for the `cli changefeed pause` command.
@@ -40,6 +43,7 @@ func newPauseChangefeedOptions() *pauseChangefeedOptions {
 // flags related to template printing to it.
 func (o *pauseChangefeedOptions) addFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
+	cmd.PersistentFlags().Uint64Var(&o.stopTs, "stop-ts", 0, "Pause the changefeed after all the events with commit ts <= stop-ts are replicated")
 	_ = cmd.MarkPersistentFlagRequired("changefeed-id")
 }
 
@@ -57,6 +61,19 @@ func (o *pauseChangefeedOptions) complete(f factory.Factory) error {
 	return nil
 }
 
+// validateStopTs checks if stopTs is a valid value.
+func (o *pauseChangefeedOptions) validateStopTs(ctx context.Context) error {
+	info, err := o.etcdClient.GetChangeFeedInfo(ctx, o.changefeedID)
+	if err != nil {
+		return err
+	}
+	status, _, err := o.etcdClient.GetChangeFeedStatus(ctx, o.changefeedID)
+	if err != nil {
+		return err
+	}
+	return info.VerifyStopTs(status, o.stopTs)
+}
+
 // run the `cli changefeed pause` command.
 func (o *pauseChangefeedOptions) run() error {
 	job := model.AdminJob{
@@ -64,7 +81,15 @@ func (o *pauseChangefeedOptions) run() error {
 		Type: model.AdminStop,
 	}
 
-	ctx := context.GetDefaultContext()
+	ctx := cmdcontext.GetDefaultContext()
+
+	if o.stopTs != 0 {
+		if err := o.validateStopTs(ctx); err != nil {
+			return err
+		}
+		job.Type = model.AdminStopAtTs
+		job.Opts = &model.AdminJobOption{StopTs: o.stopTs}
+	}
 
 	return sendOwnerAdminChangeQuery(ctx, o.etcdClient, job, o.credential)
 }
@@ -130,6 +130,9 @@ func (o *queryChangefeedOptions) run(cmd *cobra.Command) error {
 		taskStatus = append(taskStatus, captureTaskStatus{CaptureID: captureID, TaskStatus: status})
 	}
 
+	if info != nil {
+		info = info.Redacted()
+	}
 	meta := &cfMeta{Info: info, Status: status, Count: count, TaskStatus: taskStatus}
 
 	return util.JSONPrint(cmd, meta)
@@ -23,7 +23,9 @@ import (
 	cmdcontext "github.com/pingcap/ticdc/pkg/cmd/context"
 	"github.com/pingcap/ticdc/pkg/cmd/factory"
 	"github.com/pingcap/ticdc/pkg/security"
+	ticdcutil "github.com/pingcap/ticdc/pkg/util"
 	"github.com/spf13/cobra"
+	"github.com/tikv/client-go/v2/oracle"
 	pd "github.com/tikv/pd/client"
 )
 
@@ -34,8 +36,9 @@ type resumeChangefeedOptions struct {
 
 	credential *security.Credential
 
-	changefeedID string
-	noConfirm    bool
+	changefeedID          string
+	noConfirm             bool
+	overwriteCheckpointTs uint64
 }
 
 // newResumeChangefeedOptions creates new options for the `cli changefeed pause` command.
@@ -48,6 +51,7 @@ func newResumeChangefeedOptions() *resumeChangefeedOptions {
 func (o *resumeChangefeedOptions) addFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
 	cmd.PersistentFlags().BoolVar(&o.noConfirm, "no-confirm", false, "Don't ask user whether to ignore ineligible table")
+	cmd.PersistentFlags().Uint64Var(&o.overwriteCheckpointTs, "overwrite-checkpoint-ts", 0, "Resume the changefeed from the specified checkpoint ts instead of the current checkpoint ts")
 	_ = cmd.MarkPersistentFlagRequired("changefeed-id")
 }
 
@@ -90,13 +94,38 @@ func (o *resumeChangefeedOptions) confirmResumeChangefeedCheck(ctx context.Conte
 		return err
 	}
 
+	checkpointTs := info.TSO
+	if o.overwriteCheckpointTs != 0 {
+		checkpointTs = o.overwriteCheckpointTs
+	}
+
 	if !o.noConfirm {
-		return confirmLargeDataGap(cmd, currentPhysical, info.TSO)
+		return confirmLargeDataGap(cmd, currentPhysical, checkpointTs)
 	}
 
 	return nil
 }
 
+// validateOverwriteCheckpointTs checks if the changefeed can be resumed from the overwritten checkpoint ts.
+func (o *resumeChangefeedOptions) validateOverwriteCheckpointTs(ctx context.Context) error {
+	info, err := o.etcdClient.GetChangeFeedInfo(ctx, o.changefeedID)
+	if err != nil {
+		return err
+	}
+
+	currentPhysical, currentLogical, err := o.pdClient.GetTS(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = info.VerifyOverwriteCheckpointTs(o.overwriteCheckpointTs, oracle.ComposeTS(currentPhysical, currentLogical))
+	if err != nil {
+		return err
+	}
+
+	return ticdcutil.CheckSafetyOfStartTs(ctx, o.pdClient, o.changefeedID, o.overwriteCheckpointTs)
+}
+
 // run the `cli changefeed resume` command.
 func (o *resumeChangefeedOptions) run(cmd *cobra.Command) error {
 	ctx := cmdcontext.GetDefaultContext()
@@ -110,6 +139,13 @@ func (o *resumeChangefeedOptions) run(cmd *cobra.Command) error {
 		Type: model.AdminResume,
 	}
 
+	if o.overwriteCheckpointTs != 0 {
+		if err := o.validateOverwriteCheckpointTs(ctx); err != nil {
+			return err
+		}
+		job.Opts = &model.AdminJobOption{OverwriteCheckpointTs: o.overwriteCheckpointTs}
+	}
+
 	return sendOwnerAdminChangeQuery(ctx, o.etcdClient, job, o.credential)
 }
 
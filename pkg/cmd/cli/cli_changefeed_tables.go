@@ -0,0 +1,92 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/pkg/cmd/context"
+	"github.com/pingcap/ticdc/pkg/cmd/factory"
+	"github.com/pingcap/ticdc/pkg/cmd/util"
+	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/spf13/cobra"
+)
+
+// tablesChangefeedOptions defines flags for the `cli changefeed tables` command.
+type tablesChangefeedOptions struct {
+	etcdClient *kv.CDCEtcdClient
+
+	credential *security.Credential
+
+	changefeedID string
+}
+
+// newTablesChangefeedOptions creates new options for the `cli changefeed tables` command.
+func newTablesChangefeedOptions() *tablesChangefeedOptions {
+	return &tablesChangefeedOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *tablesChangefeedOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
+	_ = cmd.MarkPersistentFlagRequired("changefeed-id")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *tablesChangefeedOptions) complete(f factory.Factory) error {
+	etcdClient, err := f.EtcdClient()
+	if err != nil {
+		return err
+	}
+
+	o.etcdClient = etcdClient
+
+	o.credential = f.GetCredential()
+
+	return nil
+}
+
+// run the `cli changefeed tables` command.
+func (o *tablesChangefeedOptions) run(cmd *cobra.Command) error {
+	ctx := context.GetDefaultContext()
+
+	tables, err := sendOwnerTablesQuery(ctx, o.etcdClient, o.changefeedID, o.credential)
+	if err != nil {
+		return err
+	}
+
+	return util.JSONPrint(cmd, tables)
+}
+
+// newCmdTablesChangefeed creates the `cli changefeed tables` command.
+func newCmdTablesChangefeed(f factory.Factory) *cobra.Command {
+	o := newTablesChangefeedOptions()
+
+	command := &cobra.Command{
+		Use:   "tables",
+		Short: "Query the replication status of each table of a replication task (changefeed)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.complete(f)
+			if err != nil {
+				return err
+			}
+
+			return o.run(cmd)
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
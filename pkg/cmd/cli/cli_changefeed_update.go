@@ -21,6 +21,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc/kv"
 	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/cdc/sink"
 	cmdcontext "github.com/pingcap/ticdc/pkg/cmd/context"
 	"github.com/pingcap/ticdc/pkg/cmd/factory"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
@@ -28,13 +29,16 @@ import (
 	"github.com/r3labs/diff"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	pd "github.com/tikv/pd/client"
 	"go.uber.org/zap"
 )
 
 // updateChangefeedOptions defines common flags for the `cli changefeed update` command.
 type updateChangefeedOptions struct {
 	etcdClient *kv.CDCEtcdClient
+	pdClient   pd.Client
 
+	pdAddr     string
 	credential *security.Credential
 
 	commonChangefeedOptions *changefeedCommonOptions
@@ -69,6 +73,16 @@ func (o *updateChangefeedOptions) complete(f factory.Factory) error {
 
 	o.etcdClient = etcdClient
 
+	if o.commonChangefeedOptions.dryRun {
+		// the pd client is only used to list the tables in a dry run
+		pdClient, err := f.PdClient()
+		if err != nil {
+			return err
+		}
+		o.pdClient = pdClient
+		o.pdAddr = f.GetPdAddr()
+	}
+
 	o.credential = f.GetCredential()
 
 	return nil
@@ -85,7 +99,8 @@ func (o *updateChangefeedOptions) run(cmd *cobra.Command) error {
 	}
 	// Note that the correctness of the logic here depends on the return value of `/capture/owner/changefeed/query` interface.
 	// TODO: Using error codes instead of string containing judgments
-	if err == nil && !strings.Contains(resp, `"state": "stopped"`) {
+	// A dry run is allowed to preview the update before stopping the changefeed.
+	if err == nil && !o.commonChangefeedOptions.dryRun && !strings.Contains(resp, `"state": "stopped"`) {
 		return errors.Errorf("can only update changefeed config when it is stopped\nstatus: %s", resp)
 	}
 
@@ -99,7 +114,11 @@ func (o *updateChangefeedOptions) run(cmd *cobra.Command) error {
 		return err
 	}
 
-	changelog, err := diff.Diff(old, newInfo)
+	if o.commonChangefeedOptions.dryRun {
+		return dryRunChangefeed(ctx, cmd, o.pdClient, o.pdAddr, o.credential, old, newInfo)
+	}
+
+	changelog, err := diff.Diff(old.Redacted(), newInfo.Redacted())
 	if err != nil {
 		return err
 	}
@@ -129,7 +148,7 @@ func (o *updateChangefeedOptions) run(cmd *cobra.Command) error {
 	if err != nil {
 		return err
 	}
-	infoStr, err := newInfo.Marshal()
+	infoStr, err := newInfo.Redacted().Marshal()
 	if err != nil {
 		return err
 	}
@@ -188,11 +207,28 @@ func (o *updateChangefeedOptions) applyChanges(oldInfo *model.ChangeFeedInfo, cm
 			newInfo.SyncPointEnabled = o.commonChangefeedOptions.syncPointEnabled
 		case "sync-interval":
 			newInfo.SyncPointInterval = o.commonChangefeedOptions.syncPointInterval
-		case "tz", "changefeed-id", "no-confirm", "sort-dir":
+		case "priority":
+			newInfo.Priority = o.commonChangefeedOptions.priority
+			if err = model.ValidateChangefeedPriority(newInfo.Priority); err != nil {
+				log.Error("invalid changefeed priority", zap.Error(err))
+			}
+		case "memory-quota":
+			newInfo.Quota = updateQuota(newInfo.Quota, func(quota *model.ChangefeedQuota) {
+				quota.MemoryQuota = o.commonChangefeedOptions.memoryQuota
+			})
+		case "sorter-disk-quota":
+			newInfo.Quota = updateQuota(newInfo.Quota, func(quota *model.ChangefeedQuota) {
+				quota.SorterDiskQuota = o.commonChangefeedOptions.sorterDiskQuota
+			})
+		case "sink-concurrency":
+			newInfo.Quota = updateQuota(newInfo.Quota, func(quota *model.ChangefeedQuota) {
+				quota.SinkConcurrency = o.commonChangefeedOptions.sinkConcurrency
+			})
+		case "tz", "changefeed-id", "no-confirm", "dry-run", "sort-dir":
 			// Do nothing, these are some flags from the changefeed command that we don't use.
 		case "interact":
 			// Do nothing, this is a flags from the cli command, which we don't use.
-		case "pd", "log-level", "key", "cert", "ca":
+		case "pd", "log-level", "key", "cert", "ca", "auth-token":
 			// Do nothing, these are some global flags from the cdc command that we don't use.
 		default:
 			// use this default branch to prevent new added parameter is not added
@@ -202,10 +238,23 @@ func (o *updateChangefeedOptions) applyChanges(oldInfo *model.ChangeFeedInfo, cm
 	if err != nil {
 		return nil, err
 	}
+	if err := sink.ValidateSinkConcurrency(newInfo.SinkURI, newInfo.GetQuota().SinkConcurrency); err != nil {
+		return nil, err
+	}
 
 	return newInfo, nil
 }
 
+// updateQuota applies the update to a copy of the quota.
+func updateQuota(quota *model.ChangefeedQuota, update func(quota *model.ChangefeedQuota)) *model.ChangefeedQuota {
+	newQuota := &model.ChangefeedQuota{}
+	if quota != nil {
+		*newQuota = *quota
+	}
+	update(newQuota)
+	return newQuota
+}
+
 // newCmdPauseChangefeed creates the `cli changefeed update` command.
 func newCmdUpdateChangefeed(f factory.Factory) *cobra.Command {
 	commonChangefeedOptions := newChangefeedCommonOptions()
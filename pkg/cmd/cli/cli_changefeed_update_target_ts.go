@@ -0,0 +1,115 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	cmdcontext "github.com/pingcap/ticdc/pkg/cmd/context"
+	"github.com/pingcap/ticdc/pkg/cmd/factory"
+	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/spf13/cobra"
+)
+
+// updateTargetTsOptions defines flags for the `cli changefeed update-target-ts` command.
+type updateTargetTsOptions struct {
+	etcdClient *kv.CDCEtcdClient
+
+	credential *security.Credential
+
+	changefeedID string
+	targetTs     uint64
+}
+
+// newUpdateTargetTsOptions creates new options for the `cli changefeed update-target-ts` command.
+func newUpdateTargetTsOptions() *updateTargetTsOptions {
+	return &updateTargetTsOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *updateTargetTsOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
+	cmd.PersistentFlags().Uint64Var(&o.targetTs, "target-ts", 0, "New target ts of changefeed, 0 means the changefeed never finishes")
+	_ = cmd.MarkPersistentFlagRequired("changefeed-id")
+	_ = cmd.MarkPersistentFlagRequired("target-ts")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *updateTargetTsOptions) complete(f factory.Factory) error {
+	etcdClient, err := f.EtcdClient()
+	if err != nil {
+		return err
+	}
+
+	o.etcdClient = etcdClient
+
+	o.credential = f.GetCredential()
+
+	return nil
+}
+
+// validate checks if the target ts can be applied to the changefeed.
+func (o *updateTargetTsOptions) validate(ctx context.Context) error {
+	info, err := o.etcdClient.GetChangeFeedInfo(ctx, o.changefeedID)
+	if err != nil {
+		return err
+	}
+	status, _, err := o.etcdClient.GetChangeFeedStatus(ctx, o.changefeedID)
+	if err != nil {
+		return err
+	}
+	return info.VerifyTargetTs(status, o.targetTs)
+}
+
+// run the `cli changefeed update-target-ts` command.
+func (o *updateTargetTsOptions) run() error {
+	ctx := cmdcontext.GetDefaultContext()
+
+	if err := o.validate(ctx); err != nil {
+		return err
+	}
+
+	job := model.AdminJob{
+		CfID: o.changefeedID,
+		Type: model.AdminUpdateTargetTs,
+		Opts: &model.AdminJobOption{TargetTs: o.targetTs},
+	}
+
+	return sendOwnerAdminChangeQuery(ctx, o.etcdClient, job, o.credential)
+}
+
+// newCmdUpdateTargetTs creates the `cli changefeed update-target-ts` command.
+func newCmdUpdateTargetTs(f factory.Factory) *cobra.Command {
+	o := newUpdateTargetTsOptions()
+
+	command := &cobra.Command{
+		Use:   "update-target-ts",
+		Short: "Extend or shrink the target ts of a replication task (changefeed) without stopping it",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.complete(f)
+			if err != nil {
+				return err
+			}
+
+			return o.run()
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
@@ -56,4 +56,31 @@ func (s *changefeedUpdateSuite) TestApplyChanges(c *check.C) {
 	c.Assert(cmd.ParseFlags([]string{"--pd=http://127.0.0.1:2379"}), check.IsNil)
 	_, err = o.applyChanges(oldInfo, cmd)
 	c.Assert(err, check.IsNil)
+
+	// Test for the dry-run flag which does not change the changefeed.
+	oldInfo = &model.ChangeFeedInfo{SortDir: "."}
+	c.Assert(cmd.ParseFlags([]string{"--dry-run"}), check.IsNil)
+	newInfo, err = o.applyChanges(oldInfo, cmd)
+	c.Assert(err, check.IsNil)
+	c.Assert(newInfo.SortDir, check.Equals, ".")
+	c.Assert(commonChangefeedOptions.dryRun, check.IsTrue)
+
+	// Test for priority and quota.
+	oldInfo = &model.ChangeFeedInfo{Quota: &model.ChangefeedQuota{MemoryQuota: 1024, SinkConcurrency: 4}}
+	c.Assert(cmd.ParseFlags([]string{"--priority=low", "--sorter-disk-quota=2048"}), check.IsNil)
+	newInfo, err = o.applyChanges(oldInfo, cmd)
+	c.Assert(err, check.IsNil)
+	c.Assert(newInfo.Priority, check.Equals, model.PriorityLow)
+	c.Assert(newInfo.Quota, check.DeepEquals, &model.ChangefeedQuota{MemoryQuota: 1024, SorterDiskQuota: 2048, SinkConcurrency: 4})
+	c.Assert(oldInfo.Quota.SorterDiskQuota, check.Equals, uint64(0))
+
+	// Test for invalid priority.
+	c.Assert(cmd.ParseFlags([]string{"--priority=urgent"}), check.IsNil)
+	_, err = o.applyChanges(oldInfo, cmd)
+	c.Assert(err, check.ErrorMatches, ".*ErrInvalidChangefeedPriority.*")
+
+	// Test for the sink concurrency not supported by the sink.
+	c.Assert(cmd.ParseFlags([]string{"--priority=normal", "--sink-uri=kafka://127.0.0.1:9092/topic"}), check.IsNil)
+	_, err = o.applyChanges(oldInfo, cmd)
+	c.Assert(err, check.ErrorMatches, ".*sink-concurrency is not supported by the kafka sink.*")
 }
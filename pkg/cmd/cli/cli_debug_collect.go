@@ -0,0 +1,298 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/kv"
+	"github.com/pingcap/ticdc/cdc/model"
+	cmdcontext "github.com/pingcap/ticdc/pkg/cmd/context"
+	"github.com/pingcap/ticdc/pkg/cmd/factory"
+	"github.com/pingcap/ticdc/pkg/cmd/util"
+	"github.com/pingcap/ticdc/pkg/httputil"
+	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultDebugCollectTimeout  = 30 * time.Second
+	defaultDebugCollectLogLimit = 64 * 1024 * 1024
+)
+
+// debugCollectItem is a piece of diagnostic data collected from every capture.
+type debugCollectItem struct {
+	// the name of the file in the bundle
+	name string
+	// the path and query of the HTTP API of the capture
+	path string
+}
+
+// debugCollectItems returns the diagnostic data collected from every capture.
+// The status is collected first so that an unreachable capture is skipped
+// as soon as possible.
+func debugCollectItems(logLimit int64) []debugCollectItem {
+	return []debugCollectItem{
+		{name: "status.json", path: "/status"},
+		{name: "debug_info.txt", path: "/debug/info"},
+		{name: "goroutines.txt", path: "/debug/pprof/goroutine?debug=2"},
+		{name: "config.json", path: "/debug/config"},
+		{name: "log_levels.json", path: "/api/v1/log"},
+		{name: "metrics.txt", path: "/metrics"},
+		{name: "cdc.log", path: fmt.Sprintf("/debug/log?limit=%d", logLimit)},
+	}
+}
+
+// debugCollectOptions defines flags for the `cli debug collect` command.
+type debugCollectOptions struct {
+	etcdClient *kv.CDCEtcdClient
+
+	credential *security.Credential
+
+	output   string
+	timeout  time.Duration
+	logLimit int64
+}
+
+// newDebugCollectOptions creates new debugCollectOptions for the `cli debug collect` command.
+func newDebugCollectOptions() *debugCollectOptions {
+	return &debugCollectOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *debugCollectOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&o.output, "output", "o", "",
+		"Path of the tarball, defaults to ticdc-diagnostics-<time>.tar.gz in the current directory")
+	cmd.PersistentFlags().DurationVar(&o.timeout, "timeout", defaultDebugCollectTimeout,
+		"Timeout of each request sent to the captures")
+	cmd.PersistentFlags().Int64Var(&o.logLimit, "log-limit", defaultDebugCollectLogLimit,
+		"Maximum number of bytes of the log collected from each capture")
+}
+
+// complete adapts from the command line args to the data and client required.
+func (o *debugCollectOptions) complete(f factory.Factory) error {
+	if o.timeout <= 0 {
+		return errors.Errorf("invalid timeout: %s", o.timeout)
+	}
+	if o.logLimit <= 0 {
+		return errors.Errorf("invalid log limit: %d", o.logLimit)
+	}
+	if o.output == "" {
+		o.output = fmt.Sprintf("ticdc-diagnostics-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	etcdClient, err := f.EtcdClient()
+	if err != nil {
+		return err
+	}
+	o.etcdClient = etcdClient
+
+	o.credential = f.GetCredential()
+
+	return nil
+}
+
+// run runs the `cli debug collect` command.
+func (o *debugCollectOptions) run(cmd *cobra.Command) error {
+	ctx := cmdcontext.GetDefaultContext()
+
+	_, captures, err := o.etcdClient.GetCaptures(ctx)
+	if err != nil {
+		return err
+	}
+	kvs, err := o.etcdClient.GetAllCDCInfo(ctx)
+	if err != nil {
+		return err
+	}
+	var etcdDump strings.Builder
+	for _, kv := range kvs {
+		fmt.Fprintf(&etcdDump, "%s\n\t%s\n\n", string(kv.Key), string(kv.Value))
+	}
+
+	httpClient, err := httputil.NewClient(o.credential)
+	if err != nil {
+		return err
+	}
+	scheme := util.HTTP
+	if o.credential.IsTLSEnabled() {
+		scheme = util.HTTPS
+	}
+
+	file, err := os.Create(o.output)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	c := &diagnosticCollector{
+		client:  httpClient,
+		scheme:  scheme,
+		timeout: o.timeout,
+		prefix:  strings.TrimSuffix(strings.TrimSuffix(path.Base(o.output), ".gz"), ".tar"),
+	}
+	err = c.collect(ctx, file, captures, etcdDump.String(), debugCollectItems(o.logLimit))
+	if closeErr := file.Close(); err == nil {
+		err = errors.Trace(closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Collected diagnostic data of %d capture(s) into %s\n", len(captures), o.output)
+	for _, failure := range c.failures {
+		cmd.Printf("Failed to collect %s\n", failure)
+	}
+	return nil
+}
+
+// newCmdDebugCollect creates the `cli debug collect` command.
+func newCmdDebugCollect(f factory.Factory) *cobra.Command {
+	o := newDebugCollectOptions()
+
+	command := &cobra.Command{
+		Use:   "collect",
+		Short: "Collect the diagnostic data of all captures into a tarball, with the secrets redacted",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := o.complete(f)
+			if err != nil {
+				return err
+			}
+
+			return o.run(cmd)
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
+
+// diagnosticCollector collects the diagnostic data of the captures through
+// their HTTP APIs and writes them into a gzipped tarball.
+type diagnosticCollector struct {
+	client  *httputil.Client
+	scheme  string
+	timeout time.Duration
+	// the directory of all files in the tarball
+	prefix string
+
+	tw       *tar.Writer
+	modTime  time.Time
+	failures []string
+}
+
+// collect writes the etcd dump, the capture list and the diagnostic data of
+// every capture into w. The data that can not be collected is recorded in
+// failures.txt, so that a broken capture does not fail the whole collection.
+func (c *diagnosticCollector) collect(ctx context.Context, w io.Writer,
+	captures []*model.CaptureInfo, etcdDump string, items []debugCollectItem,
+) error {
+	gw := gzip.NewWriter(w)
+	c.tw = tar.NewWriter(gw)
+	c.modTime = time.Now()
+
+	if err := c.addFile("etcd.txt", []byte(etcdDump)); err != nil {
+		return err
+	}
+	captureList, err := json.MarshalIndent(captures, "", "  ")
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if err := c.addFile("captures.json", captureList); err != nil {
+		return err
+	}
+
+	for _, capture := range captures {
+		// the address is used as the directory name as it is readable and
+		// unique among the alive captures
+		dir := strings.ReplaceAll(capture.AdvertiseAddr, ":", "_")
+		for _, item := range items {
+			data, err := c.fetch(ctx, capture.AdvertiseAddr, item.path)
+			if err != nil {
+				c.failures = append(c.failures,
+					fmt.Sprintf("%s of capture %s (%s): %s", item.name, capture.ID, capture.AdvertiseAddr, err))
+				if item.path == "/status" {
+					// the capture is unreachable
+					break
+				}
+				continue
+			}
+			if err := c.addFile(path.Join(dir, item.name), data); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(c.failures) > 0 {
+		if err := c.addFile("failures.txt", []byte(strings.Join(c.failures, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+	if err := c.tw.Close(); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(gw.Close())
+}
+
+// fetch sends a GET request to the HTTP API of a capture and returns the
+// response body.
+func (c *diagnosticCollector) fetch(ctx context.Context, addr, apiPath string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s://%s%s", c.scheme, addr, apiPath), nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// addFile writes a file into the tarball with the secrets redacted.
+func (c *diagnosticCollector) addFile(name string, data []byte) error {
+	data = []byte(security.RedactText(string(data)))
+	err := c.tw.WriteHeader(&tar.Header{
+		Name:    path.Join(c.prefix, name),
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: c.modTime,
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = c.tw.Write(data)
+	return errors.Trace(err)
+}
@@ -14,26 +14,41 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc"
 	"github.com/pingcap/ticdc/cdc/kv"
 	"github.com/pingcap/ticdc/cdc/model"
-	"github.com/pingcap/ticdc/pkg/cmd/context"
+	cmdcontext "github.com/pingcap/ticdc/pkg/cmd/context"
 	"github.com/pingcap/ticdc/pkg/cmd/factory"
 	"github.com/pingcap/ticdc/pkg/cmd/util"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/httputil"
+	"github.com/pingcap/ticdc/pkg/security"
 	"github.com/spf13/cobra"
 )
 
 type processorMeta struct {
 	Status   *model.TaskStatus   `json:"status"`
 	Position *model.TaskPosition `json:"position"`
+	// the regions with the smallest resolved ts of each table
+	SlowestRegions []model.TableRegionsLag `json:"slowest_regions,omitempty"`
 }
 
 // queryProcessorOptions defines flags for the `cli processor query` command.
 type queryProcessorOptions struct {
 	etcdClient *kv.CDCEtcdClient
 
-	changefeedID string
-	captureID    string
+	credential *security.Credential
+
+	changefeedID   string
+	captureID      string
+	slowestRegions int
 }
 
 // newQueryProcessorOptions creates new options for the `cli changefeed query` command.
@@ -50,6 +65,8 @@ func (o *queryProcessorOptions) complete(f factory.Factory) error {
 
 	o.etcdClient = etcdClient
 
+	o.credential = f.GetCredential()
+
 	return nil
 }
 
@@ -58,13 +75,15 @@ func (o *queryProcessorOptions) complete(f factory.Factory) error {
 func (o *queryProcessorOptions) addFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVarP(&o.changefeedID, "changefeed-id", "c", "", "Replication task (changefeed) ID")
 	cmd.PersistentFlags().StringVarP(&o.captureID, "capture-id", "p", "", "capture ID")
+	cmd.PersistentFlags().IntVar(&o.slowestRegions, "slowest-regions", 0,
+		"Query the given number of regions with the smallest resolved ts of each table from the capture, 0 means not to query")
 	_ = cmd.MarkPersistentFlagRequired("changefeed-id")
 	_ = cmd.MarkPersistentFlagRequired("capture-id")
 }
 
 // run runs the `cli processor query` command.
 func (o *queryProcessorOptions) run(cmd *cobra.Command) error {
-	ctx := context.GetDefaultContext()
+	ctx := cmdcontext.GetDefaultContext()
 
 	_, status, err := o.etcdClient.GetTaskStatus(ctx, o.changefeedID, o.captureID)
 	if err != nil && cerror.ErrTaskStatusNotExists.Equal(err) {
@@ -78,9 +97,67 @@ func (o *queryProcessorOptions) run(cmd *cobra.Command) error {
 
 	meta := &processorMeta{Status: status, Position: position}
 
+	if o.slowestRegions > 0 {
+		meta.SlowestRegions, err = o.querySlowestRegions(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	return util.JSONPrint(cmd, meta)
 }
 
+// querySlowestRegions queries the regions with the smallest resolved ts of
+// each table from the capture.
+func (o *queryProcessorOptions) querySlowestRegions(ctx context.Context) ([]model.TableRegionsLag, error) {
+	captures, err := listCaptures(ctx, o.etcdClient)
+	if err != nil {
+		return nil, err
+	}
+	var addr string
+	for _, c := range captures {
+		if c.ID == o.captureID {
+			addr = c.AdvertiseAddr
+		}
+	}
+	if addr == "" {
+		return nil, cerror.ErrCaptureNotExist.GenWithStackByArgs(o.captureID)
+	}
+
+	scheme := util.HTTP
+	if o.credential.IsTLSEnabled() {
+		scheme = util.HTTPS
+	}
+	query := url.Values{}
+	query.Set(cdc.APIOpVarChangefeedID, o.changefeedID)
+	query.Set(cdc.APIOpVarLimit, fmt.Sprint(o.slowestRegions))
+	uri := fmt.Sprintf("%s://%s/debug/region-lag?%s", scheme, addr, query.Encode())
+
+	httpClient, err := httputil.NewClient(o.credential)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.BadRequestf("%s", string(body))
+	}
+
+	var regions []model.TableRegionsLag
+	if err := json.Unmarshal(body, &regions); err != nil {
+		return nil, errors.Trace(err)
+	}
+	return regions, nil
+}
+
 // newCmdQueryProcessor creates the `cli processor query` command.
 func newCmdQueryProcessor(f factory.Factory) *cobra.Command {
 	o := newQueryProcessorOptions()
@@ -0,0 +1,30 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newCmdSecret creates the `cli secret` command.
+func newCmdSecret() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage the secrets referenced by sink URIs",
+	}
+
+	command.AddCommand(newCmdEncryptSecret())
+
+	return command
+}
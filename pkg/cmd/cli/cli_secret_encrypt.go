@@ -0,0 +1,79 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/pkg/security"
+	"github.com/spf13/cobra"
+)
+
+// encryptSecretOptions defines flags for the `cli secret encrypt` command.
+type encryptSecretOptions struct {
+	keyFile string
+}
+
+// newEncryptSecretOptions creates new options for the `cli secret encrypt` command.
+func newEncryptSecretOptions() *encryptSecretOptions {
+	return &encryptSecretOptions{}
+}
+
+// addFlags receives a *cobra.Command reference and binds
+// flags related to template printing to it.
+func (o *encryptSecretOptions) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&o.keyFile, "key-file", "",
+		"Path of the hex encoded 32 bytes key, which should be the same as the secret-key-path of the captures")
+	_ = cmd.MarkPersistentFlagRequired("key-file")
+}
+
+// run runs the `cli secret encrypt` command.
+func (o *encryptSecretOptions) run(cmd *cobra.Command) error {
+	key, err := security.LoadSecretKey(o.keyFile)
+	if err != nil {
+		return err
+	}
+	// the secret is read from stdin so that it is not left in the shell history
+	secret, err := ioutil.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ref, err := security.EncryptSecret(key, strings.TrimRight(string(secret), "\r\n"))
+	if err != nil {
+		return err
+	}
+
+	cmd.Println(ref)
+
+	return nil
+}
+
+// newCmdEncryptSecret creates the `cli secret encrypt` command.
+func newCmdEncryptSecret() *cobra.Command {
+	o := newEncryptSecretOptions()
+
+	command := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt the secret read from stdin, and print the reference to use in sink URIs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.run(cmd)
+		},
+	}
+
+	o.addFlags(command)
+
+	return command
+}
@@ -15,6 +15,7 @@ package factory
 
 import (
 	"crypto/tls"
+	"os"
 
 	"github.com/pingcap/errors"
 	"github.com/pingcap/ticdc/cdc/kv"
@@ -41,6 +42,10 @@ type ClientGetter interface {
 	GetCredential() *security.Credential
 }
 
+// authTokenEnv is the environment variable of the default auth token, it is
+// preferred over the flag as the command line can be seen by other users.
+const authTokenEnv = "TICDC_AUTH_TOKEN"
+
 // ClientFlags specifies the parameters needed to construct the client.
 type ClientFlags struct {
 	pdAddr   string
@@ -48,6 +53,8 @@ type ClientFlags struct {
 	caPath   string
 	certPath string
 	keyPath  string
+	// the bearer token used to call the HTTP API of TiCDC
+	authToken string
 }
 
 var _ ClientGetter = &ClientFlags{}
@@ -96,6 +103,8 @@ func (c *ClientFlags) AddFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVar(&c.certPath, "cert", "", "Certificate path for TLS connection")
 	cmd.PersistentFlags().StringVar(&c.keyPath, "key", "", "Private key path for TLS connection")
 	cmd.PersistentFlags().StringVar(&c.logLevel, "log-level", "warn", "log level (etc: debug|info|warn|error)")
+	cmd.PersistentFlags().StringVar(&c.authToken, "auth-token", os.Getenv(authTokenEnv),
+		"Bearer token for the HTTP API of TiCDC, defaults to the value of the "+authTokenEnv+" environment variable")
 }
 
 // Validate makes sure provided values for ClientFlags are valid.
@@ -121,5 +130,6 @@ func (c *ClientFlags) GetCredential() *security.Credential {
 		CertPath:      c.certPath,
 		KeyPath:       c.keyPath,
 		CertAllowedCN: certAllowedCN,
+		AuthToken:     c.authToken,
 	}
 }
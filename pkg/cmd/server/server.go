@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/failpoint"
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/cdc"
+	"github.com/pingcap/ticdc/cdc/entry"
 	"github.com/pingcap/ticdc/cdc/puller/sorter"
 	cmdcontext "github.com/pingcap/ticdc/pkg/cmd/context"
 	"github.com/pingcap/ticdc/pkg/cmd/util"
@@ -67,6 +68,7 @@ func (o *options) addFlags(cmd *cobra.Command) {
 	cmd.Flags().Int64Var(&o.serverConfig.GcTTL, "gc-ttl", o.serverConfig.GcTTL, "CDC GC safepoint TTL duration, specified in seconds")
 	cmd.Flags().StringVar(&o.serverConfig.LogFile, "log-file", o.serverConfig.LogFile, "log file path")
 	cmd.Flags().StringVar(&o.serverConfig.LogLevel, "log-level", o.serverConfig.LogLevel, "log level (etc: debug|info|warn|error)")
+	cmd.Flags().StringVar(&o.serverConfig.Log.Format, "log-format", o.serverConfig.Log.Format, "log format (text|json)")
 	cmd.Flags().StringVar(&o.serverConfig.DataDir, "data-dir", o.serverConfig.DataDir, "the path to the directory used to store TiCDC-generated data")
 	cmd.Flags().DurationVar((*time.Duration)(&o.serverConfig.OwnerFlushInterval), "owner-flush-interval", time.Duration(o.serverConfig.OwnerFlushInterval), "owner flushes changefeed status interval")
 	cmd.Flags().DurationVar((*time.Duration)(&o.serverConfig.ProcessorFlushInterval), "processor-flush-interval", time.Duration(o.serverConfig.ProcessorFlushInterval), "processor flushes task status interval")
@@ -77,7 +79,9 @@ func (o *options) addFlags(cmd *cobra.Command) {
 	cmd.Flags().IntVar(&o.serverConfig.Sorter.MaxMemoryPressure, "sorter-max-memory-percentage", o.serverConfig.Sorter.MaxMemoryPressure, "system memory usage threshold for forcing in-disk sort")
 	// We use 8GB as a safe default before we support local configuration file.
 	cmd.Flags().Uint64Var(&o.serverConfig.Sorter.MaxMemoryConsumption, "sorter-max-memory-consumption", o.serverConfig.Sorter.MaxMemoryConsumption, "maximum memory consumption of in-memory sort")
+	cmd.Flags().Uint64Var(&o.serverConfig.Sorter.MaxDiskConsumption, "sorter-max-disk-consumption", o.serverConfig.Sorter.MaxDiskConsumption, "maximum disk consumption of the sort dir, 0 means no limit")
 	cmd.Flags().StringVar(&o.serverConfig.Sorter.SortDir, "sort-dir", o.serverConfig.Sorter.SortDir, "sorter's temporary file directory")
+	cmd.Flags().StringToStringVar(&o.serverConfig.Labels, "labels", o.serverConfig.Labels, "Labels of the capture, used by placement rules of changefeeds, e.g. zone=us-east-1,rack=r1")
 	cmd.Flags().StringVar(&o.serverPdAddr, "pd", "http://127.0.0.1:2379", "Set the PD endpoints to use. Use ',' to separate multiple PDs")
 	cmd.Flags().StringVar(&o.serverConfigFilePath, "config", "", "Path of the configuration file")
 
@@ -96,6 +100,9 @@ func (o *options) run(cmd *cobra.Command) error {
 		FileMaxSize:    o.serverConfig.Log.File.MaxSize,
 		FileMaxDays:    o.serverConfig.Log.File.MaxDays,
 		FileMaxBackups: o.serverConfig.Log.File.MaxBackups,
+		Format:         o.serverConfig.Log.Format,
+		ModuleLevels:   o.serverConfig.Log.ModuleLevels,
+		Sampling:       o.serverConfig.Log.GetSampling(),
 	})
 	defer cancel()
 
@@ -131,6 +138,8 @@ func (o *options) run(cmd *cobra.Command) error {
 	}
 	server.Close()
 	sorter.UnifiedSorterCleanUp()
+	sorter.DBSorterCleanUp()
+	entry.SchemaHistoryCleanUp()
 	log.Info("cdc server exits successfully")
 
 	return nil
@@ -171,6 +180,8 @@ func (o *options) complete(cmd *cobra.Command) error {
 			cfg.LogFile = o.serverConfig.LogFile
 		case "log-level":
 			cfg.LogLevel = o.serverConfig.LogLevel
+		case "log-format":
+			cfg.Log.Format = o.serverConfig.Log.Format
 		case "data-dir":
 			cfg.DataDir = o.serverConfig.DataDir
 		case "owner-flush-interval":
@@ -187,6 +198,8 @@ func (o *options) complete(cmd *cobra.Command) error {
 			cfg.Sorter.MaxMemoryPressure = o.serverConfig.Sorter.MaxMemoryPressure
 		case "sorter-max-memory-consumption":
 			cfg.Sorter.MaxMemoryConsumption = o.serverConfig.Sorter.MaxMemoryConsumption
+		case "sorter-max-disk-consumption":
+			cfg.Sorter.MaxDiskConsumption = o.serverConfig.Sorter.MaxDiskConsumption
 		case "ca":
 			cfg.Security.CAPath = o.serverConfig.Security.CAPath
 		case "cert":
@@ -195,6 +208,8 @@ func (o *options) complete(cmd *cobra.Command) error {
 			cfg.Security.KeyPath = o.serverConfig.Security.KeyPath
 		case "cert-allowed-cn":
 			cfg.Security.CertAllowedCN = o.serverConfig.Security.CertAllowedCN
+		case "labels":
+			cfg.Labels = o.serverConfig.Labels
 		case "sort-dir":
 			// user specified sorter dir should not take effect, it's always `/tmp/sorter`
 			// if user try to set sort-dir by flag, warn it.
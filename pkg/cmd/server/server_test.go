@@ -129,6 +129,7 @@ func (s *serverSuite) TestParseCfg(c *check.C) {
 		"--advertise-addr", "127.5.5.1:7777",
 		"--log-file", "/root/cdc.log",
 		"--log-level", "debug",
+		"--log-format", "json",
 		"--data-dir", dataDir,
 		"--gc-ttl", "10",
 		"--tz", "UTC",
@@ -139,10 +140,12 @@ func (s *serverSuite) TestParseCfg(c *check.C) {
 		"--cert-allowed-cn", "dd,ee",
 		"--sorter-chunk-size-limit", "50000000",
 		"--sorter-max-memory-consumption", "60000",
+		"--sorter-max-disk-consumption", "70000",
 		"--sorter-max-memory-percentage", "70",
 		"--sorter-num-concurrent-worker", "80",
 		"--sorter-num-workerpool-goroutine", "90",
 		"--sort-dir", "/tmp/just_a_test",
+		"--labels", "zone=us-east-1,rack=r1",
 	}), check.IsNil)
 
 	err := o.complete(cmd)
@@ -160,6 +163,12 @@ func (s *serverSuite) TestParseCfg(c *check.C) {
 				MaxDays:    0,
 				MaxBackups: 0,
 			},
+			Format: "json",
+			Sampling: &config.LogSamplingConfig{
+				Enable:     false,
+				Initial:    100,
+				Thereafter: 100,
+			},
 		},
 		DataDir:                dataDir,
 		GcTTL:                  10,
@@ -172,8 +181,10 @@ func (s *serverSuite) TestParseCfg(c *check.C) {
 			ChunkSizeLimit:         50000000,
 			MaxMemoryPressure:      70,
 			MaxMemoryConsumption:   60000,
+			MaxDiskConsumption:     70000,
 			NumWorkerPoolGoroutine: 90,
 			SortDir:                config.DefaultSortDir,
+			Compression:            config.SorterCompressionNone,
 		},
 		Security: &config.SecurityConfig{
 			CertPath:      "bb",
@@ -186,6 +197,19 @@ func (s *serverSuite) TestParseCfg(c *check.C) {
 			WorkerPoolSize:   0,
 			RegionScanLimit:  40,
 		},
+		SchemaHistory: &config.SchemaHistoryConfig{
+			Enable:           false,
+			CompactThreshold: 1024,
+		},
+		Auth: &config.AuthConfig{
+			Enable: false,
+		},
+		Tracing: &config.TracingConfig{
+			Enable:      false,
+			Endpoint:    "http://127.0.0.1:4318/v1/traces",
+			SampleRatio: 0.001,
+		},
+		Labels: map[string]string{"zone": "us-east-1", "rack": "r1"},
 	})
 }
 
@@ -217,10 +241,20 @@ max-backups = 1
 [sorter]
 chunk-size-limit = 10000000
 max-memory-consumption = 2000000
+max-disk-consumption = 3000000
 max-memory-percentage = 3
 num-concurrent-worker = 4
 num-workerpool-goroutine = 5
 sort-dir = "/tmp/just_a_test"
+
+[auth]
+enable = true
+audit-log-file = "/root/cdc-audit.log"
+
+[[auth.tokens]]
+name = "portal"
+sha256 = "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"
+role = "operator"
 `, dataDir)
 	err := ioutil.WriteFile(configPath, []byte(configContent), 0o644)
 	c.Assert(err, check.IsNil)
@@ -246,6 +280,12 @@ sort-dir = "/tmp/just_a_test"
 				MaxDays:    1,
 				MaxBackups: 1,
 			},
+			Format: "text",
+			Sampling: &config.LogSamplingConfig{
+				Enable:     false,
+				Initial:    100,
+				Thereafter: 100,
+			},
 		},
 		DataDir:                dataDir,
 		GcTTL:                  500,
@@ -258,8 +298,10 @@ sort-dir = "/tmp/just_a_test"
 			ChunkSizeLimit:         10000000,
 			MaxMemoryPressure:      3,
 			MaxMemoryConsumption:   2000000,
+			MaxDiskConsumption:     3000000,
 			NumWorkerPoolGoroutine: 5,
 			SortDir:                config.DefaultSortDir,
+			Compression:            config.SorterCompressionNone,
 		},
 		Security:            &config.SecurityConfig{},
 		PerTableMemoryQuota: 20 * 1024 * 1024, // 20M
@@ -268,6 +310,24 @@ sort-dir = "/tmp/just_a_test"
 			WorkerPoolSize:   0,
 			RegionScanLimit:  40,
 		},
+		SchemaHistory: &config.SchemaHistoryConfig{
+			Enable:           false,
+			CompactThreshold: 1024,
+		},
+		Auth: &config.AuthConfig{
+			Enable: true,
+			Tokens: []*config.AuthToken{{
+				Name:   "portal",
+				SHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+				Role:   "operator",
+			}},
+			AuditLogFile: "/root/cdc-audit.log",
+		},
+		Tracing: &config.TracingConfig{
+			Enable:      false,
+			Endpoint:    "http://127.0.0.1:4318/v1/traces",
+			SampleRatio: 0.001,
+		},
 	})
 }
 
@@ -299,6 +359,7 @@ max-backups = 1
 [sorter]
 chunk-size-limit = 10000000
 max-memory-consumption = 2000000
+max-disk-consumption = 3000000
 max-memory-percentage = 3
 num-concurrent-worker = 4
 num-workerpool-goroutine = 5
@@ -349,6 +410,12 @@ cert-allowed-cn = ["dd","ee"]
 				MaxDays:    1,
 				MaxBackups: 1,
 			},
+			Format: "text",
+			Sampling: &config.LogSamplingConfig{
+				Enable:     false,
+				Initial:    100,
+				Thereafter: 100,
+			},
 		},
 		DataDir:                dataDir,
 		GcTTL:                  10,
@@ -361,8 +428,10 @@ cert-allowed-cn = ["dd","ee"]
 			ChunkSizeLimit:         50000000,
 			MaxMemoryPressure:      70,
 			MaxMemoryConsumption:   60000000,
+			MaxDiskConsumption:     3000000,
 			NumWorkerPoolGoroutine: 5,
 			SortDir:                config.DefaultSortDir,
+			Compression:            config.SorterCompressionNone,
 		},
 		Security: &config.SecurityConfig{
 			CertPath:      "bb",
@@ -375,5 +444,17 @@ cert-allowed-cn = ["dd","ee"]
 			WorkerPoolSize:   0,
 			RegionScanLimit:  40,
 		},
+		SchemaHistory: &config.SchemaHistoryConfig{
+			Enable:           false,
+			CompactThreshold: 1024,
+		},
+		Auth: &config.AuthConfig{
+			Enable: false,
+		},
+		Tracing: &config.TracingConfig{
+			Enable:      false,
+			Endpoint:    "http://127.0.0.1:4318/v1/traces",
+			SampleRatio: 0.001,
+		},
 	})
 }
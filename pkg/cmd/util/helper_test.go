@@ -191,6 +191,9 @@ func (s *utilsSuite) TestAndWriteExampleReplicaTOML(c *check.C) {
 			{Dispatcher: "rowid", Matcher: []string{"test3.*", "test4.*"}},
 		},
 		Protocol: "default",
+		ColumnSelectors: []*config.ColumnSelector{
+			{Matcher: []string{"test1.*"}, Columns: []string{"id", "name"}},
+		},
 	})
 	c.Assert(cfg.Cyclic, check.DeepEquals, &config.CyclicConfig{
 		Enable:          false,
@@ -0,0 +1,129 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/hex"
+	"strings"
+
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// roles of the callers of the HTTP API, a role is granted all the permissions
+// of the roles before it.
+const (
+	// AuthRoleViewer can only read the states of the cluster and changefeeds
+	AuthRoleViewer = "viewer"
+	// AuthRoleOperator can additionally manage changefeeds and tables
+	AuthRoleOperator = "operator"
+	// AuthRoleAdmin can additionally manage the captures, e.g. resign the owner,
+	// change the log level and profile the server
+	AuthRoleAdmin = "admin"
+)
+
+// AuthConfig represents the authentication config of the HTTP API of a capture
+type AuthConfig struct {
+	// whether to authenticate the callers of the HTTP API, the health, status,
+	// metrics and swagger endpoints are always public
+	Enable bool `toml:"enable" json:"enable"`
+	// Tokens are the static bearer tokens accepted by the server
+	Tokens []*AuthToken `toml:"tokens" json:"tokens"`
+	// CertIdentities map the common names of the client certificates to roles,
+	// they only take effect when TLS is enabled
+	CertIdentities []*AuthCertIdentity `toml:"cert-identities" json:"cert-identities"`
+	// PeerCommonNames are the common names of the client certificates of the
+	// captures. The identity of the caller of a call forwarded by a capture,
+	// e.g. to the owner, is trusted if the call is sent with one of these
+	// certificates. The common name of the certificate of the capture itself
+	// is used if it is empty.
+	PeerCommonNames []string `toml:"peer-common-names" json:"peer-common-names"`
+	// the file to write the audit log of the mutating calls to, the audit log
+	// is written to the server log if it is empty
+	AuditLogFile string `toml:"audit-log-file" json:"audit-log-file"`
+}
+
+// AuthToken represents a static bearer token. Only the SHA-256 digest of the
+// token is kept in the config, so that the config can be logged safely.
+type AuthToken struct {
+	Name   string `toml:"name" json:"name"`
+	SHA256 string `toml:"sha256" json:"sha256"`
+	Role   string `toml:"role" json:"role"`
+}
+
+// AuthCertIdentity represents the identity of a client certificate
+type AuthCertIdentity struct {
+	CommonName string `toml:"common-name" json:"common-name"`
+	Role       string `toml:"role" json:"role"`
+}
+
+// IsValidAuthRole returns true if the role is one of the known roles
+func IsValidAuthRole(role string) bool {
+	return AuthRoleLevel(role) > 0
+}
+
+// AuthRoleLevel returns the privilege level of the role, zero is returned for
+// unknown roles.
+func AuthRoleLevel(role string) int {
+	switch role {
+	case AuthRoleViewer:
+		return 1
+	case AuthRoleOperator:
+		return 2
+	case AuthRoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+// Validate checks whether the auth config is valid
+func (c *AuthConfig) Validate(security *SecurityConfig) error {
+	if !c.Enable {
+		return nil
+	}
+	if len(c.Tokens) == 0 && len(c.CertIdentities) == 0 {
+		return cerror.ErrInvalidServerOption.GenWithStack("auth is enabled but neither tokens nor cert-identities is configured")
+	}
+	names := make(map[string]struct{}, len(c.Tokens))
+	for _, token := range c.Tokens {
+		if token.Name == "" {
+			return cerror.ErrInvalidServerOption.GenWithStack("name of auth token should not be empty")
+		}
+		if _, ok := names[token.Name]; ok {
+			return cerror.ErrInvalidServerOption.GenWithStack("duplicated auth token %s", token.Name)
+		}
+		names[token.Name] = struct{}{}
+		token.SHA256 = strings.ToLower(token.SHA256)
+		if digest, err := hex.DecodeString(token.SHA256); err != nil || len(digest) != 32 {
+			return cerror.ErrInvalidServerOption.GenWithStack("sha256 of auth token %s should be a hex encoded SHA-256 digest", token.Name)
+		}
+		if !IsValidAuthRole(token.Role) {
+			return cerror.ErrInvalidServerOption.GenWithStack("unknown role %q of auth token %s", token.Role, token.Name)
+		}
+	}
+	if len(c.CertIdentities) > 0 && (security == nil || !security.IsTLSEnabled()) {
+		return cerror.ErrInvalidServerOption.GenWithStack("auth cert-identities require TLS to be enabled")
+	}
+	if len(c.PeerCommonNames) > 0 && (security == nil || !security.IsTLSEnabled()) {
+		return cerror.ErrInvalidServerOption.GenWithStack("auth peer-common-names require TLS to be enabled")
+	}
+	for _, identity := range c.CertIdentities {
+		if identity.CommonName == "" {
+			return cerror.ErrInvalidServerOption.GenWithStack("common-name of auth cert identity should not be empty")
+		}
+		if !IsValidAuthRole(identity.Role) {
+			return cerror.ErrInvalidServerOption.GenWithStack("unknown role %q of auth cert identity %s", identity.Role, identity.CommonName)
+		}
+	}
+	return nil
+}
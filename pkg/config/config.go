@@ -14,6 +14,7 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -25,6 +26,7 @@ import (
 	"github.com/pingcap/log"
 	"github.com/pingcap/ticdc/pkg/config/outdated"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/security"
 	"go.uber.org/zap"
 )
@@ -73,6 +75,11 @@ type replicaConfig struct {
 	Sink             *SinkConfig      `toml:"sink" json:"sink"`
 	Cyclic           *CyclicConfig    `toml:"cyclic-replication" json:"cyclic-replication"`
 	Scheduler        *SchedulerConfig `toml:"scheduler" json:"scheduler"`
+	Placement        *PlacementConfig `toml:"placement" json:"placement,omitempty"`
+	Heartbeat        *HeartbeatConfig `toml:"heartbeat" json:"heartbeat,omitempty"`
+	// OldValueRules overrides EnableOldValue for the matched tables,
+	// the first rule matching a table is used.
+	OldValueRules []*OldValueRule `toml:"old-value-rules" json:"old-value-rules,omitempty"`
 }
 
 // Marshal returns the json marshal format of a ReplicationConfig
@@ -107,6 +114,18 @@ func (c *ReplicaConfig) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// UnmarshalJSONStrict unmarshals into *ReplicationConfig from json marshal byte
+// slice like UnmarshalJSON, but the unknown fields are rejected and the fields
+// of the outdated format are not accepted.
+func (c *ReplicaConfig) UnmarshalJSONStrict(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode((*replicaConfig)(c)); err != nil {
+		return cerror.WrapError(cerror.ErrDecodeFailed, err)
+	}
+	return nil
+}
+
 // Clone clones a replication
 func (c *ReplicaConfig) Clone() *ReplicaConfig {
 	str, err := c.Marshal()
@@ -153,6 +172,55 @@ type LogFileConfig struct {
 // LogConfig represents log config for server
 type LogConfig struct {
 	File *LogFileConfig `toml:"file" json:"file"`
+	// Format is the format of the log, text or json
+	Format string `toml:"format" json:"format"`
+	// ModuleLevels are the log levels of the modules, e.g. kv-client and
+	// sorter, the modules not set use the log-level
+	ModuleLevels map[string]string  `toml:"module-levels" json:"module-levels"`
+	Sampling     *LogSamplingConfig `toml:"sampling" json:"sampling"`
+}
+
+// LogSamplingConfig represents the config of sampling the debug and info logs
+// of each changefeed, the first `initial` logs with the same message in every
+// second are kept, and every `thereafter`-th log is kept after that.
+type LogSamplingConfig struct {
+	Enable     bool `toml:"enable" json:"enable"`
+	Initial    int  `toml:"initial" json:"initial"`
+	Thereafter int  `toml:"thereafter" json:"thereafter"`
+}
+
+// GetSampling returns the sampling config of logutil, nil is returned if the
+// sampling is disabled.
+func (c *LogConfig) GetSampling() *logutil.SamplingConfig {
+	if c.Sampling == nil || !c.Sampling.Enable {
+		return nil
+	}
+	return &logutil.SamplingConfig{
+		Initial:    c.Sampling.Initial,
+		Thereafter: c.Sampling.Thereafter,
+	}
+}
+
+// Validate checks whether the log config is valid
+func (c *LogConfig) Validate() error {
+	switch c.Format {
+	case "", logutil.LogFormatText, logutil.LogFormatJSON:
+	default:
+		return cerror.ErrInvalidServerOption.GenWithStack("log format should be %s or %s",
+			logutil.LogFormatText, logutil.LogFormatJSON)
+	}
+	if err := logutil.ValidateModuleLevels(c.ModuleLevels); err != nil {
+		return cerror.ErrInvalidServerOption.GenWithStack("invalid log module-levels: %s", err)
+	}
+	if c.Sampling != nil && c.Sampling.Enable {
+		if c.Sampling.Initial <= 0 {
+			return cerror.ErrInvalidServerOption.GenWithStack("log sampling initial should be positive")
+		}
+		if c.Sampling.Thereafter < 0 {
+			return cerror.ErrInvalidServerOption.GenWithStack("log sampling thereafter should not be negative")
+		}
+	}
+	return nil
 }
 
 var defaultServerConfig = &ServerConfig{
@@ -166,6 +234,12 @@ var defaultServerConfig = &ServerConfig{
 			MaxDays:    0,
 			MaxBackups: 0,
 		},
+		Format: "text",
+		Sampling: &LogSamplingConfig{
+			Enable:     false,
+			Initial:    100,
+			Thereafter: 100,
+		},
 	},
 	DataDir: "",
 	GcTTL:   24 * 60 * 60, // 24H
@@ -184,6 +258,7 @@ var defaultServerConfig = &ServerConfig{
 		MaxMemoryConsumption:   16 * 1024 * 1024 * 1024, // 16GB
 		NumWorkerPoolGoroutine: 16,
 		SortDir:                DefaultSortDir,
+		Compression:            SorterCompressionNone,
 	},
 	Security:            &SecurityConfig{},
 	PerTableMemoryQuota: 20 * 1024 * 1024, // 20MB
@@ -192,6 +267,18 @@ var defaultServerConfig = &ServerConfig{
 		WorkerPoolSize:   0, // 0 will use NumCPU() * 2
 		RegionScanLimit:  40,
 	},
+	SchemaHistory: &SchemaHistoryConfig{
+		Enable:           false,
+		CompactThreshold: 1024,
+	},
+	Auth: &AuthConfig{
+		Enable: false,
+	},
+	Tracing: &TracingConfig{
+		Enable:      false,
+		Endpoint:    "http://127.0.0.1:4318/v1/traces",
+		SampleRatio: 0.001,
+	},
 }
 
 // ServerConfig represents a config for server
@@ -217,6 +304,14 @@ type ServerConfig struct {
 	Security            *SecurityConfig `toml:"security" json:"security"`
 	PerTableMemoryQuota uint64          `toml:"per-table-memory-quota" json:"per-table-memory-quota"`
 	KVClient            *KVClientConfig `toml:"kv-client" json:"kv-client"`
+
+	SchemaHistory *SchemaHistoryConfig `toml:"schema-history" json:"schema-history"`
+	Auth          *AuthConfig          `toml:"auth" json:"auth"`
+	Tracing       *TracingConfig       `toml:"tracing" json:"tracing"`
+
+	// Labels are the user-defined labels of the capture, e.g. zone, rack and disk type.
+	// They are used by the placement rules of changefeeds.
+	Labels map[string]string `toml:"labels" json:"labels,omitempty"`
 }
 
 // Marshal returns the json marshal format of a ServerConfig
@@ -259,6 +354,22 @@ func (c *ServerConfig) Clone() *ServerConfig {
 	return clone
 }
 
+// Redacted returns a copy of the server configuration with the secrets
+// replaced, which is safe to be exposed for diagnosis.
+func (c *ServerConfig) Redacted() *ServerConfig {
+	redacted := c.Clone()
+	if redacted.Auth != nil {
+		for _, token := range redacted.Auth.Tokens {
+			// the digest of a weak token could be brute-forced
+			token.SHA256 = security.RedactedSecret
+		}
+	}
+	if redacted.Tracing != nil {
+		redacted.Tracing.Endpoint = security.RedactURI(redacted.Tracing.Endpoint)
+	}
+	return redacted
+}
+
 // ValidateAndAdjust validates and adjusts the server configuration
 func (c *ServerConfig) ValidateAndAdjust() error {
 	if c.Addr == "" {
@@ -298,6 +409,12 @@ func (c *ServerConfig) ValidateAndAdjust() error {
 		}
 	}
 
+	if c.Log != nil {
+		if err := c.Log.Validate(); err != nil {
+			return err
+		}
+	}
+
 	if c.Sorter == nil {
 		c.Sorter = defaultServerConfig.Sorter
 	}
@@ -321,6 +438,11 @@ func (c *ServerConfig) ValidateAndAdjust() error {
 	if c.Sorter.MaxMemoryPressure < 0 || c.Sorter.MaxMemoryPressure > 100 {
 		return cerror.ErrIllegalUnifiedSorterParameter.GenWithStackByArgs("max-memory-percentage should be a percentage")
 	}
+	switch c.Sorter.Compression {
+	case SorterCompressionNone, SorterCompressionSnappy, SorterCompressionZstd:
+	default:
+		return cerror.ErrIllegalUnifiedSorterParameter.GenWithStackByArgs("compression should be one of none, snappy and zstd")
+	}
 
 	if c.PerTableMemoryQuota == 0 {
 		c.PerTableMemoryQuota = defaultServerConfig.PerTableMemoryQuota
@@ -329,6 +451,33 @@ func (c *ServerConfig) ValidateAndAdjust() error {
 		return cerror.ErrInvalidServerOption.GenWithStackByArgs("per-table-memory-quota should be at least 6MB")
 	}
 
+	if c.SchemaHistory == nil {
+		c.SchemaHistory = defaultServerConfig.SchemaHistory
+	}
+	if c.SchemaHistory.CompactThreshold < 1 {
+		return cerror.ErrInvalidServerOption.GenWithStack("schema-history.compact-threshold should be at least 1")
+	}
+
+	if c.Auth == nil {
+		c.Auth = defaultServerConfig.Auth
+	}
+	if err := c.Auth.Validate(c.Security); err != nil {
+		return err
+	}
+
+	if c.Tracing == nil {
+		c.Tracing = defaultServerConfig.Tracing
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		return err
+	}
+
+	for key, value := range c.Labels {
+		if key == "" || value == "" {
+			return cerror.ErrInvalidServerOption.GenWithStack("label key and value should not be empty, key: %q, value: %q", key, value)
+		}
+	}
+
 	if c.KVClient == nil {
 		c.KVClient = defaultServerConfig.KVClient
 	}
@@ -338,6 +487,9 @@ func (c *ServerConfig) ValidateAndAdjust() error {
 	if c.KVClient.RegionScanLimit <= 0 {
 		return cerror.ErrInvalidServerOption.GenWithStackByArgs("region-scan-limit should be at least 1")
 	}
+	if c.KVClient.StoreScanLimit < 0 {
+		return cerror.ErrInvalidServerOption.GenWithStackByArgs("store-scan-limit should not be negative")
+	}
 
 	return nil
 }
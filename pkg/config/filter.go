@@ -24,4 +24,19 @@ type FilterConfig struct {
 	*filter.MySQLReplicationRules
 	IgnoreTxnStartTs []uint64           `toml:"ignore-txn-start-ts" json:"ignore-txn-start-ts"`
 	DDLAllowlist     []model.ActionType `toml:"ddl-allow-list" json:"ddl-allow-list,omitempty"`
+	// IgnoreSQL is a list of regular expressions,
+	// the DDLs whose query matches any of them will not be replicated.
+	IgnoreSQL []string `toml:"ignore-sql" json:"ignore-sql,omitempty"`
+	// DDLRewriteRules are applied in order to the query of DDLs before they are sent to the sink.
+	DDLRewriteRules []*DDLRewriteRule `toml:"ddl-rewrite-rules" json:"ddl-rewrite-rules,omitempty"`
+}
+
+// DDLRewriteRule represents a rule which rewrites the query of DDLs,
+// either Builtin or Pattern should be specified.
+type DDLRewriteRule struct {
+	// Builtin is the name of a builtin rewriter, e.g. "strip-auto-random"
+	Builtin string `toml:"builtin" json:"builtin,omitempty"`
+	// Pattern is a regular expression, the matched text will be replaced by Replacement
+	Pattern     string `toml:"pattern" json:"pattern,omitempty"`
+	Replacement string `toml:"replacement" json:"replacement,omitempty"`
 }
@@ -0,0 +1,72 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"strings"
+	"time"
+
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
+)
+
+// DefaultHeartbeatInterval is the default interval of writing heartbeats
+const DefaultHeartbeatInterval = time.Second
+
+// HeartbeatConfig represents the config of the heartbeats used to measure the
+// end-to-end replication lag of a changefeed. The owner writes heartbeats into
+// a dedicated table of the upstream periodically, and the lag is reported once
+// the heartbeats are applied or published by the sink.
+type HeartbeatConfig struct {
+	Enable bool `toml:"enable" json:"enable"`
+	// the interval of writing heartbeats, DefaultHeartbeatInterval is used if it is zero
+	Interval TomlDuration `toml:"interval" json:"interval"`
+	// the URI of the upstream TiDB to write heartbeats to, e.g. mysql://root:${env:PASSWORD}@127.0.0.1:4000/
+	UpstreamURI string `toml:"upstream-uri" json:"upstream-uri"`
+}
+
+// IsEnabled returns whether the heartbeat is enabled or not.
+func (c *HeartbeatConfig) IsEnabled() bool {
+	return c != nil && c.Enable
+}
+
+// GetInterval returns the interval of writing heartbeats
+func (c *HeartbeatConfig) GetInterval() time.Duration {
+	if c.Interval == 0 {
+		return DefaultHeartbeatInterval
+	}
+	return time.Duration(c.Interval)
+}
+
+// Validate checks whether the heartbeat config is valid
+func (c *HeartbeatConfig) Validate() error {
+	if !c.IsEnabled() {
+		return nil
+	}
+	if c.Interval < 0 {
+		return cerror.ErrInvalidHeartbeatConfig.GenWithStackByArgs("interval should not be negative")
+	}
+	// the error of parsing is not returned, as it may contain the password
+	u, err := security.ParseURIWithSecretReferences(c.UpstreamURI)
+	if err != nil || u.Host == "" {
+		return cerror.ErrInvalidHeartbeatConfig.GenWithStackByArgs("upstream-uri should be a valid URI")
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "mysql", "tidb":
+	default:
+		return cerror.ErrInvalidHeartbeatConfig.GenWithStackByArgs(
+			"the scheme of upstream-uri should be mysql or tidb")
+	}
+	return nil
+}
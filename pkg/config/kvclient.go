@@ -21,4 +21,12 @@ type KVClientConfig struct {
 	WorkerPoolSize int `toml:"worker-pool-size" json:"worker-pool-size"`
 	// region incremental scan limit for one table in a single store
 	RegionScanLimit int `toml:"region-scan-limit" json:"region-scan-limit"`
+	// region incremental scan limit for all tables in a single store, 0 means no limit
+	StoreScanLimit int `toml:"store-scan-limit" json:"store-scan-limit"`
+	// the rate limit in bytes per second of the incremental scan data received
+	// from a single store, 0 means no limit
+	StoreScanRateLimit uint64 `toml:"store-scan-rate-limit" json:"store-scan-rate-limit"`
+	// whether to share the gRPC streams to a store among all tables and
+	// changefeeds of the capture
+	EnableStreamMultiplexing bool `toml:"enable-stream-multiplexing" json:"enable-stream-multiplexing"`
 }
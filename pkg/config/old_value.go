@@ -0,0 +1,35 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// OldValueRule enables or disables old values for the matched tables. Old
+// values are only requested from TiKV for the tables they are enabled for,
+// which saves the CPU of TiKV if only a few tables need them.
+type OldValueRule struct {
+	Matcher []string `toml:"matcher" json:"matcher"`
+	Enable  bool     `toml:"enable" json:"enable"`
+}
+
+// AnyOldValueEnabled returns whether old values are enabled for any table.
+func (c *ReplicaConfig) AnyOldValueEnabled() bool {
+	if c.EnableOldValue {
+		return true
+	}
+	for _, rule := range c.OldValueRules {
+		if rule.Enable {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// label constraint operators
+const (
+	LabelConstraintOpIn        = "in"
+	LabelConstraintOpNotIn     = "not-in"
+	LabelConstraintOpExists    = "exists"
+	LabelConstraintOpNotExists = "not-exists"
+)
+
+// PlacementConfig represents the placement rules of the tables of a changefeed.
+// Tables are only dispatched to the captures whose labels satisfy the rules,
+// a table is dispatched to any capture if no capture satisfies its rule for
+// several minutes.
+type PlacementConfig struct {
+	// Constraints and SpreadLabel apply to the tables not matched by any of Rules.
+	Constraints []*LabelConstraint `toml:"constraints" json:"constraints"`
+	// SpreadLabel is a label key, tables are spread evenly across the captures
+	// with different values of the label, e.g. "zone".
+	SpreadLabel string           `toml:"spread-label" json:"spread-label"`
+	Rules       []*PlacementRule `toml:"rules" json:"rules"`
+}
+
+// PlacementRule represents the placement rule for the tables matched by Matcher
+type PlacementRule struct {
+	Matcher     []string           `toml:"matcher" json:"matcher"`
+	Constraints []*LabelConstraint `toml:"constraints" json:"constraints"`
+	SpreadLabel string             `toml:"spread-label" json:"spread-label"`
+}
+
+// LabelConstraint represents a constraint on a label of captures
+type LabelConstraint struct {
+	Key    string   `toml:"key" json:"key"`
+	Op     string   `toml:"op" json:"op"`
+	Values []string `toml:"values" json:"values"`
+}
+
+// Validate checks whether the placement config is valid
+func (c *PlacementConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if err := validateLabelConstraints(c.Constraints); err != nil {
+		return err
+	}
+	for _, rule := range c.Rules {
+		if len(rule.Matcher) == 0 {
+			return cerror.ErrInvalidPlacementRule.GenWithStackByArgs("matcher of placement rule is empty")
+		}
+		if err := validateLabelConstraints(rule.Constraints); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateLabelConstraints(constraints []*LabelConstraint) error {
+	for _, constraint := range constraints {
+		if constraint.Key == "" {
+			return cerror.ErrInvalidPlacementRule.GenWithStackByArgs("label key of constraint is empty")
+		}
+		switch constraint.Op {
+		case LabelConstraintOpIn, LabelConstraintOpNotIn:
+			if len(constraint.Values) == 0 {
+				return cerror.ErrInvalidPlacementRule.GenWithStackByArgs(
+					"values of constraint on label " + constraint.Key + " is empty")
+			}
+		case LabelConstraintOpExists, LabelConstraintOpNotExists:
+		default:
+			return cerror.ErrInvalidPlacementRule.GenWithStackByArgs(
+				"unknown operator " + constraint.Op + " of constraint on label " + constraint.Key)
+		}
+	}
+	return nil
+}
+
+// Match returns true if the labels satisfy the constraint
+func (c *LabelConstraint) Match(labels map[string]string) bool {
+	value, exist := labels[c.Key]
+	switch c.Op {
+	case LabelConstraintOpIn:
+		return exist && containsString(c.Values, value)
+	case LabelConstraintOpNotIn:
+		return !exist || !containsString(c.Values, value)
+	case LabelConstraintOpExists:
+		return exist
+	case LabelConstraintOpNotExists:
+		return !exist
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+// SchemaHistoryConfig represents config for the schema history of a capture
+type SchemaHistoryConfig struct {
+	// whether to persist the schema snapshot and the DDL jobs pulled by the
+	// DDL pullers in the data dir, so that changefeeds can build their schema
+	// snapshots locally instead of reading the whole schema from TiKV
+	Enable bool `toml:"enable" json:"enable"`
+	// the number of DDL jobs kept in the history before they are merged into
+	// the persisted schema snapshot
+	CompactThreshold int `toml:"compact-threshold" json:"compact-threshold"`
+}
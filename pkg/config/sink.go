@@ -17,6 +17,16 @@ package config
 type SinkConfig struct {
 	DispatchRules []*DispatchRule `toml:"dispatchers" json:"dispatchers"`
 	Protocol      string          `toml:"protocol" json:"protocol"`
+	// ColumnSelectors selects the columns sent to the downstream for MQ sinks,
+	// all columns are sent for the tables not matched by any selector.
+	ColumnSelectors []*ColumnSelector `toml:"column-selectors" json:"column-selectors,omitempty"`
+}
+
+// ColumnSelector represents the columns of tables sent to the downstream,
+// the handle key columns are always sent.
+type ColumnSelector struct {
+	Matcher []string `toml:"matcher" json:"matcher"`
+	Columns []string `toml:"columns" json:"columns"`
 }
 
 // DispatchRule represents partition rule for a table
@@ -13,6 +13,13 @@
 
 package config
 
+// compression algorithms of the temporary files of the sorter
+const (
+	SorterCompressionNone   = "none"
+	SorterCompressionSnappy = "snappy"
+	SorterCompressionZstd   = "zstd"
+)
+
 // SorterConfig represents sorter config for a changefeed
 type SorterConfig struct {
 	// number of concurrent heap sorts
@@ -23,8 +30,15 @@ type SorterConfig struct {
 	MaxMemoryPressure int `toml:"max-memory-percentage" json:"max-memory-percentage"`
 	// the maximum memory consumption allowed for in-memory sorting
 	MaxMemoryConsumption uint64 `toml:"max-memory-consumption" json:"max-memory-consumption"`
+	// the maximum disk space in bytes used by the temporary files, 0 means no limit
+	MaxDiskConsumption uint64 `toml:"max-disk-consumption" json:"max-disk-consumption"`
 	// the size of workerpool
 	NumWorkerPoolGoroutine int `toml:"num-workerpool-goroutine" json:"num-workerpool-goroutine"`
 	// the directory used to store the temporary files generated by the sorter
 	SortDir string `toml:"sort-dir" json:"sort-dir"`
+	// the compression algorithm of the temporary files, "none", "snappy" or "zstd"
+	Compression string `toml:"compression" json:"compression"`
+	// whether to keep the sorted but not yet replicated data of the db sort engine
+	// across restarts, so that tables can be resumed without pulling from TiKV again
+	EnablePersistence bool `toml:"enable-persistence" json:"enable-persistence"`
 }
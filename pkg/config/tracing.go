@@ -0,0 +1,45 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"net/url"
+
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// TracingConfig represents the config of tracing the row changed events through
+// the data flow of a capture, the spans are exported by OTLP over HTTP
+type TracingConfig struct {
+	Enable bool `toml:"enable" json:"enable"`
+	// the OTLP/HTTP endpoint to export the spans to, e.g. http://127.0.0.1:4318/v1/traces
+	Endpoint string `toml:"endpoint" json:"endpoint"`
+	// the ratio of the transactions to trace, in the range of (0, 1]
+	SampleRatio float64 `toml:"sample-ratio" json:"sample-ratio"`
+}
+
+// Validate checks whether the tracing config is valid
+func (c *TracingConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return cerror.ErrInvalidServerOption.GenWithStack("tracing endpoint %q should be a valid http or https URL", c.Endpoint)
+	}
+	if c.SampleRatio <= 0 || c.SampleRatio > 1 {
+		return cerror.ErrInvalidServerOption.GenWithStack("tracing sample-ratio should be in the range of (0, 1]")
+	}
+	return nil
+}
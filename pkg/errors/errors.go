@@ -56,15 +56,19 @@ var (
 	ErrRegionWorkerExit       = errors.Normalize("region worker exited", errors.RFCCodeText("CDC:ErrRegionWorkerExit"))
 
 	// rule related errors
-	ErrEncodeFailed      = errors.Normalize("encode failed: %s", errors.RFCCodeText("CDC:ErrEncodeFailed"))
-	ErrDecodeFailed      = errors.Normalize("decode failed: %s", errors.RFCCodeText("CDC:ErrDecodeFailed"))
-	ErrFilterRuleInvalid = errors.Normalize("filter rule is invalid", errors.RFCCodeText("CDC:ErrFilterRuleInvalid"))
+	ErrEncodeFailed           = errors.Normalize("encode failed: %s", errors.RFCCodeText("CDC:ErrEncodeFailed"))
+	ErrDecodeFailed           = errors.Normalize("decode failed: %s", errors.RFCCodeText("CDC:ErrDecodeFailed"))
+	ErrFilterRuleInvalid      = errors.Normalize("filter rule is invalid", errors.RFCCodeText("CDC:ErrFilterRuleInvalid"))
+	ErrInvalidPlacementRule   = errors.Normalize("placement rule is invalid: %s", errors.RFCCodeText("CDC:ErrInvalidPlacementRule"))
+	ErrInvalidDDLRewriteRule  = errors.Normalize("ddl rewrite rule is invalid: %s", errors.RFCCodeText("CDC:ErrInvalidDDLRewriteRule"))
+	ErrInvalidHeartbeatConfig = errors.Normalize("heartbeat config is invalid: %s", errors.RFCCodeText("CDC:ErrInvalidHeartbeatConfig"))
 
 	// internal errors
 	ErrAdminStopProcessor = errors.Normalize("stop processor by admin command", errors.RFCCodeText("CDC:ErrAdminStopProcessor"))
 	// ErrVersionIncompatible is an error for running CDC on an incompatible Cluster.
-	ErrVersionIncompatible   = errors.Normalize("version is incompatible: %s", errors.RFCCodeText("CDC:ErrVersionIncompatible"))
-	ErrCreateMarkTableFailed = errors.Normalize("create mark table failed", errors.RFCCodeText("CDC:ErrCreateMarkTableFailed"))
+	ErrVersionIncompatible        = errors.Normalize("version is incompatible: %s", errors.RFCCodeText("CDC:ErrVersionIncompatible"))
+	ErrCreateMarkTableFailed      = errors.Normalize("create mark table failed", errors.RFCCodeText("CDC:ErrCreateMarkTableFailed"))
+	ErrCreateHeartbeatTableFailed = errors.Normalize("create heartbeat table failed", errors.RFCCodeText("CDC:ErrCreateHeartbeatTableFailed"))
 
 	// sink related errors
 	ErrExecDDLFailed             = errors.Normalize("exec DDL failed", errors.RFCCodeText("CDC:ErrExecDDLFailed"))
@@ -125,18 +129,19 @@ var (
 	ErrOperateOnClosedNotifier   = errors.Normalize("operate on a closed notifier", errors.RFCCodeText("CDC:ErrOperateOnClosedNotifier"))
 
 	// encode/decode, data format and data integrity errors
-	ErrInvalidRecordKey      = errors.Normalize("invalid record key - %q", errors.RFCCodeText("CDC:ErrInvalidRecordKey"))
-	ErrCodecDecode           = errors.Normalize("codec decode error", errors.RFCCodeText("CDC:ErrCodecDecode"))
-	ErrUnknownMetaType       = errors.Normalize("unknown meta type %v", errors.RFCCodeText("CDC:ErrUnknownMetaType"))
-	ErrFetchHandleValue      = errors.Normalize("can't find handle column, please check if the pk is handle", errors.RFCCodeText("CDC:ErrFetchHandleValue"))
-	ErrDatumUnflatten        = errors.Normalize("unflatten datume data", errors.RFCCodeText("CDC:ErrDatumUnflatten"))
-	ErrWrongTableInfo        = errors.Normalize("wrong table info in unflatten, table id %d, index table id: %d", errors.RFCCodeText("CDC:ErrWrongTableInfo"))
-	ErrIndexKeyTableNotFound = errors.Normalize("table not found with index ID %d in index kv", errors.RFCCodeText("CDC:ErrIndexKeyTableNotFound"))
-	ErrDecodeRowToDatum      = errors.Normalize("decode row data to datum failed", errors.RFCCodeText("CDC:ErrDecodeRowToDatum"))
-	ErrMarshalFailed         = errors.Normalize("marshal failed", errors.RFCCodeText("CDC:ErrMarshalFailed"))
-	ErrUnmarshalFailed       = errors.Normalize("unmarshal failed", errors.RFCCodeText("CDC:ErrUnmarshalFailed"))
-	ErrInvalidChangefeedID   = errors.Normalize(`bad changefeed id, please match the pattern "^[a-zA-Z0-9]+(\-[a-zA-Z0-9]+)*$, the length should no more than %d", eg, "simple-changefeed-task"`, errors.RFCCodeText("CDC:ErrInvalidChangefeedID"))
-	ErrInvalidEtcdKey        = errors.Normalize("invalid key: %s", errors.RFCCodeText("CDC:ErrInvalidEtcdKey"))
+	ErrInvalidRecordKey          = errors.Normalize("invalid record key - %q", errors.RFCCodeText("CDC:ErrInvalidRecordKey"))
+	ErrCodecDecode               = errors.Normalize("codec decode error", errors.RFCCodeText("CDC:ErrCodecDecode"))
+	ErrUnknownMetaType           = errors.Normalize("unknown meta type %v", errors.RFCCodeText("CDC:ErrUnknownMetaType"))
+	ErrFetchHandleValue          = errors.Normalize("can't find handle column, please check if the pk is handle", errors.RFCCodeText("CDC:ErrFetchHandleValue"))
+	ErrDatumUnflatten            = errors.Normalize("unflatten datume data", errors.RFCCodeText("CDC:ErrDatumUnflatten"))
+	ErrWrongTableInfo            = errors.Normalize("wrong table info in unflatten, table id %d, index table id: %d", errors.RFCCodeText("CDC:ErrWrongTableInfo"))
+	ErrIndexKeyTableNotFound     = errors.Normalize("table not found with index ID %d in index kv", errors.RFCCodeText("CDC:ErrIndexKeyTableNotFound"))
+	ErrDecodeRowToDatum          = errors.Normalize("decode row data to datum failed", errors.RFCCodeText("CDC:ErrDecodeRowToDatum"))
+	ErrMarshalFailed             = errors.Normalize("marshal failed", errors.RFCCodeText("CDC:ErrMarshalFailed"))
+	ErrUnmarshalFailed           = errors.Normalize("unmarshal failed", errors.RFCCodeText("CDC:ErrUnmarshalFailed"))
+	ErrInvalidChangefeedID       = errors.Normalize(`bad changefeed id, please match the pattern "^[a-zA-Z0-9]+(\-[a-zA-Z0-9]+)*$, the length should no more than %d", eg, "simple-changefeed-task"`, errors.RFCCodeText("CDC:ErrInvalidChangefeedID"))
+	ErrInvalidEtcdKey            = errors.Normalize("invalid key: %s", errors.RFCCodeText("CDC:ErrInvalidEtcdKey"))
+	ErrInvalidChangefeedPriority = errors.Normalize(`bad changefeed priority "%s", the priority should be one of "high", "normal" and "low"`, errors.RFCCodeText("CDC:ErrInvalidChangefeedPriority"))
 
 	// schema storage errors
 	ErrSchemaStorageUnresolved = errors.Normalize("can not found schema snapshot, the specified ts(%d) is more than resolvedTs(%d)", errors.RFCCodeText("CDC:ErrSchemaStorageUnresolved"))
@@ -147,6 +152,7 @@ var (
 	ErrSnapshotTableNotFound   = errors.Normalize("table %d not found in schema snapshot", errors.RFCCodeText("CDC:ErrSnapshotTableNotFound"))
 	ErrSnapshotSchemaExists    = errors.Normalize("schema %s(%d) already exists", errors.RFCCodeText("CDC:ErrSnapshotSchemaExists"))
 	ErrSnapshotTableExists     = errors.Normalize("table %s.%s already exists", errors.RFCCodeText("CDC:ErrSnapshotTableExists"))
+	ErrSchemaHistoryIOError    = errors.Normalize("schema history IO error: %s", errors.RFCCodeText("CDC:ErrSchemaHistoryIOError"))
 
 	// puller related errors
 	ErrBufferReachLimit = errors.Normalize("puller mem buffer reach size limit", errors.RFCCodeText("CDC:ErrBufferReachLimit"))
@@ -172,6 +178,13 @@ var (
 	ErrSupportPostOnly              = errors.Normalize("this api supports POST method only", errors.RFCCodeText("CDC:ErrSupportPostOnly"))
 	ErrSupportGetOnly               = errors.Normalize("this api supports GET method only", errors.RFCCodeText("CDC:ErrSupportGetOnly"))
 	ErrAPIInvalidParam              = errors.Normalize("invalid api parameter", errors.RFCCodeText("CDC:ErrAPIInvalidParam"))
+	ErrAPIUnauthenticated           = errors.Normalize("unauthenticated api request: %s", errors.RFCCodeText("CDC:ErrAPIUnauthenticated"))
+	ErrAPIPermissionDenied          = errors.Normalize("permission denied, role %s is required", errors.RFCCodeText("CDC:ErrAPIPermissionDenied"))
+	ErrAPIWatchCompacted            = errors.Normalize("the resume token %d of the watch api has been compacted to revision %d, please watch again without a resume token", errors.RFCCodeText("CDC:ErrAPIWatchCompacted"))
+	ErrAPIWatchTooManyClients       = errors.Normalize("the number of watch api clients reaches the limit %d", errors.RFCCodeText("CDC:ErrAPIWatchTooManyClients"))
+	ErrAPIWatchTooSlow              = errors.Normalize("the watch api client is too slow to receive the changes, please watch again with the resume token", errors.RFCCodeText("CDC:ErrAPIWatchTooSlow"))
+	ErrResolveSecret                = errors.Normalize("failed to resolve secret reference %s", errors.RFCCodeText("CDC:ErrResolveSecret"))
+	ErrInvalidSecretKey             = errors.Normalize("invalid secret key", errors.RFCCodeText("CDC:ErrInvalidSecretKey"))
 	ErrRequestForwardErr            = errors.Normalize("request forward error, an request can only forward to owner one time ", errors.RFCCodeText("ErrRequestForwardErr"))
 	ErrInternalServerError          = errors.Normalize("internal server error", errors.RFCCodeText("CDC:ErrInternalServerError"))
 	ErrOwnerSortDir                 = errors.Normalize("owner sort dir", errors.RFCCodeText("CDC:ErrOwnerSortDir"))
@@ -185,12 +198,16 @@ var (
 	ErrUpdateServiceSafepointFailed = errors.Normalize("updating service safepoint failed", errors.RFCCodeText("CDC:ErrUpdateServiceSafepointFailed"))
 	ErrStartTsBeforeGC              = errors.Normalize("fail to create changefeed because start-ts %d is earlier than GC safepoint at %d", errors.RFCCodeText("CDC:ErrStartTsBeforeGC"))
 	ErrTargetTsBeforeStartTs        = errors.Normalize("fail to create changefeed because target-ts %d is earlier than start-ts %d", errors.RFCCodeText("CDC:ErrTargetTsBeforeStartTs"))
+	ErrStopTsBeforeCheckpointTs     = errors.Normalize("stop-ts %d is earlier than or equal to checkpoint-ts %d", errors.RFCCodeText("CDC:ErrStopTsBeforeCheckpointTs"))
+	ErrTargetTsBeforeCheckpointTs   = errors.Normalize("target-ts %d is earlier than or equal to checkpoint-ts %d", errors.RFCCodeText("CDC:ErrTargetTsBeforeCheckpointTs"))
+	ErrInvalidOverwriteCheckpointTs = errors.Normalize("overwritten checkpoint-ts %d is invalid: %s", errors.RFCCodeText("CDC:ErrInvalidOverwriteCheckpointTs"))
 	ErrSnapshotLostByGC             = errors.Normalize("fail to create or maintain changefeed due to snapshot loss caused by GC. checkpoint-ts %d is earlier than GC safepoint at %d", errors.RFCCodeText("CDC:ErrSnapshotLostByGC"))
 	ErrGCTTLExceeded                = errors.Normalize("the checkpoint-ts(%d) lag of the changefeed(%s) has exceeded the GC TTL", errors.RFCCodeText("CDC:ErrGCTTLExceeded"))
 	ErrNotOwner                     = errors.Normalize("this capture is not a owner", errors.RFCCodeText("CDC:ErrNotOwner"))
 	ErrOwnerNotFound                = errors.Normalize("owner not found", errors.RFCCodeText("CDC:ErrOwnerNotFound"))
 	ErrTableListenReplicated        = errors.Normalize("A table(%d) is being replicated by at least two processors(%s, %s), please report a bug", errors.RFCCodeText("CDC:ErrTableListenReplicated"))
 	ErrTableIneligible              = errors.Normalize("some tables are not eligible to replicate(%v), if you want to ignore these tables, please set ignore_ineligible_table to true", errors.RFCCodeText("CDC:ErrTableIneligible"))
+	ErrDrainCaptureNoTarget         = errors.Normalize("capture %s cannot be drained, because there is no other capture to replicate its tables", errors.RFCCodeText("CDC:ErrDrainCaptureNoTarget"))
 
 	// EtcdWorker related errors. Internal use only.
 	// ErrEtcdTryAgain is used by a PatchFunc to force a transaction abort.
@@ -215,10 +232,15 @@ var (
 	// unified sorter errors
 	ErrUnifiedSorterBackendTerminating = errors.Normalize("unified sorter backend is terminating", errors.RFCCodeText("CDC:ErrUnifiedSorterBackendTerminating"))
 	ErrIllegalUnifiedSorterParameter   = errors.Normalize("illegal parameter for unified sorter: %s", errors.RFCCodeText("CDC:ErrIllegalUnifiedSorterParameter"))
+	ErrUnifiedSorterDiskQuotaExceeded  = errors.Normalize("unified sorter disk quota exceeded: %s", errors.RFCCodeText("CDC:ErrUnifiedSorterDiskQuotaExceeded"))
 	ErrAsyncIOCancelled                = errors.Normalize("asynchronous IO operation is cancelled. Internal use only, report a bug if seen in log", errors.RFCCodeText("CDC:ErrAsyncIOCancelled"))
 	ErrUnifiedSorterIOError            = errors.Normalize("unified sorter IO error. Make sure your sort-dir is configured correctly by passing a valid argument or toml file to `cdc server`, or if you use TiUP, review the settings in `tiup cluster edit-config`. Details: %s", errors.RFCCodeText("CDC:ErrUnifiedSorterIOError"))
 	ErrConflictingFileLocks            = errors.Normalize("file lock conflict: %s", errors.RFCCodeText("ErrConflictingFileLocks"))
 	ErrSortDirLockError                = errors.Normalize("error encountered when locking sort-dir", errors.RFCCodeText("ErrSortDirLockError"))
+	ErrUnifiedSorterDataCorrupted      = errors.Normalize("unified sorter data is corrupted, the table will be rebuilt. Details: %s", errors.RFCCodeText("CDC:ErrUnifiedSorterDataCorrupted"))
+
+	// db sorter errors
+	ErrDBSorterIOError = errors.Normalize("db sorter IO error: %s", errors.RFCCodeText("CDC:ErrDBSorterIOError"))
 
 	// processor errors
 	ErrTableProcessorStoppedSafely = errors.Normalize("table processor stopped safely", errors.RFCCodeText("CDC:ErrTableProcessorStoppedSafely"))
@@ -0,0 +1,106 @@
+// Copyright 2020 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"regexp"
+
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// builtin DDL rewriters
+const (
+	// DDLRewriterStripTiDBComments removes all the TiDB specific comments, such as `/*T![auto_rand] AUTO_RANDOM(5) */`
+	DDLRewriterStripTiDBComments = "strip-tidb-comments"
+	// DDLRewriterStripAutoRandom removes the AUTO_RANDOM column attribute and the AUTO_RANDOM_BASE table option
+	DDLRewriterStripAutoRandom = "strip-auto-random"
+	// DDLRewriterStripShardRowIDBits removes the SHARD_ROW_ID_BITS and PRE_SPLIT_REGIONS table options
+	DDLRewriterStripShardRowIDBits = "strip-shard-row-id-bits"
+)
+
+// emptyTiDBCommentPat matches the TiDB specific comments left empty after rewriting
+var emptyTiDBCommentPat = regexp.MustCompile(`\s?/\*T!(\[[\w,]+\])?\s*\*/`)
+
+var builtinDDLRewriters = map[string][]*regexp.Regexp{
+	DDLRewriterStripTiDBComments: {
+		regexp.MustCompile(`(?s)\s?/\*T!(\[[\w,]+\])?.*?\*/`),
+	},
+	DDLRewriterStripAutoRandom: {
+		regexp.MustCompile(`(?i)\bAUTO_RANDOM_BASE\s*=?\s*\d+`),
+		regexp.MustCompile(`(?i)\bAUTO_RANDOM\b(\s*\(\s*\d+\s*(,\s*\d+\s*)?\))?`),
+		emptyTiDBCommentPat,
+	},
+	DDLRewriterStripShardRowIDBits: {
+		regexp.MustCompile(`(?i)\bSHARD_ROW_ID_BITS\s*=\s*\d+`),
+		regexp.MustCompile(`(?i)\bPRE_SPLIT_REGIONS\s*=\s*\d+`),
+		emptyTiDBCommentPat,
+	},
+}
+
+type ddlRewriteRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// ddlRewriter rewrites the query of DDLs by the rules in order.
+type ddlRewriter []ddlRewriteRule
+
+func newDDLRewriter(rules []*config.DDLRewriteRule) (ddlRewriter, error) {
+	var rewriter ddlRewriter
+	for _, rule := range rules {
+		switch {
+		case rule.Builtin != "" && rule.Pattern != "":
+			return nil, cerror.ErrInvalidDDLRewriteRule.GenWithStackByArgs(
+				"only one of builtin and pattern can be specified")
+		case rule.Builtin != "":
+			patterns, ok := builtinDDLRewriters[rule.Builtin]
+			if !ok {
+				return nil, cerror.ErrInvalidDDLRewriteRule.GenWithStackByArgs("unknown builtin rewriter " + rule.Builtin)
+			}
+			for _, pattern := range patterns {
+				rewriter = append(rewriter, ddlRewriteRule{pattern: pattern})
+			}
+		case rule.Pattern != "":
+			pattern, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, cerror.ErrInvalidDDLRewriteRule.GenWithStackByArgs(err.Error())
+			}
+			rewriter = append(rewriter, ddlRewriteRule{pattern: pattern, replacement: rule.Replacement})
+		default:
+			return nil, cerror.ErrInvalidDDLRewriteRule.GenWithStackByArgs(
+				"either builtin or pattern should be specified")
+		}
+	}
+	return rewriter, nil
+}
+
+func (r ddlRewriter) rewrite(query string) string {
+	for _, rule := range r {
+		query = rule.pattern.ReplaceAllString(query, rule.replacement)
+	}
+	return query
+}
+
+func compileIgnoreSQL(ignoreSQL []string) ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(ignoreSQL))
+	for _, sql := range ignoreSQL {
+		pattern, err := regexp.Compile(sql)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrFilterRuleInvalid, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
@@ -14,20 +14,27 @@
 package filter
 
 import (
+	"regexp"
+
+	"github.com/pingcap/errors"
 	"github.com/pingcap/parser/model"
 	"github.com/pingcap/ticdc/pkg/config"
 	"github.com/pingcap/ticdc/pkg/cyclic/mark"
 	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/heartbeat"
 	filterV1 "github.com/pingcap/tidb-tools/pkg/filter"
 	filterV2 "github.com/pingcap/tidb-tools/pkg/table-filter"
 )
 
 // Filter is a event filter implementation.
 type Filter struct {
-	filter           filterV2.Filter
-	ignoreTxnStartTs []uint64
-	ddlAllowlist     []model.ActionType
-	isCyclicEnabled  bool
+	filter             filterV2.Filter
+	ignoreTxnStartTs   []uint64
+	ddlAllowlist       []model.ActionType
+	isCyclicEnabled    bool
+	isHeartbeatEnabled bool
+	ignoreSQL          []*regexp.Regexp
+	ddlRewriter        ddlRewriter
 }
 
 // VerifyRules checks the filter rules in the configuration
@@ -47,6 +54,15 @@ func VerifyRules(cfg *config.ReplicaConfig) (filterV2.Filter, error) {
 	if err != nil {
 		return nil, cerror.WrapError(cerror.ErrFilterRuleInvalid, err)
 	}
+	if _, err := compileIgnoreSQL(cfg.Filter.IgnoreSQL); err != nil {
+		return nil, err
+	}
+	if _, err := newDDLRewriter(cfg.Filter.DDLRewriteRules); err != nil {
+		return nil, err
+	}
+	if _, err := NewOldValueMatcher(cfg); err != nil {
+		return nil, err
+	}
 
 	return f, nil
 }
@@ -55,17 +71,28 @@ func VerifyRules(cfg *config.ReplicaConfig) (filterV2.Filter, error) {
 func NewFilter(cfg *config.ReplicaConfig) (*Filter, error) {
 	f, err := VerifyRules(cfg)
 	if err != nil {
-		return nil, cerror.WrapError(cerror.ErrFilterRuleInvalid, err)
+		return nil, errors.Trace(err)
 	}
 
 	if !cfg.CaseSensitive {
 		f = filterV2.CaseInsensitive(f)
 	}
+	ignoreSQL, err := compileIgnoreSQL(cfg.Filter.IgnoreSQL)
+	if err != nil {
+		return nil, err
+	}
+	rewriter, err := newDDLRewriter(cfg.Filter.DDLRewriteRules)
+	if err != nil {
+		return nil, err
+	}
 	return &Filter{
-		filter:           f,
-		ignoreTxnStartTs: cfg.Filter.IgnoreTxnStartTs,
-		ddlAllowlist:     cfg.Filter.DDLAllowlist,
-		isCyclicEnabled:  cfg.Cyclic.IsEnabled(),
+		filter:             f,
+		ignoreTxnStartTs:   cfg.Filter.IgnoreTxnStartTs,
+		ddlAllowlist:       cfg.Filter.DDLAllowlist,
+		isCyclicEnabled:    cfg.Cyclic.IsEnabled(),
+		isHeartbeatEnabled: cfg.Heartbeat.IsEnabled(),
+		ignoreSQL:          ignoreSQL,
+		ddlRewriter:        rewriter,
 	}, nil
 }
 
@@ -88,6 +115,10 @@ func (f *Filter) ShouldIgnoreTable(db, tbl string) bool {
 		// Always replicate mark tables.
 		return false
 	}
+	if f.isHeartbeatEnabled && heartbeat.IsHeartbeatTable(db, tbl) {
+		// Always replicate the heartbeat table.
+		return false
+	}
 	return !f.filter.MatchTable(db, tbl)
 }
 
@@ -111,6 +142,22 @@ func (f *Filter) ShouldIgnoreDDLEvent(ts uint64, ddlType model.ActionType, schem
 	return f.shouldIgnoreStartTs(ts) || shouldIgnoreTableOrSchema
 }
 
+// ShouldIgnoreDDLQuery returns true if the query of the DDL matches any of the ignore-sql regular expressions.
+func (f *Filter) ShouldIgnoreDDLQuery(query string) bool {
+	for _, pattern := range f.ignoreSQL {
+		if pattern.MatchString(query) {
+			return true
+		}
+	}
+	return false
+}
+
+// RewriteDDLQuery transforms the query of the DDL by the DDL rewrite rules,
+// e.g. strips the TiDB specific syntax for heterogeneous downstreams.
+func (f *Filter) RewriteDDLQuery(query string) string {
+	return f.ddlRewriter.rewrite(query)
+}
+
 // ShouldDiscardDDL returns true if this DDL should be discarded.
 func (f *Filter) ShouldDiscardDDL(ddlType model.ActionType) bool {
 	if !f.shouldDiscardByBuiltInDDLAllowlist(ddlType) {
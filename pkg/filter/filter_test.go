@@ -17,6 +17,7 @@ import (
 	"testing"
 
 	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/util/testleak"
 
 	"github.com/pingcap/check"
@@ -64,6 +65,28 @@ func (s *filterSuite) TestShouldUseCustomRules(c *check.C) {
 	assertIgnore("tidb_cdc", "repl_mark_a_a", check.IsFalse)
 }
 
+func (s *filterSuite) TestShouldReplicateHeartbeatTable(c *check.C) {
+	defer testleak.AfterTest(c)()
+	cfg := &config.ReplicaConfig{
+		Filter: &config.FilterConfig{
+			Rules: []string{"sns.*"},
+		},
+	}
+	filter, err := NewFilter(cfg)
+	c.Assert(err, check.IsNil)
+	c.Assert(filter.ShouldIgnoreTable("tidb_cdc_heartbeat", ""), check.IsTrue)
+	c.Assert(filter.ShouldIgnoreTable("tidb_cdc_heartbeat", "heartbeat"), check.IsTrue)
+
+	cfg.Heartbeat = &config.HeartbeatConfig{Enable: true}
+	filter, err = NewFilter(cfg)
+	c.Assert(err, check.IsNil)
+	c.Assert(filter.ShouldIgnoreTable("tidb_cdc_heartbeat", ""), check.IsFalse)
+	c.Assert(filter.ShouldIgnoreTable("tidb_cdc_heartbeat", "heartbeat"), check.IsFalse)
+	c.Assert(filter.ShouldIgnoreTable("tidb_cdc_heartbeat", "other"), check.IsTrue)
+	c.Assert(filter.ShouldIgnoreTable("sns", "user"), check.IsFalse)
+	c.Assert(filter.ShouldIgnoreTable("other", "user"), check.IsTrue)
+}
+
 func (s *filterSuite) TestShouldIgnoreTxn(c *check.C) {
 	defer testleak.AfterTest(c)()
 	testCases := []struct {
@@ -195,3 +218,108 @@ func (s *filterSuite) TestShouldIgnoreDDL(c *check.C) {
 		}
 	}
 }
+
+func (s *filterSuite) TestShouldIgnoreDDLQuery(c *check.C) {
+	defer testleak.AfterTest(c)()
+	filter, err := NewFilter(&config.ReplicaConfig{
+		Filter: &config.FilterConfig{
+			IgnoreSQL: []string{"(?i)^DROP TABLE", "ADD INDEX"},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(filter.ShouldIgnoreDDLQuery("drop table test.t1"), check.IsTrue)
+	c.Assert(filter.ShouldIgnoreDDLQuery("ALTER TABLE test.t1 ADD INDEX idx(a)"), check.IsTrue)
+	c.Assert(filter.ShouldIgnoreDDLQuery("CREATE TABLE test.t1 (a int)"), check.IsFalse)
+
+	_, err = NewFilter(&config.ReplicaConfig{
+		Filter: &config.FilterConfig{
+			IgnoreSQL: []string{"(DROP"},
+		},
+	})
+	c.Assert(err, check.ErrorMatches, ".*CDC:ErrFilterRuleInvalid.*")
+}
+
+func (s *filterSuite) TestRewriteDDLQuery(c *check.C) {
+	defer testleak.AfterTest(c)()
+	testCases := []struct {
+		rules    []*config.DDLRewriteRule
+		query    string
+		expected string
+	}{
+		{
+			rules:    nil,
+			query:    "CREATE TABLE t (a BIGINT PRIMARY KEY /*T![auto_rand] AUTO_RANDOM(5) */)",
+			expected: "CREATE TABLE t (a BIGINT PRIMARY KEY /*T![auto_rand] AUTO_RANDOM(5) */)",
+		},
+		{
+			rules:    []*config.DDLRewriteRule{{Builtin: DDLRewriterStripTiDBComments}},
+			query:    "CREATE TABLE t (a BIGINT PRIMARY KEY /*T![clustered_index] CLUSTERED */) /*T! SHARD_ROW_ID_BITS=4 */",
+			expected: "CREATE TABLE t (a BIGINT PRIMARY KEY)",
+		},
+		{
+			rules:    []*config.DDLRewriteRule{{Builtin: DDLRewriterStripAutoRandom}},
+			query:    "CREATE TABLE t (a BIGINT /*T![auto_rand] AUTO_RANDOM(5) */ PRIMARY KEY) /*T![auto_rand_base] AUTO_RANDOM_BASE=100 */",
+			expected: "CREATE TABLE t (a BIGINT PRIMARY KEY)",
+		},
+		{
+			rules:    []*config.DDLRewriteRule{{Builtin: DDLRewriterStripAutoRandom}},
+			query:    "CREATE TABLE t (a BIGINT AUTO_RANDOM PRIMARY KEY)",
+			expected: "CREATE TABLE t (a BIGINT  PRIMARY KEY)",
+		},
+		{
+			rules:    []*config.DDLRewriteRule{{Builtin: DDLRewriterStripShardRowIDBits}},
+			query:    "CREATE TABLE t (a INT) /*T! SHARD_ROW_ID_BITS=4 PRE_SPLIT_REGIONS=2 */",
+			expected: "CREATE TABLE t (a INT)",
+		},
+		{
+			rules: []*config.DDLRewriteRule{
+				{Pattern: "(?i)CHARSET\\s*=\\s*utf8mb4", Replacement: "CHARSET=utf8"},
+				{Pattern: "COLLATE=\\w+", Replacement: ""},
+			},
+			query:    "CREATE TABLE t (a INT) CHARSET = utf8mb4 COLLATE=utf8mb4_bin",
+			expected: "CREATE TABLE t (a INT) CHARSET=utf8 ",
+		},
+	}
+	for _, tc := range testCases {
+		filter, err := NewFilter(&config.ReplicaConfig{
+			Filter: &config.FilterConfig{DDLRewriteRules: tc.rules},
+		})
+		c.Assert(err, check.IsNil)
+		c.Assert(filter.RewriteDDLQuery(tc.query), check.Equals, tc.expected)
+	}
+
+	for _, rule := range []*config.DDLRewriteRule{
+		{},
+		{Builtin: "unknown"},
+		{Builtin: DDLRewriterStripAutoRandom, Pattern: "a"},
+		{Pattern: "(a"},
+	} {
+		_, err := NewFilter(&config.ReplicaConfig{
+			Filter: &config.FilterConfig{DDLRewriteRules: []*config.DDLRewriteRule{rule}},
+		})
+		c.Assert(cerror.ErrInvalidDDLRewriteRule.Equal(err), check.IsTrue)
+	}
+}
+
+func (s *filterSuite) TestOldValueMatcher(c *check.C) {
+	defer testleak.AfterTest(c)()
+	cfg := config.GetDefaultReplicaConfig()
+	cfg.EnableOldValue = false
+	cfg.CaseSensitive = false
+	cfg.OldValueRules = []*config.OldValueRule{
+		{Matcher: []string{"test.t1"}, Enable: false},
+		{Matcher: []string{"test.*"}, Enable: true},
+	}
+	m, err := NewOldValueMatcher(cfg)
+	c.Assert(err, check.IsNil)
+	c.Assert(m.IsOldValueEnabled("test", "t1"), check.IsFalse)
+	c.Assert(m.IsOldValueEnabled("TEST", "T2"), check.IsTrue)
+	c.Assert(m.IsOldValueEnabled("other", "t1"), check.IsFalse)
+	c.Assert(cfg.AnyOldValueEnabled(), check.IsTrue)
+	cfg.OldValueRules = cfg.OldValueRules[:1]
+	c.Assert(cfg.AnyOldValueEnabled(), check.IsFalse)
+
+	cfg.OldValueRules = []*config.OldValueRule{{Matcher: []string{"test.t1^"}, Enable: true}}
+	_, err = NewFilter(cfg)
+	c.Assert(err, check.ErrorMatches, ".*CDC:ErrFilterRuleInvalid.*")
+}
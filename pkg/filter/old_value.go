@@ -0,0 +1,59 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filter
+
+import (
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	filterV2 "github.com/pingcap/tidb-tools/pkg/table-filter"
+)
+
+// OldValueMatcher decides whether old values are enabled for a table by the
+// old value rules of a changefeed, the first rule matching a table is used,
+// and enable-old-value is used for the tables not matched by any rule.
+type OldValueMatcher struct {
+	defaultEnable bool
+	rules         []oldValueRule
+}
+
+type oldValueRule struct {
+	filterV2.Filter
+	enable bool
+}
+
+// NewOldValueMatcher creates an OldValueMatcher.
+func NewOldValueMatcher(cfg *config.ReplicaConfig) (*OldValueMatcher, error) {
+	m := &OldValueMatcher{defaultEnable: cfg.EnableOldValue}
+	for _, rule := range cfg.OldValueRules {
+		f, err := filterV2.Parse(rule.Matcher)
+		if err != nil {
+			return nil, cerror.WrapError(cerror.ErrFilterRuleInvalid, err)
+		}
+		if !cfg.CaseSensitive {
+			f = filterV2.CaseInsensitive(f)
+		}
+		m.rules = append(m.rules, oldValueRule{Filter: f, enable: rule.Enable})
+	}
+	return m, nil
+}
+
+// IsOldValueEnabled returns whether old values are enabled for the table.
+func (m *OldValueMatcher) IsOldValueEnabled(schema, table string) bool {
+	for _, rule := range m.rules {
+		if rule.MatchTable(schema, table) {
+			return rule.enable
+		}
+	}
+	return m.defaultEnable
+}
@@ -0,0 +1,102 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heartbeat
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/ticdc/cdc/model"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/quotes"
+)
+
+const (
+	// SchemaName is the name of the schema where the heartbeat table is created
+	SchemaName = "tidb_cdc_heartbeat"
+	// TableName is the name of the heartbeat table
+	TableName = "heartbeat"
+
+	changefeedIDCol = "changefeed_id"
+	// tsCol is the wall clock time of the owner when the heartbeat is written,
+	// in milliseconds since the unix epoch
+	tsCol = "ts"
+)
+
+// IsHeartbeatTable tells whether the table is the heartbeat table or not, an
+// empty table name matches the whole heartbeat schema.
+func IsHeartbeatTable(schema, table string) bool {
+	return schema == SchemaName && (table == "" || table == TableName)
+}
+
+// CreateTable creates the heartbeat table if it does not exist
+func CreateTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, "CREATE DATABASE IF NOT EXISTS "+quotes.QuoteName(SchemaName))
+	if err != nil {
+		return cerror.WrapError(cerror.ErrCreateHeartbeatTableFailed, err)
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s
+		(
+			%s VARCHAR(255) NOT NULL,
+			%s BIGINT NOT NULL,
+			PRIMARY KEY (%s)
+		)`, quotes.QuoteSchema(SchemaName, TableName), changefeedIDCol, tsCol, changefeedIDCol))
+	if err != nil {
+		return cerror.WrapError(cerror.ErrCreateHeartbeatTableFailed, err)
+	}
+	return nil
+}
+
+// Write writes a heartbeat of the changefeed with the time ts
+func Write(ctx context.Context, db *sql.DB, changefeedID model.ChangeFeedID, ts time.Time) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf("REPLACE INTO %s (%s, %s) VALUES (?, ?)",
+		quotes.QuoteSchema(SchemaName, TableName), changefeedIDCol, tsCol),
+		changefeedID, ts.UnixNano()/int64(time.Millisecond))
+	return errors.Trace(err)
+}
+
+// ParseRow returns the changefeed ID and the time of the heartbeat if the row
+// is an insertion or update of the heartbeat table.
+func ParseRow(row *model.RowChangedEvent) (changefeedID model.ChangeFeedID, ts time.Time, ok bool) {
+	if row.Table == nil || !IsHeartbeatTable(row.Table.Schema, row.Table.Table) || row.IsDelete() {
+		return "", time.Time{}, false
+	}
+	var hasID, hasTs bool
+	for _, col := range row.Columns {
+		if col == nil {
+			continue
+		}
+		switch col.Name {
+		case changefeedIDCol:
+			switch v := col.Value.(type) {
+			case []byte:
+				changefeedID, hasID = string(v), true
+			case string:
+				changefeedID, hasID = v, true
+			}
+		case tsCol:
+			switch v := col.Value.(type) {
+			case int64:
+				ts, hasTs = time.Unix(0, v*int64(time.Millisecond)), true
+			case uint64:
+				ts, hasTs = time.Unix(0, int64(v)*int64(time.Millisecond)), true
+			}
+		}
+	}
+	return changefeedID, ts, hasID && hasTs
+}
@@ -0,0 +1,149 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heartbeat
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"time"
+
+	dmysql "github.com/go-sql-driver/mysql"
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/cdc/model"
+	"github.com/pingcap/ticdc/pkg/config"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+	"github.com/pingcap/ticdc/pkg/security"
+	"go.uber.org/zap"
+)
+
+const defaultUpstreamPort = "4000"
+
+// Writer writes the heartbeats of a changefeed into the upstream periodically
+type Writer struct {
+	changefeedID model.ChangeFeedID
+	cfg          *config.HeartbeatConfig
+	credential   *security.Credential
+
+	openDB func(ctx context.Context, dsn string) (*sql.DB, error)
+	now    func() time.Time
+}
+
+// NewWriter creates a heartbeat writer for the changefeed
+func NewWriter(changefeedID model.ChangeFeedID, cfg *config.HeartbeatConfig, credential *security.Credential) *Writer {
+	return &Writer{
+		changefeedID: changefeedID,
+		cfg:          cfg,
+		credential:   credential,
+		openDB:       openDB,
+		now:          time.Now,
+	}
+}
+
+func openDB(ctx context.Context, dsn string) (*sql.DB, error) {
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close() //nolint:errcheck
+		return nil, errors.Trace(err)
+	}
+	return db, nil
+}
+
+// Run writes heartbeats until the context is canceled. Failing to write a
+// heartbeat does not affect the replication, so the errors are logged and
+// retried in the next interval instead of being returned.
+func (w *Writer) Run(ctx context.Context) error {
+	var db *sql.DB
+	defer func() {
+		if db != nil {
+			db.Close() //nolint:errcheck
+		}
+	}()
+	ticker := time.NewTicker(w.cfg.GetInterval())
+	defer ticker.Stop()
+	tableCreated := false
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if db == nil {
+			dsn, err := w.dsn()
+			if err == nil {
+				db, err = w.openDB(ctx, dsn)
+			}
+			if err != nil {
+				log.Warn("failed to connect to the upstream to write heartbeats",
+					zap.String("changefeed", w.changefeedID), zap.Error(err))
+				continue
+			}
+		}
+		if !tableCreated {
+			if err := CreateTable(ctx, db); err != nil {
+				log.Warn("failed to create the heartbeat table",
+					zap.String("changefeed", w.changefeedID), zap.Error(err))
+				continue
+			}
+			tableCreated = true
+		}
+		if err := Write(ctx, db, w.changefeedID, w.now()); err != nil {
+			log.Warn("failed to write heartbeat",
+				zap.String("changefeed", w.changefeedID), zap.Error(err))
+		}
+	}
+}
+
+// dsn returns the DSN of the upstream with the secret references resolved
+func (w *Writer) dsn() (string, error) {
+	uri, err := w.credential.ResolveSecrets(w.cfg.UpstreamURI)
+	if err != nil {
+		return "", err
+	}
+	u, err := security.ParseURIWithSecretReferences(uri)
+	if err != nil {
+		return "", cerror.ErrInvalidHeartbeatConfig.GenWithStackByArgs("upstream-uri should be a valid URI")
+	}
+	cfg := dmysql.NewConfig()
+	cfg.User = u.User.Username()
+	if cfg.User == "" {
+		cfg.User = "root"
+	}
+	cfg.Passwd, _ = u.User.Password()
+	port := u.Port()
+	if port == "" {
+		port = defaultUpstreamPort
+	}
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(u.Hostname(), port)
+	cfg.Timeout = 5 * time.Second
+	cfg.ReadTimeout = 5 * time.Second
+	cfg.WriteTimeout = 5 * time.Second
+	if w.credential.IsTLSEnabled() {
+		tlsCfg, err := w.credential.ToTLSConfig()
+		if err != nil {
+			return "", err
+		}
+		name := "cdc_heartbeat_tls" + w.changefeedID
+		if err := dmysql.RegisterTLSConfig(name, tlsCfg); err != nil {
+			return "", errors.Trace(err)
+		}
+		cfg.TLSConfig = name
+	}
+	return cfg.FormatDSN(), nil
+}
@@ -24,6 +24,20 @@ type Client struct {
 	http.Client
 }
 
+// authTransport sets the bearer token of the requests
+type authTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper should not modify the request
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req)
+}
+
 // NewClient creates an HTTP client with the given Credential.
 func NewClient(credential *security.Credential) (*Client, error) {
 	transport := http.DefaultTransport
@@ -38,6 +52,9 @@ func NewClient(credential *security.Credential) (*Client, error) {
 			transport = httpTrans
 		}
 	}
+	if credential != nil && credential.AuthToken != "" {
+		transport = &authTransport{token: credential.AuthToken, next: transport}
+	}
 	// TODO: specific timeout in http client
 	return &Client{
 		Client: http.Client{Transport: transport},
@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -75,6 +76,24 @@ func (s *httputilSuite) TestHttputilNewClient(c *check.C) {
 	c.Assert(string(body), check.Equals, httputilServerMsg)
 }
 
+func (s *httputilSuite) TestHttputilAuthToken(c *check.C) {
+	defer testleak.AfterTest(c)()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		//nolint:errcheck
+		w.Write([]byte(req.Header.Get("Authorization")))
+	}))
+	defer server.Close()
+
+	cli, err := NewClient(&security.Credential{AuthToken: "token"})
+	c.Assert(err, check.IsNil)
+	resp, err := cli.Get(server.URL)
+	c.Assert(err, check.IsNil)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, check.IsNil)
+	c.Assert(string(body), check.Equals, "Bearer token")
+}
+
 func handler(w http.ResponseWriter, req *http.Request) {
 	w.Header().Set("Content-Type", "text/plain")
 	//nolint:errcheck
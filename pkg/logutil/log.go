@@ -17,7 +17,6 @@ import (
 	"bytes"
 	"os"
 	"strconv"
-	"strings"
 
 	"github.com/Shopify/sarama"
 	"github.com/pingcap/errors"
@@ -30,6 +29,12 @@ import (
 // _globalP is the global ZapProperties in log
 var _globalP *log.ZapProperties
 
+// log formats
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
 const (
 	defaultLogLevel   = "info"
 	defaultLogMaxDays = 7
@@ -48,6 +53,20 @@ type Config struct {
 	FileMaxDays int `toml:"max-days" json:"max-days"`
 	// Maximum number of old log files to retain.
 	FileMaxBackups int `toml:"max-backups" json:"max-backups"`
+	// Log format, one of text and json.
+	Format string `toml:"format" json:"format"`
+	// Log levels of the modules, the modules not set use Level.
+	ModuleLevels map[string]string `toml:"module-levels" json:"module-levels"`
+	// Sampling of the debug and info logs of each changefeed, nil to disable.
+	Sampling *SamplingConfig `toml:"sampling" json:"sampling"`
+}
+
+// SamplingConfig is the config of sampling the logs of each changefeed, the
+// first Initial entries with the same message in every second are logged, and
+// every Thereafter-th entry is logged after that.
+type SamplingConfig struct {
+	Initial    int `toml:"initial" json:"initial"`
+	Thereafter int `toml:"thereafter" json:"thereafter"`
 }
 
 // Adjust adjusts config
@@ -64,27 +83,33 @@ func (cfg *Config) Adjust() {
 	if cfg.FileMaxDays == 0 {
 		cfg.FileMaxDays = defaultLogMaxDays
 	}
+	if len(cfg.Format) == 0 {
+		cfg.Format = LogFormatText
+	}
 }
 
-// SetLogLevel changes TiCDC log level dynamically.
+// SetLogLevel changes TiCDC log level dynamically, the modules with their own
+// levels are not affected.
 func SetLogLevel(level string) error {
-	oldLevel := log.GetLevel()
-	if strings.EqualFold(oldLevel.String(), level) {
-		return nil
-	}
-	var lv zapcore.Level
-	err := lv.UnmarshalText([]byte(level))
+	lv, err := parseLevel(level)
 	if err != nil {
-		return errors.Trace(err)
+		return err
+	}
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	old := getLevelState()
+	if old.base == lv {
+		return nil
 	}
-	log.SetLevel(lv)
+	storeLevelState(newLevelState(lv, old.modules))
 	return nil
 }
 
 // InitLogger initializes logger
 func InitLogger(cfg *Config) error {
 	pclogConfig := &log.Config{
-		Level: cfg.Level,
+		Level:  cfg.Level,
+		Format: cfg.Format,
 		File: log.FileLogConfig{
 			Filename:   cfg.File,
 			MaxSize:    cfg.FileMaxSize,
@@ -93,8 +118,27 @@ func InitLogger(cfg *Config) error {
 		},
 	}
 
+	switch cfg.Format {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		// the unknown formats make the log package panic
+		return errors.Errorf("unknown log format %s, the formats are %s and %s",
+			cfg.Format, LogFormatText, LogFormatJSON)
+	}
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+	moduleLevels, err := parseModuleLevels(cfg.ModuleLevels)
+	if err != nil {
+		return err
+	}
+	var sampler *changefeedSampler
+	if cfg.Sampling != nil {
+		sampler = newChangefeedSampler(cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	}
+
 	var lg *zap.Logger
-	var err error
 	lg, _globalP, err = log.InitLogger(pclogConfig)
 	if err != nil {
 		return err
@@ -103,14 +147,19 @@ func InitLogger(cfg *Config) error {
 	// Do not log stack traces at all, as we'll get the stack trace from the
 	// error itself.
 	lg = lg.WithOptions(zap.AddStacktrace(zap.DPanicLevel))
+	// The module core filters the logs by the levels of the modules and
+	// samples the logs of the changefeeds.
+	lg = lg.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newModuleCore(core, sampler)
+	}))
+	_globalP.Core = lg.Core()
 
 	log.ReplaceGlobals(lg, _globalP)
+	globalSampler.Store(sampler)
+	levelMu.Lock()
+	storeLevelState(newLevelState(level, moduleLevels))
+	levelMu.Unlock()
 
-	var level zapcore.Level
-	err = level.UnmarshalText([]byte(cfg.Level))
-	if err != nil {
-		return errors.Trace(err)
-	}
 	err = initSaramaLogger(level)
 	if err != nil {
 		return err
@@ -15,8 +15,12 @@ package logutil
 
 import (
 	"context"
+	"encoding/json"
+	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/pingcap/check"
 	"github.com/pingcap/errors"
@@ -61,6 +65,60 @@ func (s *logSuite) TestInitLoggerAndSetLogLevel(c *check.C) {
 	c.Assert(err, check.NotNil)
 }
 
+func (s *logSuite) TestJSONFormat(c *check.C) {
+	defer testleak.AfterTest(c)()
+	f := filepath.Join(c.MkDir(), "test")
+	cfg := &Config{
+		Level:        "info",
+		File:         f,
+		Format:       LogFormatJSON,
+		ModuleLevels: map[string]string{ModuleKVClient: "debug"},
+	}
+	cfg.Adjust()
+	err := InitLogger(cfg)
+	c.Assert(err, check.IsNil)
+	level, moduleLevels := GetLogLevels()
+	c.Assert(level, check.Equals, "info")
+	c.Assert(moduleLevels, check.DeepEquals, map[string]string{ModuleKVClient: "debug"})
+	// the global level isn't lowered by the kv client
+	c.Assert(log.GetLevel(), check.Equals, zapcore.InfoLevel)
+
+	log.Debug("debug message")
+	log.Info("info message", zap.String("changefeed", "test-cf"), zap.Int("count", 1))
+	c.Assert(log.Sync(), check.IsNil)
+	content, err := ioutil.ReadFile(f)
+	c.Assert(err, check.IsNil)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	c.Assert(lines, check.HasLen, 1)
+	entry := make(map[string]interface{})
+	c.Assert(json.Unmarshal([]byte(lines[0]), &entry), check.IsNil)
+	c.Assert(entry["level"], check.Equals, "INFO")
+	c.Assert(entry["message"], check.Equals, "info message")
+	c.Assert(entry["changefeed"], check.Equals, "test-cf")
+	c.Assert(entry["count"], check.Equals, float64(1))
+	c.Assert(entry["caller"], check.Matches, "log_test.go:.*")
+	_, err = time.Parse("2006/01/02 15:04:05.000 -07:00", entry["time"].(string))
+	c.Assert(err, check.IsNil)
+
+	// the debug logs of a module reach the file under the global info level
+	restore := withTestModule()
+	c.Assert(SetModuleLogLevel("test", "debug"), check.IsNil)
+	log.Debug("module debug message")
+	restore()
+	c.Assert(log.Sync(), check.IsNil)
+	content, err = ioutil.ReadFile(f)
+	c.Assert(err, check.IsNil)
+	lines = strings.Split(strings.TrimSpace(string(content)), "\n")
+	c.Assert(lines, check.HasLen, 2)
+	c.Assert(lines[1], check.Matches, ".*module debug message.*")
+
+	cfg.ModuleLevels = nil
+	cfg.Format = "xml"
+	c.Assert(InitLogger(cfg), check.ErrorMatches, ".*unknown log format xml.*")
+	cfg.Format = LogFormatText
+	c.Assert(InitLogger(cfg), check.IsNil)
+}
+
 func (s *logSuite) TestZapErrorFilter(c *check.C) {
 	defer testleak.AfterTest(c)()
 	var (
@@ -0,0 +1,344 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"go.uber.org/zap/zapcore"
+)
+
+// The modules whose log levels can be set separately from the global level,
+// the module of a log is decided by the package of the function logging it.
+const (
+	ModuleKVClient  = "kv-client"
+	ModulePuller    = "puller"
+	ModuleSorter    = "sorter"
+	ModuleEntry     = "entry"
+	ModuleSink      = "sink"
+	ModuleOwner     = "owner"
+	ModuleProcessor = "processor"
+	ModuleCapture   = "capture"
+)
+
+// modulePackages maps the packages to the modules, a package also covers its
+// sub packages. The more specific packages must go first.
+var modulePackages = []struct {
+	module string
+	pkg    string
+}{
+	{ModuleSorter, "github.com/pingcap/ticdc/cdc/puller/sorter"},
+	{ModulePuller, "github.com/pingcap/ticdc/cdc/puller"},
+	{ModuleKVClient, "github.com/pingcap/ticdc/cdc/kv"},
+	{ModuleEntry, "github.com/pingcap/ticdc/cdc/entry"},
+	{ModuleSink, "github.com/pingcap/ticdc/cdc/sink"},
+	{ModuleOwner, "github.com/pingcap/ticdc/cdc/owner"},
+	{ModuleProcessor, "github.com/pingcap/ticdc/cdc/processor"},
+	{ModuleCapture, "github.com/pingcap/ticdc/cdc/capture"},
+}
+
+// loggerFuncPrefixes are the prefixes of the functions in the logging path,
+// they are skipped when looking for the function logging an entry.
+var loggerFuncPrefixes = []string{
+	"runtime.",
+	"go.uber.org/zap.",
+	"go.uber.org/zap/",
+	"github.com/pingcap/log.",
+	"github.com/pingcap/ticdc/pkg/logutil.(*moduleCore)",
+	"github.com/pingcap/ticdc/pkg/logutil.(*levelState)",
+	"github.com/pingcap/ticdc/pkg/logutil.callerModule",
+	"github.com/pingcap/ticdc/pkg/logutil.DebugEnabled",
+}
+
+// Modules returns the modules whose log levels can be set
+func Modules() []string {
+	modules := make([]string, 0, len(modulePackages))
+	for _, p := range modulePackages {
+		modules = append(modules, p.module)
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+func isModule(module string) bool {
+	for _, p := range modulePackages {
+		if p.module == module {
+			return true
+		}
+	}
+	return false
+}
+
+// levelState is the global log level and the levels of the modules, it is
+// replaced as a whole when any level changes.
+type levelState struct {
+	base    zapcore.Level
+	modules map[string]zapcore.Level
+	// the min and max of all the levels, the module of an entry is only
+	// looked up if its level is in the range of [min, max)
+	min zapcore.Level
+	max zapcore.Level
+}
+
+func newLevelState(base zapcore.Level, modules map[string]zapcore.Level) *levelState {
+	s := &levelState{base: base, modules: modules, min: base, max: base}
+	for _, lvl := range modules {
+		if lvl < s.min {
+			s.min = lvl
+		}
+		if lvl > s.max {
+			s.max = lvl
+		}
+	}
+	return s
+}
+
+func (s *levelState) enabled(lvl zapcore.Level) bool {
+	if lvl >= s.max {
+		return true
+	}
+	if lvl < s.min {
+		return false
+	}
+	if moduleLvl, ok := s.modules[callerModule()]; ok {
+		return lvl >= moduleLvl
+	}
+	return lvl >= s.base
+}
+
+var (
+	levelMu sync.Mutex
+	levels  atomic.Value // *levelState
+)
+
+func init() {
+	levels.Store(newLevelState(zapcore.InfoLevel, nil))
+}
+
+func getLevelState() *levelState {
+	return levels.Load().(*levelState)
+}
+
+// storeLevelState stores the state, and sets the level of the underlying
+// logger to the global level, so that log.GetLevel keeps reporting it. The
+// entries of the modules with lower levels are let through by the module core.
+func storeLevelState(s *levelState) {
+	levels.Store(s)
+	log.SetLevel(s.base)
+}
+
+// DebugEnabled returns whether the debug logs of the calling function are
+// enabled, taking the level of its module into account. It should be used to
+// guard the expensive debug logs instead of log.GetLevel.
+func DebugEnabled() bool {
+	return getLevelState().enabled(zapcore.DebugLevel)
+}
+
+func parseLevel(level string) (zapcore.Level, error) {
+	if level == "warning" {
+		level = "warn"
+	}
+	var lv zapcore.Level
+	err := lv.UnmarshalText([]byte(level))
+	if err != nil {
+		return lv, errors.Trace(err)
+	}
+	return lv, nil
+}
+
+// ValidateModuleLevels checks whether the modules and the levels are valid
+func ValidateModuleLevels(moduleLevels map[string]string) error {
+	_, err := parseModuleLevels(moduleLevels)
+	return err
+}
+
+func parseModuleLevels(moduleLevels map[string]string) (map[string]zapcore.Level, error) {
+	modules := make(map[string]zapcore.Level, len(moduleLevels))
+	for module, level := range moduleLevels {
+		if !isModule(module) {
+			return nil, errors.Errorf("unknown log module %s, the modules are %s",
+				module, strings.Join(Modules(), ", "))
+		}
+		lv, err := parseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+		modules[module] = lv
+	}
+	return modules, nil
+}
+
+// SetModuleLogLevel changes the log level of the module dynamically, the
+// global level is used by the module again if level is empty.
+func SetModuleLogLevel(module, level string) error {
+	if !isModule(module) {
+		return errors.Errorf("unknown log module %s, the modules are %s",
+			module, strings.Join(Modules(), ", "))
+	}
+	var lv zapcore.Level
+	if level != "" {
+		var err error
+		lv, err = parseLevel(level)
+		if err != nil {
+			return err
+		}
+	}
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	old := getLevelState()
+	modules := make(map[string]zapcore.Level, len(old.modules)+1)
+	for m, l := range old.modules {
+		modules[m] = l
+	}
+	if level == "" {
+		delete(modules, module)
+	} else {
+		modules[module] = lv
+	}
+	storeLevelState(newLevelState(old.base, modules))
+	return nil
+}
+
+// GetLogLevels returns the global log level and the levels of the modules
+// set separately.
+func GetLogLevels() (level string, moduleLevels map[string]string) {
+	s := getLevelState()
+	moduleLevels = make(map[string]string, len(s.modules))
+	for module, lvl := range s.modules {
+		moduleLevels[module] = lvl.String()
+	}
+	return s.base.String(), moduleLevels
+}
+
+// pcModules caches the module of the program counters, loggerFrame is cached
+// for the ones in the logging path.
+var pcModules sync.Map
+
+const loggerFrame = "\x00logger"
+
+// callerFramesBatch is the number of frames unwound at a time to look for the
+// function logging an entry, which is enough to cover the logging path in
+// most cases, as unwinding the whole stack is much more expensive.
+const callerFramesBatch = 8
+
+// callerModule returns the module of the function logging the current entry,
+// or an empty string if the function doesn't belong to any module.
+func callerModule() string {
+	var pcs [callerFramesBatch]uintptr
+	for skip := 2; ; skip += callerFramesBatch {
+		n := runtime.Callers(skip, pcs[:])
+		for _, pc := range pcs[:n] {
+			module, ok := pcModules.Load(pc)
+			if !ok {
+				module = moduleOfPC(pc)
+				pcModules.Store(pc, module)
+			}
+			if module != loggerFrame {
+				return module.(string)
+			}
+		}
+		if n < callerFramesBatch {
+			return ""
+		}
+	}
+}
+
+// moduleOfPC returns the module of the outermost function not in the logging
+// path, the pc may represent multiple functions because of inlining.
+func moduleOfPC(pc uintptr) string {
+	frames := runtime.CallersFrames([]uintptr{pc})
+	for {
+		frame, more := frames.Next()
+		if !isLoggerFunc(frame.Function) {
+			return moduleOfFunc(frame.Function)
+		}
+		if !more {
+			return loggerFrame
+		}
+	}
+}
+
+func isLoggerFunc(fn string) bool {
+	for _, prefix := range loggerFuncPrefixes {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func moduleOfFunc(fn string) string {
+	for _, p := range modulePackages {
+		if strings.HasPrefix(fn, p.pkg) && len(fn) > len(p.pkg) &&
+			(fn[len(p.pkg)] == '.' || fn[len(p.pkg)] == '/') {
+			return p.module
+		}
+	}
+	return ""
+}
+
+// moduleCore filters the entries by the levels of the modules, and samples the
+// debug and info entries of each changefeed if the sampler is set.
+type moduleCore struct {
+	zapcore.Core
+	sampler *changefeedSampler
+	// the changefeed of the fields added by With
+	changefeed string
+}
+
+func newModuleCore(core zapcore.Core, sampler *changefeedSampler) zapcore.Core {
+	return &moduleCore{Core: core, sampler: sampler}
+}
+
+func (c *moduleCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.Core = c.Core.With(fields)
+	if changefeed, ok := changefeedOfFields(fields); ok {
+		clone.changefeed = changefeed
+	}
+	return &clone
+}
+
+// Enabled lets through the levels of all the modules, the entries are filtered
+// by their modules in Check. The level of the underlying core is ignored as it
+// is the global level.
+func (c *moduleCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= getLevelState().min
+}
+
+func (c *moduleCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if getLevelState().enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *moduleCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if c.sampler != nil && ent.Level <= zapcore.InfoLevel {
+		changefeed := c.changefeed
+		if id, ok := changefeedOfFields(fields); ok {
+			changefeed = id
+		}
+		if changefeed != "" && !c.sampler.sample(changefeed, ent) {
+			return nil
+		}
+	}
+	return c.Core.Write(ent, fields)
+}
@@ -0,0 +1,180 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type moduleSuite struct{}
+
+var _ = check.Suite(&moduleSuite{})
+
+func (s *moduleSuite) TestModuleOfFunc(c *check.C) {
+	defer testleak.AfterTest(c)()
+	c.Assert(moduleOfFunc("github.com/pingcap/ticdc/cdc/kv.(*eventFeedSession).handleError"), check.Equals, ModuleKVClient)
+	c.Assert(moduleOfFunc("github.com/pingcap/ticdc/cdc/puller/sorter.(*UnifiedSorter).Run"), check.Equals, ModuleSorter)
+	c.Assert(moduleOfFunc("github.com/pingcap/ticdc/cdc/puller.(*pullerImpl).Run"), check.Equals, ModulePuller)
+	c.Assert(moduleOfFunc("github.com/pingcap/ticdc/cdc/sink/producer/kafka.NewKafkaSaramaProducer"), check.Equals, ModuleSink)
+	c.Assert(moduleOfFunc("github.com/pingcap/ticdc/cdc/owner.(*Owner).Tick.func1"), check.Equals, ModuleOwner)
+	c.Assert(moduleOfFunc("github.com/pingcap/ticdc/cdc/kvx.run"), check.Equals, "")
+	c.Assert(moduleOfFunc("github.com/pingcap/ticdc/pkg/util.Foo"), check.Equals, "")
+	c.Assert(isLoggerFunc("github.com/pingcap/log.Info"), check.IsTrue)
+	c.Assert(isLoggerFunc("go.uber.org/zap.(*Logger).Info"), check.IsTrue)
+	c.Assert(isLoggerFunc("github.com/pingcap/ticdc/cdc/kv.run"), check.IsFalse)
+}
+
+// withTestModule makes the functions in this package belong to the module
+// "test" until the returned function is called.
+func withTestModule() func() {
+	oldPackages := modulePackages
+	modulePackages = append([]struct {
+		module string
+		pkg    string
+	}{{"test", "github.com/pingcap/ticdc/pkg/logutil"}}, oldPackages...)
+	pcModules = sync.Map{}
+	oldState := getLevelState()
+	return func() {
+		modulePackages = oldPackages
+		pcModules = sync.Map{}
+		storeLevelState(oldState)
+	}
+}
+
+func (s *moduleSuite) TestModuleLevels(c *check.C) {
+	defer testleak.AfterTest(c)()
+	defer withTestModule()()
+	storeLevelState(newLevelState(zapcore.InfoLevel, nil))
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	lg := zap.New(newModuleCore(core, nil))
+	// the logs of the standard library logger don't belong to any module
+	stdDebug, err := zap.NewStdLogAt(lg, zapcore.DebugLevel)
+	c.Assert(err, check.IsNil)
+	stdInfo, err := zap.NewStdLogAt(lg, zapcore.InfoLevel)
+	c.Assert(err, check.IsNil)
+	logAll := func() []string {
+		lg.Debug("test debug")
+		lg.Info("test info")
+		stdDebug.Print("std debug")
+		stdInfo.Print("std info")
+		var messages []string
+		for _, entry := range logs.TakeAll() {
+			messages = append(messages, entry.Message)
+		}
+		return messages
+	}
+	c.Assert(logAll(), check.DeepEquals, []string{"test info", "std info"})
+
+	c.Assert(SetModuleLogLevel("test", "debug"), check.IsNil)
+	c.Assert(logAll(), check.DeepEquals, []string{"test debug", "test info", "std info"})
+	level, moduleLevels := GetLogLevels()
+	c.Assert(level, check.Equals, "info")
+	c.Assert(moduleLevels, check.DeepEquals, map[string]string{"test": "debug"})
+	// the global level isn't lowered by the module
+	c.Assert(log.GetLevel(), check.Equals, zapcore.InfoLevel)
+	c.Assert(DebugEnabled(), check.IsTrue)
+
+	// the module is not affected by the global level
+	c.Assert(SetLogLevel("warning"), check.IsNil)
+	c.Assert(logAll(), check.DeepEquals, []string{"test debug", "test info"})
+
+	c.Assert(SetModuleLogLevel("test", "error"), check.IsNil)
+	c.Assert(SetLogLevel("debug"), check.IsNil)
+	c.Assert(logAll(), check.DeepEquals, []string{"std debug", "std info"})
+	c.Assert(log.GetLevel(), check.Equals, zapcore.DebugLevel)
+	c.Assert(DebugEnabled(), check.IsFalse)
+
+	// reset the level of the module
+	c.Assert(SetModuleLogLevel("test", ""), check.IsNil)
+	c.Assert(logAll(), check.DeepEquals, []string{"test debug", "test info", "std debug", "std info"})
+	_, moduleLevels = GetLogLevels()
+	c.Assert(moduleLevels, check.HasLen, 0)
+
+	c.Assert(SetModuleLogLevel("unknown", "debug"), check.ErrorMatches, ".*unknown log module unknown.*")
+	c.Assert(SetModuleLogLevel("test", "verbose"), check.NotNil)
+	c.Assert(ValidateModuleLevels(map[string]string{ModuleKVClient: "debug", ModuleSorter: "warning"}), check.IsNil)
+}
+
+func (s *moduleSuite) TestChangefeedSampler(c *check.C) {
+	defer testleak.AfterTest(c)()
+	sampler := newChangefeedSampler(2, 3)
+	now := time.Now()
+	sample := func(changefeed string, n int) (sampled []int) {
+		for i := 1; i <= n; i++ {
+			ent := zapcore.Entry{Level: zapcore.InfoLevel, Message: "msg", Time: now}
+			if sampler.sample(changefeed, ent) {
+				sampled = append(sampled, i)
+			}
+		}
+		return
+	}
+	c.Assert(sample("cf-1", 10), check.DeepEquals, []int{1, 2, 5, 8})
+	// the changefeeds are sampled separately
+	c.Assert(sample("cf-2", 3), check.DeepEquals, []int{1, 2})
+	// only the 11th entry of cf-1 is sampled
+	c.Assert(sample("cf-1", 3), check.DeepEquals, []int{1})
+	// the counters are reset in the next interval
+	now = now.Add(samplingInterval)
+	c.Assert(sample("cf-1", 3), check.DeepEquals, []int{1, 2})
+
+	// the counters of a removed changefeed are dropped
+	sampler.remove("cf-1")
+	_, ok := sampler.shards.Load("cf-1")
+	c.Assert(ok, check.IsFalse)
+	c.Assert(sample("cf-1", 3), check.DeepEquals, []int{1, 2})
+	globalSampler.Store(sampler)
+	defer globalSampler.Store((*changefeedSampler)(nil))
+	RemoveChangefeed("cf-2")
+	_, ok = sampler.shards.Load("cf-2")
+	c.Assert(ok, check.IsFalse)
+
+	sampler = newChangefeedSampler(1, 0)
+	c.Assert(sample("cf-1", 3), check.DeepEquals, []int{1})
+}
+
+func (s *moduleSuite) TestSampleByChangefeedField(c *check.C) {
+	defer testleak.AfterTest(c)()
+	defer withTestModule()()
+	storeLevelState(newLevelState(zapcore.InfoLevel, nil))
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	lg := zap.New(newModuleCore(core, newChangefeedSampler(1, 0)))
+	cfLogger := lg.With(zap.String("changefeed", "cf-1"))
+	for i := 0; i < 3; i++ {
+		cfLogger.Info("with changefeed")
+		lg.Info("field changefeed", zap.String("changefeedID", "cf-2"))
+		// the warnings and the logs without changefeeds are not sampled
+		cfLogger.Warn("warn")
+		lg.Info("no changefeed")
+	}
+	counts := make(map[string]int)
+	for _, entry := range logs.TakeAll() {
+		counts[entry.Message]++
+	}
+	c.Assert(counts, check.DeepEquals, map[string]int{
+		"with changefeed":  1,
+		"field changefeed": 1,
+		"warn":             3,
+		"no changefeed":    3,
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logutil
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// globalSampler is the *changefeedSampler of the global logger, it is nil if
+// the sampling is disabled.
+var globalSampler atomic.Value
+
+// RemoveChangefeed drops the sampling counters of the changefeed, it should be
+// called when the changefeed is removed from the capture, otherwise the
+// counters are kept until the process exits.
+func RemoveChangefeed(changefeedID string) {
+	if sampler, _ := globalSampler.Load().(*changefeedSampler); sampler != nil {
+		sampler.remove(changefeedID)
+	}
+}
+
+// changefeedFieldKeys are the keys of the fields carrying the changefeed ID
+var changefeedFieldKeys = []string{"changefeed", "changefeedID", "changefeed-id"}
+
+func changefeedOfFields(fields []zapcore.Field) (string, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		field := fields[i]
+		if field.Type != zapcore.StringType {
+			continue
+		}
+		for _, key := range changefeedFieldKeys {
+			if field.Key == key {
+				return field.String, true
+			}
+		}
+	}
+	return "", false
+}
+
+// samplingInterval is the interval the counters of the sampler are reset
+const samplingInterval = time.Second
+
+type samplerKey struct {
+	level   zapcore.Level
+	message string
+}
+
+// samplerShard holds the counters of a changefeed
+type samplerShard struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[samplerKey]int
+}
+
+// changefeedSampler samples the entries with the same message of each
+// changefeed separately, so that a noisy changefeed doesn't suppress the logs
+// of the others. In every interval, the first `initial` entries are logged,
+// and every `thereafter`-th entry is logged after that.
+type changefeedSampler struct {
+	initial    int
+	thereafter int
+
+	// shards maps the changefeeds to their *samplerShard, so that the logs of
+	// different changefeeds don't contend for the same lock
+	shards sync.Map
+}
+
+func newChangefeedSampler(initial, thereafter int) *changefeedSampler {
+	return &changefeedSampler{
+		initial:    initial,
+		thereafter: thereafter,
+	}
+}
+
+// remove drops the counters of the changefeed
+func (s *changefeedSampler) remove(changefeed string) {
+	s.shards.Delete(changefeed)
+}
+
+// sample returns true if the entry should be logged
+func (s *changefeedSampler) sample(changefeed string, ent zapcore.Entry) bool {
+	v, ok := s.shards.Load(changefeed)
+	if !ok {
+		v, _ = s.shards.LoadOrStore(changefeed, &samplerShard{})
+	}
+	shard := v.(*samplerShard)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if ent.Time.Sub(shard.windowStart) >= samplingInterval || ent.Time.Before(shard.windowStart) {
+		// all the counters of the changefeed are reset at once to bound the memory
+		shard.windowStart = ent.Time
+		shard.counts = make(map[samplerKey]int)
+	}
+	key := samplerKey{level: ent.Level, message: ent.Message}
+	n := shard.counts[key] + 1
+	shard.counts[key] = n
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
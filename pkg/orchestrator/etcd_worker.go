@@ -23,12 +23,12 @@ import (
 	"github.com/pingcap/log"
 	cerrors "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/ticdc/pkg/etcd"
+	"github.com/pingcap/ticdc/pkg/logutil"
 	"github.com/pingcap/ticdc/pkg/orchestrator/util"
 	"go.etcd.io/etcd/clientv3"
 	"go.etcd.io/etcd/clientv3/concurrency"
 	"go.etcd.io/etcd/mvcc/mvccpb"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 )
 
 // EtcdWorker handles all interactions with Etcd
@@ -358,7 +358,7 @@ func (worker *EtcdWorker) applyUpdates() error {
 }
 
 func logEtcdOps(ops []clientv3.Op, commited bool) {
-	if log.GetLevel() != zapcore.DebugLevel || len(ops) == 0 {
+	if !logutil.DebugEnabled() || len(ops) == 0 {
 		return
 	}
 	log.Debug("[etcd worker] ==========Update State to ETCD==========")
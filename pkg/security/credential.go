@@ -15,6 +15,9 @@ package security
 
 import (
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
 
 	cerror "github.com/pingcap/ticdc/pkg/errors"
 	"github.com/pingcap/tidb-tools/pkg/utils"
@@ -29,6 +32,12 @@ type Credential struct {
 	CertPath      string   `toml:"cert-path" json:"cert-path"`
 	KeyPath       string   `toml:"key-path" json:"key-path"`
 	CertAllowedCN []string `toml:"cert-allowed-cn" json:"cert-allowed-cn"`
+	// SecretKeyPath is the path of the key to decrypt the encrypted secrets
+	// referenced by the sink URIs, see ResolveSecrets.
+	SecretKeyPath string `toml:"secret-key-path" json:"secret-key-path"`
+	// AuthToken is the bearer token sent to the HTTP API of TiCDC by clients,
+	// it is never serialized.
+	AuthToken string `toml:"-" json:"-"`
 }
 
 // IsTLSEnabled checks whether TLS is enabled or not.
@@ -60,6 +69,23 @@ func (s *Credential) ToTLSConfig() (*tls.Config, error) {
 	return cfg, cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
 }
 
+// CertCommonName returns the common name of the certificate at CertPath.
+func (s *Credential) CertCommonName() (string, error) {
+	data, err := ioutil.ReadFile(s.CertPath)
+	if err != nil {
+		return "", cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return "", cerror.ErrToTLSConfigFailed.GenWithStack("no certificate is found in %s", s.CertPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return "", cerror.WrapError(cerror.ErrToTLSConfigFailed, err)
+	}
+	return cert.Subject.CommonName, nil
+}
+
 // ToTLSConfigWithVerify generates tls's config from *Security and requires
 // verifing remote cert common name.
 func (s *Credential) ToTLSConfigWithVerify() (*tls.Config, error) {
@@ -0,0 +1,184 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// RedactedSecret replaces the secrets in the redacted URIs and options
+const RedactedSecret = "xxxxx"
+
+// the parameters whose names contain any of the words are treated as secrets
+var sensitiveParamWords = []string{"password", "secret", "token", "access-key", "auth."}
+
+var (
+	// uriInTextRe matches the URIs embedded in text, "\u0026" is how the
+	// encoding/json package escapes "&" by default.
+	uriInTextRe = regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.\-]*://(?:[^\s"'<>\\\]]|\\u0026)+`)
+	// uriPasswordInTextRe matches the passwords of the URIs embedded in text,
+	// up to the last "@" of the authority section. Unlike uriInTextRe, it does
+	// not stop at the whitespaces, quotes and brackets which may be a part of
+	// a password, only a double quote followed by a JSON delimiter ends it.
+	uriPasswordInTextRe = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.\-]*://[^\s:/?#@"'<>\\\]]*:)((?:[^/?#"\\\r\n]|\\.|"[^/?#"\\\r\n,:}\]])*)@`)
+	// sensitiveJSONFieldRe matches the JSON string fields whose names contain
+	// any of the sensitive words.
+	sensitiveJSONFieldRe = func() *regexp.Regexp {
+		words := make([]string, 0, len(sensitiveParamWords))
+		for _, word := range sensitiveParamWords {
+			words = append(words, regexp.QuoteMeta(word))
+		}
+		return regexp.MustCompile(`("[^"]*(?i:` + strings.Join(words, "|") + `)[^"]*"\s*:\s*")((?:[^"\\]|\\.)*)(")`)
+	}()
+)
+
+func isSensitiveParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveParamWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactURI returns the URI with the plaintext secrets, i.e. the password and
+// the sensitive query parameters, replaced. Secret references are kept as they
+// are not secrets themselves, and the URI is returned as is if it contains no
+// plaintext secret.
+func RedactURI(uri string) string {
+	// secret references are not allowed in the user info of a URL, so they
+	// are replaced by placeholders before parsing
+	var refs []string
+	placeholder := func(i int) string { return fmt.Sprintf("TICDCSECRETREF%dX", i) }
+	masked := secretReferenceRe.ReplaceAllStringFunc(uri, func(ref string) string {
+		refs = append(refs, ref)
+		return placeholder(len(refs) - 1)
+	})
+	isReference := func(value string) bool {
+		for i := range refs {
+			if value == placeholder(i) {
+				return true
+			}
+		}
+		return false
+	}
+
+	u, err := url.Parse(masked)
+	if err != nil {
+		return RedactedSecret
+	}
+	changed := false
+	if u.User != nil {
+		password, hasPassword := u.User.Password()
+		if hasPassword && !isReference(password) {
+			u.User = url.UserPassword(u.User.Username(), RedactedSecret)
+			changed = true
+		} else if !hasPassword && strings.HasPrefix(strings.ToLower(u.Scheme), "pulsar") &&
+			!isReference(u.User.Username()) {
+			// pulsar takes the user name as the auth token
+			u.User = url.User(RedactedSecret)
+			changed = true
+		}
+	}
+	query := u.Query()
+	queryChanged := false
+	for name, values := range query {
+		if !isSensitiveParam(name) {
+			continue
+		}
+		for i, value := range values {
+			if !isReference(value) {
+				values[i] = RedactedSecret
+				queryChanged = true
+			}
+		}
+	}
+	if queryChanged {
+		u.RawQuery = query.Encode()
+	}
+	if !changed && !queryChanged {
+		return uri
+	}
+
+	redacted := u.String()
+	for i, ref := range refs {
+		redacted = strings.ReplaceAll(redacted, placeholder(i), ref)
+	}
+	return redacted
+}
+
+// RedactOpts returns a copy of the sink options with the plaintext secrets
+// replaced, e.g. the password of the schema registry.
+func RedactOpts(opts map[string]string) map[string]string {
+	if opts == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(opts))
+	for k, v := range opts {
+		switch {
+		case strings.Contains(v, "://"):
+			redacted[k] = RedactURI(v)
+		case isSensitiveParam(k) && !HasSecretReference(v):
+			redacted[k] = RedactedSecret
+		default:
+			redacted[k] = v
+		}
+	}
+	return redacted
+}
+
+// ParseURIWithSecretReferences parses a URI which may reference secrets, the
+// references are replaced by RedactedSecret, which is enough to inspect the
+// other parts of the URI without resolving the secrets.
+func ParseURIWithSecretReferences(uri string) (*url.URL, error) {
+	return url.Parse(secretReferenceRe.ReplaceAllString(uri, RedactedSecret))
+}
+
+// RedactText returns the text with the plaintext secrets replaced, including
+// the secrets of the URIs in the text and the values of the sensitive JSON
+// fields. It is used to redact free-form data such as logs and debug dumps,
+// so it is best-effort and errs on the side of redacting too much.
+func RedactText(text string) string {
+	text = uriPasswordInTextRe.ReplaceAllStringFunc(text, func(userInfo string) string {
+		parts := uriPasswordInTextRe.FindStringSubmatch(userInfo)
+		if secretReferenceRe.ReplaceAllString(parts[2], "") == "" {
+			return userInfo
+		}
+		return parts[1] + RedactedSecret + "@"
+	})
+	text = uriInTextRe.ReplaceAllStringFunc(text, func(uri string) string {
+		escaped := strings.Contains(uri, `\u0026`)
+		unescaped := strings.ReplaceAll(uri, `\u0026`, "&")
+		redacted := RedactURI(unescaped)
+		if redacted == unescaped {
+			return uri
+		}
+		if escaped {
+			redacted = strings.ReplaceAll(redacted, "&", `\u0026`)
+		}
+		return redacted
+	})
+	return sensitiveJSONFieldRe.ReplaceAllStringFunc(text, func(field string) string {
+		parts := sensitiveJSONFieldRe.FindStringSubmatch(field)
+		if HasSecretReference(parts[2]) || strings.Contains(parts[2], "://") {
+			// the URIs are redacted above
+			return field
+		}
+		return parts[1] + RedactedSecret + parts[3]
+	})
+}
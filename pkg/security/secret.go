@@ -0,0 +1,219 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pingcap/errors"
+	cerror "github.com/pingcap/ticdc/pkg/errors"
+)
+
+// Secrets such as the passwords in sink URIs can be referenced indirectly
+// instead of being written verbatim, so that they are never persisted.
+// ${env:NAME} references the value of the environment variable NAME,
+// ${file:PATH} references the content of the file PATH with the trailing
+// newlines trimmed, and ${enc:BLOB} references BLOB decrypted by the key at
+// Credential.SecretKeyPath. The references are resolved inside the captures right before the sinks are
+// created, so the environment variables and files must exist on all the
+// captures.
+const (
+	secretKindEnv  = "env"
+	secretKindFile = "file"
+	secretKindEnc  = "enc"
+)
+
+var secretReferenceRe = regexp.MustCompile(`\$\{(env|file|enc):([^}]+)\}`)
+
+// secretKeyLen is the length of the AES-256 key to encrypt secrets
+const secretKeyLen = 32
+
+// HasSecretReference returns true if s references any secret
+func HasSecretReference(s string) bool {
+	return secretReferenceRe.MatchString(s)
+}
+
+// ResolveSecrets replaces the secret references in s with the secret values.
+// As the references usually appear in URIs, the values are percent-encoded.
+func (s *Credential) ResolveSecrets(str string) (string, error) {
+	var resolveErr error
+	resolved := secretReferenceRe.ReplaceAllStringFunc(str, func(ref string) string {
+		if resolveErr != nil {
+			return ref
+		}
+		value, err := s.resolveSecret(ref)
+		if err != nil {
+			resolveErr = err
+			return ref
+		}
+		return escapeSecret(value)
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
+
+// ResolveSecretsInOpts returns the opts with the secret references resolved,
+// opts itself is returned if there is no reference.
+func (s *Credential) ResolveSecretsInOpts(opts map[string]string) (map[string]string, error) {
+	hasReference := false
+	for _, v := range opts {
+		if HasSecretReference(v) {
+			hasReference = true
+			break
+		}
+	}
+	if !hasReference {
+		return opts, nil
+	}
+	resolved := make(map[string]string, len(opts))
+	for k, v := range opts {
+		value, err := s.ResolveSecrets(v)
+		if err != nil {
+			return nil, err
+		}
+		resolved[k] = value
+	}
+	return resolved, nil
+}
+
+func (s *Credential) resolveSecret(ref string) (string, error) {
+	match := secretReferenceRe.FindStringSubmatch(ref)
+	kind, arg := match[1], match[2]
+	switch kind {
+	case secretKindEnv:
+		value, ok := os.LookupEnv(arg)
+		if !ok {
+			return "", cerror.ErrResolveSecret.GenWithStackByArgs(ref)
+		}
+		return value, nil
+	case secretKindFile:
+		content, err := ioutil.ReadFile(arg)
+		if err != nil {
+			return "", cerror.ErrResolveSecret.Wrap(err).GenWithStackByArgs(ref)
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	case secretKindEnc:
+		if s.SecretKeyPath == "" {
+			return "", cerror.ErrResolveSecret.GenWithStack(
+				"failed to resolve secret reference %s, secret-key-path is not configured", ref)
+		}
+		key, err := LoadSecretKey(s.SecretKeyPath)
+		if err != nil {
+			return "", err
+		}
+		value, err := DecryptSecret(key, arg)
+		if err != nil {
+			return "", cerror.ErrResolveSecret.Wrap(err).GenWithStackByArgs(ref)
+		}
+		return value, nil
+	}
+	return "", cerror.ErrResolveSecret.GenWithStackByArgs(ref)
+}
+
+// escapeSecret percent-encodes all the characters except the unreserved ones,
+// so that the value is decoded correctly wherever it is in a URI.
+func escapeSecret(value string) string {
+	const upperhex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(upperhex[c>>4])
+		b.WriteByte(upperhex[c&15])
+	}
+	return b.String()
+}
+
+// LoadSecretKey loads the hex encoded AES-256 key from the file
+func LoadSecretKey(path string) ([]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrInvalidSecretKey, err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrInvalidSecretKey, err)
+	}
+	if len(key) != secretKeyLen {
+		return nil, cerror.ErrInvalidSecretKey.GenWithStack("the secret key should be %d bytes, got %d bytes", secretKeyLen, len(key))
+	}
+	return key, nil
+}
+
+// EncryptSecret encrypts the secret with AES-256-GCM, and returns a reference
+// to the encrypted secret which can be resolved by ResolveSecrets.
+func EncryptSecret(key []byte, secret string) (string, error) {
+	aead, err := newSecretAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.Trace(err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(secret), nil)
+	return "${" + secretKindEnc + ":" + base64.RawURLEncoding.EncodeToString(sealed) + "}", nil
+}
+
+// DecryptSecret decrypts the blob of an encrypted secret reference
+func DecryptSecret(key []byte, blob string) (string, error) {
+	aead, err := newSecretAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(blob)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted secret is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return string(plaintext), nil
+}
+
+func newSecretAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != secretKeyLen {
+		return nil, cerror.ErrInvalidSecretKey.GenWithStack("the secret key should be %d bytes, got %d bytes", secretKeyLen, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrInvalidSecretKey, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, cerror.WrapError(cerror.ErrInvalidSecretKey, err)
+	}
+	return aead, nil
+}
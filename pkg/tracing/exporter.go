@@ -0,0 +1,217 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pingcap/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const exportTimeout = 10 * time.Second
+
+// otlpHTTPExporter exports the spans to an OTLP/HTTP endpoint in the JSON
+// encoding of the OTLP protocol.
+type otlpHTTPExporter struct {
+	endpoint string
+	client   *http.Client
+}
+
+func newOTLPHTTPExporter(endpoint string) *otlpHTTPExporter {
+	return &otlpHTTPExporter{
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: exportTimeout},
+	}
+}
+
+// ExportSpans implements the sdktrace.SpanExporter interface
+func (e *otlpHTTPExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(newOTLPTraceRequest(spans))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("export spans to %s failed, status: %s, body: %s", e.endpoint, resp.Status, msg)
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
+
+// Shutdown implements the sdktrace.SpanExporter interface
+func (e *otlpHTTPExporter) Shutdown(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// The following types are the JSON encoding of the OTLP trace service request.
+// The IDs are hex encoded, and the 64 bits integers are encoded as strings.
+type otlpTraceRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource      `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope   `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// the status codes of OTLP, which are different from the ones of the SDK
+const (
+	otlpStatusCodeOk    = 1
+	otlpStatusCodeError = 2
+)
+
+// newOTLPTraceRequest groups the spans by the resources and the
+// instrumentation libraries.
+func newOTLPTraceRequest(spans []sdktrace.ReadOnlySpan) *otlpTraceRequest {
+	req := &otlpTraceRequest{}
+	resources := make(map[attribute.Distinct]*otlpResourceSpans)
+	scopes := make(map[attribute.Distinct]map[string]*otlpScopeSpans)
+	for _, span := range spans {
+		res := span.Resource()
+		resKey := res.Equivalent()
+		resSpans, ok := resources[resKey]
+		if !ok {
+			resSpans = &otlpResourceSpans{
+				Resource: otlpResource{Attributes: newOTLPAttributes(res.Attributes())},
+			}
+			resources[resKey] = resSpans
+			scopes[resKey] = make(map[string]*otlpScopeSpans)
+			req.ResourceSpans = append(req.ResourceSpans, resSpans)
+		}
+		library := span.InstrumentationLibrary()
+		scopeSpans, ok := scopes[resKey][library.Name]
+		if !ok {
+			scopeSpans = &otlpScopeSpans{
+				Scope: otlpScope{Name: library.Name, Version: library.Version},
+			}
+			scopes[resKey][library.Name] = scopeSpans
+			resSpans.ScopeSpans = append(resSpans.ScopeSpans, scopeSpans)
+		}
+		scopeSpans.Spans = append(scopeSpans.Spans, newOTLPSpan(span))
+	}
+	return req
+}
+
+func newOTLPSpan(span sdktrace.ReadOnlySpan) *otlpSpan {
+	sc := span.SpanContext()
+	s := &otlpSpan{
+		TraceID:           sc.TraceID().String(),
+		SpanID:            sc.SpanID().String(),
+		Name:              span.Name(),
+		Kind:              int(span.SpanKind()),
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.EndTime().UnixNano(), 10),
+		Attributes:        newOTLPAttributes(span.Attributes()),
+	}
+	if parent := span.Parent(); parent.SpanID().IsValid() {
+		s.ParentSpanID = parent.SpanID().String()
+	}
+	switch status := span.Status(); status.Code {
+	case codes.Ok:
+		s.Status = otlpStatus{Code: otlpStatusCodeOk}
+	case codes.Error:
+		s.Status = otlpStatus{Code: otlpStatusCodeError, Message: status.Description}
+	}
+	return s
+}
+
+func newOTLPAttributes(attrs []attribute.KeyValue) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		var value otlpAnyValue
+		switch attr.Value.Type() {
+		case attribute.BOOL:
+			v := attr.Value.AsBool()
+			value.BoolValue = &v
+		case attribute.INT64:
+			v := strconv.FormatInt(attr.Value.AsInt64(), 10)
+			value.IntValue = &v
+		case attribute.FLOAT64:
+			v := attr.Value.AsFloat64()
+			value.DoubleValue = &v
+		default:
+			v := attr.Value.Emit()
+			value.StringValue = &v
+		}
+		kvs = append(kvs, otlpKeyValue{Key: string(attr.Key), Value: value})
+	}
+	return kvs
+}
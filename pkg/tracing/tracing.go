@@ -0,0 +1,233 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pingcap/errors"
+	"github.com/pingcap/log"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/version"
+	"github.com/pingcap/tidb/tablecodec"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// The names of the spans of the stages of the data flow, a row changed event
+// goes through them in order.
+const (
+	// StageKVClientReceive is the span of receiving the event from TiKV
+	StageKVClientReceive = "kv-client.receive"
+	// StageSorterInput is the span of adding the event to the sorter
+	StageSorterInput = "sorter.input"
+	// StageSorterOutput is the span of outputting the event from the sorter
+	StageSorterOutput = "sorter.output"
+	// StageMounter is the span of mounting the raw kv to a row changed event
+	StageMounter = "mounter.mount"
+	// StageSinkEmit is the span of emitting the event to the table sink
+	StageSinkEmit = "sink.emit"
+	// StageSinkFlush is the span of flushing the event to the downstream
+	StageSinkFlush = "sink.flush"
+)
+
+const (
+	serviceName = "ticdc"
+	tracerName  = "github.com/pingcap/ticdc"
+)
+
+// The attribute keys of the spans.
+const (
+	attrChangefeed = attribute.Key("ticdc.changefeed")
+	attrTableID    = attribute.Key("ticdc.table_id")
+	attrCommitTs   = attribute.Key("ticdc.commit_ts")
+	// attrLag is the lag in milliseconds of the event when the stage starts,
+	// which is the time elapsed since the transaction was committed.
+	attrLag = attribute.Key("ticdc.lag_ms")
+)
+
+// EventKey identifies the row changed events of a transaction in a table, the
+// spans of all the stages of the events with the same key share a trace.
+type EventKey struct {
+	ChangefeedID string
+	TableID      int64
+	CommitTs     uint64
+}
+
+// NewEventKey returns the key of a row changed event of the raw kv key.
+func NewEventKey(changefeedID string, rawKey []byte, commitTs uint64) EventKey {
+	return EventKey{
+		ChangefeedID: changefeedID,
+		TableID:      tablecodec.DecodeTableID(rawKey),
+		CommitTs:     commitTs,
+	}
+}
+
+// traceID derives the trace ID from the key, so that the spans of all stages
+// are put in the same trace without passing the span context along with the
+// events, and they are sampled consistently as the sampler only depends on
+// the trace ID.
+func (k EventKey) traceID() trace.TraceID {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(k.TableID))
+	binary.BigEndian.PutUint64(buf[8:], k.CommitTs)
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(k.ChangefeedID))
+	_, _ = hash.Write(buf[:])
+	var id trace.TraceID
+	copy(id[:], hash.Sum(nil))
+	return id
+}
+
+type eventKeyCtxKey struct{}
+
+// eventIDGenerator generates the trace IDs from the event keys in the context,
+// and random span IDs.
+type eventIDGenerator struct {
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+func newEventIDGenerator() *eventIDGenerator {
+	return &eventIDGenerator{random: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// NewIDs implements the sdktrace.IDGenerator interface
+func (g *eventIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var traceID trace.TraceID
+	if key, ok := ctx.Value(eventKeyCtxKey{}).(EventKey); ok {
+		traceID = key.traceID()
+	} else {
+		g.mu.Lock()
+		_, _ = g.random.Read(traceID[:])
+		g.mu.Unlock()
+	}
+	return traceID, g.NewSpanID(ctx, traceID)
+}
+
+// NewSpanID implements the sdktrace.IDGenerator interface
+func (g *eventIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	var spanID trace.SpanID
+	g.mu.Lock()
+	_, _ = g.random.Read(spanID[:])
+	g.mu.Unlock()
+	return spanID
+}
+
+type tracingState struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// globalState stores a *tracingState, which is nil if tracing is disabled.
+var globalState atomic.Value
+
+func init() {
+	globalState.Store((*tracingState)(nil))
+}
+
+func loadState() *tracingState {
+	return globalState.Load().(*tracingState)
+}
+
+// Init enables tracing with the config if it is enabled, the spans are
+// exported to the OTLP/HTTP endpoint in batches.
+func Init(cfg *config.TracingConfig, captureAddr string) {
+	if cfg == nil || !cfg.Enable {
+		return
+	}
+	res := resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceNameKey.String(serviceName),
+		semconv.ServiceVersionKey.String(version.ReleaseVersion),
+		semconv.ServiceInstanceIDKey.String(captureAddr),
+	)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(newOTLPHTTPExporter(cfg.Endpoint)),
+		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(cfg.SampleRatio)),
+		sdktrace.WithIDGenerator(newEventIDGenerator()),
+		sdktrace.WithResource(res),
+	)
+	globalState.Store(&tracingState{
+		provider: provider,
+		tracer:   provider.Tracer(tracerName),
+	})
+	log.Info("tracing is enabled",
+		zap.String("endpoint", cfg.Endpoint), zap.Float64("sampleRatio", cfg.SampleRatio))
+}
+
+// Shutdown disables tracing, and flushes the spans not exported yet.
+func Shutdown(ctx context.Context) error {
+	state := loadState()
+	if state == nil {
+		return nil
+	}
+	globalState.Store((*tracingState)(nil))
+	return errors.Trace(state.provider.Shutdown(ctx))
+}
+
+// Enabled returns true if tracing is enabled.
+func Enabled() bool {
+	return loadState() != nil
+}
+
+// StartEventSpan starts the span of a stage of the row changed events with the
+// key. A non-recording span is returned if tracing is disabled or the
+// transaction is not sampled, so the callers can always end the span.
+func StartEventSpan(ctx context.Context, stage string, key EventKey) trace.Span {
+	return startEventSpan(ctx, stage, key, time.Now())
+}
+
+// StartKVEventSpan is like StartEventSpan, but the key is only decoded from the
+// raw kv key if tracing is enabled, it is used in the hot paths of the events.
+func StartKVEventSpan(ctx context.Context, stage string, changefeedID string, rawKey []byte, commitTs uint64) trace.Span {
+	if !Enabled() {
+		return trace.SpanFromContext(context.Background())
+	}
+	return StartEventSpan(ctx, stage, NewEventKey(changefeedID, rawKey, commitTs))
+}
+
+// RecordEventSpan records the span of a stage of the row changed events with
+// the key, which started and ended at the given time.
+func RecordEventSpan(ctx context.Context, stage string, key EventKey, start, end time.Time) {
+	startEventSpan(ctx, stage, key, start).End(trace.WithTimestamp(end))
+}
+
+func startEventSpan(ctx context.Context, stage string, key EventKey, start time.Time) trace.Span {
+	state := loadState()
+	if state == nil {
+		return trace.SpanFromContext(context.Background())
+	}
+	ctx = context.WithValue(ctx, eventKeyCtxKey{}, key)
+	_, span := state.tracer.Start(ctx, stage,
+		trace.WithNewRoot(),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attrChangefeed.String(key.ChangefeedID),
+			attrTableID.Int64(key.TableID),
+			attrCommitTs.Int64(int64(key.CommitTs)),
+			attrLag.Int64(start.Sub(oracle.GetTimeFromTS(key.CommitTs)).Milliseconds()),
+		))
+	return span
+}
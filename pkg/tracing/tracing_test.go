@@ -0,0 +1,192 @@
+// Copyright 2021 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pingcap/check"
+	"github.com/pingcap/ticdc/pkg/config"
+	"github.com/pingcap/ticdc/pkg/util/testleak"
+	"github.com/tikv/client-go/v2/oracle"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type tracingSuite struct{}
+
+var _ = check.Suite(&tracingSuite{})
+
+// mockCollector is a stand-in of the OTLP/HTTP collector.
+type mockCollector struct {
+	mu    sync.Mutex
+	reqs  []*otlpTraceRequest
+	spans []*otlpSpan
+}
+
+func (m *mockCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req := &otlpTraceRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reqs = append(m.reqs, req)
+	for _, resSpans := range req.ResourceSpans {
+		for _, scopeSpans := range resSpans.ScopeSpans {
+			m.spans = append(m.spans, scopeSpans.Spans...)
+		}
+	}
+}
+
+func attributeValue(attrs []otlpKeyValue, key string) *otlpAnyValue {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return &attr.Value
+		}
+	}
+	return nil
+}
+
+func (s *tracingSuite) TestDisabled(c *check.C) {
+	defer testleak.AfterTest(c)()
+	Init(&config.TracingConfig{Enable: false}, "127.0.0.1:8300")
+	c.Assert(Enabled(), check.IsFalse)
+	span := StartKVEventSpan(context.Background(), StageSorterInput, "test", []byte("t"), 1)
+	c.Assert(span.IsRecording(), check.IsFalse)
+	c.Assert(span.SpanContext().IsSampled(), check.IsFalse)
+	span.End()
+	c.Assert(Shutdown(context.Background()), check.IsNil)
+}
+
+func (s *tracingSuite) TestExportSpans(c *check.C) {
+	defer testleak.AfterTest(c)()
+	collector := &mockCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	Init(&config.TracingConfig{
+		Enable:      true,
+		Endpoint:    server.URL + "/v1/traces",
+		SampleRatio: 1,
+	}, "127.0.0.1:8300")
+	c.Assert(Enabled(), check.IsTrue)
+
+	commitTs := oracle.ComposeTS(time.Now().Add(-time.Second).UnixNano()/int64(time.Millisecond), 0)
+	key := EventKey{ChangefeedID: "test", TableID: 47, CommitTs: commitTs}
+	ctx := context.Background()
+	StartEventSpan(ctx, StageKVClientReceive, key).End()
+	StartEventSpan(ctx, StageSinkEmit, key).End()
+	start := time.Now()
+	RecordEventSpan(ctx, StageSinkFlush, key, start, start.Add(time.Millisecond))
+	StartEventSpan(ctx, StageSinkEmit, EventKey{ChangefeedID: "test", TableID: 47, CommitTs: commitTs + 1}).End()
+
+	c.Assert(Shutdown(ctx), check.IsNil)
+	c.Assert(Enabled(), check.IsFalse)
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	c.Assert(collector.spans, check.HasLen, 4)
+	res := collector.reqs[0].ResourceSpans[0].Resource
+	c.Assert(*attributeValue(res.Attributes, "service.name").StringValue, check.Equals, "ticdc")
+	c.Assert(*attributeValue(res.Attributes, "service.instance.id").StringValue, check.Equals, "127.0.0.1:8300")
+	c.Assert(collector.reqs[0].ResourceSpans[0].ScopeSpans[0].Scope.Name, check.Equals, tracerName)
+
+	spans := make(map[string][]*otlpSpan)
+	for _, span := range collector.spans {
+		spans[span.Name] = append(spans[span.Name], span)
+	}
+	receive := spans[StageKVClientReceive][0]
+	flush := spans[StageSinkFlush][0]
+	c.Assert(spans[StageSinkEmit], check.HasLen, 2)
+	// the spans of the same transaction share a trace
+	c.Assert(receive.TraceID, check.Equals, key.traceID().String())
+	c.Assert(flush.TraceID, check.Equals, receive.TraceID)
+	c.Assert(receive.SpanID, check.Not(check.Equals), flush.SpanID)
+	c.Assert(spans[StageSinkEmit][0].TraceID, check.Not(check.Equals), spans[StageSinkEmit][1].TraceID)
+
+	c.Assert(*attributeValue(receive.Attributes, string(attrChangefeed)).StringValue, check.Equals, "test")
+	c.Assert(*attributeValue(receive.Attributes, string(attrTableID)).IntValue, check.Equals, "47")
+	lag, err := strconv.ParseInt(*attributeValue(receive.Attributes, string(attrLag)).IntValue, 10, 64)
+	c.Assert(err, check.IsNil)
+	c.Assert(lag >= 1000, check.IsTrue, check.Commentf("lag: %d", lag))
+	flushStart, err := strconv.ParseInt(flush.StartTimeUnixNano, 10, 64)
+	c.Assert(err, check.IsNil)
+	flushEnd, err := strconv.ParseInt(flush.EndTimeUnixNano, 10, 64)
+	c.Assert(err, check.IsNil)
+	c.Assert(flushStart, check.Equals, start.UnixNano())
+	c.Assert(flushEnd-flushStart, check.Equals, int64(time.Millisecond))
+}
+
+func (s *tracingSuite) TestSampleByEventKey(c *check.C) {
+	defer testleak.AfterTest(c)()
+	collector := &mockCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+
+	Init(&config.TracingConfig{
+		Enable:      true,
+		Endpoint:    server.URL + "/v1/traces",
+		SampleRatio: 0.5,
+	}, "127.0.0.1:8300")
+	defer func() {
+		c.Assert(Shutdown(context.Background()), check.IsNil)
+	}()
+
+	ctx := context.Background()
+	sampled := 0
+	for ts := uint64(1); ts <= 100; ts++ {
+		key := EventKey{ChangefeedID: "test", TableID: 1, CommitTs: ts}
+		span1 := StartEventSpan(ctx, StageSorterInput, key)
+		span2 := StartEventSpan(ctx, StageMounter, key)
+		// all stages of a transaction are either sampled or not
+		c.Assert(span1.SpanContext().IsSampled(), check.Equals, span2.SpanContext().IsSampled())
+		if span1.SpanContext().IsSampled() {
+			sampled++
+		}
+		span1.End()
+		span2.End()
+	}
+	c.Assert(sampled > 0 && sampled < 100, check.IsTrue, check.Commentf("sampled: %d", sampled))
+}
+
+func (s *tracingSuite) TestExportFailed(c *check.C) {
+	defer testleak.AfterTest(c)()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("collector is unavailable"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	exporter := newOTLPHTTPExporter(server.URL)
+	c.Assert(exporter.ExportSpans(ctx, nil), check.IsNil)
+	spans := tracetest.SpanStubs{{Name: StageMounter}}.Snapshots()
+	err := exporter.ExportSpans(ctx, spans)
+	c.Assert(err, check.ErrorMatches, ".*status: 503 Service Unavailable, body: collector is unavailable.*")
+	c.Assert(exporter.Shutdown(ctx), check.IsNil)
+}
@@ -29,16 +29,31 @@ const (
 	cdcChangefeedCreatingServiceGCSafePointID = "ticdc-creating-"
 	// cdcChangefeedCreatingServiceGCSafePointTTL is service GC safe point TTL
 	cdcChangefeedCreatingServiceGCSafePointTTL = 10 * 60 // 10 mins
+	// cdcChangefeedDryRunServiceGCSafePointID is the service GC safe point ID
+	// used by the dry runs, it is never registered.
+	cdcChangefeedDryRunServiceGCSafePointID = "ticdc-dry-run-"
 )
 
 // CheckSafetyOfStartTs checks if the startTs less than the minimum of Service-GC-Ts
 // and this function will update the service GC to startTs
-func CheckSafetyOfStartTs(ctx context.Context, pdCli pd.Client, changefeedID string, startTs uint64) (err error) {
+func CheckSafetyOfStartTs(ctx context.Context, pdCli pd.Client, changefeedID string, startTs uint64) error {
+	return checkStartTs(ctx, pdCli, cdcChangefeedCreatingServiceGCSafePointID+changefeedID,
+		cdcChangefeedCreatingServiceGCSafePointTTL, startTs)
+}
+
+// CheckStartTsForDryRun checks if the startTs less than the minimum of
+// Service-GC-Ts like CheckSafetyOfStartTs, but it doesn't update the service GC.
+func CheckStartTsForDryRun(ctx context.Context, pdCli pd.Client, changefeedID string, startTs uint64) error {
+	// PD removes the service GC safe point if the TTL is not positive, the ID
+	// is never registered so nothing is removed.
+	return checkStartTs(ctx, pdCli, cdcChangefeedDryRunServiceGCSafePointID+changefeedID, 0, startTs)
+}
+
+func checkStartTs(ctx context.Context, pdCli pd.Client, serviceID string, ttl int64, startTs uint64) (err error) {
 	var minServiceGCTs uint64
 	// pd leader switch may happen, so just retry it.
 	if err := retry.Do(ctx, func() error {
-		minServiceGCTs, err = pdCli.UpdateServiceGCSafePoint(ctx, cdcChangefeedCreatingServiceGCSafePointID+changefeedID,
-			cdcChangefeedCreatingServiceGCSafePointTTL, startTs)
+		minServiceGCTs, err = pdCli.UpdateServiceGCSafePoint(ctx, serviceID, ttl, startTs)
 		if err != nil {
 			log.Warn("update GC safepoint failed, retry later", zap.Error(err))
 		}
@@ -69,6 +69,20 @@ func (s *gcServiceSuite) TestCheckSafetyOfStartTs(c *check.C) {
 	c.Assert(err.Error(), check.Equals, "[CDC:ErrStartTsBeforeGC]fail to create changefeed because start-ts 50 is earlier than GC safepoint at 60")
 }
 
+func (s *gcServiceSuite) TestCheckStartTsForDryRun(c *check.C) {
+	defer testleak.AfterTest(c)()
+	ctx := context.Background()
+	pdCli := &mockPdClientForServiceGCSafePoint{serviceSafePoint: make(map[string]uint64)}
+
+	pdCli.UpdateServiceGCSafePoint(ctx, "service1", 10, 60) //nolint:errcheck
+	err := CheckStartTsForDryRun(ctx, pdCli, "changefeed1", 50)
+	c.Assert(err.Error(), check.Equals, "[CDC:ErrStartTsBeforeGC]fail to create changefeed because start-ts 50 is earlier than GC safepoint at 60")
+	err = CheckStartTsForDryRun(ctx, pdCli, "changefeed1", 65)
+	c.Assert(err, check.IsNil)
+	// no service GC safe point is registered by the dry runs.
+	c.Assert(pdCli.serviceSafePoint, check.DeepEquals, map[string]uint64{"service1": 60})
+}
+
 type mockPdClientForServiceGCSafePoint struct {
 	pd.Client
 	serviceSafePoint   map[string]uint64
@@ -85,12 +99,16 @@ func (m *mockPdClientForServiceGCSafePoint) UpdateServiceGCSafePoint(ctx context
 		return minSafePoint, errors.New("not pd leader")
 	}
 
+	if ttl <= 0 {
+		delete(m.serviceSafePoint, serviceID)
+	}
+
 	for _, safePoint := range m.serviceSafePoint {
 		if minSafePoint > safePoint {
 			minSafePoint = safePoint
 		}
 	}
-	if safePoint < minSafePoint && len(m.serviceSafePoint) != 0 {
+	if ttl <= 0 || (safePoint < minSafePoint && len(m.serviceSafePoint) != 0) {
 		return minSafePoint, nil
 	}
 	m.serviceSafePoint[serviceID] = safePoint